@@ -0,0 +1,468 @@
+// Package anki reads and writes vocabulary as Anki packages (.apkg) and CSV
+// files, so learners can bring their existing decks in and take their
+// words out to Anki.
+//
+// Packages use the legacy collection format (schema 11), which every Anki
+// version since 2.1 imports. Newer packages that only contain the compressed
+// collection.anki21b are rejected with ErrNewFormat.
+package anki
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ailanguagetutor/srs"
+)
+
+// Note is one vocabulary entry of a deck.
+type Note struct {
+	Word        string
+	Translation string
+	Phonetic    string
+	Tags        []string
+	Schedule    *srs.State // review state; nil for cards not yet studied
+}
+
+// Mapping selects the fields holding each part of a note, by field name
+// (case-insensitive) or 1-based position. Word and Translation default to
+// the first and second fields; Phonetic defaults to a field named like
+// "phonetic", "pronunciation", "ipa" or "reading", if there is one.
+type Mapping struct {
+	Word        string
+	Translation string
+	Phonetic    string
+}
+
+// MaxNotes caps how many notes one import reads.
+const MaxNotes = 5000
+
+// MaxWordRunes is the longest word an imported note may have; notes with
+// longer words are skipped. Translations and phonetics are cut to
+// MaxFieldRunes.
+const (
+	MaxWordRunes  = 100
+	MaxFieldRunes = 10000
+)
+
+var (
+	ErrNewFormat = errors.New(`anki: package uses the newer collection format; export it again with "Support older Anki versions" enabled`)
+	ErrNoNotes   = errors.New("anki: no notes found for the field mapping")
+)
+
+// maxCollection bounds the decompressed collection read from a package.
+const maxCollection = 100 << 20
+
+// ── Import ────────────────────────────────────────────────────────────────────
+
+// ReadPackage reads the notes of an .apkg file. Fields are converted from
+// Anki's HTML to plain text, and review cards keep their schedule.
+func ReadPackage(data []byte, m Mapping) ([]Note, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("anki: not a valid package: %w", err)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	f := files["collection.anki21"]
+	if f == nil {
+		if files["collection.anki21b"] != nil {
+			// The legacy file in such packages only holds an "update Anki" note.
+			return nil, ErrNewFormat
+		}
+		f = files["collection.anki2"]
+	}
+	if f == nil {
+		return nil, errors.New("anki: package has no collection")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	raw, err := io.ReadAll(io.LimitReader(rc, maxCollection+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxCollection {
+		return nil, errors.New("anki: collection too large")
+	}
+	db, err := openSQLite(raw)
+	if err != nil {
+		return nil, err
+	}
+	return readCollection(db, m)
+}
+
+type ankiModel struct {
+	Flds []struct {
+		Name string `json:"name"`
+		Ord  int    `json:"ord"`
+	} `json:"flds"`
+}
+
+type ankiCard struct {
+	ord, typ, due, ivl, factor, reps, lapses int64
+}
+
+func readCollection(db *sqliteDB, m Mapping) ([]Note, error) {
+	root, err := db.table("col")
+	if err != nil {
+		return nil, err
+	}
+	var crt int64
+	models := map[string]ankiModel{}
+	err = db.rows(root, func(_ int64, rec []any) error {
+		crt = asInt(field(rec, 1))
+		return json.Unmarshal([]byte(asString(field(rec, 9))), &models)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("anki: reading collection: %w", err)
+	}
+	created := time.Unix(crt, 0)
+
+	// The first card of each note carries its schedule.
+	cards := map[int64]ankiCard{}
+	if root, err := db.table("cards"); err == nil {
+		err = db.rows(root, func(_ int64, rec []any) error {
+			nid := asInt(field(rec, 1))
+			c := ankiCard{
+				ord: asInt(field(rec, 3)), typ: asInt(field(rec, 6)), due: asInt(field(rec, 8)),
+				ivl: asInt(field(rec, 9)), factor: asInt(field(rec, 10)),
+				reps: asInt(field(rec, 11)), lapses: asInt(field(rec, 12)),
+			}
+			if prev, ok := cards[nid]; !ok || c.ord < prev.ord {
+				cards[nid] = c
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("anki: reading cards: %w", err)
+		}
+	}
+
+	root, err = db.table("notes")
+	if err != nil {
+		return nil, err
+	}
+	var notes []Note
+	errFull := errors.New("full")
+	err = db.rows(root, func(id int64, rec []any) error {
+		var names []string
+		if model, ok := models[strconv.FormatInt(asInt(field(rec, 2)), 10)]; ok {
+			names = make([]string, len(model.Flds))
+			for _, f := range model.Flds {
+				if f.Ord >= 0 && f.Ord < len(names) {
+					names[f.Ord] = f.Name
+				}
+			}
+		}
+		values := strings.Split(asString(field(rec, 6)), "\x1f")
+		n := mapNote(names, values, m)
+		if n.Word == "" || utf8.RuneCountInString(n.Word) > MaxWordRunes {
+			return nil
+		}
+		n.Tags = strings.Fields(asString(field(rec, 5)))
+		if c, ok := cards[id]; ok {
+			n.Schedule = c.schedule(created)
+		}
+		notes = append(notes, n)
+		if len(notes) >= MaxNotes {
+			return errFull
+		}
+		return nil
+	})
+	if err != nil && err != errFull {
+		return nil, fmt.Errorf("anki: reading notes: %w", err)
+	}
+	if len(notes) == 0 {
+		return nil, ErrNoNotes
+	}
+	return notes, nil
+}
+
+// schedule converts a review card's Anki schedule; new and learning cards
+// return nil and start afresh.
+func (c ankiCard) schedule(created time.Time) *srs.State {
+	const review, relearning = 2, 3
+	if c.typ != review && c.typ != relearning {
+		return nil
+	}
+	due := created.AddDate(0, 0, int(c.due))
+	st := &srs.State{
+		Reps:     int(max(1, c.reps-c.lapses)),
+		Lapses:   int(c.lapses),
+		Ease:     max(srs.MinEase, float64(c.factor)/1000),
+		Interval: min(srs.MaxInterval, float64(max(1, c.ivl))),
+		Due:      due,
+	}
+	st.LastReview = due.AddDate(0, 0, -int(st.Interval))
+	return st
+}
+
+var phoneticNames = []string{"phonetic", "pronunciation", "ipa", "reading"}
+
+// mapNote picks the mapped fields out of values, whose field names are names
+// (nil when unknown).
+func mapNote(names, values []string, m Mapping) Note {
+	get := func(spec string, fallback int) string {
+		i := fieldIndex(names, spec, fallback)
+		if i < 0 || i >= len(values) {
+			return ""
+		}
+		return plainText(values[i])
+	}
+	phonetic := -1
+	if m.Phonetic == "" {
+		for i, n := range names {
+			for _, p := range phoneticNames {
+				if strings.Contains(strings.ToLower(n), p) {
+					phonetic = i
+				}
+			}
+		}
+	}
+	return Note{
+		Word:        get(m.Word, 0),
+		Translation: truncate(get(m.Translation, 1), MaxFieldRunes),
+		Phonetic:    truncate(get(m.Phonetic, phonetic), MaxFieldRunes),
+	}
+}
+
+// truncate cuts s to at most n runes.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// fieldIndex resolves a field spec against names: a 1-based position, a
+// name, or empty for fallback. Unknown names resolve to -1.
+func fieldIndex(names []string, spec string, fallback int) int {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return fallback
+	}
+	if n, err := strconv.Atoi(spec); err == nil {
+		return n - 1
+	}
+	for i, n := range names {
+		if strings.EqualFold(strings.TrimSpace(n), spec) {
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	soundTag = regexp.MustCompile(`\[sound:[^\]]*\]`)
+	breakTag = regexp.MustCompile(`(?i)<br\s*/?>|</?(div|p|li)[^>]*>`)
+	anyTag   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// plainText strips Anki's field HTML and sound references.
+func plainText(s string) string {
+	s = soundTag.ReplaceAllString(s, "")
+	s = breakTag.ReplaceAllString(s, " ")
+	s = anyTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func field(rec []any, i int) any {
+	if i < len(rec) {
+		return rec[i]
+	}
+	return nil
+}
+
+func asInt(v any) int64 {
+	switch x := v.(type) {
+	case int64:
+		return x
+	case float64:
+		return int64(x)
+	case string:
+		n, _ := strconv.ParseInt(x, 10, 64)
+		return n
+	}
+	return 0
+}
+
+func asString(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	}
+	return ""
+}
+
+// ── Export ────────────────────────────────────────────────────────────────────
+
+// Field names of the exported note type, in order.
+var exportFields = []string{"Word", "Translation", "Phonetic"}
+
+// WritePackage writes notes as an .apkg holding one deck named deck. Notes
+// with a schedule become review cards; the rest are new cards.
+func WritePackage(w io.Writer, deck string, notes []Note, now time.Time) error {
+	coll, err := buildCollection(deck, notes, now)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(w)
+	f, err := zw.Create("collection.anki2")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(coll); err != nil {
+		return err
+	}
+	if f, err = zw.Create("media"); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, "{}"); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Anki's schema 11 tables.
+const (
+	colSQL    = `CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null, ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null, models text not null, decks text not null, dconf text not null, tags text not null)`
+	notesSQL  = `CREATE TABLE notes (id integer primary key, guid text not null, mid integer not null, mod integer not null, usn integer not null, tags text not null, flds text not null, sfld integer not null, csum integer not null, flags integer not null, data text not null)`
+	cardsSQL  = `CREATE TABLE cards (id integer primary key, nid integer not null, did integer not null, ord integer not null, mod integer not null, usn integer not null, type integer not null, queue integer not null, due integer not null, ivl integer not null, factor integer not null, reps integer not null, lapses integer not null, left integer not null, odue integer not null, odid integer not null, flags integer not null, data text not null)`
+	revlogSQL = `CREATE TABLE revlog (id integer primary key, cid integer not null, usn integer not null, ease integer not null, ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null, type integer not null)`
+	gravesSQL = `CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null)`
+)
+
+func buildCollection(deck string, notes []Note, now time.Time) ([]byte, error) {
+	ms := now.UnixMilli()
+	day := 24 * time.Hour
+	// Review due dates are counted in days from the collection's creation.
+	crt := now.Truncate(day)
+	for _, n := range notes {
+		if n.Schedule != nil && n.Schedule.Due.Before(crt) {
+			crt = n.Schedule.Due.Truncate(day)
+		}
+	}
+	modelID, deckID := ms, ms+1
+
+	var noteRows, cardRows [][]any
+	for i, n := range notes {
+		id := ms + int64(i)
+		values := []string{html.EscapeString(n.Word), html.EscapeString(n.Translation), html.EscapeString(n.Phonetic)}
+		tags := ""
+		if len(n.Tags) > 0 {
+			tags = " " + strings.Join(n.Tags, " ") + " "
+		}
+		noteRows = append(noteRows, []any{
+			id, guid(n.Word), modelID, now.Unix(), int64(-1), tags,
+			strings.Join(values, "\x1f"), n.Word, checksum(n.Word), int64(0), "",
+		})
+
+		// type, queue, due, ivl, factor, reps, lapses
+		card := []int64{0, 0, int64(i + 1), 0, 0, 0, 0}
+		if s := n.Schedule; s != nil && s.Interval >= 1 {
+			card = []int64{2, 2, int64(s.Due.Sub(crt) / day), int64(s.Interval), int64(s.Ease * 1000), int64(s.Reps + s.Lapses), int64(s.Lapses)}
+		}
+		cardRows = append(cardRows, []any{
+			id, id, deckID, int64(0), now.Unix(), int64(-1),
+			card[0], card[1], card[2], card[3], card[4], card[5], card[6],
+			int64(0), int64(0), int64(0), int64(0), "",
+		})
+	}
+
+	conf, models, decks, dconf := collectionJSON(deck, modelID, deckID, len(notes), ms)
+	colRow := []any{int64(1), crt.Unix(), ms, ms, int64(11), int64(0), int64(0), int64(0), conf, models, decks, dconf, "{}"}
+
+	return writeSQLite([]sqliteTable{
+		{name: "col", sql: colSQL, alias: true, rows: [][]any{colRow}},
+		{name: "notes", sql: notesSQL, alias: true, rows: noteRows},
+		{name: "cards", sql: cardsSQL, alias: true, rows: cardRows},
+		{name: "revlog", sql: revlogSQL, alias: true},
+		{name: "graves", sql: gravesSQL},
+	})
+}
+
+// collectionJSON returns the col table's conf, models, decks and dconf.
+func collectionJSON(deck string, modelID, deckID int64, nextPos int, ms int64) (conf, models, decks, dconf string) {
+	flds := make([]map[string]any, len(exportFields))
+	for i, name := range exportFields {
+		flds[i] = map[string]any{"name": name, "ord": i, "sticky": false, "rtl": false, "font": "Arial", "size": 20, "media": []any{}}
+	}
+	model := map[string]any{
+		"id": modelID, "name": "AI Language Tutor Vocabulary", "type": 0, "mod": ms / 1000, "usn": -1,
+		"sortf": 0, "did": deckID, "flds": flds,
+		"tmpls": []map[string]any{{
+			"name": "Card 1", "ord": 0, "did": nil, "bqfmt": "", "bafmt": "", "bfont": "", "bsize": 0,
+			"qfmt": "{{Word}}",
+			"afmt": "{{FrontSide}}<hr id=answer>{{Translation}}<br><i>{{Phonetic}}</i>",
+		}},
+		"css":       ".card { font-family: arial; font-size: 20px; text-align: center; color: black; background-color: white; }",
+		"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+		"latexPost": "\\end{document}",
+		"latexsvg":  false,
+		"req":       []any{[]any{0, "any", []int{0}}},
+		"tags":      []string{},
+		"vers":      []any{},
+	}
+	deckJSON := func(id int64, name string) map[string]any {
+		return map[string]any{
+			"id": id, "name": name, "mod": ms / 1000, "usn": -1, "desc": "", "dyn": 0, "conf": 1,
+			"collapsed": false, "browserCollapsed": false, "extendNew": 0, "extendRev": 0,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+		}
+	}
+	marshal := func(v any) string {
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	conf = marshal(map[string]any{
+		"nextPos": nextPos + 1, "curDeck": deckID, "activeDecks": []int64{deckID}, "curModel": modelID,
+		"sortType": "noteFld", "sortBackwards": false, "addToCur": true, "newSpread": 0,
+		"collapseTime": 1200, "timeLim": 0, "estTimes": true, "dueCounts": true, "dayLearnFirst": false,
+	})
+	models = marshal(map[string]any{strconv.FormatInt(modelID, 10): model})
+	decks = marshal(map[string]any{"1": deckJSON(1, "Default"), strconv.FormatInt(deckID, 10): deckJSON(deckID, deck)})
+	dconf = marshal(map[string]any{"1": map[string]any{
+		"id": 1, "name": "Default", "mod": 0, "usn": 0, "maxTaken": 60, "autoplay": true, "timer": 0,
+		"replayq": true, "dyn": false,
+		"new":   map[string]any{"delays": []float64{1, 10}, "ints": []int{1, 4, 0}, "initialFactor": 2500, "order": 1, "perDay": 20, "bury": false},
+		"rev":   map[string]any{"perDay": 200, "ease4": 1.3, "ivlFct": 1, "maxIvl": 36500, "hardFactor": 1.2, "bury": false},
+		"lapse": map[string]any{"delays": []float64{10}, "mult": 0, "minInt": 1, "leechFails": 8, "leechAction": 1},
+	}})
+	return conf, models, decks, dconf
+}
+
+// guid derives a stable note id from the word, so exporting the same word
+// again updates the learner's existing Anki note instead of duplicating it.
+func guid(word string) string {
+	sum := sha1.Sum([]byte(strings.ToLower(word)))
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}
+
+// checksum is Anki's duplicate-detection checksum of the sort field: the
+// first 8 hex digits of its SHA-1.
+func checksum(s string) int64 {
+	sum := sha1.Sum([]byte(plainText(s)))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
@@ -0,0 +1,219 @@
+package anki
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/srs"
+)
+
+// testdata/spanish.apkg was written by SQLite itself: 300 notes spread over
+// interior and leaf pages, a models JSON on overflow pages, and indexes.
+func TestReadPackage_Fixture(t *testing.T) {
+	data, err := os.ReadFile("testdata/spanish.apkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes, err := ReadPackage(data, Mapping{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 300 {
+		t.Fatalf("got %d notes, want 300", len(notes))
+	}
+	first := notes[0]
+	if first.Word != "comer" || first.Translation != "to eat" || first.Phonetic != "ko-MER" {
+		t.Errorf("first note = %+v; HTML and sound tags should be stripped, phonetic auto-mapped", first)
+	}
+	if first.Schedule != nil {
+		t.Error("new card should have no schedule")
+	}
+	if len(first.Tags) != 1 || first.Tags[0] != "verbs" {
+		t.Errorf("tags = %q", first.Tags)
+	}
+
+	s := notes[1].Schedule
+	if s == nil {
+		t.Fatal("review card lost its schedule")
+	}
+	wantDue := time.Unix(1700006400, 0).AddDate(0, 0, 30)
+	if s.Interval != 12 || s.Ease != 2.3 || s.Lapses != 1 || !s.Due.Equal(wantDue) {
+		t.Errorf("schedule = %+v, want interval 12, ease 2.3, 1 lapse, due %v", *s, wantDue)
+	}
+}
+
+func TestReadPackage_FieldMapping(t *testing.T) {
+	data, _ := os.ReadFile("testdata/spanish.apkg")
+	notes, err := ReadPackage(data, Mapping{Word: "english", Translation: "1", Phonetic: "none"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := notes[0]; n.Word != "to eat" || n.Translation != "comer" || n.Phonetic != "" {
+		t.Errorf("mapped note = %+v", n)
+	}
+	if _, err := ReadPackage(data, Mapping{Word: "missing"}); !errors.Is(err, ErrNoNotes) {
+		t.Errorf("unknown word field: err = %v, want ErrNoNotes", err)
+	}
+}
+
+func TestReadPackage_NewFormat(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"collection.anki2", "collection.anki21b"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte("x"))
+	}
+	zw.Close()
+	if _, err := ReadPackage(buf.Bytes(), Mapping{}); !errors.Is(err, ErrNewFormat) {
+		t.Errorf("err = %v, want ErrNewFormat", err)
+	}
+}
+
+func TestPackageRoundTrip(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var notes []Note
+	for i := 0; i < 1500; i++ {
+		n := Note{Word: fmt.Sprintf("parola %d", i), Translation: "word <" + fmt.Sprint(i) + "> & more", Tags: []string{"vocab"}}
+		if i%2 == 0 {
+			n.Schedule = &srs.State{Reps: 3, Ease: 2.2, Interval: 9, Due: now.AddDate(0, 0, i%20-5)}
+		}
+		notes = append(notes, n)
+	}
+	notes[7].Translation = strings.Repeat("a long translation ", 500) // overflow pages
+
+	var buf bytes.Buffer
+	if err := WritePackage(&buf, "Italian", notes, now); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadPackage(buf.Bytes(), Mapping{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(notes) {
+		t.Fatalf("read %d notes, wrote %d", len(got), len(notes))
+	}
+	for i := range notes {
+		w, g := notes[i], got[i]
+		if g.Word != w.Word || g.Translation != strings.TrimSpace(w.Translation) || strings.Join(g.Tags, " ") != "vocab" {
+			t.Fatalf("note %d = %+v, want %+v", i, g, w)
+		}
+		if (w.Schedule == nil) != (g.Schedule == nil) {
+			t.Fatalf("note %d schedule = %v, want %v", i, g.Schedule, w.Schedule)
+		}
+		if w.Schedule != nil && (g.Schedule.Interval != 9 || g.Schedule.Ease != 2.2 ||
+			!g.Schedule.Due.Equal(w.Schedule.Due.Truncate(24*time.Hour))) {
+			t.Fatalf("note %d schedule = %+v, want %+v", i, *g.Schedule, *w.Schedule)
+		}
+	}
+}
+
+func TestRecordEncoding(t *testing.T) {
+	vals := []any{nil, int64(0), int64(-1), int64(300), int64(-70000), int64(1 << 40), int64(-1 << 60), 2.5, "héllo", []byte{1, 2}}
+	got, err := decodeRecord(encodeRecord(vals))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(vals) {
+		t.Errorf("decoded %v, want %v", got, vals)
+	}
+	for _, v := range []uint64{0, 127, 128, 16383, 1 << 30, 1<<56 - 1, 1 << 62} {
+		if got, _ := readVarint(appendVarint(nil, v)); got != v {
+			t.Errorf("varint %d decoded as %d", v, got)
+		}
+	}
+}
+
+// corruptDB writes rows into table "t" and returns the file and the table's
+// root page, for tests that then damage the file.
+func corruptDB(t *testing.T, rows [][]any) (data []byte, root int) {
+	t.Helper()
+	data, err := writeSQLite([]sqliteTable{{name: "t", sql: "CREATE TABLE t (v)", rows: rows}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, _ := openSQLite(data)
+	if root, err = db.table("t"); err != nil {
+		t.Fatal(err)
+	}
+	return data, root
+}
+
+func readTable(data []byte) error {
+	db, err := openSQLite(data)
+	if err != nil {
+		return err
+	}
+	root, err := db.table("t")
+	if err != nil {
+		return err
+	}
+	return db.rows(root, func(int64, []any) error { return nil })
+}
+
+func pageAt(data []byte, n int) []byte {
+	return data[(n-1)*writePageSize : n*writePageSize]
+}
+
+func TestReadSQLite_Truncated(t *testing.T) {
+	var rows [][]any
+	for i := 0; i < 2000; i++ {
+		rows = append(rows, []any{fmt.Sprintf("row %d", i)})
+	}
+	data, _ := corruptDB(t, rows)
+	for _, n := range []int{100, 512, writePageSize, writePageSize + 1, len(data) / 2, len(data) - 1} {
+		if err := readTable(data[:n]); err == nil {
+			t.Errorf("truncated to %d bytes: no error", n)
+		}
+	}
+
+	// A cell count far beyond the page's cell-pointer array
+	data, root := corruptDB(t, [][]any{{"uno"}})
+	binary.BigEndian.PutUint16(pageAt(data, root)[3:], 0xFFFF)
+	if err := readTable(data); err == nil {
+		t.Error("cell count past the page: no error")
+	}
+}
+
+func TestReadSQLite_Cycles(t *testing.T) {
+	var rows [][]any
+	for i := 0; i < 2000; i++ {
+		rows = append(rows, []any{fmt.Sprintf("row %d", i)})
+	}
+	data, root := corruptDB(t, rows)
+	p := pageAt(data, root)
+	if p[0] != 0x05 {
+		t.Fatalf("root page type %#x, want an interior page", p[0])
+	}
+	// The right-most child points back at the root
+	binary.BigEndian.PutUint32(p[8:], uint32(root))
+	if err := readTable(data); err == nil {
+		t.Error("b-tree cycle: no error")
+	}
+
+	// One row spilling onto overflow pages 2, 3, ...: page 2 links to itself
+	data, _ = corruptDB(t, [][]any{{strings.Repeat("x", 3*writePageSize)}})
+	binary.BigEndian.PutUint32(pageAt(data, 2), 2)
+	if err := readTable(data); err == nil {
+		t.Error("overflow cycle: no error")
+	}
+}
+
+func TestReadSQLite_OversizedCell(t *testing.T) {
+	for _, size := range []uint64{1 << 40, 1<<63 + 5} {
+		data, root := corruptDB(t, [][]any{{strings.Repeat("x", 40)}})
+		p := pageAt(data, root)
+		off := int(binary.BigEndian.Uint16(p[8:]))
+		cell := appendVarint(appendVarint(nil, size), 1)
+		copy(p[off:], cell)
+		if err := readTable(data); err == nil {
+			t.Errorf("cell of %d bytes: no error", size)
+		}
+	}
+}
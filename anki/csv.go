@@ -0,0 +1,141 @@
+package anki
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ReadCSV reads notes from a CSV or tab-separated file. The separator is
+// detected from the first line unless an Anki "#separator:" header sets it.
+// Field names come from an Anki "#columns:" header, or from the first row
+// when the mapping refers to fields by name.
+func ReadCSV(r io.Reader, m Mapping) ([]Note, error) {
+	br := bufio.NewReader(r)
+	sep := rune(0)
+	var names []string
+	// Anki text exports start with "#key:value" header lines.
+	for {
+		peek, err := br.Peek(1)
+		if err != nil || peek[0] != '#' {
+			break
+		}
+		line, _ := br.ReadString('\n')
+		key, value, _ := strings.Cut(strings.TrimSpace(line[1:]), ":")
+		switch strings.ToLower(key) {
+		case "separator":
+			sep = separators[strings.ToLower(value)]
+		case "columns":
+			names = splitHeader(value, sep)
+		}
+	}
+	if sep == 0 {
+		first, _ := br.Peek(4096)
+		line, _, _ := strings.Cut(string(first), "\n")
+		sep = detectSeparator(line)
+	}
+
+	cr := csv.NewReader(br)
+	cr.Comma = sep
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	byName := names == nil && (isName(m.Word) || isName(m.Translation) || isName(m.Phonetic))
+
+	var notes []Note
+	for len(notes) < MaxNotes {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if byName {
+			names, byName = rec, false
+			continue
+		}
+		n := mapNote(names, rec, m)
+		if n.Word == "" || (names == nil && len(notes) == 0 && strings.EqualFold(n.Word, "word")) {
+			continue // blank line or an unmapped header row
+		}
+		if utf8.RuneCountInString(n.Word) > MaxWordRunes {
+			continue
+		}
+		if names != nil {
+			if i := fieldIndex(names, "tags", -1); i >= 0 && i < len(rec) {
+				n.Tags = strings.Fields(rec[i])
+			}
+		}
+		notes = append(notes, n)
+	}
+	if len(notes) == 0 {
+		return nil, ErrNoNotes
+	}
+	return notes, nil
+}
+
+var separators = map[string]rune{
+	"comma": ',', ",": ',', "semicolon": ';', ";": ';', "tab": '\t', "\t": '\t', "pipe": '|', "|": '|',
+}
+
+func detectSeparator(line string) rune {
+	best, count := ',', strings.Count(line, ",")
+	for _, c := range []rune{'\t', ';', '|'} {
+		if n := strings.Count(line, string(c)); n > count {
+			best, count = c, n
+		}
+	}
+	return best
+}
+
+func splitHeader(value string, sep rune) []string {
+	if sep == 0 {
+		sep = detectSeparator(value)
+	}
+	names := strings.Split(value, string(sep))
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	return names
+}
+
+// isName reports whether a mapping spec names a field rather than giving
+// its position.
+func isName(spec string) bool {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return false
+	}
+	for _, r := range spec {
+		if r < '0' || r > '9' {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteCSV writes notes as a comma-separated file with Anki's text-import
+// headers, so the file imports into Anki as is and back into ReadCSV with
+// its column names.
+func WriteCSV(w io.Writer, notes []Note) error {
+	header := "#separator:comma\n#html:false\n#columns:Word,Translation,Phonetic,Tags,Due\n#tags column:4\n"
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	for _, n := range notes {
+		due := ""
+		if n.Schedule != nil {
+			due = n.Schedule.Due.UTC().Format(time.RFC3339)
+		}
+		if err := cw.Write([]string{n.Word, n.Translation, n.Phonetic, strings.Join(n.Tags, " "), due}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
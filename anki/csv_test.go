@@ -0,0 +1,86 @@
+package anki
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/srs"
+)
+
+func TestReadCSV(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		m    Mapping
+		want []Note
+	}{
+		{
+			name: "plain comma, positional",
+			in:   "comer,to eat\nbeber,\"to drink, sip\"\n",
+			want: []Note{{Word: "comer", Translation: "to eat"}, {Word: "beber", Translation: "to drink, sip"}},
+		},
+		{
+			name: "semicolons detected, header row skipped",
+			in:   "word;translation\ncasa;house\n",
+			want: []Note{{Word: "casa", Translation: "house"}},
+		},
+		{
+			name: "header row mapped by name",
+			in:   "english\tspanish\tipa\ndog\tperro\t/ˈpero/\n",
+			m:    Mapping{Word: "Spanish", Translation: "English", Phonetic: "ipa"},
+			want: []Note{{Word: "perro", Translation: "dog", Phonetic: "/ˈpero/"}},
+		},
+		{
+			name: "anki headers",
+			in:   "#separator:tab\n#html:true\n#columns:Front\tBack\tTags\n<b>gato</b>\tcat\tanimals pets\n",
+			want: []Note{{Word: "gato", Translation: "cat", Tags: []string{"animals", "pets"}}},
+		},
+		{
+			name: "over-long words skipped, translations cut",
+			in:   "perro,dog\n" + strings.Repeat("a", MaxWordRunes+1) + ",long\ngato," + strings.Repeat("c", MaxFieldRunes+1) + "\n",
+			want: []Note{{Word: "perro", Translation: "dog"}, {Word: "gato", Translation: strings.Repeat("c", MaxFieldRunes)}},
+		},
+	}
+	for _, c := range cases {
+		got, err := ReadCSV(strings.NewReader(c.in), c.m)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+			continue
+		}
+		for i := range got {
+			g, w := got[i], c.want[i]
+			if g.Word != w.Word || g.Translation != w.Translation || g.Phonetic != w.Phonetic || strings.Join(g.Tags, " ") != strings.Join(w.Tags, " ") {
+				t.Errorf("%s: note %d = %+v, want %+v", c.name, i, g, w)
+			}
+		}
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	due := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	notes := []Note{
+		{Word: "l'acqua", Translation: "the water, \"aqua\"", Phonetic: "LAK-kwa", Tags: []string{"vocab", "weak"}, Schedule: &srs.State{Due: due, Interval: 3}},
+		{Word: "il cane", Translation: "the dog"},
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, notes); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "2026-05-01T00:00:00Z") {
+		t.Errorf("due date missing from export:\n%s", buf.String())
+	}
+	got, err := ReadCSV(&buf, Mapping{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Word != "l'acqua" || got[0].Translation != notes[0].Translation ||
+		got[0].Phonetic != "LAK-kwa" || strings.Join(got[0].Tags, ",") != "vocab,weak" || got[1].Word != "il cane" {
+		t.Errorf("round trip = %+v", got)
+	}
+}
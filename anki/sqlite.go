@@ -0,0 +1,535 @@
+package anki
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// A minimal SQLite 3 file-format reader and writer, enough for Anki
+// collections: it walks and builds table b-trees (with overflow pages) and
+// encodes records. Indexes, free lists and WAL files are not supported, and
+// text must be UTF-8, which Anki always uses.
+
+const sqliteMagic = "SQLite format 3\x00"
+
+var errNotSQLite = errors.New("not an SQLite database")
+
+// ── Reading ───────────────────────────────────────────────────────────────────
+
+type sqliteDB struct {
+	data     []byte
+	pageSize int
+	usable   int
+}
+
+func openSQLite(data []byte) (*sqliteDB, error) {
+	if len(data) < 100 || string(data[:16]) != sqliteMagic {
+		return nil, errNotSQLite
+	}
+	ps := int(binary.BigEndian.Uint16(data[16:18]))
+	if ps == 1 {
+		ps = 65536
+	}
+	if ps < 512 || ps&(ps-1) != 0 {
+		return nil, fmt.Errorf("sqlite: bad page size %d", ps)
+	}
+	if enc := binary.BigEndian.Uint32(data[56:60]); enc > 1 {
+		return nil, fmt.Errorf("sqlite: unsupported text encoding %d", enc)
+	}
+	return &sqliteDB{data: data, pageSize: ps, usable: ps - int(data[20])}, nil
+}
+
+func (db *sqliteDB) page(n int) ([]byte, error) {
+	off := (n - 1) * db.pageSize
+	if n < 1 || off+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("sqlite: page %d out of range", n)
+	}
+	return db.data[off : off+db.pageSize], nil
+}
+
+// table returns the root page of the named table.
+func (db *sqliteDB) table(name string) (int, error) {
+	root := 0
+	err := db.rows(1, func(_ int64, rec []any) error {
+		if len(rec) >= 4 && rec[0] == "table" && rec[1] == name {
+			if n, ok := rec[3].(int64); ok {
+				root = int(n)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if root == 0 {
+		return 0, fmt.Errorf("sqlite: no table %q", name)
+	}
+	return root, nil
+}
+
+// rows calls fn with the rowid and decoded record of every row in the table
+// b-tree rooted at page root, in rowid order.
+func (db *sqliteDB) rows(root int, fn func(rowid int64, rec []any) error) error {
+	return db.walk(root, 0, map[int]bool{}, fn)
+}
+
+// walk visits the b-tree page n. Uploaded files are untrusted, so every page
+// may be visited once: a child pointing back up the tree is an error rather
+// than an endless walk.
+func (db *sqliteDB) walk(n, depth int, visited map[int]bool, fn func(int64, []any) error) error {
+	if depth > 20 {
+		return errors.New("sqlite: b-tree too deep")
+	}
+	if visited[n] {
+		return fmt.Errorf("sqlite: page %d is linked twice", n)
+	}
+	visited[n] = true
+	p, err := db.page(n)
+	if err != nil {
+		return err
+	}
+	hdr := 0
+	if n == 1 {
+		hdr = 100
+	}
+	ncells := int(binary.BigEndian.Uint16(p[hdr+3:]))
+	switch p[hdr] {
+	case 0x0D: // table leaf
+		if hdr+8+2*ncells > len(p) {
+			return errors.New("sqlite: cell pointers overrun page")
+		}
+		for i := 0; i < ncells; i++ {
+			off := int(binary.BigEndian.Uint16(p[hdr+8+2*i:]))
+			if off >= len(p) {
+				return errors.New("sqlite: bad cell offset")
+			}
+			size, k := readVarint(p[off:])
+			rowid, k2 := readVarint(p[off+k:])
+			payload, err := db.payload(p, off+k+k2, int(size))
+			if err != nil {
+				return err
+			}
+			rec, err := decodeRecord(payload)
+			if err != nil {
+				return err
+			}
+			if err := fn(int64(rowid), rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	case 0x05: // table interior
+		if hdr+12+2*ncells > len(p) {
+			return errors.New("sqlite: cell pointers overrun page")
+		}
+		for i := 0; i < ncells; i++ {
+			off := int(binary.BigEndian.Uint16(p[hdr+12+2*i:]))
+			if off+4 > len(p) {
+				return errors.New("sqlite: bad cell offset")
+			}
+			if err := db.walk(int(binary.BigEndian.Uint32(p[off:])), depth+1, visited, fn); err != nil {
+				return err
+			}
+		}
+		return db.walk(int(binary.BigEndian.Uint32(p[hdr+8:])), depth+1, visited, fn)
+	default:
+		return fmt.Errorf("sqlite: page %d is not a table b-tree page", n)
+	}
+}
+
+// localPayload is how many bytes of a payload of size p are stored in the
+// leaf cell itself; the rest goes to overflow pages.
+func localPayload(p, usable int) int {
+	x := usable - 35
+	if p <= x {
+		return p
+	}
+	m := (usable-12)*32/255 - 23
+	k := m + (p-m)%(usable-4)
+	if k <= x {
+		return k
+	}
+	return m
+}
+
+// payload reads a cell's payload of size bytes starting at off in page p,
+// following its overflow chain. size must not exceed the database itself.
+func (db *sqliteDB) payload(p []byte, off, size int) ([]byte, error) {
+	if size < 0 || size > len(db.data) {
+		return nil, errors.New("sqlite: cell larger than the database")
+	}
+	local := localPayload(size, db.usable)
+	if off+local > len(p) || (local < size && off+local+4 > len(p)) {
+		return nil, errors.New("sqlite: cell overruns page")
+	}
+	if local == size {
+		return p[off : off+size], nil
+	}
+	out := make([]byte, 0, size)
+	out = append(out, p[off:off+local]...)
+	next := int(binary.BigEndian.Uint32(p[off+local:]))
+	visited := map[int]bool{}
+	for len(out) < size {
+		if visited[next] {
+			return nil, fmt.Errorf("sqlite: overflow page %d is linked twice", next)
+		}
+		visited[next] = true
+		op, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		chunk := min(size-len(out), db.usable-4)
+		out = append(out, op[4:4+chunk]...)
+		next = int(binary.BigEndian.Uint32(op))
+	}
+	return out, nil
+}
+
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return v, len(b)
+}
+
+func decodeRecord(b []byte) ([]any, error) {
+	hsize, n := readVarint(b)
+	if hsize > uint64(len(b)) {
+		return nil, errors.New("sqlite: bad record header")
+	}
+	var types []uint64
+	for n < int(hsize) {
+		t, k := readVarint(b[n:])
+		types = append(types, t)
+		n += k
+	}
+	body := b[hsize:]
+	rec := make([]any, len(types))
+	for i, t := range types {
+		var size int
+		switch {
+		case t == 0, t == 8, t == 9:
+			size = 0
+		case t <= 4:
+			size = int(t)
+		case t == 5:
+			size = 6
+		case t == 6, t == 7:
+			size = 8
+		case t >= 12:
+			size = int(t-12) / 2
+		default:
+			return nil, fmt.Errorf("sqlite: bad serial type %d", t)
+		}
+		if size < 0 || size > len(body) {
+			return nil, errors.New("sqlite: record overruns payload")
+		}
+		v := body[:size]
+		body = body[size:]
+		switch {
+		case t == 0:
+			rec[i] = nil
+		case t == 8:
+			rec[i] = int64(0)
+		case t == 9:
+			rec[i] = int64(1)
+		case t <= 6:
+			var x int64
+			for _, c := range v {
+				x = x<<8 | int64(c)
+			}
+			shift := 64 - 8*uint(size)
+			rec[i] = x << shift >> shift // sign-extend
+		case t == 7:
+			rec[i] = math.Float64frombits(binary.BigEndian.Uint64(v))
+		case t%2 == 0:
+			rec[i] = append([]byte(nil), v...)
+		default:
+			rec[i] = string(v)
+		}
+	}
+	return rec, nil
+}
+
+// ── Writing ───────────────────────────────────────────────────────────────────
+
+// sqliteTable is a table to write. When alias is set the first column is an
+// INTEGER PRIMARY KEY: its value is the rowid and is stored as NULL.
+type sqliteTable struct {
+	name  string
+	sql   string
+	alias bool
+	rows  [][]any
+}
+
+const writePageSize = 4096
+
+// btreeChild is a page referenced from an interior page, with the largest
+// rowid stored beneath it.
+type btreeChild struct {
+	page  int
+	maxID int64
+}
+
+type sqliteWriter struct {
+	pages [][]byte // pages[0] is page 1
+}
+
+func (w *sqliteWriter) alloc() int {
+	w.pages = append(w.pages, make([]byte, writePageSize))
+	return len(w.pages)
+}
+
+// writeSQLite encodes tables as a complete database file.
+func writeSQLite(tables []sqliteTable) ([]byte, error) {
+	w := &sqliteWriter{}
+	w.alloc() // page 1: header + sqlite_master
+
+	var master [][]byte
+	for i, t := range tables {
+		root, err := w.writeTable(t)
+		if err != nil {
+			return nil, err
+		}
+		rec := encodeRecord([]any{"table", t.name, t.name, int64(root), t.sql})
+		cell, err := w.leafCell(int64(i+1), rec)
+		if err != nil {
+			return nil, err
+		}
+		master = append(master, cell)
+	}
+	if !fits(master, writePageSize-100-8) {
+		return nil, errors.New("sqlite: schema does not fit on the first page")
+	}
+	writeLeaf(w.pages[0], 100, master)
+
+	h := w.pages[0]
+	copy(h, sqliteMagic)
+	binary.BigEndian.PutUint16(h[16:], writePageSize)
+	h[18], h[19] = 1, 1 // legacy journal mode
+	h[21], h[22], h[23] = 64, 32, 32
+	binary.BigEndian.PutUint32(h[24:], 1)                    // file change counter
+	binary.BigEndian.PutUint32(h[28:], uint32(len(w.pages))) // database size in pages
+	binary.BigEndian.PutUint32(h[40:], 1)                    // schema cookie
+	binary.BigEndian.PutUint32(h[44:], 4)                    // schema format
+	binary.BigEndian.PutUint32(h[56:], 1)                    // UTF-8
+	binary.BigEndian.PutUint32(h[92:], 1)                    // version-valid-for
+	binary.BigEndian.PutUint32(h[96:], 3045000)
+
+	out := make([]byte, 0, len(w.pages)*writePageSize)
+	for _, p := range w.pages {
+		out = append(out, p...)
+	}
+	return out, nil
+}
+
+// writeTable writes the rows as a b-tree and returns its root page.
+func (w *sqliteWriter) writeTable(t sqliteTable) (int, error) {
+	type row struct {
+		id  int64
+		rec []byte
+	}
+	rows := make([]row, len(t.rows))
+	for i, r := range t.rows {
+		id := int64(i + 1)
+		vals := r
+		if t.alias {
+			id = r[0].(int64)
+			vals = append([]any{nil}, r[1:]...)
+		}
+		rows[i] = row{id, encodeRecord(vals)}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].id < rows[j].id })
+
+	// Leaves, then interior levels until a single root remains.
+	var level []btreeChild
+	var cells [][]byte
+	var last int64
+	flush := func() {
+		n := w.alloc()
+		writeLeaf(w.pages[n-1], 0, cells)
+		level = append(level, btreeChild{n, last})
+		cells = nil
+	}
+	for i, r := range rows {
+		if i > 0 && r.id == rows[i-1].id {
+			return 0, fmt.Errorf("sqlite: duplicate rowid %d in %s", r.id, t.name)
+		}
+		cell, err := w.leafCell(r.id, r.rec)
+		if err != nil {
+			return 0, err
+		}
+		if !fits(append(cells, cell), writePageSize-8) {
+			flush()
+		}
+		cells = append(cells, cell)
+		last = r.id
+	}
+	if len(cells) > 0 || len(level) == 0 {
+		flush()
+	}
+
+	for len(level) > 1 {
+		var next, group []btreeChild
+		emit := func() {
+			n := w.alloc()
+			writeInterior(w.pages[n-1], group)
+			next = append(next, btreeChild{n, group[len(group)-1].maxID})
+			group = nil
+		}
+		for _, c := range level {
+			// Each child but the right-most needs a cell: 4-byte page + varint key.
+			if len(group) > 0 && 12+(len(group)+1)*(2+4+9) > writePageSize {
+				emit()
+			}
+			group = append(group, c)
+		}
+		emit()
+		level = next
+	}
+	return level[0].page, nil
+}
+
+// leafCell encodes a table leaf cell, spilling to overflow pages if needed.
+func (w *sqliteWriter) leafCell(rowid int64, payload []byte) ([]byte, error) {
+	cell := appendVarint(nil, uint64(len(payload)))
+	cell = appendVarint(cell, uint64(rowid))
+	local := localPayload(len(payload), writePageSize)
+	cell = append(cell, payload[:local]...)
+	if local == len(payload) {
+		return cell, nil
+	}
+	rest := payload[local:]
+	first := 0
+	prev := -1
+	for len(rest) > 0 {
+		n := w.alloc()
+		if prev < 0 {
+			first = n
+		} else {
+			binary.BigEndian.PutUint32(w.pages[prev-1], uint32(n))
+		}
+		chunk := min(len(rest), writePageSize-4)
+		copy(w.pages[n-1][4:], rest[:chunk])
+		rest = rest[chunk:]
+		prev = n
+	}
+	return binary.BigEndian.AppendUint32(cell, uint32(first)), nil
+}
+
+func fits(cells [][]byte, space int) bool {
+	n := 0
+	for _, c := range cells {
+		n += len(c) + 2
+	}
+	return n <= space
+}
+
+func writeLeaf(p []byte, hdr int, cells [][]byte) {
+	p[hdr] = 0x0D
+	binary.BigEndian.PutUint16(p[hdr+3:], uint16(len(cells)))
+	end := len(p)
+	for i, c := range cells {
+		end -= len(c)
+		copy(p[end:], c)
+		binary.BigEndian.PutUint16(p[hdr+8+2*i:], uint16(end))
+	}
+	binary.BigEndian.PutUint16(p[hdr+5:], uint16(end))
+}
+
+func writeInterior(p []byte, children []btreeChild) {
+	p[0] = 0x05
+	cells := len(children) - 1
+	binary.BigEndian.PutUint16(p[3:], uint16(cells))
+	end := len(p)
+	for i, c := range children[:cells] {
+		cell := binary.BigEndian.AppendUint32(nil, uint32(c.page))
+		cell = appendVarint(cell, uint64(c.maxID))
+		end -= len(cell)
+		copy(p[end:], cell)
+		binary.BigEndian.PutUint16(p[12+2*i:], uint16(end))
+	}
+	binary.BigEndian.PutUint16(p[5:], uint16(end))
+	binary.BigEndian.PutUint32(p[8:], uint32(children[cells].page))
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	if v > 0x00ffffffffffffff {
+		var buf [9]byte
+		buf[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			buf[i] = byte(v&0x7f) | 0x80
+			v >>= 7
+		}
+		return append(b, buf[:]...)
+	}
+	var buf [8]byte
+	i := len(buf) - 1
+	buf[i] = byte(v & 0x7f)
+	for v >>= 7; v > 0; v >>= 7 {
+		i--
+		buf[i] = byte(v&0x7f) | 0x80
+	}
+	return append(b, buf[i:]...)
+}
+
+func encodeRecord(vals []any) []byte {
+	var types, body []byte
+	for _, v := range vals {
+		switch x := v.(type) {
+		case nil:
+			types = appendVarint(types, 0)
+		case int64:
+			t, size := intSerialType(x)
+			types = appendVarint(types, t)
+			for i := size - 1; i >= 0; i-- {
+				body = append(body, byte(x>>(8*uint(i))))
+			}
+		case float64:
+			types = appendVarint(types, 7)
+			body = binary.BigEndian.AppendUint64(body, math.Float64bits(x))
+		case string:
+			types = appendVarint(types, uint64(len(x))*2+13)
+			body = append(body, x...)
+		case []byte:
+			types = appendVarint(types, uint64(len(x))*2+12)
+			body = append(body, x...)
+		default:
+			panic(fmt.Sprintf("sqlite: unsupported value %T", v))
+		}
+	}
+	// The header size counts itself; one or two bytes cover any Anki row.
+	hsize := len(types) + 1
+	if hsize > 127 {
+		hsize++
+	}
+	return append(append(appendVarint(nil, uint64(hsize)), types...), body...)
+}
+
+func intSerialType(x int64) (uint64, int) {
+	switch {
+	case x >= -128 && x <= 127:
+		return 1, 1
+	case x >= -32768 && x <= 32767:
+		return 2, 2
+	case x >= -8388608 && x <= 8388607:
+		return 3, 3
+	case x >= -2147483648 && x <= 2147483647:
+		return 4, 4
+	case x >= -140737488355328 && x <= 140737488355327:
+		return 5, 6
+	default:
+		return 6, 8
+	}
+}
@@ -0,0 +1,420 @@
+// Package conjugate builds verb conjugation tables for Italian, Spanish and
+// Portuguese deterministically, so drills never depend on a model producing
+// a paradigm.
+//
+// Regular verbs are conjugated by rule, including the spelling changes each
+// language makes to keep a stem's sound (busqué, cerchi, conheço). The verbs
+// that can be drilled and everything irregular about them are shipped as
+// data files (data/<lang>.tsv), one verb<TAB>key<TAB>value line per fact:
+//
+//	hablar	verb	A1	to speak          declares a drillable verb
+//	pensar	stem	e>ie                  stem change (Spanish)
+//	finire	isc                           -isc- verb (Italian)
+//	arrivare	aux	essere            perfect auxiliary (Italian)
+//	hacer	participle	hecho
+//	tener	future	tendr             future and conditional stem
+//	tener	present	tengo,tienes,…    irregular forms, "-" keeps the regular one
+package conjugate
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Tense IDs. Not every language has every tense.
+const (
+	Present     = "present"
+	Preterite   = "preterite" // es pretérito indefinido, pt pretérito perfeito
+	Perfect     = "perfect"   // es pretérito perfecto, it passato prossimo
+	Imperfect   = "imperfect"
+	Future      = "future"
+	Conditional = "conditional"
+	Subjunctive = "subjunctive" // present subjunctive
+)
+
+// Tense describes a tense of one language.
+type Tense struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`    // name in the language, e.g. "Passato prossimo"
+	Band    string `json:"band"`    // CEFR band where learners meet it
+	Concept string `json:"concept"` // grammar concept ID drill results count towards
+}
+
+// Person is one grammatical person. Index runs 0-5: 1st, 2nd and 3rd
+// singular, then plural.
+type Person struct {
+	Index   int    `json:"index"`
+	Pronoun string `json:"pronoun"`
+}
+
+// Verb is a drillable verb.
+type Verb struct {
+	Infinitive string `json:"infinitive"`
+	Band       string `json:"band"`
+	Gloss      string `json:"gloss"` // English meaning, "to speak"
+}
+
+// Form is a conjugated form for one person.
+type Form struct {
+	Person       int      `json:"person"`
+	Pronoun      string   `json:"pronoun"`
+	Text         string   `json:"text"`
+	Alternatives []string `json:"alternatives,omitempty"` // also correct, e.g. "sono arrivata"
+}
+
+var (
+	ErrUnsupported  = errors.New("conjugate: language not supported")
+	ErrUnknownVerb  = errors.New("conjugate: unknown verb")
+	ErrUnknownTense = errors.New("conjugate: unknown tense")
+)
+
+var tenses = map[string][]Tense{
+	"es": {
+		{Present, "Presente", "A1", "es.present-tense"},
+		{Preterite, "Pretérito indefinido", "A2", "es.preterite"},
+		{Perfect, "Pretérito perfecto", "A2", "es.perfect-tenses"},
+		{Imperfect, "Pretérito imperfecto", "A2", "es.imperfect"},
+		{Future, "Futuro simple", "B1", "es.future-conditional"},
+		{Conditional, "Condicional", "B1", "es.future-conditional"},
+		{Subjunctive, "Presente de subjuntivo", "B1", "es.subjunctive-present"},
+	},
+	"it": {
+		{Present, "Presente", "A1", "it.present-tense"},
+		{Perfect, "Passato prossimo", "A2", "it.passato-prossimo-aux"},
+		{Imperfect, "Imperfetto", "A2", "it.imperfetto"},
+		{Future, "Futuro semplice", "B1", "it.future-conditional"},
+		{Conditional, "Condizionale presente", "B1", "it.future-conditional"},
+		{Subjunctive, "Congiuntivo presente", "B1", "it.congiuntivo"},
+	},
+	"pt": {
+		{Present, "Presente", "A1", "pt.present-tense"},
+		{Preterite, "Pretérito perfeito", "A2", "pt.preterite"},
+		{Imperfect, "Pretérito imperfeito", "A2", "pt.imperfect"},
+		{Future, "Futuro do presente", "B1", "pt.future-conditional"},
+		{Conditional, "Futuro do pretérito", "B1", "pt.future-conditional"},
+		{Subjunctive, "Presente do subjuntivo", "B1", "pt.subjunctive-present"},
+	},
+}
+
+// persons lists the persons drilled per language. Portuguese leaves out
+// vós, which is no longer in everyday use.
+var persons = map[string][]Person{
+	"es": {{0, "yo"}, {1, "tú"}, {2, "él/ella"}, {3, "nosotros"}, {4, "vosotros"}, {5, "ellos/ellas"}},
+	"it": {{0, "io"}, {1, "tu"}, {2, "lui/lei"}, {3, "noi"}, {4, "voi"}, {5, "loro"}},
+	"pt": {{0, "eu"}, {1, "tu"}, {2, "ele/ela"}, {3, "nós"}, {5, "eles/elas"}},
+}
+
+// bands orders the CEFR bands.
+var bands = map[string]int{"A1": 1, "A2": 2, "B1": 3, "B2": 4, "C1": 5, "C2": 6}
+
+// verbData is a verb with everything irregular about it.
+type verbData struct {
+	Verb
+	stemChange string // Spanish "e>ie", "o>ue", "e>i", "u>ue"
+	isc        bool
+	aux        string
+	participle string
+	future     string
+	forms      map[string][6]string // tense → irregular forms, "" = regular
+}
+
+//go:embed data/*.tsv
+var data embed.FS
+
+var (
+	loadOnce sync.Once
+	verbs    map[string]map[string]*verbData // lang → infinitive → verb
+	order    map[string][]string             // lang → infinitives in file order
+)
+
+func load() {
+	verbs = make(map[string]map[string]*verbData)
+	order = make(map[string][]string)
+	files, _ := data.ReadDir("data")
+	for _, f := range files {
+		lang := strings.TrimSuffix(f.Name(), ".tsv")
+		file, err := data.Open(path.Join("data", f.Name()))
+		if err != nil {
+			log.Printf("conjugate: %v", err)
+			continue
+		}
+		vs := make(map[string]*verbData)
+		sc := bufio.NewScanner(file)
+		for n := 1; sc.Scan(); n++ {
+			line := sc.Text()
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if err := parseLine(lang, vs, strings.Split(line, "\t")); err != nil {
+				log.Printf("conjugate: %s:%d: %v", f.Name(), n, err)
+				continue
+			}
+			if fields := strings.Split(line, "\t"); fields[1] == "verb" {
+				order[lang] = append(order[lang], fields[0])
+			}
+		}
+		file.Close()
+		verbs[lang] = vs
+	}
+}
+
+func parseLine(lang string, vs map[string]*verbData, fields []string) error {
+	if len(fields) < 2 {
+		return fmt.Errorf("too few fields")
+	}
+	inf, key := fields[0], fields[1]
+	if key == "verb" {
+		if len(fields) != 4 || bands[fields[2]] == 0 {
+			return fmt.Errorf("verb line needs a band and a gloss")
+		}
+		if vs[inf] != nil {
+			return fmt.Errorf("%s declared twice", inf)
+		}
+		vs[inf] = &verbData{Verb: Verb{Infinitive: inf, Band: fields[2], Gloss: fields[3]}, forms: map[string][6]string{}}
+		return nil
+	}
+	v := vs[inf]
+	if v == nil {
+		return fmt.Errorf("%s is not declared", inf)
+	}
+	if key == "isc" {
+		v.isc = true
+		return nil
+	}
+	if len(fields) != 3 {
+		return fmt.Errorf("%s %s needs a value", inf, key)
+	}
+	val := fields[2]
+	switch key {
+	case "stem":
+		v.stemChange = val
+	case "aux":
+		v.aux = val
+	case "participle":
+		v.participle = val
+	case "future":
+		v.future = val
+	default:
+		if tenseOf(lang, key) == nil {
+			return fmt.Errorf("unknown key %q", key)
+		}
+		parts := strings.Split(val, ",")
+		if len(parts) != 6 {
+			return fmt.Errorf("%s %s needs 6 forms", inf, key)
+		}
+		var forms [6]string
+		for i, p := range parts {
+			if p = strings.TrimSpace(p); p != "-" {
+				forms[i] = p
+			}
+		}
+		v.forms[key] = forms
+	}
+	return nil
+}
+
+func tenseOf(lang, id string) *Tense {
+	for i, t := range tenses[lang] {
+		if t.ID == id {
+			return &tenses[lang][i]
+		}
+	}
+	return nil
+}
+
+// Supported reports whether conjugation tables are available for lang.
+func Supported(lang string) bool {
+	loadOnce.Do(load)
+	return len(verbs[lang]) > 0 && tenses[lang] != nil
+}
+
+// Tenses returns the tenses of lang, easiest first.
+func Tenses(lang string) []Tense {
+	return tenses[lang]
+}
+
+// LookupTense returns tense id of lang.
+func LookupTense(lang, id string) (Tense, bool) {
+	if t := tenseOf(lang, id); t != nil {
+		return *t, true
+	}
+	return Tense{}, false
+}
+
+// Persons returns the persons drilled in lang.
+func Persons(lang string) []Person {
+	return persons[lang]
+}
+
+// Verbs returns the drillable verbs of lang up to band, most common first.
+func Verbs(lang, band string) []Verb {
+	loadOnce.Do(load)
+	var out []Verb
+	for _, inf := range order[lang] {
+		if v := verbs[lang][inf]; bands[v.Band] <= bands[band] {
+			out = append(out, v.Verb)
+		}
+	}
+	return out
+}
+
+// LookupVerb returns a drillable verb of lang.
+func LookupVerb(lang, infinitive string) (Verb, bool) {
+	loadOnce.Do(load)
+	if v := verbs[lang][infinitive]; v != nil {
+		return v.Verb, true
+	}
+	return Verb{}, false
+}
+
+// InBand reports whether band is at or below limit.
+func InBand(band, limit string) bool {
+	return bands[band] <= bands[limit]
+}
+
+// StemChanging reports whether a Spanish verb changes its stem vowel.
+func StemChanging(lang, infinitive string) bool {
+	loadOnce.Do(load)
+	v := verbs[lang][infinitive]
+	return v != nil && v.stemChange != ""
+}
+
+// Match is a verb form recognised by Identify.
+type Match struct {
+	Infinitive string
+	Tense      Tense
+}
+
+var (
+	formsOnce sync.Once
+	forms     map[string]map[string][]Match // lang → lower-case form → matches
+)
+
+func indexForms() {
+	loadOnce.Do(load)
+	forms = make(map[string]map[string][]Match)
+	for lang := range verbs {
+		idx := make(map[string][]Match)
+		for _, inf := range order[lang] {
+			for _, t := range tenses[lang] {
+				all, err := table(lang, inf, t.ID)
+				if err != nil {
+					continue
+				}
+				seen := map[string]bool{}
+				for _, p := range persons[lang] {
+					for _, f := range append([]string{all[p.Index].Text}, all[p.Index].Alternatives...) {
+						if !seen[f] {
+							seen[f] = true
+							idx[f] = append(idx[f], Match{Infinitive: inf, Tense: t})
+						}
+					}
+				}
+			}
+		}
+		forms[lang] = idx
+	}
+}
+
+// Identify returns the verbs and tenses form can be a conjugation of:
+// "hablamos" is hablar in the present and the preterite.
+// Compound forms are written with one space ("ha hablado"). Only the
+// drillable verbs are known.
+func Identify(lang, form string) []Match {
+	formsOnce.Do(indexForms)
+	return forms[lang][strings.ToLower(form)]
+}
+
+// Conjugate returns the forms of a verb in a tense for the persons drilled
+// in lang.
+func Conjugate(lang, infinitive, tense string) ([]Form, error) {
+	all, err := table(lang, infinitive, tense)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Form, 0, len(persons[lang]))
+	for _, p := range persons[lang] {
+		out = append(out, all[p.Index])
+	}
+	return out, nil
+}
+
+// Conjugation returns the form of a verb in a tense for one person.
+func Conjugation(lang, infinitive, tense string, person int) (Form, error) {
+	forms, err := Conjugate(lang, infinitive, tense)
+	if err != nil {
+		return Form{}, err
+	}
+	for _, f := range forms {
+		if f.Person == person {
+			return f, nil
+		}
+	}
+	return Form{}, fmt.Errorf("conjugate: person %d not drilled in %s", person, lang)
+}
+
+func table(lang, infinitive, tense string) ([6]Form, error) {
+	var out [6]Form
+	if !Supported(lang) {
+		return out, ErrUnsupported
+	}
+	v := verbs[lang][infinitive]
+	if v == nil {
+		return out, ErrUnknownVerb
+	}
+	if tenseOf(lang, tense) == nil {
+		return out, ErrUnknownTense
+	}
+	var texts [6]string
+	var alts [6][]string
+	switch lang {
+	case "es":
+		texts = esForms(v, tense)
+	case "it":
+		texts, alts = itForms(v, tense)
+	case "pt":
+		texts = ptForms(v, tense)
+	}
+	pronouns := map[int]string{}
+	for _, p := range persons[lang] {
+		pronouns[p.Index] = p.Pronoun
+	}
+	for i := range out {
+		out[i] = Form{Person: i, Pronoun: pronouns[i], Text: texts[i], Alternatives: alts[i]}
+	}
+	return out, nil
+}
+
+// withOverrides returns regular with the verb's irregular forms of tense
+// swapped in.
+func (v *verbData) withOverrides(tense string, regular [6]string) [6]string {
+	if forms, ok := v.forms[tense]; ok {
+		for i, f := range forms {
+			if f != "" {
+				regular[i] = f
+			}
+		}
+	}
+	return regular
+}
+
+// split returns the stem and infinitive ending (class) of v.
+func (v *verbData) split(endingLen int) (stem, class string) {
+	inf := v.Infinitive
+	return inf[:len(inf)-endingLen], inf[len(inf)-endingLen:]
+}
+
+// build joins stem to each ending with join.
+func build(stem string, endings [6]string, join func(stem, ending string) string) [6]string {
+	var out [6]string
+	for i, e := range endings {
+		out[i] = join(stem, e)
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package conjugate
+
+import (
+	"testing"
+
+	"github.com/ailanguagetutor/grammar"
+)
+
+func TestForms(t *testing.T) {
+	cases := []struct {
+		lang, verb, tense string
+		person            int
+		want              string
+	}{
+		// Spanish: regular, spelling changes, stem changes, irregulars
+		{"es", "hablar", Preterite, 2, "habló"},
+		{"es", "comer", Imperfect, 3, "comíamos"},
+		{"es", "vivir", Future, 0, "viviré"},
+		{"es", "buscar", Preterite, 0, "busqué"},
+		{"es", "buscar", Subjunctive, 0, "busque"},
+		{"es", "empezar", Preterite, 0, "empecé"},
+		{"es", "empezar", Subjunctive, 3, "empecemos"},
+		{"es", "pensar", Present, 0, "pienso"},
+		{"es", "pensar", Present, 3, "pensamos"},
+		{"es", "pensar", Subjunctive, 3, "pensemos"},
+		{"es", "jugar", Subjunctive, 0, "juegue"},
+		{"es", "jugar", Subjunctive, 3, "juguemos"},
+		{"es", "dormir", Preterite, 5, "durmieron"},
+		{"es", "dormir", Subjunctive, 3, "durmamos"},
+		{"es", "sentir", Preterite, 2, "sintió"},
+		{"es", "seguir", Present, 0, "sigo"},
+		{"es", "seguir", Present, 1, "sigues"},
+		{"es", "seguir", Subjunctive, 3, "sigamos"},
+		{"es", "tener", Future, 0, "tendré"},
+		{"es", "tener", Subjunctive, 3, "tengamos"},
+		{"es", "conocer", Subjunctive, 0, "conozca"},
+		{"es", "hacer", Perfect, 2, "ha hecho"},
+		{"es", "ir", Perfect, 0, "he ido"},
+		{"es", "ir", Conditional, 3, "iríamos"},
+		{"es", "ver", Subjunctive, 0, "vea"},
+		{"es", "ser", Imperfect, 3, "éramos"},
+		// Italian
+		{"it", "parlare", Present, 3, "parliamo"},
+		{"it", "cercare", Present, 1, "cerchi"},
+		{"it", "cercare", Future, 0, "cercherò"},
+		{"it", "pagare", Conditional, 2, "pagherebbe"},
+		{"it", "mangiare", Present, 1, "mangi"},
+		{"it", "mangiare", Future, 0, "mangerò"},
+		{"it", "studiare", Future, 3, "studieremo"},
+		{"it", "cominciare", Present, 3, "cominciamo"},
+		{"it", "finire", Present, 5, "finiscono"},
+		{"it", "capire", Subjunctive, 0, "capisca"},
+		{"it", "credere", Imperfect, 5, "credevano"},
+		{"it", "dormire", Present, 5, "dormono"},
+		{"it", "parlare", Perfect, 0, "ho parlato"},
+		{"it", "arrivare", Perfect, 3, "siamo arrivati"},
+		{"it", "prendere", Perfect, 2, "ha preso"},
+		{"it", "essere", Perfect, 0, "sono stato"},
+		{"it", "andare", Future, 0, "andrò"},
+		{"it", "fare", Imperfect, 0, "facevo"},
+		{"it", "venire", Perfect, 5, "sono venuti"},
+		// Portuguese
+		{"pt", "falar", Preterite, 2, "falou"},
+		{"pt", "comer", Preterite, 2, "comeu"},
+		{"pt", "partir", Preterite, 0, "parti"},
+		{"pt", "ficar", Preterite, 0, "fiquei"},
+		{"pt", "chegar", Subjunctive, 0, "chegue"},
+		{"pt", "começar", Subjunctive, 2, "comece"},
+		{"pt", "conhecer", Present, 0, "conheço"},
+		{"pt", "conhecer", Subjunctive, 3, "conheçamos"},
+		{"pt", "dormir", Subjunctive, 3, "durmamos"},
+		{"pt", "ter", Subjunctive, 0, "tenha"},
+		{"pt", "ter", Imperfect, 3, "tínhamos"},
+		{"pt", "fazer", Future, 0, "farei"},
+		{"pt", "estudar", Imperfect, 3, "estudávamos"},
+		{"pt", "ver", Subjunctive, 5, "vejam"},
+	}
+	for _, c := range cases {
+		f, err := Conjugation(c.lang, c.verb, c.tense, c.person)
+		if err != nil {
+			t.Errorf("%s %s %s %d: %v", c.lang, c.verb, c.tense, c.person, err)
+			continue
+		}
+		if f.Text != c.want {
+			t.Errorf("%s %s %s %d = %q, want %q", c.lang, c.verb, c.tense, c.person, f.Text, c.want)
+		}
+	}
+}
+
+// seguir drops the u of its stem before every back vowel, stressed or not:
+// sigáis, not siguáis.
+func TestSeguirSubjunctive(t *testing.T) {
+	forms, err := Conjugate("es", "seguir", Subjunctive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"siga", "sigas", "siga", "sigamos", "sigáis", "sigan"}
+	if len(forms) != len(want) {
+		t.Fatalf("got %d forms, want %d", len(forms), len(want))
+	}
+	for i, f := range forms {
+		if f.Text != want[i] {
+			t.Errorf("seguir subjunctive %d = %q, want %q", i, f.Text, want[i])
+		}
+	}
+}
+
+func TestEssereAgreement(t *testing.T) {
+	f, err := Conjugation("it", "arrivare", Perfect, 0)
+	if err != nil || len(f.Alternatives) != 1 || f.Alternatives[0] != "sono arrivata" {
+		t.Errorf("arrivare 1s = %+v, %v", f, err)
+	}
+	if f, _ := Conjugation("it", "parlare", Perfect, 0); len(f.Alternatives) != 0 {
+		t.Errorf("avere verbs do not agree: %+v", f)
+	}
+}
+
+func TestTablesComplete(t *testing.T) {
+	for _, lang := range []string{"es", "it", "pt"} {
+		if !Supported(lang) {
+			t.Fatalf("%s not supported", lang)
+		}
+		verbs := Verbs(lang, "C2")
+		if len(verbs) < 30 {
+			t.Errorf("%s: only %d verbs", lang, len(verbs))
+		}
+		for _, tense := range Tenses(lang) {
+			if _, ok := grammar.Lookup(tense.Concept); !ok {
+				t.Errorf("%s %s: unknown concept %s", lang, tense.ID, tense.Concept)
+			}
+			for _, v := range verbs {
+				forms, err := Conjugate(lang, v.Infinitive, tense.ID)
+				if err != nil || len(forms) != len(Persons(lang)) {
+					t.Fatalf("%s %s %s: %v", lang, v.Infinitive, tense.ID, err)
+				}
+				for _, f := range forms {
+					if f.Text == "" || f.Pronoun == "" {
+						t.Errorf("%s %s %s: empty form %+v", lang, v.Infinitive, tense.ID, f)
+					}
+				}
+			}
+		}
+	}
+	if Supported("en") {
+		t.Error("en should not be supported")
+	}
+	if _, err := Conjugate("es", "xyzar", Present); err != ErrUnknownVerb {
+		t.Errorf("unknown verb: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package conjugate
+
+import "strings"
+
+var esEndings = map[string]map[string][6]string{
+	"ar": {
+		Present:     {"o", "as", "a", "amos", "áis", "an"},
+		Preterite:   {"é", "aste", "ó", "amos", "asteis", "aron"},
+		Imperfect:   {"aba", "abas", "aba", "ábamos", "abais", "aban"},
+		Subjunctive: {"e", "es", "e", "emos", "éis", "en"},
+	},
+	"er": {
+		Present:     {"o", "es", "e", "emos", "éis", "en"},
+		Preterite:   {"í", "iste", "ió", "imos", "isteis", "ieron"},
+		Imperfect:   {"ía", "ías", "ía", "íamos", "íais", "ían"},
+		Subjunctive: {"a", "as", "a", "amos", "áis", "an"},
+	},
+	"ir": {
+		Present:     {"o", "es", "e", "imos", "ís", "en"},
+		Preterite:   {"í", "iste", "ió", "imos", "isteis", "ieron"},
+		Imperfect:   {"ía", "ías", "ía", "íamos", "íais", "ían"},
+		Subjunctive: {"a", "as", "a", "amos", "áis", "an"},
+	},
+}
+
+var (
+	esFuture      = [6]string{"é", "ás", "á", "emos", "éis", "án"}
+	esConditional = [6]string{"ía", "ías", "ía", "íamos", "íais", "ían"}
+	esHaber       = [6]string{"he", "has", "ha", "hemos", "habéis", "han"}
+)
+
+// esJoin adds ending to stem, respelling the stem's last consonant so it
+// keeps its sound: busc+é → busqué, empez+é → empecé, sig+o → sigo.
+func esJoin(class string) func(stem, ending string) string {
+	return func(stem, ending string) string {
+		front := strings.HasPrefix(ending, "e") || strings.HasPrefix(ending, "é")
+		back := strings.HasPrefix(ending, "a") || strings.HasPrefix(ending, "á") ||
+			strings.HasPrefix(ending, "o") || strings.HasPrefix(ending, "ó")
+		switch {
+		case class == "ar" && front && strings.HasSuffix(stem, "c"):
+			stem = strings.TrimSuffix(stem, "c") + "qu"
+		case class == "ar" && front && strings.HasSuffix(stem, "g"):
+			stem += "u"
+		case class == "ar" && front && strings.HasSuffix(stem, "z"):
+			stem = strings.TrimSuffix(stem, "z") + "c"
+		case class != "ar" && back && strings.HasSuffix(stem, "gu"):
+			stem = strings.TrimSuffix(stem, "u")
+		case class != "ar" && back && strings.HasSuffix(stem, "g"):
+			stem = strings.TrimSuffix(stem, "g") + "j"
+		}
+		return stem + ending
+	}
+}
+
+// changeStem applies a vowel change ("e>ie") to the last matching vowel.
+func changeStem(stem, change string) string {
+	from, to, ok := strings.Cut(change, ">")
+	if !ok {
+		return stem
+	}
+	i := strings.LastIndex(stem, from)
+	if i < 0 {
+		return stem
+	}
+	return stem[:i] + to + stem[i+len(from):]
+}
+
+// esRaisedStem is the stem of -ir stem-changing verbs where the vowel is
+// raised instead of diphthongised: sintió, durmamos.
+func esRaisedStem(stem, change string) string {
+	switch change {
+	case "e>ie", "e>i":
+		return changeStem(stem, "e>i")
+	case "o>ue":
+		return changeStem(stem, "o>u")
+	}
+	return stem
+}
+
+func esForms(v *verbData, tense string) [6]string {
+	stem, class := v.split(2)
+	join := esJoin(class)
+	endings := esEndings[class]
+	var out [6]string
+
+	switch tense {
+	case Present:
+		out = build(stem, endings[Present], join)
+		if v.stemChange != "" {
+			changed := changeStem(stem, v.stemChange)
+			for _, i := range []int{0, 1, 2, 5} {
+				out[i] = join(changed, endings[Present][i])
+			}
+		}
+	case Preterite:
+		out = build(stem, endings[Preterite], join)
+		if class == "ir" && v.stemChange != "" {
+			raised := esRaisedStem(stem, v.stemChange)
+			out[2] = join(raised, endings[Preterite][2])
+			out[5] = join(raised, endings[Preterite][5])
+		}
+	case Imperfect:
+		out = build(stem, endings[Imperfect], join)
+	case Future, Conditional:
+		fstem := v.Infinitive
+		if v.future != "" {
+			fstem = v.future
+		}
+		e := esFuture
+		if tense == Conditional {
+			e = esConditional
+		}
+		out = build(fstem, e, func(s, e string) string { return s + e })
+	case Subjunctive:
+		// Built on the yo form of the present: tengo → tenga, pienso → piense
+		// (the yo form is already spelled for a back vowel, so -er/-ir stems
+		// are not respelled again: sigo → siga, not sija).
+		yo := esForms(v, Present)[0]
+		ystem, ok := strings.CutSuffix(yo, "o")
+		if !ok {
+			ystem = stem
+		}
+		yjoin := join
+		if class != "ar" {
+			yjoin = func(s, e string) string { return s + e }
+		}
+		out = build(ystem, endings[Subjunctive], yjoin)
+		if v.stemChange != "" {
+			plain := stem
+			if class == "ir" {
+				plain = esRaisedStem(stem, v.stemChange)
+			}
+			out[3] = join(plain, endings[Subjunctive][3])
+			out[4] = join(plain, endings[Subjunctive][4])
+		}
+	case Perfect:
+		out = build("", esHaber, func(_, aux string) string { return aux + " " + esParticiple(v, stem, class) })
+	}
+	return v.withOverrides(tense, out)
+}
+
+func esParticiple(v *verbData, stem, class string) string {
+	if v.participle != "" {
+		return v.participle
+	}
+	if class == "ar" {
+		return stem + "ado"
+	}
+	return stem + "ido"
+}
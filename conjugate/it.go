@@ -0,0 +1,128 @@
+package conjugate
+
+import "strings"
+
+var itEndings = map[string]map[string][6]string{
+	"are": {
+		Present:     {"o", "i", "a", "iamo", "ate", "ano"},
+		Imperfect:   {"avo", "avi", "ava", "avamo", "avate", "avano"},
+		Subjunctive: {"i", "i", "i", "iamo", "iate", "ino"},
+	},
+	"ere": {
+		Present:     {"o", "i", "e", "iamo", "ete", "ono"},
+		Imperfect:   {"evo", "evi", "eva", "evamo", "evate", "evano"},
+		Subjunctive: {"a", "a", "a", "iamo", "iate", "ano"},
+	},
+	"ire": {
+		Present:     {"o", "i", "e", "iamo", "ite", "ono"},
+		Imperfect:   {"ivo", "ivi", "iva", "ivamo", "ivate", "ivano"},
+		Subjunctive: {"a", "a", "a", "iamo", "iate", "ano"},
+	},
+}
+
+var (
+	itIscPresent     = [6]string{"isco", "isci", "isce", "iamo", "ite", "iscono"}
+	itIscSubjunctive = [6]string{"isca", "isca", "isca", "iamo", "iate", "iscano"}
+	itFuture         = [6]string{"ò", "ai", "à", "emo", "ete", "anno"}
+	itConditional    = [6]string{"ei", "esti", "ebbe", "emmo", "este", "ebbero"}
+	itAvere          = [6]string{"ho", "hai", "ha", "abbiamo", "avete", "hanno"}
+	itEssere         = [6]string{"sono", "sei", "è", "siamo", "siete", "sono"}
+)
+
+// itJoin adds ending to stem. For -are verbs a hard c/g takes an h before
+// e and i (cerchi, pagherò), and a stem's i is not doubled or kept before e
+// where it only softens c/g (mangi, mangerò).
+func itJoin(class string) func(stem, ending string) string {
+	return func(stem, ending string) string {
+		if class != "are" {
+			return stem + ending
+		}
+		front := strings.HasPrefix(ending, "e") || strings.HasPrefix(ending, "i")
+		switch {
+		case front && (strings.HasSuffix(stem, "c") || strings.HasSuffix(stem, "g")):
+			stem += "h"
+		case strings.HasPrefix(ending, "e") && (strings.HasSuffix(stem, "ci") || strings.HasSuffix(stem, "gi")):
+			stem = strings.TrimSuffix(stem, "i")
+		case strings.HasPrefix(ending, "i") && strings.HasSuffix(stem, "i"):
+			stem = strings.TrimSuffix(stem, "i")
+		}
+		return stem + ending
+	}
+}
+
+func itForms(v *verbData, tense string) ([6]string, [6][]string) {
+	stem, class := v.split(3)
+	join := itJoin(class)
+	endings := itEndings[class]
+	var out [6]string
+	var alts [6][]string
+
+	switch tense {
+	case Present:
+		e := endings[Present]
+		if v.isc {
+			e = itIscPresent
+		}
+		out = build(stem, e, join)
+	case Imperfect:
+		out = build(stem, endings[Imperfect], join)
+	case Future, Conditional:
+		fstem := v.future
+		if fstem == "" {
+			fstem = join(stem, "er")
+			if class == "ire" {
+				fstem = stem + "ir"
+			}
+		}
+		e := itFuture
+		if tense == Conditional {
+			e = itConditional
+		}
+		out = build(fstem, e, func(s, e string) string { return s + e })
+	case Subjunctive:
+		e := endings[Subjunctive]
+		if v.isc {
+			e = itIscSubjunctive
+		}
+		out = build(stem, e, join)
+	case Perfect:
+		part := itParticiple(v, stem, class)
+		if v.aux == "essere" {
+			// The participle agrees with the subject: arrivato/arrivata,
+			// arrivati/arrivate.
+			base := strings.TrimSuffix(part, "o")
+			for i, aux := range itEssere {
+				if i < 3 {
+					out[i] = aux + " " + base + "o"
+					alts[i] = []string{aux + " " + base + "a"}
+				} else {
+					out[i] = aux + " " + base + "i"
+					alts[i] = []string{aux + " " + base + "e"}
+				}
+			}
+		} else {
+			out = build("", itAvere, func(_, aux string) string { return aux + " " + part })
+		}
+	}
+	if forms, ok := v.forms[tense]; ok {
+		for i, f := range forms {
+			if f != "" {
+				out[i], alts[i] = f, nil
+			}
+		}
+	}
+	return out, alts
+}
+
+func itParticiple(v *verbData, stem, class string) string {
+	if v.participle != "" {
+		return v.participle
+	}
+	switch class {
+	case "are":
+		return stem + "ato"
+	case "ere":
+		return stem + "uto"
+	}
+	return stem + "ito"
+}
@@ -0,0 +1,89 @@
+package conjugate
+
+import "strings"
+
+var ptEndings = map[string]map[string][6]string{
+	"ar": {
+		Present:     {"o", "as", "a", "amos", "ais", "am"},
+		Preterite:   {"ei", "aste", "ou", "amos", "astes", "aram"},
+		Imperfect:   {"ava", "avas", "ava", "ávamos", "áveis", "avam"},
+		Subjunctive: {"e", "es", "e", "emos", "eis", "em"},
+	},
+	"er": {
+		Present:     {"o", "es", "e", "emos", "eis", "em"},
+		Preterite:   {"i", "este", "eu", "emos", "estes", "eram"},
+		Imperfect:   {"ia", "ias", "ia", "íamos", "íeis", "iam"},
+		Subjunctive: {"a", "as", "a", "amos", "ais", "am"},
+	},
+	"ir": {
+		Present:     {"o", "es", "e", "imos", "is", "em"},
+		Preterite:   {"i", "iste", "iu", "imos", "istes", "iram"},
+		Imperfect:   {"ia", "ias", "ia", "íamos", "íeis", "iam"},
+		Subjunctive: {"a", "as", "a", "amos", "ais", "am"},
+	},
+}
+
+var (
+	ptFuture      = [6]string{"ei", "ás", "á", "emos", "eis", "ão"}
+	ptConditional = [6]string{"ia", "ias", "ia", "íamos", "íeis", "iam"}
+)
+
+// ptJoin adds ending to stem, respelling the stem's last consonant so it
+// keeps its sound: fic+ei → fiquei, começ+e → comece, conhec+o → conheço.
+func ptJoin(class string) func(stem, ending string) string {
+	return func(stem, ending string) string {
+		front := strings.HasPrefix(ending, "e") || strings.HasPrefix(ending, "ê")
+		back := strings.HasPrefix(ending, "a") || strings.HasPrefix(ending, "o")
+		switch {
+		case class == "ar" && front && strings.HasSuffix(stem, "c"):
+			stem = strings.TrimSuffix(stem, "c") + "qu"
+		case class == "ar" && front && strings.HasSuffix(stem, "g"):
+			stem += "u"
+		case class == "ar" && front && strings.HasSuffix(stem, "ç"):
+			stem = strings.TrimSuffix(stem, "ç") + "c"
+		case class != "ar" && back && strings.HasSuffix(stem, "c"):
+			stem = strings.TrimSuffix(stem, "c") + "ç"
+		case class != "ar" && back && strings.HasSuffix(stem, "gu"):
+			stem = strings.TrimSuffix(stem, "u")
+		case class != "ar" && back && strings.HasSuffix(stem, "g"):
+			stem = strings.TrimSuffix(stem, "g") + "j"
+		}
+		return stem + ending
+	}
+}
+
+func ptForms(v *verbData, tense string) [6]string {
+	stem, class := v.split(2)
+	join := ptJoin(class)
+	endings := ptEndings[class]
+	var out [6]string
+
+	switch tense {
+	case Present, Preterite, Imperfect:
+		out = build(stem, endings[tense], join)
+	case Future, Conditional:
+		fstem := v.Infinitive
+		if v.future != "" {
+			fstem = v.future
+		}
+		e := ptFuture
+		if tense == Conditional {
+			e = ptConditional
+		}
+		out = build(fstem, e, func(s, e string) string { return s + e })
+	case Subjunctive:
+		// Built on the eu form of the present (tenho → tenha, durmo →
+		// durmamos); -er/-ir stems are already spelled for a back vowel.
+		eu := ptForms(v, Present)[0]
+		ystem, ok := strings.CutSuffix(eu, "o")
+		if !ok {
+			ystem = stem
+		}
+		yjoin := join
+		if class != "ar" {
+			yjoin = func(s, e string) string { return s + e }
+		}
+		out = build(ystem, endings[Subjunctive], yjoin)
+	}
+	return v.withOverrides(tense, out)
+}
@@ -0,0 +1,204 @@
+// Package curriculum ranks each language's core vocabulary by frequency, so
+// learners meet the most useful words first and can see how much of the
+// core they know.
+//
+// The lists are shipped as data files (data/<lang>.tsv), one lemma<TAB>band
+// line per word, most frequent first. Bands are CEFR levels.
+package curriculum
+
+import (
+	"bufio"
+	"embed"
+	"log"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/ailanguagetutor/lemma"
+	"github.com/ailanguagetutor/textnorm"
+)
+
+// Word is one entry of a frequency list.
+type Word struct {
+	Lemma string `json:"lemma"`
+	Rank  int    `json:"rank"` // 1 = most frequent
+	Band  string `json:"band"` // CEFR band, "A1".."C2"
+}
+
+// bands orders the CEFR bands.
+var bands = map[string]int{"A1": 1, "A2": 2, "B1": 3, "B2": 4, "C1": 5, "C2": 6}
+
+// levelBands maps the app's levels 1-5 to the highest band they cover.
+var levelBands = map[int]string{1: "A1", 2: "A2", 3: "B1", 4: "B2", 5: "C1"}
+
+//go:embed data/*.tsv
+var data embed.FS
+
+var (
+	loadOnce sync.Once
+	lists    map[string][]Word
+	index    map[string]map[string]int // lang → folded lemma → position in list
+)
+
+func load() {
+	lists = make(map[string][]Word)
+	index = make(map[string]map[string]int)
+	files, _ := data.ReadDir("data")
+	for _, f := range files {
+		lang := strings.TrimSuffix(f.Name(), ".tsv")
+		file, err := data.Open(path.Join("data", f.Name()))
+		if err != nil {
+			log.Printf("curriculum: %v", err)
+			continue
+		}
+		var words []Word
+		sc := bufio.NewScanner(file)
+		for sc.Scan() {
+			line := sc.Text()
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			lem, band, ok := strings.Cut(line, "\t")
+			if !ok || bands[band] == 0 {
+				continue
+			}
+			words = append(words, Word{Lemma: lem, Rank: len(words) + 1, Band: band})
+		}
+		file.Close()
+		lists[lang] = words
+		idx := make(map[string]int, len(words))
+		for i, w := range words {
+			k := textnorm.FoldAccents(w.Lemma, lang)
+			if _, ok := idx[k]; !ok {
+				idx[k] = i
+			}
+		}
+		index[lang] = idx
+	}
+}
+
+// Words returns the frequency list of lang, most frequent first, or nil if
+// none ships. The slice is shared and must not be modified.
+func Words(lang string) []Word {
+	loadOnce.Do(load)
+	return lists[lang]
+}
+
+// Supported reports whether a frequency list ships for lang.
+func Supported(lang string) bool {
+	return len(Words(lang)) > 0
+}
+
+// LevelBand returns the highest CEFR band a level (1-5) covers.
+func LevelBand(level int) string {
+	if b, ok := levelBands[level]; ok {
+		return b
+	}
+	return "C1"
+}
+
+// Find returns the list entry for word, which may be in any inflected form:
+// the most frequent of the lemmas it can belong to. Accents are ignored.
+func Find(lang, word string) (Word, bool) {
+	loadOnce.Do(load)
+	idx := index[lang]
+	best := -1
+	try := func(lem string) {
+		if i, ok := idx[textnorm.FoldAccents(strings.ToLower(lem), lang)]; ok && (best < 0 || i < best) {
+			best = i
+		}
+	}
+	try(word)
+	for _, e := range lemma.Lookup(lang, word) {
+		try(e.Lemma)
+	}
+	if best < 0 {
+		return Word{}, false
+	}
+	return lists[lang][best], true
+}
+
+// BandOrder returns the position of a CEFR band, 1 for A1 up to 6 for C2,
+// or 0 for an unknown band.
+func BandOrder(band string) int {
+	return bands[band]
+}
+
+// Next returns up to n of the most frequent words at or below the level's
+// band that are not in seen.
+func Next(lang string, level, n int, seen *Set) []Word {
+	limit := bands[LevelBand(level)]
+	var out []Word
+	for _, w := range Words(lang) {
+		if len(out) == n {
+			break
+		}
+		if bands[w.Band] <= limit && !seen.Has(w.Lemma) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// Coverage returns how many of the top most frequent words are in known.
+func Coverage(lang string, top int, known *Set) int {
+	words := Words(lang)
+	if top > len(words) {
+		top = len(words)
+	}
+	n := 0
+	for _, w := range words[:top] {
+		if known.Has(w.Lemma) {
+			n++
+		}
+	}
+	return n
+}
+
+// Set is a set of known or seen lemmas. Words are added in any inflected
+// form and count for each lemma they may belong to, so "casas" marks both
+// "casa" and "casar"; case and accents are ignored.
+type Set struct {
+	lang   string
+	lemmas map[string]bool
+}
+
+// NewSet returns a set holding the lemmas of words in lang.
+func NewSet(lang string, words ...string) *Set {
+	s := &Set{lang: lang, lemmas: make(map[string]bool, len(words))}
+	for _, w := range words {
+		s.Add(w)
+	}
+	return s
+}
+
+func (s *Set) key(lem string) string {
+	return textnorm.FoldAccents(strings.ToLower(strings.TrimSpace(lem)), s.lang)
+}
+
+// Add puts the lemmas of word in the set. Phrases count as their lemmatised
+// form ("los libros" as "libro").
+func (s *Set) Add(word string) {
+	if strings.ContainsAny(strings.TrimSpace(word), " '’") {
+		if k := s.key(lemma.Of(s.lang, word)); k != "" {
+			s.lemmas[k] = true
+		}
+		return
+	}
+	if k := s.key(word); k != "" {
+		s.lemmas[k] = true
+	}
+	for _, e := range lemma.Lookup(s.lang, word) {
+		s.lemmas[s.key(e.Lemma)] = true
+	}
+}
+
+// Has reports whether lem is in the set.
+func (s *Set) Has(lem string) bool {
+	return s.lemmas[s.key(lem)]
+}
+
+// Len returns the number of lemmas in the set.
+func (s *Set) Len() int {
+	return len(s.lemmas)
+}
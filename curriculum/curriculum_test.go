@@ -0,0 +1,53 @@
+package curriculum
+
+import "testing"
+
+func TestListsLoad(t *testing.T) {
+	for _, lang := range []string{"es", "it", "pt", "en"} {
+		words := Words(lang)
+		if len(words) != 1000 {
+			t.Errorf("%s: %d words, want 1000", lang, len(words))
+			continue
+		}
+		distinct := map[string]bool{}
+		for i, w := range words {
+			if w.Rank != i+1 {
+				t.Errorf("%s: %q has rank %d, want %d", lang, w.Lemma, w.Rank, i+1)
+			}
+			if i > 0 && bands[w.Band] < bands[words[i-1].Band] {
+				t.Errorf("%s: band goes down at %q", lang, w.Lemma)
+			}
+			distinct[NewSet(lang).key(w.Lemma)] = true
+		}
+		// Lemmas may differ only in accents (e.g. "como"/"cómo"), but not many.
+		if len(distinct) < 990 {
+			t.Errorf("%s: only %d distinct lemmas", lang, len(distinct))
+		}
+	}
+	if Supported("xx") {
+		t.Error("xx should not be supported")
+	}
+}
+
+func TestNext_SkipsSeenAndStaysInBand(t *testing.T) {
+	seen := NewSet("es", "de", "que", "y")
+	got := Next("es", 1, 3, seen)
+	if len(got) != 3 || got[0].Lemma != "a" || got[0].Rank != 4 {
+		t.Fatalf("Next = %+v", got)
+	}
+	for _, w := range Next("es", 1, 1000, seen) {
+		if w.Band != "A1" {
+			t.Fatalf("level 1 got %s word %q", w.Band, w.Lemma)
+		}
+	}
+}
+
+func TestCoverage_CountsInflectedForms(t *testing.T) {
+	known := NewSet("es", "comí", "Casas", "tuvieron", "ornitorrinco")
+	if got := Coverage("es", 1000, known); got != 3 {
+		t.Errorf("Coverage = %d, want 3 (comer, casa, tener)", got)
+	}
+	if got := Coverage("es", 10, known); got != 0 {
+		t.Errorf("Coverage top 10 = %d, want 0", got)
+	}
+}
@@ -169,6 +169,149 @@ ALTER TABLE student_profiles ADD COLUMN IF NOT EXISTS weak_grammar JSONB DEFAULT
 	_, err = pool.Exec(ctx, `
 ALTER TABLE users DROP COLUMN IF EXISTS password_reset_token;
 ALTER TABLE users DROP COLUMN IF EXISTS password_reset_expires_at;
+`)
+	if err != nil {
+		return err
+	}
+
+	// TTS metering: characters per user, day and provider (idempotent)
+	_, err = pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS tts_usage (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    provider TEXT NOT NULL,
+    requests INT DEFAULT 0,
+    chars BIGINT DEFAULT 0,
+    billed_chars BIGINT DEFAULT 0,
+    PRIMARY KEY (user_id, day, provider)
+);
+CREATE INDEX IF NOT EXISTS tts_usage_day_idx ON tts_usage (day);
+`)
+	if err != nil {
+		return err
+	}
+
+	// TTS pronunciation lexicons, one term per language (idempotent)
+	_, err = pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS tts_lexicon (
+    language TEXT NOT NULL,
+    term TEXT NOT NULL,
+    alias TEXT DEFAULT '',
+    phoneme TEXT DEFAULT '',
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS tts_lexicon_term_idx ON tts_lexicon (language, lower(term));
+`)
+	if err != nil {
+		return err
+	}
+
+	// Pronunciation practice: cache index and weak sounds on student profiles (idempotent)
+	_, err = pool.Exec(ctx, `
+ALTER TABLE student_profiles ADD COLUMN IF NOT EXISTS pronunciation_list_idx JSONB DEFAULT '{}';
+ALTER TABLE student_profiles ADD COLUMN IF NOT EXISTS weak_sounds            JSONB DEFAULT '[]';
+`)
+	if err != nil {
+		return err
+	}
+
+	// Lemma tracking: dictionary forms the student has practised (idempotent)
+	_, err = pool.Exec(ctx, `
+ALTER TABLE student_profiles ADD COLUMN IF NOT EXISTS known_lemmas JSONB DEFAULT '{}';
+`)
+	if err != nil {
+		return err
+	}
+
+	// Grammar concepts: error and success counts per concept ID (idempotent)
+	_, err = pool.Exec(ctx, `
+ALTER TABLE student_profiles ADD COLUMN IF NOT EXISTS grammar_stats JSONB DEFAULT '{}';
+`)
+	if err != nil {
+		return err
+	}
+
+	// Spaced repetition: one review card per user, language and word (idempotent)
+	_, err = pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS vocab_cards (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    word TEXT NOT NULL,
+    translation TEXT DEFAULT '',
+    phonetic TEXT DEFAULT '',
+    source TEXT DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    reps INT DEFAULT 0,
+    lapses INT DEFAULT 0,
+    ease DOUBLE PRECISION DEFAULT 2.5,
+    interval_days DOUBLE PRECISION DEFAULT 0,
+    due_at TIMESTAMPTZ DEFAULT NOW(),
+    last_review_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS vocab_cards_word_idx ON vocab_cards (user_id, language, lower(word));
+CREATE INDEX IF NOT EXISTS vocab_cards_due_idx ON vocab_cards (user_id, language, due_at);
+`)
+	if err != nil {
+		return err
+	}
+
+	// Custom word lists: user-created, optionally shared (idempotent)
+	_, err = pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS word_lists (
+    id TEXT PRIMARY KEY,
+    owner_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    language TEXT NOT NULL,
+    description TEXT DEFAULT '',
+    visibility TEXT DEFAULT 'private',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS word_lists_owner_idx ON word_lists (owner_id, language);
+CREATE INDEX IF NOT EXISTS word_lists_public_idx ON word_lists (language, updated_at DESC) WHERE visibility = 'public';
+CREATE TABLE IF NOT EXISTS word_list_items (
+    list_id TEXT NOT NULL REFERENCES word_lists(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    word TEXT NOT NULL,
+    translation TEXT DEFAULT '',
+    phonetic TEXT DEFAULT ''
+);
+CREATE UNIQUE INDEX IF NOT EXISTS word_list_items_word_idx ON word_list_items (list_id, lower(word));
+`)
+	if err != nil {
+		return err
+	}
+
+	// Essay mode: corrected essay with span corrections and rubric (idempotent)
+	_, err = pool.Exec(ctx, `
+ALTER TABLE conversation_history ADD COLUMN IF NOT EXISTS essay JSONB;
+`)
+	if err != nil {
+		return err
+	}
+
+	// Misspellings: one row per misspelled word found in a session (idempotent)
+	_, err = pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS misspellings (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    wrong TEXT NOT NULL,
+    correct TEXT NOT NULL,
+    note TEXT DEFAULT '',
+    session_id TEXT DEFAULT '',
+    source TEXT DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS misspellings_user_idx ON misspellings (user_id, language, lower(correct));
+`)
+	if err != nil {
+		return err
+	}
+
+	// Reading mode: per-pool-key text index (idempotent)
+	_, err = pool.Exec(ctx, `
+ALTER TABLE student_profiles ADD COLUMN IF NOT EXISTS reading_list_idx JSONB DEFAULT '{}';
 `)
 	return err
 }
@@ -0,0 +1,308 @@
+// Package difficulty estimates the CEFR band of generated text from what can
+// be measured in it: how long its sentences are, how frequent its words are
+// and which tenses it uses. Content generated for a level is checked with it
+// before it is pooled, since a prompt asking for A1 does not guarantee A1.
+//
+// Word frequency comes from the curriculum lists (top 1,000 lemmas, A1-B1),
+// with verb forms recognised through the conjugation tables; tenses are only
+// detected for the languages those tables cover.
+package difficulty
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/ailanguagetutor/conjugate"
+	"github.com/ailanguagetutor/curriculum"
+	"github.com/ailanguagetutor/lemma"
+	"github.com/ailanguagetutor/textnorm"
+)
+
+// Score is the measured difficulty of a text.
+type Score struct {
+	Band       string `json:"band"` // overall estimate: the highest of the three below
+	LengthBand string `json:"length_band"`
+	VocabBand  string `json:"vocab_band"`
+	TenseBand  string `json:"tense_band,omitempty"`
+
+	Sentences         int                `json:"sentences"`
+	Words             int                `json:"words"`               // words counted for vocabulary
+	AvgSentenceLength float64            `json:"avg_sentence_length"` // words per sentence
+	BandShares        map[string]float64 `json:"band_shares"`         // share of words per frequency band
+	UnknownRatio      float64            `json:"unknown_ratio"`       // share of words outside the frequency list
+	Tenses            []string           `json:"tenses,omitempty"`    // tense IDs used
+	List              bool               `json:"list,omitempty"`      // scored as a vocabulary list
+}
+
+var bandNames = []string{"", "A1", "A2", "B1", "B2", "C1", "C2"}
+
+// lengthLimits is the longest average sentence, in words, for each band from
+// A1 up; longer is C1.
+var lengthLimits = []float64{8, 12, 17, 22}
+
+// Vocabulary thresholds: a text is at the lowest frequency band whose words
+// (and easier ones) make up vocabCoverage of it. Past B1, where the lists
+// end, it is B2 while words outside the lists stay under maxUnknownB2, and
+// C1 beyond. The lists are short, so everyday topic words fall outside them
+// even in A1 texts; the thresholds leave room for that.
+const (
+	vocabCoverage = 0.65
+	maxUnknownB2  = 0.5
+)
+
+// Vocabulary lists: at least minListKnown entries must be in the frequency
+// list for a band, and a list with more than listUnknownB2 of its entries
+// outside it is at least B2.
+const (
+	minListKnown  = 3
+	listUnknownB2 = 0.35
+)
+
+// minTenseUses is how often a tense must occur to count, so one stray form
+// (or a homograph) does not raise the band.
+const minTenseUses = 2
+
+// Supported reports whether texts in lang can be analysed.
+func Supported(lang string) bool {
+	return curriculum.Supported(lang)
+}
+
+// Analyse measures text in lang. It reports false if lang is not supported
+// or the text has no words.
+func Analyse(lang, text string) (Score, bool) {
+	if !Supported(lang) {
+		return Score{}, false
+	}
+	sentences := splitSentences(text)
+	var s Score
+	var totalWords int
+	bandCounts := map[string]int{}
+	unknown := 0
+	tenseUses := map[string]int{}
+	tenseBands := map[string]string{}
+
+	for _, sent := range sentences {
+		words := textnorm.Words(sent, lang)
+		if len(words) == 0 {
+			continue
+		}
+		s.Sentences++
+		totalWords += len(words)
+		names := properNouns(sent, lang)
+
+		for i := 0; i < len(words); i++ {
+			w := words[i]
+			if names[w] || isNumber(w) || determiner(lang, w) {
+				continue
+			}
+			// Compound tenses span two words ("ha comido")
+			if i+1 < len(words) {
+				if m := conjugate.Identify(lang, w+" "+words[i+1]); len(m) > 0 {
+					countTense(m, tenseUses, tenseBands)
+					s.Words++
+					bandCounts[wordBand(lang, m[0].Infinitive)]++
+					i++
+					continue
+				}
+			}
+			band := wordBand(lang, w)
+			if m := conjugate.Identify(lang, w); len(m) > 0 {
+				countTense(m, tenseUses, tenseBands)
+				if band == "" {
+					band = wordBand(lang, m[0].Infinitive)
+				}
+			}
+			s.Words++
+			if band == "" {
+				unknown++
+				continue
+			}
+			bandCounts[band]++
+		}
+	}
+	if s.Sentences == 0 || s.Words == 0 {
+		return Score{}, false
+	}
+
+	s.AvgSentenceLength = float64(totalWords) / float64(s.Sentences)
+	s.LengthBand = bandNames[len(lengthLimits)+1]
+	for i, limit := range lengthLimits {
+		if s.AvgSentenceLength <= limit {
+			s.LengthBand = bandNames[i+1]
+			break
+		}
+	}
+
+	s.BandShares = make(map[string]float64, len(bandCounts))
+	for b, n := range bandCounts {
+		s.BandShares[b] = float64(n) / float64(s.Words)
+	}
+	s.UnknownRatio = float64(unknown) / float64(s.Words)
+	s.VocabBand = coverageBand(s.BandShares)
+	if s.VocabBand == "" {
+		s.VocabBand = "C1"
+		if s.UnknownRatio <= maxUnknownB2 {
+			s.VocabBand = "B2"
+		}
+	}
+
+	for _, t := range conjugate.Tenses(lang) {
+		if tenseUses[t.ID] >= minTenseUses {
+			s.Tenses = append(s.Tenses, t.ID)
+			s.TenseBand = highest(s.TenseBand, tenseBands[t.ID])
+		}
+	}
+
+	s.Band = highest(s.LengthBand, s.VocabBand, s.TenseBand)
+	return s, true
+}
+
+// AnalyseList measures a vocabulary list in lang, one word or short phrase
+// per entry. Only the vocabulary band is set: from the bands of the entries
+// in the frequency list, raised to B2 or C1 when many entries are outside
+// it. It reports false if lang is not supported or too few entries are
+// known.
+func AnalyseList(lang string, entries []string) (Score, bool) {
+	if !Supported(lang) {
+		return Score{}, false
+	}
+	var s Score
+	counts := map[string]int{}
+	known := 0
+	for _, e := range entries {
+		if strings.TrimSpace(e) == "" {
+			continue
+		}
+		s.Words++
+		// A phrase is as hard as its least frequent known word
+		band := ""
+		for _, w := range textnorm.Words(e, lang) {
+			if b := wordBand(lang, w); b != "" && curriculum.BandOrder(b) > curriculum.BandOrder(band) {
+				band = b
+			}
+		}
+		if band == "" {
+			continue
+		}
+		known++
+		counts[band]++
+	}
+	if known < minListKnown {
+		return Score{}, false
+	}
+	s.BandShares = make(map[string]float64, len(counts))
+	for b, n := range counts {
+		s.BandShares[b] = float64(n) / float64(known)
+	}
+	s.UnknownRatio = float64(s.Words-known) / float64(s.Words)
+	s.VocabBand = coverageBand(s.BandShares)
+	switch {
+	case s.UnknownRatio > maxUnknownB2:
+		s.VocabBand = "C1"
+	case s.UnknownRatio > listUnknownB2 || s.VocabBand == "":
+		s.VocabBand = highest(s.VocabBand, "B2")
+	}
+	s.Band = s.VocabBand
+	s.List = true
+	return s, true
+}
+
+// coverageBand returns the lowest band whose words and easier ones reach
+// vocabCoverage, or "" if even B1 does not.
+func coverageBand(shares map[string]float64) string {
+	covered := 0.0
+	for order := 1; order <= 3; order++ {
+		covered += shares[bandNames[order]]
+		if covered >= vocabCoverage {
+			return bandNames[order]
+		}
+	}
+	return ""
+}
+
+func highest(bands ...string) string {
+	out := ""
+	for _, b := range bands {
+		if curriculum.BandOrder(b) > curriculum.BandOrder(out) {
+			out = b
+		}
+	}
+	return out
+}
+
+// Delta returns how many bands the score lies above (positive) or below
+// (negative) the band of level.
+func (s Score) Delta(level int) int {
+	return curriculum.BandOrder(s.Band) - curriculum.BandOrder(curriculum.LevelBand(level))
+}
+
+// Fits reports whether the score suits level: not above its band, and not
+// more than two bands below it. Lists may be one band above, since everyday
+// topic words (food, clothes) rank a band higher in general frequency than
+// learners meet them.
+func (s Score) Fits(level int) bool {
+	d := s.Delta(level)
+	if s.List {
+		d--
+	}
+	return d <= 0 && d >= -2
+}
+
+// wordBand returns the frequency band of a word, or "" if it is not in the
+// list.
+func wordBand(lang, word string) string {
+	if w, ok := curriculum.Find(lang, word); ok {
+		return w.Band
+	}
+	return ""
+}
+
+// countTense counts one use of the easiest tense a form can be in, since a
+// form like "hablamos" gives no reason to assume the harder reading.
+func countTense(matches []conjugate.Match, uses map[string]int, bands map[string]string) {
+	easiest := matches[0].Tense
+	for _, m := range matches[1:] {
+		if curriculum.BandOrder(m.Tense.Band) < curriculum.BandOrder(easiest.Band) {
+			easiest = m.Tense
+		}
+	}
+	uses[easiest.ID]++
+	bands[easiest.ID] = easiest.Band
+}
+
+func determiner(lang, word string) bool {
+	entries := lemma.Lookup(lang, word)
+	return len(entries) > 0 && entries[0].POS == lemma.Determiner
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// properNouns returns the normalised forms of capitalised words after the
+// first word of a sentence, which are taken to be names.
+func properNouns(sentence, lang string) map[string]bool {
+	out := map[string]bool{}
+	for i, f := range strings.Fields(sentence) {
+		r := []rune(strings.TrimLeft(f, "¿¡\"'«“("))
+		if i == 0 || len(r) == 0 || !unicode.IsUpper(r[0]) {
+			continue
+		}
+		for _, w := range textnorm.Words(f, lang) {
+			out[w] = true
+		}
+	}
+	return out
+}
+
+// splitSentences splits text at sentence-final punctuation and line breaks.
+func splitSentences(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == '.' || r == '!' || r == '?' || r == '…' || r == '\n'
+	})
+}
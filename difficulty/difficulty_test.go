@@ -0,0 +1,69 @@
+package difficulty
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAnalyse_Bands(t *testing.T) {
+	cases := []struct {
+		lang, text, want string
+	}{
+		{"es", "Hola. Me llamo Ana. Vivo en una casa pequeña con mi familia. Tengo un perro y un gato. Por la mañana como pan y bebo café. Mi hermano va a la escuela. Mi madre trabaja en un hospital. Los domingos comemos juntos en casa.", "A1"},
+		{"es", "Ayer fui al mercado con mi abuela. Compramos fruta, verduras y pescado fresco. Cuando era niña, mi abuela vivía en un pueblo cerca del mar. Me contó que todos los días iba a la playa con sus amigas. Después de comprar, tomamos un café en la plaza y hablamos mucho.", "A2"},
+		{"it", "Ciao, mi chiamo Marco. Abito a Roma con la mia famiglia. Ho una sorella e un fratello. La mattina mangio un cornetto e bevo un caffè. Poi vado a scuola in autobus. La sera guardiamo la televisione insieme.", "A1"},
+		{"it", "Ieri sono andato al mare con i miei amici. Abbiamo mangiato la pizza e abbiamo nuotato. Quando ero piccolo, andavo sempre in montagna con mio nonno. Lui mi raccontava molte storie della guerra.", "A2"},
+	}
+	for _, c := range cases {
+		s, ok := Analyse(c.lang, c.text)
+		if !ok {
+			t.Fatalf("%s: not analysed", c.text)
+		}
+		if s.Band != c.want {
+			t.Errorf("%s text: band %s, want %s (%+v)", c.want, s.Band, c.want, s)
+		}
+	}
+}
+
+func TestAnalyse_HardText(t *testing.T) {
+	s, ok := Analyse("es", "Aunque la globalización ha facilitado el intercambio cultural, numerosos expertos advierten que las tradiciones locales podrían desaparecer si no se adoptan políticas que fomenten su preservación. Sería conveniente que los gobiernos invirtieran en programas educativos que valoraran el patrimonio inmaterial de cada comunidad.")
+	if !ok {
+		t.Fatal("not analysed")
+	}
+	if s.Fits(1) || s.Fits(2) || s.Fits(3) {
+		t.Errorf("B2+ text fits a lower level: %+v", s)
+	}
+	if s.LengthBand != "B2" || !slices.Contains(s.Tenses, "conditional") {
+		t.Errorf("length %s, tenses %v", s.LengthBand, s.Tenses)
+	}
+}
+
+func TestAnalyse_CountsCompoundTenses(t *testing.T) {
+	s, _ := Analyse("it", "Ho mangiato la pasta. Ho bevuto il vino. Sono andato a casa.")
+	if !slices.Contains(s.Tenses, "perfect") || s.TenseBand != "A2" {
+		t.Errorf("tenses %v, band %s", s.Tenses, s.TenseBand)
+	}
+}
+
+func TestAnalyseList(t *testing.T) {
+	easy, ok := AnalyseList("es", []string{"manzana", "pan", "leche", "queso", "zanahoria", "agua", "carne", "pescado", "huevo", "arroz", "comer", "beber"})
+	if !ok || !easy.Fits(1) {
+		t.Errorf("everyday list should fit level 1: %+v", easy)
+	}
+	if easy.Fits(5) {
+		t.Errorf("everyday list should be too easy for level 5: %+v", easy)
+	}
+	hard, ok := AnalyseList("es", []string{"desarrollo", "sin embargo", "aprovechar", "lograr", "empresa", "señalar", "advertir", "proporcionar", "ámbito", "fomentar", "medida", "plantear"})
+	if !ok || hard.Fits(1) || hard.Fits(2) {
+		t.Errorf("academic list should not fit levels 1-2: %+v", hard)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	if _, ok := Analyse("xx", "Hello there friend."); ok {
+		t.Error("unsupported language analysed")
+	}
+	if _, ok := Analyse("es", "   "); ok {
+		t.Error("empty text analysed")
+	}
+}
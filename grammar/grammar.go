@@ -0,0 +1,190 @@
+// Package grammar holds each language's catalogue of grammar concepts, so
+// errors can be classified and tracked per concept ("es.ser-estar",
+// "it.passato-prossimo-aux") rather than as free-text tips.
+//
+// The catalogues are shipped as data files (data/<lang>.tsv), one
+// id<TAB>band<TAB>name<TAB>description line per concept. IDs are stable:
+// they are stored in student profiles. Every language has the generic
+// concepts <lang>.articles, <lang>.spelling, <lang>.vocabulary and
+// <lang>.other.
+package grammar
+
+import (
+	"bufio"
+	"embed"
+	"log"
+	"path"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Concept is one entry of a language's grammar catalogue.
+type Concept struct {
+	ID          string `json:"id"`
+	Language    string `json:"language"`
+	Band        string `json:"band"` // CEFR band where learners usually meet it
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// bands orders the CEFR bands.
+var bands = map[string]int{"A1": 1, "A2": 2, "B1": 3, "B2": 4, "C1": 5, "C2": 6}
+
+//go:embed data/*.tsv
+var data embed.FS
+
+var (
+	loadOnce  sync.Once
+	catalogue map[string][]Concept
+	byID      map[string]Concept
+)
+
+func load() {
+	catalogue = make(map[string][]Concept)
+	byID = make(map[string]Concept)
+	files, _ := data.ReadDir("data")
+	for _, f := range files {
+		lang := strings.TrimSuffix(f.Name(), ".tsv")
+		file, err := data.Open(path.Join("data", f.Name()))
+		if err != nil {
+			log.Printf("grammar: %v", err)
+			continue
+		}
+		sc := bufio.NewScanner(file)
+		for sc.Scan() {
+			line := sc.Text()
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Split(line, "\t")
+			if len(fields) != 4 || !strings.HasPrefix(fields[0], lang+".") {
+				continue
+			}
+			c := Concept{ID: fields[0], Language: lang, Band: fields[1], Name: fields[2], Description: fields[3]}
+			catalogue[lang] = append(catalogue[lang], c)
+			byID[c.ID] = c
+		}
+		file.Close()
+	}
+}
+
+// Concepts returns the catalogue of lang, or nil if none ships. The slice is
+// shared and must not be modified.
+func Concepts(lang string) []Concept {
+	loadOnce.Do(load)
+	return catalogue[lang]
+}
+
+// Lessons returns the concepts of lang that can be taught as grammar lessons
+// up to band, in catalogue order. The generic spelling, vocabulary and
+// catch-all concepts are left out.
+func Lessons(lang, band string) []Concept {
+	limit := bands[band]
+	var out []Concept
+	for _, c := range Concepts(lang) {
+		if bands[c.Band] <= limit && IsLesson(c.ID) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// IsLesson reports whether id is a concept that has a grammar lesson.
+func IsLesson(id string) bool {
+	c, ok := Lookup(id)
+	return ok && id != Spelling(c.Language) && practisable(id)
+}
+
+// Lookup returns the concept with the given ID.
+func Lookup(id string) (Concept, bool) {
+	loadOnce.Do(load)
+	c, ok := byID[id]
+	return c, ok
+}
+
+// Name returns the display name of a concept, or the ID itself if unknown.
+func Name(id string) string {
+	if c, ok := Lookup(id); ok {
+		return c.Name
+	}
+	return id
+}
+
+// Classify returns id if it is a concept of lang, and <lang>.other otherwise,
+// so a model's answer can always be recorded.
+func Classify(lang, id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if c, ok := Lookup(id); ok && c.Language == lang {
+		return id
+	}
+	return Other(lang)
+}
+
+// Articles returns the articles concept of lang.
+func Articles(lang string) string { return lang + ".articles" }
+
+// Spelling returns the spelling and accents concept of lang.
+func Spelling(lang string) string { return lang + ".spelling" }
+
+// Other returns the catch-all concept of lang.
+func Other(lang string) string { return lang + ".other" }
+
+// PromptList formats the catalogue of lang for an LLM prompt, one
+// "- id: name — description" line per concept.
+func PromptList(lang string) string {
+	var b strings.Builder
+	for _, c := range Concepts(lang) {
+		b.WriteString("- " + c.ID + ": " + c.Name + " — " + c.Description + "\n")
+	}
+	return b.String()
+}
+
+// Known returns the IDs in ids that are catalogue concepts, dropping
+// anything else (such as free-text tips stored before concepts existed).
+func Known(ids []string) []string {
+	var out []string
+	for _, id := range ids {
+		if _, ok := Lookup(id); ok {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// Match classifies free text, such as a tip stored before concepts existed,
+// by the concept of lang whose name or ID it mentions: "Ser vs estar" and
+// "ser/estar confusion" both give es.ser-estar. The longest mention wins;
+// ok is false if no concept is mentioned. The catch-all concepts are never
+// matched.
+func Match(lang, text string) (id string, ok bool) {
+	text = " " + words(text) + " "
+	best := 0
+	for _, c := range Concepts(lang) {
+		if !practisable(c.ID) {
+			continue
+		}
+		slug := strings.TrimPrefix(c.ID, lang+".")
+		for _, m := range []string{words(c.Name), words(slug)} {
+			if len(m) > best && strings.Contains(text, " "+m+" ") {
+				id, best = c.ID, len(m)
+			}
+		}
+	}
+	return id, best > 0
+}
+
+// words lower-cases s and reduces it to its words separated by single
+// spaces. "vs" is dropped, so "ser vs estar" reads as "ser estar".
+func words(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		if f != "vs" {
+			out = append(out, f)
+		}
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,95 @@
+package grammar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCataloguesLoad(t *testing.T) {
+	for _, lang := range []string{"es", "it", "pt", "en"} {
+		concepts := Concepts(lang)
+		if len(concepts) < 15 {
+			t.Errorf("%s: only %d concepts", lang, len(concepts))
+		}
+		for _, id := range []string{Articles(lang), Spelling(lang), Other(lang), lang + ".vocabulary"} {
+			if _, ok := Lookup(id); !ok {
+				t.Errorf("%s: missing generic concept %s", lang, id)
+			}
+		}
+	}
+	if c, ok := Lookup("it.passato-prossimo-aux"); !ok || c.Language != "it" {
+		t.Errorf("Lookup(it.passato-prossimo-aux) = %+v, %v", c, ok)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	if got := Classify("es", " ES.Ser-Estar "); got != "es.ser-estar" {
+		t.Errorf("Classify = %q", got)
+	}
+	if got := Classify("es", "it.essere-avere"); got != "es.other" {
+		t.Errorf("other language: Classify = %q", got)
+	}
+	if got := Classify("es", "made-up"); got != "es.other" {
+		t.Errorf("unknown: Classify = %q", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		lang, text, want string
+	}{
+		{"es", "Ser vs estar", "es.ser-estar"},
+		{"es", "Watch the ser/estar confusion!", "es.ser-estar"},
+		{"es", "Practise gustar-type verbs", "es.gustar"},
+		{"es", "What did the speaker buy at the market?", ""},
+		{"es", "Other", ""},
+	}
+	for _, c := range cases {
+		got, ok := Match(c.lang, c.text)
+		if got != c.want || ok != (c.want != "") {
+			t.Errorf("Match(%q, %q) = %q, %v; want %q", c.lang, c.text, got, ok, c.want)
+		}
+	}
+}
+
+func TestWeakest(t *testing.T) {
+	now := time.Now()
+	s := Stats{}
+	s.Record("es.ser-estar", false, now)
+	s.Record("es.ser-estar", false, now)
+	s.Record("es.por-para", false, now)
+	for range 5 {
+		s.Record("es.por-para", true, now)
+	}
+	s.Record("es.gustar", true, now)
+	s.Record("es.other", false, now)
+
+	got := s.Weakest(5)
+	if len(got) != 2 || got[0] != "es.ser-estar" || got[1] != "es.por-para" {
+		t.Errorf("Weakest = %v", got)
+	}
+	if got := s.Weakest(1); len(got) != 1 {
+		t.Errorf("Weakest(1) = %v", got)
+	}
+}
+
+func TestLessons(t *testing.T) {
+	a1 := Lessons("es", "A1")
+	all := Lessons("es", "C2")
+	if len(a1) == 0 || len(a1) >= len(all) {
+		t.Fatalf("A1 lessons = %d, all = %d", len(a1), len(all))
+	}
+	for _, c := range all {
+		if c.ID == "es.other" || c.ID == "es.vocabulary" || c.ID == "es.spelling" {
+			t.Errorf("generic concept %s listed as a lesson", c.ID)
+		}
+	}
+	for _, c := range a1 {
+		if c.Band != "A1" {
+			t.Errorf("A1 lessons include %s (%s)", c.ID, c.Band)
+		}
+	}
+	if (Stat{Errors: 1, Successes: 3}).Mastery() != 75 || (Stat{}).Mastery() != 0 {
+		t.Error("Mastery")
+	}
+}
@@ -0,0 +1,75 @@
+package grammar
+
+import (
+	"sort"
+	"time"
+)
+
+// Stat counts a learner's errors and successes on one concept.
+type Stat struct {
+	Errors    int       `json:"errors"`
+	Successes int       `json:"successes"`
+	LastError time.Time `json:"last_error,omitzero"`
+}
+
+// Mastery returns the share of attempts that were successes, 0-100, or 0
+// with no attempts.
+func (s Stat) Mastery() int {
+	if n := s.Errors + s.Successes; n > 0 {
+		return s.Successes * 100 / n
+	}
+	return 0
+}
+
+// errorRate is the Laplace-smoothed share of attempts that were errors, so
+// one slip weighs less than a pattern of them.
+func (s Stat) errorRate() float64 {
+	return float64(s.Errors+1) / float64(s.Errors+s.Successes+2)
+}
+
+// Stats maps concept IDs to a learner's record on them.
+type Stats map[string]Stat
+
+// Record counts one error or success on concept id.
+func (s Stats) Record(id string, correct bool, now time.Time) {
+	st := s[id]
+	if correct {
+		st.Successes++
+	} else {
+		st.Errors++
+		st.LastError = now
+	}
+	s[id] = st
+}
+
+// Weakest returns up to n concept IDs with errors, highest error rate first;
+// ties go to the more recent error. The catch-all concepts <lang>.other and
+// <lang>.vocabulary are left out as they name no grammar to practise.
+func (s Stats) Weakest(n int) []string {
+	var ids []string
+	for id, st := range s {
+		if st.Errors == 0 || !practisable(id) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := s[ids[i]], s[ids[j]]
+		if ra, rb := a.errorRate(), b.errorRate(); ra != rb {
+			return ra > rb
+		}
+		if !a.LastError.Equal(b.LastError) {
+			return a.LastError.After(b.LastError)
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+	return ids
+}
+
+func practisable(id string) bool {
+	c, ok := Lookup(id)
+	return ok && id != Other(c.Language) && id != c.Language+".vocabulary"
+}
@@ -2,12 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/curriculum"
+	"github.com/ailanguagetutor/difficulty"
 	"github.com/ailanguagetutor/middleware"
 	"github.com/ailanguagetutor/store"
 	"github.com/go-chi/chi/v5"
@@ -20,10 +25,22 @@ type AdminHandler struct {
 	historyStore *store.ConversationHistoryStore
 	billing      *BillingHandler
 	resetStore   *store.ResetTokenStore
+	ttsUsage     *store.TTSUsageStore
+	lexicon      *store.LexiconStore
+	pools        GradedPools
 }
 
-func NewAdminHandler(cfg *config.Config, us *store.UserStore, bh *BillingHandler, hs *store.ConversationHistoryStore, rs *store.ResetTokenStore) *AdminHandler {
-	return &AdminHandler{cfg: cfg, userStore: us, billing: bh, historyStore: hs, resetStore: rs}
+// GradedPools are the content pools whose items are difficulty-graded.
+type GradedPools struct {
+	Vocab     *store.ItemPool
+	Sentences *store.ItemPool
+	Listening *store.ItemPool
+	Grammar   *store.ItemPool
+	Reading   *store.ItemPool
+}
+
+func NewAdminHandler(cfg *config.Config, us *store.UserStore, bh *BillingHandler, hs *store.ConversationHistoryStore, rs *store.ResetTokenStore, tu *store.TTSUsageStore, ls *store.LexiconStore, pools GradedPools) *AdminHandler {
+	return &AdminHandler{cfg: cfg, userStore: us, billing: bh, historyStore: hs, resetStore: rs, ttsUsage: tu, lexicon: ls, pools: pools}
 }
 
 // requireAdmin checks the caller is the admin user; returns false and writes 403 if not.
@@ -309,3 +326,258 @@ func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	log.Printf("admin: user %s (%s) deleted by admin %s", targetID, u.Email, callerID)
 	writeJSON(w, http.StatusOK, map[string]string{"deleted": targetID})
 }
+
+// ── TTS usage ─────────────────────────────────────────────────────────────────
+
+// GET /api/admin/tts-usage?days=30
+func (h *AdminHandler) TTSUsage(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	days := 30
+	if d, err := strconv.Atoi(r.URL.Query().Get("days")); err == nil && d > 0 && d <= 365 {
+		days = d
+	}
+	users, err := h.ttsUsage.TopUsers(r.Context(), days, 100)
+	if err != nil {
+		log.Printf("admin tts usage error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load usage"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"days": days, "users": users})
+}
+
+// GET /api/admin/users/{id}/tts-usage
+func (h *AdminHandler) UserTTSUsage(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	u, err := h.userStore.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "user not found"})
+		return
+	}
+	writeTTSUsage(w, r, h.ttsUsage, u, h.cfg.TTSMaxChars)
+}
+
+// ── Pronunciation lexicon ─────────────────────────────────────────────────────
+// Entries apply to new renders only: audio already in the TTS cache keeps its
+// old pronunciation until evicted, but its cache key changes so /api/tts
+// re-renders on the next request.
+
+// GET /api/admin/lexicon?language=it
+func (h *AdminHandler) ListLexicon(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	lang := r.URL.Query().Get("language")
+	if !IsValidLanguage(lang) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid language"})
+		return
+	}
+	entries := h.lexicon.Entries(lang)
+	if entries == nil {
+		entries = []store.LexiconEntry{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"language": lang, "entries": entries})
+}
+
+// PUT /api/admin/lexicon  {language, term, alias, phoneme}
+func (h *AdminHandler) UpsertLexicon(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	var e store.LexiconEntry
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if !IsValidLanguage(e.Language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid language"})
+		return
+	}
+	if strings.TrimSpace(e.Term) == "" || (e.Alias == "" && e.Phoneme == "") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "term and an alias or phoneme are required"})
+		return
+	}
+	if err := h.lexicon.Upsert(r.Context(), e); err != nil {
+		log.Printf("admin lexicon upsert error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save entry"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"saved": e.Term})
+}
+
+// DELETE /api/admin/lexicon?language=it&term=...
+func (h *AdminHandler) DeleteLexicon(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	lang, term := r.URL.Query().Get("language"), r.URL.Query().Get("term")
+	if !IsValidLanguage(lang) || term == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "language and term are required"})
+		return
+	}
+	if err := h.lexicon.Delete(r.Context(), lang, term); err != nil {
+		log.Printf("admin lexicon delete error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete entry"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"deleted": term})
+}
+
+// ── Reading texts ─────────────────────────────────────────────────────────────
+
+type readingImportRequest struct {
+	Language string `json:"language"`
+	Level    int    `json:"level"`
+	Topic    string `json:"topic"`
+	ReadingText
+}
+
+// POST /api/admin/reading  {language, level, topic, title, text, questions}
+//
+// Adds a text to the reading pool. Texts are graded like generated ones and
+// rejected if they are out of level.
+func (h *AdminHandler) ImportReading(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	var req readingImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	msg := validReadingKey(req.Language, req.Level, req.Topic)
+	if msg == "" {
+		msg = validReadingText(req.ReadingText)
+	}
+	if msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+		return
+	}
+
+	var score *difficulty.Score
+	if s, ok := scoreReading(req.Language, req.ReadingText); ok {
+		if !s.Fits(req.Level) {
+			writeJSON(w, http.StatusBadRequest, map[string]any{
+				"error": fmt.Sprintf("text is graded %s, outside level %d", s.Band, req.Level),
+				"score": s,
+			})
+			return
+		}
+		score = &s
+	}
+	text := req.ReadingText
+	text.Imported = true
+	raw, _ := json.Marshal(text) // ReadingText is always serialisable
+	key := h.pools.Reading.Key(req.Language, req.Level, req.Topic)
+	h.pools.Reading.AppendScored(key, raw, score)
+	writeJSON(w, http.StatusOK, map[string]any{"key": key, "index": h.pools.Reading.Len(key) - 1, "score": score})
+}
+
+// ── Pool difficulty ───────────────────────────────────────────────────────────
+
+type poolKeyReport struct {
+	Key        string         `json:"key"`
+	Band       string         `json:"band"` // band of the key's level
+	Items      int            `json:"items"`
+	Scored     int            `json:"scored"`
+	Bands      map[string]int `json:"bands"`        // items per scored band
+	OutOfLevel []int          `json:"out_of_level"` // indexes of items that do not fit the level
+}
+
+type poolReport struct {
+	Pool string          `json:"pool"`
+	Keys []poolKeyReport `json:"keys"`
+}
+
+// GET /api/admin/pool-difficulty?pool=vocab
+//
+// Reports the difficulty of pooled items per key. Items pooled before
+// grading are scored for the report but nothing is saved.
+func (h *AdminHandler) PoolDifficulty(w http.ResponseWriter, r *http.Request) {
+	h.poolDifficulty(w, r, false)
+}
+
+// POST /api/admin/pool-difficulty?pool=vocab
+//
+// Like PoolDifficulty, but also saves the scores of items pooled before
+// grading, one pool write per key.
+func (h *AdminHandler) BackfillPoolDifficulty(w http.ResponseWriter, r *http.Request) {
+	h.poolDifficulty(w, r, true)
+}
+
+func (h *AdminHandler) poolDifficulty(w http.ResponseWriter, r *http.Request, backfill bool) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	pools := []struct {
+		name  string
+		pool  *store.ItemPool
+		score poolScorer
+	}{
+		{"vocab", h.pools.Vocab, rawScorer(scoreVocab)},
+		{"sentences", h.pools.Sentences, rawScorer(scoreSentences)},
+		{"listening", h.pools.Listening, rawScorer(scoreStory)},
+		{"grammar", h.pools.Grammar, rawScorer(scoreLesson)},
+		{"reading", h.pools.Reading, rawScorer(scoreReading)},
+	}
+	only := r.URL.Query().Get("pool")
+	reports := []poolReport{}
+	for _, p := range pools {
+		if p.pool == nil || (only != "" && only != p.name) {
+			continue
+		}
+		report := poolReport{Pool: p.name, Keys: []poolKeyReport{}}
+		for _, key := range p.pool.Keys() {
+			keyReport, scored := poolKeyDifficulty(p.pool, key, p.score)
+			if backfill {
+				p.pool.SetScores(key, scored)
+			}
+			report.Keys = append(report.Keys, keyReport)
+		}
+		reports = append(reports, report)
+	}
+	if only != "" && len(reports) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown pool"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"pools": reports})
+}
+
+// poolKeyDifficulty reports the items pooled under key, which starts with
+// "language:level:". It also returns the scores computed for items that were
+// pooled without one, by index; the pool itself is not modified.
+func poolKeyDifficulty(pool *store.ItemPool, key string, score poolScorer) (poolKeyReport, map[int]*difficulty.Score) {
+	report := poolKeyReport{Key: key, Items: pool.Len(key), Bands: map[string]int{}, OutOfLevel: []int{}}
+	scored := map[int]*difficulty.Score{}
+	parts := strings.SplitN(key, ":", 3)
+	if len(parts) < 2 {
+		return report, scored
+	}
+	language := parts[0]
+	level, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return report, scored
+	}
+	report.Band = curriculum.LevelBand(level)
+	for i := 0; i < report.Items; i++ {
+		s := pool.Score(key, i)
+		if s == nil {
+			raw, _ := pool.Get(key, i)
+			fresh, ok := score(language, raw)
+			if !ok {
+				continue
+			}
+			s = &fresh
+			scored[i] = s
+		}
+		report.Scored++
+		report.Bands[s.Band]++
+		if !s.Fits(level) {
+			report.OutOfLevel = append(report.OutOfLevel, i)
+		}
+	}
+	return report, scored
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/ailanguagetutor/config"
 	"github.com/ailanguagetutor/middleware"
 	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/tts"
 )
 
 const elevenLabsAPI = "https://api.elevenlabs.io"
@@ -19,10 +20,11 @@ type AgentHandler struct {
 	cfg          *config.Config
 	sessionStore *store.SessionStore
 	profileStore *store.StudentProfileStore
+	tts          *tts.Service
 }
 
-func NewAgentHandler(cfg *config.Config, ss *store.SessionStore, ps *store.StudentProfileStore) *AgentHandler {
-	return &AgentHandler{cfg: cfg, sessionStore: ss, profileStore: ps}
+func NewAgentHandler(cfg *config.Config, ss *store.SessionStore, ps *store.StudentProfileStore, svc *tts.Service) *AgentHandler {
+	return &AgentHandler{cfg: cfg, sessionStore: ss, profileStore: ps, tts: svc}
 }
 
 // ── Setup Agent (admin, one-time) ─────────────────────────────────────────────
@@ -162,7 +164,12 @@ func (h *AgentHandler) GetConversationURL(w http.ResponseWriter, r *http.Request
 	systemPrompt = strings.ReplaceAll(systemPrompt, "[", "")
 	systemPrompt = strings.ReplaceAll(systemPrompt, "]", "")
 
-	voiceID := h.cfg.VoiceForPersonality(session.Personality, session.Language)
+	// Conversational AI only speaks with ElevenLabs voices, whatever provider
+	// the one-shot TTS endpoint is routed to.
+	var voiceID string
+	if v, ok := h.tts.Voice("elevenlabs", session.Personality, session.Language); ok {
+		voiceID = v.ID
+	}
 
 	signedURL, err := h.getSignedURL()
 	if err != nil {
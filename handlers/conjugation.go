@@ -0,0 +1,452 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ailanguagetutor/conjugate"
+	"github.com/ailanguagetutor/curriculum"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/textnorm"
+	"github.com/google/uuid"
+)
+
+// ConjugationHandler serves the verb conjugation drill mode. Forms come from
+// the conjugate package's tables, never from the model, and typed answers
+// are checked against them deterministically.
+type ConjugationHandler struct {
+	userStore     *store.UserStore
+	profileStore  *store.StudentProfileStore
+	historyStore  *store.ConversationHistoryStore
+	presenceStore *store.PresenceStore
+	cacheStore    *store.CacheStore
+}
+
+func NewConjugationHandler(us *store.UserStore, ps *store.StudentProfileStore, hs *store.ConversationHistoryStore, presence *store.PresenceStore, cache *store.CacheStore) *ConjugationHandler {
+	return &ConjugationHandler{userStore: us, profileStore: ps, historyStore: hs, presenceStore: presence, cacheStore: cache}
+}
+
+// ── Types ─────────────────────────────────────────────────────────────────────
+
+// conjugationTopic is the topic ID the mode is listed under in meta.go.
+const conjugationTopic = "grammar-conjugation"
+
+const (
+	conjugationItems      = 12 // items per session
+	maxConjugationAnswers = 50
+)
+
+type ConjugationItem struct {
+	ID        string `json:"id"` // verb:tense:person
+	Verb      string `json:"verb"`
+	Gloss     string `json:"gloss"`
+	Tense     string `json:"tense"`
+	TenseName string `json:"tense_name"`
+	Person    int    `json:"person"`
+	Pronoun   string `json:"pronoun"`
+}
+
+type conjugationSessionRequest struct {
+	Language     string   `json:"language"`
+	Level        int      `json:"level"`
+	Tenses       []string `json:"tenses"` // optional: limit to these tense IDs
+	MistakesMode bool     `json:"mistakes_mode"`
+}
+
+type conjugationSessionResponse struct {
+	Items  []ConjugationItem `json:"items"`
+	Tenses []conjugate.Tense `json:"tenses"`
+}
+
+type conjugationAnswer struct {
+	Verb   string `json:"verb"`
+	Tense  string `json:"tense"`
+	Person int    `json:"person"`
+	Answer string `json:"answer"`
+}
+
+type conjugationCheckRequest struct {
+	Language string `json:"language"`
+	conjugationAnswer
+}
+
+type conjugationCheckResponse struct {
+	Correct      bool          `json:"correct"`
+	Answer       string        `json:"answer"`
+	Alternatives []string      `json:"alternatives,omitempty"`
+	Mismatch     textnorm.Kind `json:"mismatch,omitempty"` // accent or typo when close but wrong
+	Feedback     string        `json:"feedback"`
+}
+
+type conjugationCompleteRequest struct {
+	Language string              `json:"language"`
+	Level    int                 `json:"level"`
+	Answers  []conjugationAnswer `json:"answers"`
+}
+
+type tenseScore struct {
+	Tense   string `json:"tense"`
+	Name    string `json:"name"`
+	Correct int    `json:"correct"`
+	Total   int    `json:"total"`
+}
+
+type conjugationCompleteResponse struct {
+	FPEarned     int          `json:"fp_earned"`
+	CorrectCount int          `json:"correct_count"`
+	Total        int          `json:"total"`
+	Tenses       []tenseScore `json:"tenses"`
+	WeakTenses   []string     `json:"weak_tenses"`
+	RecordID     string       `json:"record_id"`
+}
+
+// ── Table ─────────────────────────────────────────────────────────────────────
+
+// Table returns the full conjugation of a verb (?language=es&verb=tener).
+func (h *ConjugationHandler) Table(w http.ResponseWriter, r *http.Request) {
+	language := r.URL.Query().Get("language")
+	if !conjugate.Supported(language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "conjugation is not available for this language"})
+		return
+	}
+	verb, ok := conjugate.LookupVerb(language, strings.ToLower(strings.TrimSpace(r.URL.Query().Get("verb"))))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "verb not found"})
+		return
+	}
+	type tenseTable struct {
+		conjugate.Tense
+		Forms []conjugate.Form `json:"forms"`
+	}
+	var tables []tenseTable
+	for _, t := range conjugate.Tenses(language) {
+		forms, err := conjugate.Conjugate(language, verb.Infinitive, t.ID)
+		if err != nil {
+			log.Printf("conjugation/table error: %v", err)
+			continue
+		}
+		tables = append(tables, tenseTable{Tense: t, Forms: forms})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"verb": verb, "tenses": tables})
+}
+
+// ── Session ───────────────────────────────────────────────────────────────────
+
+func (h *ConjugationHandler) Session(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req conjugationSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	if !conjugate.Supported(req.Language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "conjugation is not available for this language"})
+		return
+	}
+	if req.Level < 1 || req.Level > 5 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "level must be 1-5"})
+		return
+	}
+	band := curriculum.LevelBand(req.Level)
+	tenses, err := sessionTenses(req.Language, band, req.Tenses)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	profile, _ := h.profileStore.Get(r.Context(), userID, req.Language)
+	weak := weakTenses(profileWeakGrammar(profile), tenses)
+
+	// Mistakes mode: only the tenses the learner keeps getting wrong
+	if req.MistakesMode {
+		if len(weak) == 0 {
+			writeJSON(w, http.StatusOK, map[string]any{
+				"items":   []ConjugationItem{},
+				"message": "No conjugation mistakes on record yet. Complete some conjugation drills first!",
+			})
+			return
+		}
+		tenses, weak = weak, nil
+	}
+
+	_ = h.presenceStore.Set(r.Context(), userID, store.LessonPresence{
+		Type:      "conjugation",
+		Language:  req.Language,
+		Topic:     conjugationTopic,
+		StartedAt: time.Now(),
+	})
+
+	items := conjugationDrill(req.Language, conjugate.Verbs(req.Language, band), tenses, weak, conjugationItems)
+	writeJSON(w, http.StatusOK, conjugationSessionResponse{Items: items, Tenses: tenses})
+}
+
+// sessionTenses returns the tenses to drill: the requested ones, or all
+// tenses up to band.
+func sessionTenses(language, band string, requested []string) ([]conjugate.Tense, error) {
+	var out []conjugate.Tense
+	if len(requested) > 0 {
+		for _, id := range requested {
+			t, ok := conjugate.LookupTense(language, id)
+			if !ok {
+				return nil, fmt.Errorf("unknown tense %q", id)
+			}
+			out = append(out, t)
+		}
+		return out, nil
+	}
+	for _, t := range conjugate.Tenses(language) {
+		if conjugate.InBand(t.Band, band) {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// weakTenses returns the tenses among tenses whose grammar concept is one
+// of the learner's weak concepts.
+func weakTenses(weakConcepts []string, tenses []conjugate.Tense) []conjugate.Tense {
+	var out []conjugate.Tense
+	for _, t := range tenses {
+		if slices.Contains(weakConcepts, t.Concept) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// conjugationDrill picks n distinct verb/tense/person items. Weak tenses
+// are drawn twice as often as the others.
+func conjugationDrill(language string, verbs []conjugate.Verb, tenses, weak []conjugate.Tense, n int) []ConjugationItem {
+	persons := conjugate.Persons(language)
+	pool := append(slices.Clone(tenses), weak...)
+	if len(verbs) == 0 || len(pool) == 0 {
+		return []ConjugationItem{}
+	}
+	n = min(n, len(verbs)*len(tenses)*len(persons))
+	seen := map[string]bool{}
+	items := make([]ConjugationItem, 0, n)
+	for len(items) < n {
+		v := verbs[rand.IntN(len(verbs))]
+		t := pool[rand.IntN(len(pool))]
+		p := persons[rand.IntN(len(persons))]
+		id := fmt.Sprintf("%s:%s:%d", v.Infinitive, t.ID, p.Index)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		items = append(items, ConjugationItem{
+			ID: id, Verb: v.Infinitive, Gloss: v.Gloss,
+			Tense: t.ID, TenseName: t.Name, Person: p.Index, Pronoun: p.Pronoun,
+		})
+	}
+	return items
+}
+
+// ── Check ─────────────────────────────────────────────────────────────────────
+
+func (h *ConjugationHandler) Check(w http.ResponseWriter, r *http.Request) {
+	var req conjugationCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	if !conjugate.Supported(req.Language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "conjugation is not available for this language"})
+		return
+	}
+	form, err := conjugate.Conjugation(req.Language, req.Verb, req.Tense, req.Person)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown verb, tense or person"})
+		return
+	}
+	writeJSON(w, http.StatusOK, gradeConjugation(req.Language, form, req.Answer))
+}
+
+// gradeConjugation checks answer against form. Accents are part of the
+// form (habló is not hablo), so only exact answers count; the answer may
+// start with the pronoun ("yo hablo"). Near misses are named in Mismatch.
+func gradeConjugation(language string, form conjugate.Form, answer string) conjugationCheckResponse {
+	forms := append([]string{form.Text}, form.Alternatives...)
+	accepted := slices.Clone(forms)
+	for _, p := range strings.Split(form.Pronoun, "/") {
+		for _, f := range forms {
+			accepted = append(accepted, p+" "+f)
+		}
+	}
+	m := textnorm.MatchAny(accepted, answer, language, textnorm.Strict)
+	resp := conjugationCheckResponse{Correct: m.Correct, Answer: form.Text, Alternatives: form.Alternatives}
+	switch m.Kind {
+	case textnorm.KindExact, textnorm.KindScript:
+	case textnorm.KindAccent:
+		resp.Mismatch = m.Kind
+		resp.Feedback = fmt.Sprintf("Watch the accents: %s.", form.Text)
+	case textnorm.KindTypo:
+		resp.Mismatch = m.Kind
+		resp.Feedback = fmt.Sprintf("Close — check the spelling: %s.", form.Text)
+	default:
+		resp.Feedback = fmt.Sprintf("The correct form is %s.", form.Text)
+	}
+	return resp
+}
+
+// conjugationConcept returns the grammar concept a drill result counts
+// towards: the tense's, or stem changes for Spanish stem-changing verbs in
+// the present.
+func conjugationConcept(language, verb string, t conjugate.Tense) string {
+	if language == "es" && t.ID == conjugate.Present && conjugate.StemChanging(language, verb) {
+		return "es.stem-changing"
+	}
+	return t.Concept
+}
+
+// ── Complete ──────────────────────────────────────────────────────────────────
+
+func (h *ConjugationHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req conjugationCompleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	if !conjugate.Supported(req.Language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "conjugation is not available for this language"})
+		return
+	}
+	if len(req.Answers) == 0 || len(req.Answers) > maxConjugationAnswers {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("between 1 and %d answers required", maxConjugationAnswers)})
+		return
+	}
+
+	ctx := context.Background()
+	profile, err := h.profileStore.Get(ctx, userID, req.Language)
+	if err != nil || profile == nil {
+		profile = &store.StudentProfile{
+			UserID:   userID,
+			Language: req.Language,
+		}
+	}
+
+	scores := map[string]*tenseScore{}
+	var order []string
+	var correctCount int
+	var corrections, practised []string
+	for _, a := range req.Answers {
+		t, ok := conjugate.LookupTense(req.Language, a.Tense)
+		if !ok {
+			continue
+		}
+		form, err := conjugate.Conjugation(req.Language, a.Verb, a.Tense, a.Person)
+		if err != nil {
+			continue
+		}
+		correct := gradeConjugation(req.Language, form, a.Answer).Correct
+		s := scores[t.ID]
+		if s == nil {
+			s = &tenseScore{Tense: t.ID, Name: t.Name}
+			scores[t.ID] = s
+			order = append(order, t.ID)
+		}
+		s.Total++
+		if correct {
+			s.Correct++
+			correctCount++
+		} else {
+			corrections = append(corrections, fmt.Sprintf("%s (%s, %s) → %s", a.Verb, t.Name, form.Pronoun, form.Text))
+		}
+		practised = append(practised, a.Verb)
+		profile.RecordGrammar(conjugationConcept(req.Language, a.Verb, t), correct)
+	}
+	total := 0
+	tenseScores := make([]tenseScore, 0, len(order))
+	weak := []string{}
+	for _, id := range order {
+		s := scores[id]
+		total += s.Total
+		tenseScores = append(tenseScores, *s)
+		if s.Correct*2 < s.Total {
+			weak = append(weak, s.Name)
+		}
+	}
+	if total == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "no valid answers"})
+		return
+	}
+
+	fp := correctCount * 3
+	if fp < 10 {
+		fp = 10
+	}
+	if correctCount == total {
+		fp += 10
+	}
+
+	if _, _, err := h.userStore.UpdateActivity(userID, req.Language, fp); err != nil {
+		log.Printf("conjugation/complete UpdateActivity error: %v", err)
+	}
+
+	profile.RecentTopics = prependUnique([]string{"Verb Conjugation"}, profile.RecentTopics, 10)
+	profile.SessionCount++
+	if err := h.profileStore.Upsert(ctx, profile); err != nil {
+		log.Printf("conjugation/complete Upsert error: %v", err)
+	}
+
+	topicName, _ := TopicDetails(conjugationTopic)
+	summary := fmt.Sprintf("Completed Verb Conjugation: %d/%d forms correct.", correctCount, total)
+	var suggestions []string
+	if len(weak) > 0 {
+		suggestions = []string{
+			fmt.Sprintf("Drill these tenses again: %s", strings.Join(weak[:min(3, len(weak))], ", ")),
+			"Review the full tables of the verbs you missed",
+			"Use mistakes mode to practise only your weakest tenses",
+		}
+	} else {
+		suggestions = []string{
+			"Try a higher level to add new tenses",
+			"Use these verbs in a sentence or writing session",
+			"Drill the irregular verbs in the tenses you know",
+		}
+	}
+
+	recordID := uuid.New().String()
+	record := &store.ConversationRecord{
+		ID:           recordID,
+		UserID:       userID,
+		Language:     req.Language,
+		Topic:        conjugationTopic,
+		TopicName:    topicName,
+		Level:        req.Level,
+		Personality:  "conjugation-coach",
+		MessageCount: total,
+		FPEarned:     fp,
+		Summary:      summary,
+		Topics:       []string{topicName},
+		Vocabulary:   prependUnique(practised, nil, 0),
+		Corrections:  corrections,
+		Suggestions:  suggestions,
+		CreatedAt:    time.Now(),
+		EndedAt:      time.Now(),
+	}
+	h.historyStore.Save(record)
+
+	_ = h.presenceStore.Clear(r.Context(), userID)
+	_ = h.cacheStore.InvalidateUserStats(r.Context(), userID)
+
+	writeJSON(w, http.StatusOK, conjugationCompleteResponse{
+		FPEarned:     fp,
+		CorrectCount: correctCount,
+		Total:        total,
+		Tenses:       tenseScores,
+		WeakTenses:   weak,
+		RecordID:     recordID,
+	})
+}
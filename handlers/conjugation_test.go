@@ -0,0 +1,94 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ailanguagetutor/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkConjugation(t *testing.T, body map[string]any) (int, map[string]any) {
+	t.Helper()
+	h := handlers.NewConjugationHandler(nil, nil, nil, nil, nil)
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/conjugation/check", bytes.NewReader(raw))
+	w := httptest.NewRecorder()
+	h.Check(w, req)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestConjugationCheck_GradesAgainstTables(t *testing.T) {
+	cases := []struct {
+		lang, verb, tense string
+		person            int
+		answer            string
+		correct           bool
+		mismatch          string
+	}{
+		{"es", "pensar", "present", 0, "pienso", true, ""},
+		{"es", "pensar", "present", 0, "Yo pienso", true, ""},
+		{"es", "pensar", "present", 0, "penso", false, "typo"},
+		{"es", "hablar", "preterite", 2, "ella habló", true, ""},
+		{"es", "hablar", "preterite", 2, "hablo", false, "accent"},
+		{"es", "tener", "future", 0, "teneré", false, ""},
+		{"it", "arrivare", "perfect", 0, "sono arrivata", true, ""},
+		{"it", "arrivare", "perfect", 0, "ho arrivato", false, ""},
+		{"pt", "fazer", "future", 0, "farei", true, ""},
+	}
+	for _, c := range cases {
+		code, resp := checkConjugation(t, map[string]any{
+			"language": c.lang, "verb": c.verb, "tense": c.tense, "person": c.person, "answer": c.answer,
+		})
+		require.Equal(t, http.StatusOK, code, c.answer)
+		assert.Equal(t, c.correct, resp["correct"], c.answer)
+		if c.mismatch != "" {
+			assert.Equal(t, c.mismatch, resp["mismatch"], c.answer)
+		}
+	}
+}
+
+func TestConjugationCheck_RejectsUnknownInput(t *testing.T) {
+	for _, body := range []map[string]any{
+		{"language": "en", "verb": "speak", "tense": "present", "person": 0, "answer": "speak"},
+		{"language": "es", "verb": "xyzar", "tense": "present", "person": 0, "answer": "xyzo"},
+		{"language": "es", "verb": "hablar", "tense": "pluperfect", "person": 0, "answer": "había hablado"},
+		{"language": "pt", "verb": "falar", "tense": "present", "person": 4, "answer": "falais"},
+	} {
+		code, _ := checkConjugation(t, body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+	}
+}
+
+func TestConjugationTable(t *testing.T) {
+	h := handlers.NewConjugationHandler(nil, nil, nil, nil, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/conjugation/table?language=it&verb=essere", nil)
+	w := httptest.NewRecorder()
+	h.Table(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Tenses []struct {
+			ID    string `json:"id"`
+			Forms []struct {
+				Text string `json:"text"`
+			} `json:"forms"`
+		} `json:"tenses"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Tenses, 6)
+	assert.Equal(t, "present", resp.Tenses[0].ID)
+	assert.Equal(t, "sono", resp.Tenses[0].Forms[0].Text)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/conjugation/table?language=it&verb=nonverbo", nil)
+	w = httptest.NewRecorder()
+	h.Table(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/grammar"
 	"github.com/ailanguagetutor/middleware"
 	"github.com/ailanguagetutor/store"
 	"github.com/go-chi/chi/v5"
@@ -282,6 +283,7 @@ type summaryResult struct {
 	Topics      []string `json:"topics_discussed"`
 	Vocabulary  []string `json:"vocabulary_learned"`
 	Corrections []string `json:"grammar_corrections"`
+	Concepts    []string `json:"grammar_concepts"` // concept IDs of the student's mistakes
 	Suggestions []string `json:"suggested_next_lessons"`
 	StudentName string   `json:"student_name"`
 }
@@ -326,6 +328,7 @@ RULES — you MUST follow these exactly:
 - "topics_discussed": List 2-4 specific topics or themes that came up. Never leave this empty — at minimum list the session topic.
 - "vocabulary_learned": List every %s word or phrase that appeared in the conversation (format: "word: %s meaning"). If fewer than 3 appear, infer 2-3 relevant words for this topic and level that the student likely encountered.
 - "grammar_corrections": List any grammar mistakes the student made with a brief correction. If no mistakes, write one grammar tip relevant to their level and the topic (e.g. "Tip: Use estar for temporary states like feelings and locations").
+- "grammar_concepts": For each actual grammar mistake the student made, the ID of the concept it falls under, from the list below. Empty list if they made no mistakes (tips do not count).
 - "suggested_next_lessons": Always provide exactly 3 specific, actionable next steps tailored to this student's level and what they practiced today.
 - "student_name": The student's first name if they introduced themselves in the conversation, otherwise empty string.
 
@@ -334,9 +337,11 @@ Topic: %s
 Duration: %s
 Messages exchanged: %d
 
+Grammar concepts:
+%s
 Transcript:
 %s`,
-		langName, langName, native, levelName, level, topicName, durationStr, len(msgs), transcript.String(),
+		langName, langName, native, levelName, level, topicName, durationStr, len(msgs), grammar.PromptList(language), transcript.String(),
 	)
 
 	payload := ionosPayload{
@@ -1196,6 +1201,13 @@ func buildStudentContextBlock(p *store.StudentProfile, isFirstSession bool) stri
 	if len(p.WeakAreas) > 0 {
 		sb.WriteString(fmt.Sprintf("\nRecurring mistakes to watch for: %s", strings.Join(p.WeakAreas, ", ")))
 	}
+	if weak := profileWeakGrammar(p); len(weak) > 0 {
+		names := make([]string, 0, min(len(weak), 5))
+		for _, id := range weak[:min(len(weak), 5)] {
+			names = append(names, fmt.Sprintf("%s (%s)", grammar.Name(id), id))
+		}
+		sb.WriteString(fmt.Sprintf("\nWeakest grammar concepts, reinforce them naturally: %s", strings.Join(names, ", ")))
+	}
 	if len(p.NextSuggestions) > 0 {
 		sb.WriteString(fmt.Sprintf("\nSuggested next focus: %s", strings.Join(p.NextSuggestions, ", ")))
 	}
@@ -1247,6 +1259,9 @@ func buildUpdatedProfile(existing *store.StudentProfile, userID, language string
 		p.Name = sr.StudentName
 	}
 	p.WeakAreas = prependUnique(sr.Corrections, p.WeakAreas, 5)
+	for _, id := range sr.Concepts {
+		p.RecordGrammar(grammar.Classify(language, id), false)
+	}
 	if len(sr.Corrections) == 0 && record.TopicName != "" {
 		p.StrongAreas = prependUnique([]string{record.TopicName}, p.StrongAreas, 10)
 	}
@@ -1254,6 +1269,7 @@ func buildUpdatedProfile(existing *store.StudentProfile, userID, language string
 		p.RecentTopics = prependUnique([]string{record.TopicName}, p.RecentTopics, 5)
 	}
 	p.RecentVocab = prependUnique(sr.Vocabulary, p.RecentVocab, 10)
+	recordLemmas(p, sr.Vocabulary)
 	if len(sr.Suggestions) > 0 {
 		p.NextSuggestions = sr.Suggestions
 	}
@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ailanguagetutor/lemma"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/textnorm"
+	"github.com/ailanguagetutor/tts"
+	"github.com/google/uuid"
+)
+
+// Dictation reuses the pooled listening stories: the learner hears a story
+// one sentence at a time and types it, and the server aligns the typed text
+// with the original word by word.
+
+// ── Types ─────────────────────────────────────────────────────────────────────
+
+type DictationSentence struct {
+	Index int    `json:"index"`
+	Audio string `json:"audio"`
+	Words int    `json:"words"` // number of words to type
+}
+
+type dictationSessionResponse struct {
+	SessionID string              `json:"session_id"`
+	Title     string              `json:"title"`
+	Speed     float64             `json:"speed"`
+	Sentences []DictationSentence `json:"sentences"`
+}
+
+type dictationCheckRequest struct {
+	SessionID string `json:"session_id"`
+	Index     int    `json:"index"`
+	Answer    string `json:"answer"`
+}
+
+type dictationCheckResponse struct {
+	Correct         bool                `json:"correct"`  // every word right, accents included
+	Text            string              `json:"text"`     // the original sentence
+	Accuracy        float64             `json:"accuracy"` // share of words right, 0-1
+	Words           []textnorm.WordDiff `json:"words"`
+	AlreadyAnswered bool                `json:"already_answered,omitempty"`
+}
+
+type dictationCompleteResponse struct {
+	FPEarned     int      `json:"fp_earned"`
+	CorrectCount int      `json:"correct_count"` // sentences typed without a mistake
+	TotalCount   int      `json:"total_count"`
+	Accuracy     float64  `json:"accuracy"`
+	Misspellings []string `json:"misspellings"`
+	MissedWords  []string `json:"missed_words"` // lemmas, added to weak vocabulary
+	RecordID     string   `json:"record_id"`
+}
+
+// dictationSentences is the number of sentences per session, and
+// dictationMaxWords the longest sentence dictated, by level.
+var (
+	dictationSentences = map[int]int{1: 5, 2: 6, 3: 7, 4: 8, 5: 8}
+	dictationMaxWords  = map[int]int{1: 8, 2: 10, 3: 14, 4: 18, 5: 22}
+)
+
+// Typed answers are aligned word by word against the sentence, which costs
+// time and memory in the product of both lengths. maxDictationBody caps the
+// check request, and an answer may have at most maxDictationAnswerRunes
+// characters and dictationAnswerWordFactor times the sentence's words.
+const (
+	maxDictationBody          = 16 << 10
+	maxDictationAnswerRunes   = 600
+	dictationAnswerWordFactor = 3
+)
+
+// ── Session ───────────────────────────────────────────────────────────────────
+
+func (h *ListeningHandler) DictationSession(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req listeningSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	if !IsValidLanguage(req.Language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid language"})
+		return
+	}
+	if req.Level < 1 || req.Level > 5 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "level must be 1-5"})
+		return
+	}
+
+	// Any pooled story for the key will do; generate one only if there is none
+	key := fmt.Sprintf("%s:%d:%s:%s", req.Language, req.Level, req.Topic, req.Personality)
+	var story Story
+	pooled := false // the story was generated and pooled by this request
+	if n := h.pool.Len(key); n > 0 {
+		raw, _ := h.pool.Get(key, rand.IntN(n))
+		if err := json.Unmarshal(raw, &story); err != nil {
+			log.Printf("dictation/session pool entry error: %v", err)
+		}
+	}
+	if len(story.Segments) == 0 {
+		var weak []string
+		if profile, _ := h.profileStore.Get(r.Context(), userID, req.Language); profile != nil {
+			weak = profile.WeakAreas
+		}
+		generated, score, fits, err := h.generateGradedStory(r.Context(), "dictation/session", req.Language, req.Level, req.Topic, req.Personality, nil, weak)
+		if err != nil {
+			log.Printf("dictation/session AI error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "AI service error"})
+			return
+		}
+		story = *generated
+		if fits {
+			raw, _ := json.Marshal(story)
+			h.pool.AppendScored(key, raw, score)
+			pooled = true
+		}
+	}
+
+	texts := dictationTexts(story, req.Language, dictationMaxWords[req.Level], dictationSentences[req.Level])
+	if len(texts) == 0 {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "no sentences to dictate"})
+		return
+	}
+
+	sentences := make([]DictationSentence, len(texts))
+	var queued []string
+	for i, text := range texts {
+		k, err := h.dictationAudio(text, req.Language, req.Level, req.Personality)
+		if err != nil {
+			log.Printf("dictation/session audio error: %v", err)
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "dictation audio is not available for this language"})
+			return
+		}
+		queued = append(queued, k)
+		sentences[i] = DictationSentence{Index: i, Audio: audioURL(k), Words: len(textnorm.Words(text, req.Language))}
+	}
+	// As in listening, clips are rendered ahead only for a story entering the
+	// pool; others are rendered, and metered, on first fetch.
+	if pooled {
+		h.renderer.Enqueue(userID, queued...)
+	}
+
+	id := uuid.New().String()
+	session := store.ListeningSession{
+		Mode:        store.ListeningDictation,
+		Language:    req.Language,
+		Level:       req.Level,
+		Topic:       req.Topic,
+		Personality: req.Personality,
+		PoolKey:     key,
+		Sentences:   texts,
+	}
+	if err := h.sessionStore.Save(r.Context(), userID, id, session); err != nil {
+		log.Printf("dictation/session save error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not start session"})
+		return
+	}
+
+	_ = h.presenceStore.Set(r.Context(), userID, store.LessonPresence{
+		Type:      "dictation",
+		Language:  req.Language,
+		Topic:     req.Topic,
+		StartedAt: time.Now(),
+	})
+
+	writeJSON(w, http.StatusOK, dictationSessionResponse{
+		SessionID: id,
+		Title:     story.Title,
+		Speed:     speedForLevel(req.Level),
+		Sentences: sentences,
+	})
+}
+
+// dictationTexts returns up to n sentences of story in order, skipping any
+// shorter than three words or longer than maxWords.
+func dictationTexts(story Story, language string, maxWords, n int) []string {
+	var out []string
+	for _, seg := range story.Segments {
+		for _, s := range splitSentences(seg.Text) {
+			words := len(textnorm.Words(s, language))
+			if words < 3 || words > maxWords {
+				continue
+			}
+			out = append(out, s)
+			if len(out) == n {
+				return out
+			}
+		}
+	}
+	return out
+}
+
+// splitSentences splits text after sentence-final punctuation followed by a
+// space, keeping the punctuation with its sentence.
+func splitSentences(text string) []string {
+	var out []string
+	start := 0
+	for i, r := range text {
+		if !strings.ContainsRune(".!?…", r) {
+			continue
+		}
+		next := i + utf8.RuneLen(r)
+		if next < len(text) && text[next] != ' ' && text[next] != '\n' {
+			continue
+		}
+		if s := strings.TrimSpace(text[start:next]); s != "" {
+			out = append(out, s)
+		}
+		start = next
+	}
+	if s := strings.TrimSpace(text[start:]); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+// dictationAudio prepares the clip of one sentence, read by the story's
+// narrator at the level's speed, and returns its cache key.
+func (h *ListeningHandler) dictationAudio(text, language string, level int, personality string) (string, error) {
+	if h.renderer == nil {
+		return "", tts.ErrNoVoice
+	}
+	return h.renderer.Prepare(tts.Request{Text: text, Language: language, Personality: personality, Speed: speedForLevel(level)})
+}
+
+// ── Check ─────────────────────────────────────────────────────────────────────
+
+// DictationCheck aligns the typed sentence with the original. Only the first
+// answer to a sentence is recorded; later ones get feedback on the first.
+func (h *ListeningHandler) DictationCheck(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxDictationBody)
+	var req dictationCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "answer too long"})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	session, ok := h.dictationSession(w, r.Context(), userID, req.SessionID)
+	if !ok {
+		return
+	}
+	if req.Index < 0 || req.Index >= len(session.Sentences) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid sentence index"})
+		return
+	}
+	text := session.Sentences[req.Index]
+
+	answer, already := session.Answers[req.Index]
+	if !already && !dictationAnswerFits(text, req.Answer, session.Language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "answer too long"})
+		return
+	}
+	var words []textnorm.WordDiff
+	if !already {
+		submitted := store.SessionAnswer{Answer: strings.TrimSpace(req.Answer)}
+		words = textnorm.Align(text, submitted.Answer, session.Language)
+		submitted.Correct = dictationCorrect(words)
+		first, err := h.sessionStore.RecordAnswer(r.Context(), userID, req.SessionID, req.Index, submitted)
+		if err != nil {
+			log.Printf("dictation/check record error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not record answer"})
+			return
+		}
+		answer, already = first, first != submitted
+	}
+	if already {
+		words = textnorm.Align(text, answer.Answer, session.Language)
+	}
+	writeJSON(w, http.StatusOK, dictationCheckResponse{
+		Correct:         answer.Correct,
+		Text:            text,
+		Accuracy:        dictationAccuracy(words),
+		Words:           words,
+		AlreadyAnswered: already,
+	})
+}
+
+// dictationAnswerFits reports whether answer is short enough to align
+// against text and store.
+func dictationAnswerFits(text, answer, language string) bool {
+	if utf8.RuneCountInString(answer) > maxDictationAnswerRunes {
+		return false
+	}
+	return len(textnorm.Words(answer, language)) <= dictationAnswerWordFactor*max(len(textnorm.Words(text, language)), 1)
+}
+
+// dictationSession loads a dictation session, writing the error response
+// if there is none.
+func (h *ListeningHandler) dictationSession(w http.ResponseWriter, ctx context.Context, userID, id string) (*store.ListeningSession, bool) {
+	session, err := h.sessionStore.Get(ctx, userID, id)
+	if err != nil {
+		log.Printf("dictation session error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not load session"})
+		return nil, false
+	}
+	if session == nil || session.Mode != store.ListeningDictation {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "session not found or expired"})
+		return nil, false
+	}
+	return session, true
+}
+
+// dictationCorrect reports whether every word was typed exactly.
+func dictationCorrect(words []textnorm.WordDiff) bool {
+	for _, d := range words {
+		if d.Kind != textnorm.KindExact {
+			return false
+		}
+	}
+	return true
+}
+
+// dictationAccuracy is the share of the original's words typed right. Extra
+// words count against it as much as missing ones.
+func dictationAccuracy(words []textnorm.WordDiff) float64 {
+	var right, total int
+	for _, d := range words {
+		total++
+		if d.Kind == textnorm.KindExact {
+			right++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(right) / float64(total)
+}
+
+// ── Complete ──────────────────────────────────────────────────────────────────
+
+func (h *ListeningHandler) DictationComplete(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req listeningCompleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	session, ok := h.dictationSession(w, r.Context(), userID, req.SessionID)
+	if !ok {
+		return
+	}
+	first, err := h.sessionStore.MarkCompleted(r.Context(), userID, req.SessionID)
+	if err != nil {
+		log.Printf("dictation/complete error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not complete session"})
+		return
+	}
+	if !first {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "session already completed"})
+		return
+	}
+
+	// Score from the recorded answers; sentences never typed count as all
+	// words missing. Spelling and accent slips are misspellings, words left
+	// out or heard as another word are weak vocabulary.
+	var correctCount, rightWords, totalWords int
+	var misspellings, missed, corrections []string
+	for i, text := range session.Sentences {
+		a := session.Answers[i]
+		if a.Correct {
+			correctCount++
+		} else {
+			corrections = append(corrections, text)
+		}
+		for _, d := range textnorm.Align(text, a.Answer, session.Language) {
+			totalWords++
+			switch d.Kind {
+			case textnorm.KindExact:
+				rightWords++
+			case textnorm.KindAccent, textnorm.KindTypo:
+				misspellings = append(misspellings, fmt.Sprintf("%s → %s", d.Answer, d.Expected))
+			case textnorm.KindMissing, textnorm.KindWrong:
+				if utf8.RuneCountInString(d.Expected) > 3 {
+					missed = append(missed, d.Expected)
+				}
+			}
+		}
+	}
+	misspellings = prependUnique(misspellings, nil, 0)
+	missed = lemma.Unique(session.Language, missed)
+	if missed == nil {
+		missed = []string{}
+	}
+	if misspellings == nil {
+		misspellings = []string{}
+	}
+	totalCount := len(session.Sentences)
+	accuracy := 0.0
+	if totalWords > 0 {
+		accuracy = float64(rightWords) / float64(totalWords)
+	}
+
+	fp := correctCount*10 + rightWords
+	if fp < 15 {
+		fp = 15
+	}
+	if correctCount == totalCount {
+		fp += 15
+	}
+
+	if _, _, err := h.userStore.UpdateActivity(userID, session.Language, fp); err != nil {
+		log.Printf("dictation/complete UpdateActivity error: %v", err)
+	}
+
+	ctx := context.Background()
+	profile, err := h.profileStore.Get(ctx, userID, session.Language)
+	if err != nil || profile == nil {
+		profile = &store.StudentProfile{
+			UserID:   userID,
+			Language: session.Language,
+		}
+	}
+	topicName, _ := TopicDetails(session.Topic)
+	profile.WeakVocab = prependUnique(missed, profile.WeakVocab, 30)
+	profile.WeakAreas = prependUnique(missed, profile.WeakAreas, 20)
+	profile.RecentTopics = prependUnique([]string{topicName}, profile.RecentTopics, 10)
+	profile.SessionCount++
+	if err := h.profileStore.Upsert(ctx, profile); err != nil {
+		log.Printf("dictation/complete Upsert error: %v", err)
+	}
+
+	summary := fmt.Sprintf("Completed Dictation on %s: %d/%d sentences without a mistake, %.0f%% of words right.", topicName, correctCount, totalCount, accuracy*100)
+	var suggestions []string
+	if len(misspellings) > 0 || len(missed) > 0 {
+		suggestions = []string{
+			"Replay the sentences you missed and type them again",
+			"Practise the missed words in a vocabulary session",
+			"Listen to the whole story in Listening Comprehension",
+		}
+	} else {
+		suggestions = []string{
+			"Try a higher level for longer, faster sentences",
+			"Dictate a story on a new topic",
+			"Write about this topic in the Writing Coach",
+		}
+	}
+
+	recordID := uuid.New().String()
+	record := &store.ConversationRecord{
+		ID:           recordID,
+		UserID:       userID,
+		Language:     session.Language,
+		Topic:        session.Topic,
+		TopicName:    topicName,
+		Level:        session.Level,
+		Personality:  "dictation",
+		MessageCount: totalCount,
+		FPEarned:     fp,
+		Summary:      summary,
+		Topics:       []string{topicName},
+		Corrections:  corrections,
+		Misspellings: misspellings,
+		Suggestions:  suggestions,
+		CreatedAt:    time.Now(),
+		EndedAt:      time.Now(),
+	}
+	h.historyStore.Save(record)
+	h.misspellings.record(r.Context(), userID, session.Language, req.SessionID, store.MisspellingDictation, parseMisspellings(misspellings))
+
+	_ = h.presenceStore.Clear(r.Context(), userID)
+	_ = h.cacheStore.InvalidateUserStats(r.Context(), userID)
+
+	writeJSON(w, http.StatusOK, dictationCompleteResponse{
+		FPEarned:     fp,
+		CorrectCount: correctCount,
+		TotalCount:   totalCount,
+		Accuracy:     accuracy,
+		Misspellings: misspellings,
+		MissedWords:  missed,
+		RecordID:     recordID,
+	})
+}
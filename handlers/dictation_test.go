@@ -0,0 +1,98 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDictationHandler(t *testing.T) *handlers.ListeningHandler {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	sessions := store.NewListeningSessionStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+	require.NoError(t, sessions.Save(ctx, "user-1", "d-1", store.ListeningSession{
+		Mode: store.ListeningDictation, Language: "es", Level: 2, Topic: "food",
+		Sentences: []string{"Ayer fuimos al mercado del pueblo.", "Compramos pan y fruta."},
+	}))
+	require.NoError(t, sessions.Save(ctx, "user-1", "l-1", store.ListeningSession{Language: "es"}))
+	return handlers.NewListeningHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, sessions, nil, nil)
+}
+
+func checkDictation(t *testing.T, h *handlers.ListeningHandler, body map[string]any) (int, map[string]any) {
+	t.Helper()
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/dictation/check", bytes.NewReader(raw))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+	h.DictationCheck(w, req)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestDictationCheck_AlignsWordByWord(t *testing.T) {
+	h := newTestDictationHandler(t)
+
+	code, resp := checkDictation(t, h, map[string]any{"session_id": "d-1", "index": 0, "answer": "ayer fuimos mercado del puebo"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, resp["correct"])
+	assert.Equal(t, "Ayer fuimos al mercado del pueblo.", resp["text"])
+	words := resp["words"].([]any)
+	require.Len(t, words, 6)
+	assert.Equal(t, map[string]any{"expected": "al", "kind": "missing"}, words[2])
+	assert.Equal(t, map[string]any{"expected": "pueblo", "answer": "puebo", "kind": "typo"}, words[5])
+	assert.InDelta(t, 4.0/6, resp["accuracy"], 1e-9)
+
+	// Case and punctuation do not matter; only the first answer counts
+	_, resp = checkDictation(t, h, map[string]any{"session_id": "d-1", "index": 1, "answer": "compramos pan y fruta"})
+	assert.Equal(t, true, resp["correct"])
+	_, resp = checkDictation(t, h, map[string]any{"session_id": "d-1", "index": 0, "answer": "Ayer fuimos al mercado del pueblo."})
+	assert.Equal(t, false, resp["correct"])
+	assert.Equal(t, true, resp["already_answered"])
+}
+
+func TestDictationCheck_RejectsOtherSessions(t *testing.T) {
+	h := newTestDictationHandler(t)
+
+	code, _ := checkDictation(t, h, map[string]any{"session_id": "l-1", "index": 0, "answer": "hola"})
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = checkDictation(t, h, map[string]any{"session_id": "d-1", "index": 5, "answer": "hola"})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestDictationCheck_RejectsOversizedAnswers(t *testing.T) {
+	h := newTestDictationHandler(t)
+
+	cases := []struct {
+		name   string
+		answer string
+		status int
+	}{
+		{"too many words", strings.Repeat("pan ", 20), http.StatusBadRequest},
+		{"too many characters", strings.Repeat("a", 700), http.StatusBadRequest},
+		{"body too large", strings.Repeat("pan y fruta ", 3000), http.StatusRequestEntityTooLarge},
+	}
+	for _, c := range cases {
+		code, _ := checkDictation(t, h, map[string]any{"session_id": "d-1", "index": 1, "answer": c.answer})
+		assert.Equal(t, c.status, code, c.name)
+	}
+
+	// Nothing was recorded, so a real answer still counts
+	_, resp := checkDictation(t, h, map[string]any{"session_id": "d-1", "index": 1, "answer": "Compramos pan y fruta."})
+	assert.Equal(t, true, resp["correct"])
+	assert.Nil(t, resp["already_answered"])
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ailanguagetutor/difficulty"
+)
+
+// gradeAttempts is how many times content is generated for a pool before the
+// last attempt is served unpooled.
+const gradeAttempts = 2
+
+// errBadAIResponse is returned by generators when the model's reply cannot be
+// parsed.
+var errBadAIResponse = errors.New("failed to parse AI response")
+
+// writeGenerationError sends the 500 response for a failed generation.
+func writeGenerationError(w http.ResponseWriter, err error) {
+	msg := "AI service error"
+	if errors.Is(err, errBadAIResponse) {
+		msg = errBadAIResponse.Error()
+	}
+	writeJSON(w, http.StatusInternalServerError, map[string]string{"error": msg})
+}
+
+// generateGraded generates content for level and scores it, generating again
+// if it is out of level. It returns the content, its score (nil if the
+// language or content cannot be scored) and whether it may be pooled: content
+// that cannot be scored may, and content still out of level after
+// gradeAttempts is served but not pooled.
+func generateGraded[T any](mode string, level int, generate func() (T, error), score func(T) (difficulty.Score, bool)) (T, *difficulty.Score, bool, error) {
+	var (
+		item T
+		s    difficulty.Score
+		ok   bool
+		err  error
+	)
+	for attempt := 1; attempt <= gradeAttempts; attempt++ {
+		item, err = generate()
+		if err != nil {
+			return item, nil, false, err
+		}
+		s, ok = score(item)
+		if !ok {
+			return item, nil, true, nil
+		}
+		if s.Fits(level) {
+			return item, &s, true, nil
+		}
+		log.Printf("%s: generated content scored %s for level %d (attempt %d)", mode, s.Band, level, attempt)
+	}
+	return item, &s, false, nil
+}
+
+// ── Scoring pooled content ────────────────────────────────────────────────────
+
+func scoreVocab(language string, words []VocabWord) (difficulty.Score, bool) {
+	entries := make([]string, len(words))
+	for i, w := range words {
+		entries[i] = w.Word
+	}
+	return difficulty.AnalyseList(language, entries)
+}
+
+func scoreSentences(language string, sentences []Sentence) (difficulty.Score, bool) {
+	texts := make([]string, len(sentences))
+	for i, s := range sentences {
+		texts[i] = s.Target
+	}
+	return difficulty.Analyse(language, strings.Join(texts, "\n"))
+}
+
+// scoreStory scores the narration and the questions the learner hears.
+func scoreStory(language string, story Story) (difficulty.Score, bool) {
+	var texts []string
+	for _, seg := range story.Segments {
+		texts = append(texts, seg.Text, seg.Question.Question)
+	}
+	return difficulty.Analyse(language, strings.Join(texts, "\n"))
+}
+
+// scoreReading scores the text and its questions.
+func scoreReading(language string, text ReadingText) (difficulty.Score, bool) {
+	texts := []string{text.Text}
+	for _, q := range text.Questions {
+		texts = append(texts, q.Question)
+	}
+	return difficulty.Analyse(language, strings.Join(texts, "\n"))
+}
+
+// scoreLesson scores the target-language examples and exercises; the
+// explanation is in the learner's native language.
+func scoreLesson(language string, lesson *GrammarLesson) (difficulty.Score, bool) {
+	var texts []string
+	for _, ex := range lesson.Examples {
+		texts = append(texts, ex.Target)
+	}
+	for _, ex := range lesson.Exercises {
+		texts = append(texts, ex.Prompt)
+	}
+	return difficulty.Analyse(language, strings.Join(texts, "\n"))
+}
+
+// poolScorer scores one raw pool entry of a pool's item type.
+type poolScorer func(language string, raw json.RawMessage) (difficulty.Score, bool)
+
+func rawScorer[T any](score func(string, T) (difficulty.Score, bool)) poolScorer {
+	return func(language string, raw json.RawMessage) (difficulty.Score, bool) {
+		var item T
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return difficulty.Score{}, false
+		}
+		return score(language, item)
+	}
+}
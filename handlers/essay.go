@@ -0,0 +1,537 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ailanguagetutor/curriculum"
+	"github.com/ailanguagetutor/grammar"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/google/uuid"
+)
+
+// essayPersonality marks writing sessions in essay mode.
+const essayPersonality = "essay-coach"
+
+// essayWords is the word range an essay task asks for, by level. Essays of
+// less than half the minimum are not reviewed.
+var essayWords = map[int][2]int{1: {40, 100}, 2: {60, 150}, 3: {100, 250}, 4: {150, 350}, 5: {200, 450}}
+
+// essayMaxChars caps the length of a submitted essay.
+const essayMaxChars = 8000
+
+// essayCriteria are the CEFR written assessment criteria an essay is scored
+// on, in report order.
+var essayCriteria = []struct{ ID, Name string }{
+	{"task", "Task achievement: the prompt is answered fully and appropriately"},
+	{"coherence", "Coherence and cohesion: ideas are organised and linked"},
+	{"range", "Range: variety of vocabulary and structures"},
+	{"accuracy", "Accuracy: control of grammar and spelling"},
+}
+
+// ── Types ──────────────────────────────────────────────────────────────────────
+
+type essaySessionResponse struct {
+	SessionID string `json:"session_id"`
+	Prompt    string `json:"prompt"`
+	MinWords  int    `json:"min_words"`
+	MaxWords  int    `json:"max_words"`
+	Language  string `json:"language"`
+	Level     int    `json:"level"`
+	Topic     string `json:"topic"`
+	TopicName string `json:"topic_name"`
+}
+
+type essaySubmitRequest struct {
+	SessionID    string `json:"session_id"`
+	Text         string `json:"text"`
+	DurationSecs int    `json:"duration_secs"`
+	TopicName    string `json:"topic_name"`
+}
+
+type essaySubmitResponse struct {
+	RecordID        string            `json:"record_id"`
+	FPEarned        int               `json:"fp_earned"`
+	NewStreak       int               `json:"new_streak"`
+	NewAchievements []string          `json:"new_achievements"`
+	TotalFP         int               `json:"total_fp"`
+	Summary         string            `json:"summary"`
+	Suggestions     []string          `json:"suggested_next_lessons"`
+	Essay           store.EssayReview `json:"essay"`
+}
+
+// essayAIReview is the model's review before its corrections are located in
+// the essay.
+type essayAIReview struct {
+	Corrections []struct {
+		Original    string `json:"original"`
+		Suggestion  string `json:"suggestion"`
+		Category    string `json:"category"`
+		Concept     string `json:"concept"`
+		Explanation string `json:"explanation"`
+	} `json:"corrections"`
+	Rubric      []store.EssayCriterion `json:"rubric"`
+	Summary     string                 `json:"summary"`
+	Suggestions []string               `json:"suggestions"`
+}
+
+// ── Session ────────────────────────────────────────────────────────────────────
+
+// EssaySession starts an essay: a writing task on the topic, stored as a
+// writing session so the submitted essay is reviewed against it.
+func (h *WritingHandler) EssaySession(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req writingSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	if !IsValidLanguage(req.Language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid language"})
+		return
+	}
+	if req.Level < 1 || req.Level > 5 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "level must be 1-5"})
+		return
+	}
+	if !h.checkAccess(w, userID, req.Level) {
+		return
+	}
+
+	langName := LanguageName(req.Language)
+	topicName, topicDesc := TopicDetails(req.Topic)
+	if req.TopicName != "" {
+		topicName = req.TopicName
+	}
+	spec := levelSpec[req.Level]
+	if spec == "" {
+		spec = "INTERMEDIATE (B1)"
+	}
+	words := essayWords[req.Level]
+
+	prompt := fmt.Sprintf(
+		`You are a language teacher setting a writing task for a student of %s.
+Topic: %s — %s | Level: %s
+Write ONE essay prompt in %s (2–3 sentences) asking for %d–%d words: an email, story, opinion piece or description suited to the level, with two or three points to cover.
+Output ONLY the prompt text — no JSON, no quotes, no explanation.`,
+		langName, topicName, topicDesc, spec, langName, words[0], words[1],
+	)
+	result, err := h.callAI(r.Context(), prompt, 200, 0.9)
+	if err != nil {
+		log.Printf("writing/essay session AI error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "AI service error"})
+		return
+	}
+	task := strings.TrimSpace(result)
+
+	session := h.sessionStore.Create(userID, req.Language, req.Topic, req.Level, essayPersonality, task)
+	_ = h.sessionStore.AddMessage(session.ID, store.Message{Role: "assistant", Content: task})
+
+	_ = h.presenceStore.Set(r.Context(), userID, store.LessonPresence{
+		Type:      "writing",
+		Language:  req.Language,
+		Topic:     req.Topic,
+		StartedAt: session.CreatedAt,
+	})
+
+	writeJSON(w, http.StatusOK, essaySessionResponse{
+		SessionID: session.ID,
+		Prompt:    task,
+		MinWords:  words[0],
+		MaxWords:  words[1],
+		Language:  req.Language,
+		Level:     req.Level,
+		Topic:     req.Topic,
+		TopicName: topicName,
+	})
+}
+
+// ── Submit ─────────────────────────────────────────────────────────────────────
+
+// EssaySubmit reviews an essay and completes its session: span corrections,
+// the corrected text and a CEFR rubric are returned and stored with the
+// conversation record. An essay can be submitted once.
+func (h *WritingHandler) EssaySubmit(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req essaySubmitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if utf8.RuneCountInString(req.Text) > essayMaxChars {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("essay must be at most %d characters", essayMaxChars)})
+		return
+	}
+
+	session, err := h.sessionStore.Get(req.SessionID)
+	if err != nil || session.Personality != essayPersonality {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "session not found"})
+		return
+	}
+	if session.UserID != userID {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return
+	}
+	wordCount := len(strings.Fields(req.Text))
+	if minWords := essayWords[session.Level][0] / 2; wordCount < minWords {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("essay must be at least %d words", minWords)})
+		return
+	}
+
+	var task string
+	msgs, _ := h.sessionStore.GetMessages(req.SessionID)
+	for _, m := range msgs {
+		switch m.Role {
+		case "assistant":
+			task = m.Content
+		case "user":
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "essay already submitted"})
+			return
+		}
+	}
+
+	// The review is slow: claim the submission first so that racing
+	// submits are not each reviewed and scored.
+	claimed, err := h.sessionStore.Claim(session.ID, "submitted")
+	if err != nil {
+		log.Printf("writing/essay Claim error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to submit essay"})
+		return
+	}
+	if !claimed {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "essay already submitted"})
+		return
+	}
+
+	review, err := h.reviewEssay(r.Context(), session.Language, session.Level, task, req.Text)
+	if err != nil {
+		log.Printf("writing/essay review error: %v", err)
+		if err := h.sessionStore.Unclaim(session.ID, "submitted"); err != nil {
+			log.Printf("writing/essay Unclaim error: %v", err)
+		}
+		writeGenerationError(w, err)
+		return
+	}
+	if err := h.sessionStore.AddMessage(session.ID, store.Message{Role: "user", Content: req.Text}); err != nil {
+		log.Printf("writing/essay AddMessage error: %v", err)
+	}
+
+	fp := 10 + wordCount/10 + session.Level*5
+	if fp > 80 {
+		fp = 80
+	}
+	newStreak, newBadges, _ := h.userStore.UpdateActivity(userID, session.Language, fp)
+	totalFP := 0
+	if u, err := h.userStore.GetByID(userID); err == nil {
+		totalFP = u.TotalFP
+	}
+	if newBadges == nil {
+		newBadges = []string{}
+	}
+
+	topicName := req.TopicName
+	if topicName == "" {
+		topicName, _ = TopicDetails(session.Topic)
+	}
+	essay := review.essay
+	corrections, misspellings := essayCorrectionStrings(essay.Corrections)
+
+	ctx := r.Context()
+	profile, err := h.profileStore.Get(ctx, userID, session.Language)
+	if err != nil || profile == nil {
+		profile = &store.StudentProfile{UserID: userID, Language: session.Language}
+	}
+	for _, c := range essay.Corrections {
+		switch c.Category {
+		case store.EssaySpelling:
+			profile.RecordGrammar(grammar.Spelling(session.Language), false)
+		case store.EssayGrammar:
+			profile.RecordGrammar(c.Concept, false)
+		}
+	}
+	profile.WeakAreas = prependUnique(corrections, profile.WeakAreas, 5)
+	profile.RecentTopics = prependUnique([]string{topicName}, profile.RecentTopics, 10)
+	if len(review.suggestions) > 0 {
+		profile.NextSuggestions = review.suggestions
+	}
+	profile.SessionCount++
+	if err := h.profileStore.Upsert(ctx, profile); err != nil {
+		log.Printf("writing/essay Upsert error: %v", err)
+	}
+
+	record := &store.ConversationRecord{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		SessionID:    session.ID,
+		Language:     session.Language,
+		Topic:        session.Topic,
+		TopicName:    topicName,
+		Level:        session.Level,
+		Personality:  essayPersonality,
+		MessageCount: 1,
+		DurationSecs: req.DurationSecs,
+		FPEarned:     fp,
+		Summary:      review.summary,
+		Corrections:  corrections,
+		Suggestions:  review.suggestions,
+		Misspellings: misspellings,
+		Essay:        &essay,
+		CreatedAt:    session.CreatedAt,
+		EndedAt:      time.Now(),
+	}
+	h.historyStore.Save(record)
+	h.misspellings.record(ctx, userID, session.Language, session.ID, store.MisspellingEssay, essayMisspellings(essay.Corrections))
+
+	_ = h.presenceStore.Clear(ctx, userID)
+	_ = h.cacheStore.InvalidateUserStats(ctx, userID)
+
+	writeJSON(w, http.StatusOK, essaySubmitResponse{
+		RecordID:        record.ID,
+		FPEarned:        fp,
+		NewStreak:       newStreak,
+		NewAchievements: newBadges,
+		TotalFP:         totalFP,
+		Summary:         review.summary,
+		Suggestions:     review.suggestions,
+		Essay:           essay,
+	})
+}
+
+// ── Review ─────────────────────────────────────────────────────────────────────
+
+type essayReviewResult struct {
+	essay       store.EssayReview
+	summary     string
+	suggestions []string
+}
+
+// reviewEssay asks the model for corrections and a rubric, then locates the
+// corrections in the text and builds the corrected version from them, so the
+// offsets and the corrected text always agree.
+func (h *WritingHandler) reviewEssay(ctx context.Context, language string, level int, task, text string) (essayReviewResult, error) {
+	langName := LanguageName(language)
+	spec := levelSpec[level]
+	if spec == "" {
+		spec = "INTERMEDIATE (B1)"
+	}
+	var criteria strings.Builder
+	for _, c := range essayCriteria {
+		criteria.WriteString("  - " + c.ID + ": " + c.Name + "\n")
+	}
+
+	prompt := fmt.Sprintf(`You are an experienced %s teacher marking a student's essay.
+Student level: %s
+Task: %s
+
+Return ONLY valid JSON — no markdown, no code fences, no explanation:
+{"corrections":[{"original":"...","suggestion":"...","category":"...","concept":"...","explanation":"..."}],"rubric":[{"criterion":"...","band":"...","comment":"..."}],"summary":"...","suggestions":["..."]}
+
+Rules:
+- "corrections": every error in the essay, in the order they appear
+  - "original": the erroneous text copied EXACTLY from the essay, as short as possible (a word or phrase, not the whole sentence)
+  - "suggestion": the corrected text that replaces it
+  - "category": one of "spelling", "grammar", "word_choice", "style"
+  - "concept": for grammar corrections, the ID of the grammar concept from this list, otherwise "":
+%s  - "explanation": one short sentence in English
+- "rubric": one entry per criterion, with the CEFR band (A1, A2, B1, B2, C1 or C2) the essay shows on it and a one-sentence comment in English:
+%s- "summary": 2-3 sentences in English on the essay's strengths and what to work on
+- "suggestions": exactly 3 specific next steps
+
+Essay:
+%s`,
+		langName, spec, task, grammar.PromptList(language), criteria.String(), text)
+
+	result, err := h.callAI(ctx, prompt, 2500, 0.2)
+	if err != nil {
+		return essayReviewResult{}, err
+	}
+	result = strings.TrimSpace(result)
+	if idx := strings.Index(result, "{"); idx > 0 {
+		result = result[idx:]
+	}
+	if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
+		result = result[:idx+1]
+	}
+	var parsed essayAIReview
+	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+		log.Printf("writing/essay JSON parse error: %v\nraw: %s", err, result)
+		return essayReviewResult{}, errBadAIResponse
+	}
+
+	var found []store.EssayCorrection
+	for _, c := range parsed.Corrections {
+		found = append(found, store.EssayCorrection{
+			Original:    c.Original,
+			Suggestion:  strings.TrimSpace(c.Suggestion),
+			Category:    essayCategory(c.Category),
+			Concept:     c.Concept,
+			Explanation: c.Explanation,
+		})
+	}
+	corrections := locateCorrections(text, found)
+	for i := range corrections {
+		if corrections[i].Category == store.EssayGrammar {
+			corrections[i].Concept = grammar.Classify(language, corrections[i].Concept)
+		} else {
+			corrections[i].Concept = ""
+		}
+	}
+	rubric, band := essayRubric(parsed.Rubric)
+
+	return essayReviewResult{
+		essay: store.EssayReview{
+			Prompt:      task,
+			Text:        text,
+			Corrected:   applyCorrections(text, corrections),
+			WordCount:   len(strings.Fields(text)),
+			Corrections: corrections,
+			Rubric:      rubric,
+			Band:        band,
+		},
+		summary:     parsed.Summary,
+		suggestions: parsed.Suggestions,
+	}, nil
+}
+
+// essayCategory maps the model's category onto the known ones; anything
+// else counts as grammar.
+func essayCategory(category string) string {
+	c := strings.ToLower(strings.TrimSpace(category))
+	c = strings.NewReplacer(" ", "_", "-", "_").Replace(c)
+	switch c {
+	case store.EssaySpelling, store.EssayWordChoice, store.EssayStyle:
+		return c
+	}
+	return store.EssayGrammar
+}
+
+// locateCorrections sets the character offsets of each correction by finding
+// its original text in the essay. Corrections are searched for after the
+// previous one, as the model lists them in order, and then anywhere; those
+// not found, unchanged or overlapping an earlier one are dropped. The result
+// is in text order.
+func locateCorrections(text string, corrections []store.EssayCorrection) []store.EssayCorrection {
+	var out []store.EssayCorrection
+	taken := func(start, end int) bool {
+		for _, c := range out {
+			if start < c.End && c.Start < end {
+				return true
+			}
+		}
+		return false
+	}
+	cursor := 0 // byte offset after the last correction placed
+	for _, c := range corrections {
+		if strings.TrimSpace(c.Original) == "" || c.Original == c.Suggestion {
+			continue
+		}
+		placed := false
+		for _, from := range []int{cursor, 0} {
+			for off := from; off <= len(text); {
+				i := strings.Index(text[off:], c.Original)
+				if i < 0 {
+					break
+				}
+				b := off + i
+				start := utf8.RuneCountInString(text[:b])
+				end := start + utf8.RuneCountInString(c.Original)
+				if !taken(start, end) {
+					c.Start, c.End = start, end
+					out = append(out, c)
+					cursor = b + len(c.Original)
+					placed = true
+					break
+				}
+				off = b + len(c.Original)
+			}
+			if placed {
+				break
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+	return out
+}
+
+// applyCorrections returns text with each correction's span replaced by its
+// suggestion. Corrections must be in text order and not overlap.
+func applyCorrections(text string, corrections []store.EssayCorrection) string {
+	runes := []rune(text)
+	var b strings.Builder
+	pos := 0
+	for _, c := range corrections {
+		b.WriteString(string(runes[pos:c.Start]))
+		b.WriteString(c.Suggestion)
+		pos = c.End
+	}
+	b.WriteString(string(runes[pos:]))
+	return b.String()
+}
+
+// essayRubric keeps one entry per known criterion, in report order, and
+// returns the overall band: the rounded mean of the criteria's bands.
+func essayRubric(rubric []store.EssayCriterion) ([]store.EssayCriterion, string) {
+	out := []store.EssayCriterion{}
+	sum := 0
+	for _, c := range essayCriteria {
+		for _, rc := range rubric {
+			band := strings.ToUpper(strings.TrimSpace(rc.Band))
+			if strings.EqualFold(strings.TrimSpace(rc.Criterion), c.ID) && curriculum.BandOrder(band) > 0 {
+				out = append(out, store.EssayCriterion{Criterion: c.ID, Band: band, Comment: rc.Comment})
+				sum += curriculum.BandOrder(band)
+				break
+			}
+		}
+	}
+	if len(out) == 0 {
+		return out, ""
+	}
+	mean := int(math.Round(float64(sum) / float64(len(out))))
+	for _, band := range []string{"A1", "A2", "B1", "B2", "C1", "C2"} {
+		if curriculum.BandOrder(band) == mean {
+			return out, band
+		}
+	}
+	return out, ""
+}
+
+// essayCorrectionStrings formats corrections for the record's grammar
+// corrections and misspellings, as "wrong → correct (note)".
+func essayCorrectionStrings(corrections []store.EssayCorrection) (grammarCorrections, misspellings []string) {
+	grammarCorrections, misspellings = []string{}, []string{}
+	for _, c := range corrections {
+		s := c.Original + " → " + c.Suggestion
+		if c.Explanation != "" {
+			s += " (" + c.Explanation + ")"
+		}
+		if c.Category == store.EssaySpelling {
+			misspellings = append(misspellings, s)
+		} else {
+			grammarCorrections = append(grammarCorrections, s)
+		}
+	}
+	return grammarCorrections, misspellings
+}
+
+// essayMisspellings returns the spelling corrections as misspellings.
+func essayMisspellings(corrections []store.EssayCorrection) []store.Misspelling {
+	var out []store.Misspelling
+	for _, c := range corrections {
+		if c.Category == store.EssaySpelling {
+			out = append(out, store.Misspelling{Wrong: c.Original, Correct: c.Suggestion, Note: c.Explanation})
+		}
+	}
+	return out
+}
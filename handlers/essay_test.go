@@ -0,0 +1,95 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func submitEssay(t *testing.T, h *handlers.WritingHandler, body map[string]any) (int, map[string]any) {
+	t.Helper()
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/writing/essay/submit", bytes.NewReader(raw))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+	h.EssaySubmit(w, req)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestEssaySubmit_RejectsBeforeReview(t *testing.T) {
+	mr := miniredis.RunT(t)
+	sessions := store.NewSessionStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), time.Hour)
+	h := handlers.NewWritingHandler(nil, nil, nil, nil, sessions, nil, nil, nil, nil, nil)
+
+	essay := sessions.Create("user-1", "es", "travel", 1, "essay-coach", "Escribe un correo.")
+	require.NoError(t, sessions.AddMessage(essay.ID, store.Message{Role: "assistant", Content: "Escribe un correo."}))
+	chat := sessions.Create("user-1", "es", "travel", 1, "writing-coach", "system")
+	other := sessions.Create("user-2", "es", "travel", 1, "essay-coach", "Escribe un correo.")
+	long := strings.Repeat("palabra ", 30)
+
+	cases := []struct {
+		name    string
+		session string
+		text    string
+		status  int
+	}{
+		{"unknown session", "missing", long, http.StatusNotFound},
+		{"chat session", chat.ID, long, http.StatusNotFound},
+		{"other user", other.ID, long, http.StatusForbidden},
+		{"too short", essay.ID, "Hola, ¿qué tal?", http.StatusBadRequest},
+		{"too long", essay.ID, strings.Repeat("a", 8001), http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		code, _ := submitEssay(t, h, map[string]any{"session_id": c.session, "text": c.text})
+		assert.Equal(t, c.status, code, c.name)
+	}
+
+	// An essay is reviewed once
+	require.NoError(t, sessions.AddMessage(essay.ID, store.Message{Role: "user", Content: long}))
+	code, resp := submitEssay(t, h, map[string]any{"session_id": essay.ID, "text": long})
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "essay already submitted", resp["error"])
+}
+
+func TestEssaySubmit_ClaimsSubmissionBeforeReview(t *testing.T) {
+	ai := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(ai.Close)
+	mr := miniredis.RunT(t)
+	sessions := store.NewSessionStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), time.Hour)
+	h := handlers.NewWritingHandler(&config.Config{IONOSBaseURL: ai.URL}, nil, nil, nil, sessions, nil, nil, nil, nil, nil)
+
+	essay := sessions.Create("user-1", "es", "travel", 1, "essay-coach", "Escribe un correo.")
+	require.NoError(t, sessions.AddMessage(essay.ID, store.Message{Role: "assistant", Content: "Escribe un correo."}))
+	long := strings.Repeat("palabra ", 30)
+
+	// A failed review releases the claim so the essay can be submitted again
+	code, _ := submitEssay(t, h, map[string]any{"session_id": essay.ID, "text": long})
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	// A submit still under review holds the claim
+	claimed, err := sessions.Claim(essay.ID, "submitted")
+	require.NoError(t, err)
+	require.True(t, claimed)
+	code, resp := submitEssay(t, h, map[string]any{"session_id": essay.ID, "text": long})
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "essay already submitted", resp["error"])
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 
+	"github.com/ailanguagetutor/grammar"
 	"github.com/ailanguagetutor/middleware"
 	"github.com/ailanguagetutor/store"
 	"github.com/go-chi/chi/v5"
@@ -13,10 +15,11 @@ type GamificationHandler struct {
 	historyStore *store.ConversationHistoryStore
 	profileStore *store.StudentProfileStore
 	cacheStore   *store.CacheStore
+	misspellings *store.MisspellingStore
 }
 
-func NewGamificationHandler(us *store.UserStore, hs *store.ConversationHistoryStore, ps *store.StudentProfileStore, cs *store.CacheStore) *GamificationHandler {
-	return &GamificationHandler{userStore: us, historyStore: hs, profileStore: ps, cacheStore: cs}
+func NewGamificationHandler(us *store.UserStore, hs *store.ConversationHistoryStore, ps *store.StudentProfileStore, cs *store.CacheStore, ms *store.MisspellingStore) *GamificationHandler {
+	return &GamificationHandler{userStore: us, historyStore: hs, profileStore: ps, cacheStore: cs, misspellings: ms}
 }
 
 // Stats returns the current user's gamification stats and recent conversations.
@@ -134,13 +137,24 @@ func (h *GamificationHandler) GetMistakes(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	misspelled := []store.MisspelledWord{}
+	if h.misspellings != nil {
+		if words, err := h.misspellings.Frequent(r.Context(), userID, language, misspelledLimit); err != nil {
+			log.Printf("mistakes Frequent error: %v", err)
+		} else {
+			misspelled = words
+		}
+	}
+
 	profile, err := h.profileStore.Get(r.Context(), userID, language)
 	if err != nil || profile == nil {
 		writeJSON(w, http.StatusOK, map[string]any{
 			"language":    language,
 			"weak_vocab":  []string{},
 			"weak_grammar": []string{},
-			"has_mistakes": false,
+			"grammar_concepts": []grammarConceptStat{},
+			"misspelled_words": misspelled,
+			"has_mistakes": len(misspelled) > 0,
 		})
 		return
 	}
@@ -149,15 +163,29 @@ func (h *GamificationHandler) GetMistakes(w http.ResponseWriter, r *http.Request
 	if weakVocab == nil {
 		weakVocab = []string{}
 	}
-	weakGrammar := profile.WeakGrammar
-	if weakGrammar == nil {
-		weakGrammar = []string{}
+	// Weak grammar is tracked by concept ID; names are listed for display
+	weakGrammar := []string{}
+	concepts := []grammarConceptStat{}
+	for _, id := range grammar.Known(profile.WeakGrammar) {
+		c, _ := grammar.Lookup(id)
+		st := profile.GrammarStats[id]
+		weakGrammar = append(weakGrammar, c.Name)
+		concepts = append(concepts, grammarConceptStat{Concept: c, Errors: st.Errors, Successes: st.Successes})
 	}
 
 	writeJSON(w, http.StatusOK, map[string]any{
-		"language":     language,
-		"weak_vocab":   weakVocab,
-		"weak_grammar": weakGrammar,
-		"has_mistakes": len(weakVocab) > 0 || len(weakGrammar) > 0,
+		"language":         language,
+		"weak_vocab":       weakVocab,
+		"weak_grammar":     weakGrammar,
+		"grammar_concepts": concepts,
+		"misspelled_words": misspelled,
+		"has_mistakes":     len(weakVocab) > 0 || len(weakGrammar) > 0 || len(misspelled) > 0,
 	})
 }
+
+// grammarConceptStat is a weak grammar concept with the user's record on it.
+type grammarConceptStat struct {
+	grammar.Concept
+	Errors    int `json:"errors"`
+	Successes int `json:"successes"`
+}
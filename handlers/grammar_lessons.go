@@ -0,0 +1,617 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/curriculum"
+	"github.com/ailanguagetutor/difficulty"
+	"github.com/ailanguagetutor/grammar"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/textnorm"
+	"github.com/google/uuid"
+)
+
+// GrammarHandler serves the grammar lessons mode: one lesson per grammar
+// concept, with an explanation in the learner's native language, worked
+// examples and a short graded exercise set. Lessons are generated once per
+// language, level and concept and cached in the pool. The answer key of the
+// lesson served is kept in a session; an exercise's answer is only revealed
+// once the learner's first answer to it is recorded.
+type GrammarHandler struct {
+	cfg           *config.Config
+	userStore     *store.UserStore
+	profileStore  *store.StudentProfileStore
+	historyStore  *store.ConversationHistoryStore
+	pool          *store.ItemPool
+	presenceStore *store.PresenceStore
+	cacheStore    *store.CacheStore
+	sessionStore  *store.GrammarSessionStore
+}
+
+func NewGrammarHandler(cfg *config.Config, us *store.UserStore, ps *store.StudentProfileStore, hs *store.ConversationHistoryStore, pool *store.ItemPool, presence *store.PresenceStore, cache *store.CacheStore, sessions *store.GrammarSessionStore) *GrammarHandler {
+	return &GrammarHandler{cfg: cfg, userStore: us, profileStore: ps, historyStore: hs, pool: pool, presenceStore: presence, cacheStore: cache, sessionStore: sessions}
+}
+
+// ── Types ─────────────────────────────────────────────────────────────────────
+
+// Lesson exercise kinds.
+const (
+	LessonChoice = "choice" // pick one of Options
+	LessonFill   = "fill"   // type the word(s) replacing ___ in Prompt
+)
+
+// grammarLessonsTopic is the topic ID the mode is listed under in meta.go.
+const grammarLessonsTopic = "grammar-lessons"
+
+type LessonExample struct {
+	Target      string `json:"target"`
+	Translation string `json:"translation"`
+	Note        string `json:"note"`
+}
+
+type LessonExercise struct {
+	ID          string   `json:"id"`
+	Kind        string   `json:"kind"` // LessonChoice or LessonFill
+	Prompt      string   `json:"prompt"`
+	Options     []string `json:"options,omitempty"`
+	Hint        string   `json:"hint,omitempty"`
+	Answer      string   `json:"answer,omitempty"`      // stripped before serving
+	Explanation string   `json:"explanation,omitempty"` // stripped before serving
+}
+
+type GrammarLesson struct {
+	ID          string           `json:"id"` // grammar concept ID
+	Language    string           `json:"language"`
+	Level       int              `json:"level"`
+	Title       string           `json:"title"`
+	Band        string           `json:"band"`
+	Explanation string           `json:"explanation"` // in the learner's native language
+	Examples    []LessonExample  `json:"examples"`
+	Exercises   []LessonExercise `json:"exercises"`
+}
+
+type grammarLessonInfo struct {
+	grammar.Concept
+	Errors    int  `json:"errors"`
+	Successes int  `json:"successes"`
+	Mastery   int  `json:"mastery"` // 0-100
+	Weak      bool `json:"weak"`    // among the learner's weakest concepts
+}
+
+type grammarLessonRequest struct {
+	Language string `json:"language"`
+	Level    int    `json:"level"`
+	LessonID string `json:"lesson_id"`
+}
+
+type grammarLessonResponse struct {
+	SessionID string        `json:"session_id"`
+	Lesson    GrammarLesson `json:"lesson"` // without answers
+}
+
+type grammarCheckRequest struct {
+	SessionID  string `json:"session_id"`
+	ExerciseID string `json:"exercise_id"`
+	Answer     string `json:"answer"`
+}
+
+type grammarCheckResponse struct {
+	Correct         bool   `json:"correct"`
+	Answer          string `json:"answer"`
+	Explanation     string `json:"explanation"`
+	AlreadyAnswered bool   `json:"already_answered,omitempty"` // only the first answer counts
+}
+
+type grammarCompleteRequest struct {
+	SessionID string `json:"session_id"`
+}
+
+type lessonResult struct {
+	ExerciseID string `json:"exercise_id"`
+	Correct    bool   `json:"correct"`
+	Answer     string `json:"answer"`
+}
+
+type grammarCompleteResponse struct {
+	FPEarned     int            `json:"fp_earned"`
+	CorrectCount int            `json:"correct_count"`
+	Total        int            `json:"total"`
+	Mastery      int            `json:"mastery"`
+	Results      []lessonResult `json:"results"`
+	RecordID     string         `json:"record_id"`
+}
+
+// ── Catalogue ─────────────────────────────────────────────────────────────────
+
+// Lessons lists the lessons up to a level (?language=es&level=1-5) with the
+// learner's record on each concept.
+func (h *GrammarHandler) Lessons(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	language := r.URL.Query().Get("language")
+	if !IsValidLanguage(language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "valid language param required"})
+		return
+	}
+	level, err := strconv.Atoi(r.URL.Query().Get("level"))
+	if err != nil || level < 1 || level > 5 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "level must be 1-5"})
+		return
+	}
+
+	profile, err := h.profileStore.Get(r.Context(), userID, language)
+	if err != nil {
+		log.Printf("grammar/lessons profile Get error: %v", err)
+	}
+	weak := map[string]bool{}
+	for _, id := range profileWeakGrammar(profile) {
+		weak[id] = true
+	}
+
+	lessons := []grammarLessonInfo{}
+	for _, c := range grammar.Lessons(language, curriculum.LevelBand(level)) {
+		info := grammarLessonInfo{Concept: c, Weak: weak[c.ID]}
+		if profile != nil {
+			st := profile.GrammarStats[c.ID]
+			info.Errors, info.Successes, info.Mastery = st.Errors, st.Successes, st.Mastery()
+		}
+		lessons = append(lessons, info)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"language": language, "level": level, "lessons": lessons})
+}
+
+// ── Lesson ────────────────────────────────────────────────────────────────────
+
+// Lesson starts a session on a lesson and returns the lesson without its
+// answer key, generating and caching it on first request.
+func (h *GrammarHandler) Lesson(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req grammarLessonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	if !validLessonRequest(w, req.Language, req.Level, req.LessonID) {
+		return
+	}
+
+	_ = h.presenceStore.Set(r.Context(), userID, store.LessonPresence{
+		Type:      "grammar",
+		Language:  req.Language,
+		Topic:     grammarLessonsTopic,
+		StartedAt: time.Now(),
+	})
+
+	key := h.pool.Key(req.Language, req.Level, req.LessonID)
+	if lesson := h.cachedLesson(key); lesson != nil {
+		h.writeSession(w, r.Context(), userID, lesson)
+		return
+	}
+
+	lesson, score, fits, err := generateGraded("grammar/lesson", req.Level, func() (*GrammarLesson, error) {
+		return h.generateLesson(r.Context(), req.Language, req.Level, req.LessonID)
+	}, func(lesson *GrammarLesson) (difficulty.Score, bool) {
+		return scoreLesson(req.Language, lesson)
+	})
+	if err != nil {
+		log.Printf("grammar/lesson AI error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "AI service error"})
+		return
+	}
+	if raw, err := json.Marshal(lesson); err == nil && fits {
+		h.pool.AppendScored(key, raw, score)
+	}
+	h.writeSession(w, r.Context(), userID, lesson)
+}
+
+// writeSession stores the lesson's answer key as a new session and sends the
+// lesson without answers. Check and Complete grade against the session, so
+// they see the lesson this learner was served even when it was not pooled
+// or the pool holds another lesson for the concept.
+func (h *GrammarHandler) writeSession(w http.ResponseWriter, ctx context.Context, userID string, lesson *GrammarLesson) {
+	session := store.GrammarSession{
+		Language: lesson.Language,
+		Level:    lesson.Level,
+		LessonID: lesson.ID,
+		Title:    lesson.Title,
+	}
+	for _, ex := range lesson.Exercises {
+		session.Exercises = append(session.Exercises, store.GrammarExercise{
+			ID:          ex.ID,
+			Kind:        ex.Kind,
+			Prompt:      ex.Prompt,
+			Answer:      ex.Answer,
+			Explanation: ex.Explanation,
+		})
+	}
+	id := uuid.New().String()
+	if err := h.sessionStore.Save(ctx, userID, id, session); err != nil {
+		log.Printf("grammar/lesson session save error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not start lesson"})
+		return
+	}
+	writeJSON(w, http.StatusOK, grammarLessonResponse{SessionID: id, Lesson: publicLesson(lesson)})
+}
+
+// loadSession fetches the caller's lesson session, writing the error
+// response and returning nil if it is missing.
+func (h *GrammarHandler) loadSession(w http.ResponseWriter, ctx context.Context, userID, id string) *store.GrammarSession {
+	session, err := h.sessionStore.Get(ctx, userID, id)
+	if err != nil {
+		log.Printf("grammar session Get error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load lesson"})
+		return nil
+	}
+	if session == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "lesson not found or expired"})
+		return nil
+	}
+	return session
+}
+
+// validLessonRequest checks the language, level and lesson ID, writing a 400
+// response if any is invalid.
+func validLessonRequest(w http.ResponseWriter, language string, level int, lessonID string) bool {
+	if !IsValidLanguage(language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid language"})
+		return false
+	}
+	if level < 1 || level > 5 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "level must be 1-5"})
+		return false
+	}
+	if c, ok := grammar.Lookup(lessonID); !ok || c.Language != language || !grammar.IsLesson(lessonID) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown lesson"})
+		return false
+	}
+	return true
+}
+
+// cachedLesson returns the pooled lesson for key, or nil.
+func (h *GrammarHandler) cachedLesson(key string) *GrammarLesson {
+	raw, ok := h.pool.Get(key, 0)
+	if !ok {
+		return nil
+	}
+	var lesson GrammarLesson
+	if err := json.Unmarshal(raw, &lesson); err != nil {
+		return nil
+	}
+	return &lesson
+}
+
+// publicLesson returns a copy of l without answers and explanations.
+func publicLesson(l *GrammarLesson) GrammarLesson {
+	out := *l
+	out.Exercises = make([]LessonExercise, len(l.Exercises))
+	for i, ex := range l.Exercises {
+		ex.Answer, ex.Explanation = "", ""
+		out.Exercises[i] = ex
+	}
+	return out
+}
+
+func (h *GrammarHandler) generateLesson(ctx context.Context, language string, level int, lessonID string) (*GrammarLesson, error) {
+	concept, _ := grammar.Lookup(lessonID)
+	langName := LanguageName(language)
+	native := nativeLang(language)
+
+	prompt := fmt.Sprintf(`You are a %s teacher writing a short grammar lesson for a student whose native language is %s.
+Grammar point: %s — %s
+Student level: %s
+
+Return ONLY valid JSON — no markdown, no code fences, no explanation:
+{"explanation":"...","examples":[{"target":"...","translation":"...","note":"..."}],"exercises":[{"kind":"choice","prompt":"...","options":["...","...","..."],"answer":"...","explanation":"..."},{"kind":"fill","prompt":"... ___ ...","hint":"...","answer":"...","explanation":"..."}]}
+
+Rules:
+- "explanation": a clear explanation of the grammar point written in %s, 120-250 words, with the key forms or rules; match the depth to the level
+- "examples": exactly 5 worked examples in %s with their %s translation and a one-line note (in %s) on how the rule applies
+- "exercises": exactly 8 exercises practising only this grammar point, mixing both kinds
+- "choice": a %s sentence with ___ for the missing part and 3 options; "answer" must be copied exactly from "options"
+- "fill": a %s sentence with ___ for the missing word(s); "hint" gives the base form or a %s cue; "answer" is the exact missing text
+- "explanation" of an exercise: one sentence in %s saying why the answer is right
+- Use vocabulary suitable for the level`,
+		langName, native, concept.Name, concept.Description, levelSpec[level],
+		native, langName, native, native, langName, langName, native, native)
+
+	result, err := h.callAI(ctx, prompt, 2200, 0.5)
+	if err != nil {
+		return nil, err
+	}
+	result = strings.TrimSpace(result)
+	if idx := strings.Index(result, "{"); idx > 0 {
+		result = result[idx:]
+	}
+	if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
+		result = result[:idx+1]
+	}
+	var parsed GrammarLesson
+	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+		return nil, fmt.Errorf("parse lesson: %w (raw: %s)", err, result)
+	}
+	parsed.Exercises = normalizeLessonExercises(parsed.Exercises)
+	if parsed.Explanation == "" || len(parsed.Exercises) == 0 {
+		return nil, fmt.Errorf("incomplete lesson (raw: %s)", result)
+	}
+	parsed.ID = concept.ID
+	parsed.Language = language
+	parsed.Level = level
+	parsed.Title = concept.Name
+	parsed.Band = concept.Band
+	return &parsed, nil
+}
+
+// normalizeLessonExercises drops malformed exercises and numbers the rest,
+// so grading can rely on every exercise having an answer.
+func normalizeLessonExercises(in []LessonExercise) []LessonExercise {
+	out := make([]LessonExercise, 0, len(in))
+	for _, ex := range in {
+		ex.Answer = strings.TrimSpace(ex.Answer)
+		if ex.Answer == "" || strings.TrimSpace(ex.Prompt) == "" {
+			continue
+		}
+		switch ex.Kind {
+		case LessonChoice:
+			if len(ex.Options) < 2 || !containsFold(ex.Options, ex.Answer) {
+				continue
+			}
+			ex.Hint = ""
+		case LessonFill:
+			ex.Options = nil
+		default:
+			continue
+		}
+		ex.ID = strconv.Itoa(len(out) + 1)
+		out = append(out, ex)
+	}
+	return out
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(strings.TrimSpace(v), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// gradeLessonExercise reports whether answer is correct. Choices must match
+// the option; typed answers may differ in accents or by a typo.
+func gradeLessonExercise(language string, ex store.GrammarExercise, answer string) bool {
+	answer = strings.TrimSpace(answer)
+	if ex.Kind == LessonChoice {
+		return strings.EqualFold(answer, ex.Answer)
+	}
+	return textnorm.Match(ex.Answer, answer, language, textnorm.Standard).Correct
+}
+
+// ── Check ─────────────────────────────────────────────────────────────────────
+
+// Check grades a single exercise for immediate feedback and records the
+// learner's first answer to it, which is what Complete scores. The answer is
+// only revealed once that first answer is recorded, so it cannot be looked up
+// before answering.
+func (h *GrammarHandler) Check(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req grammarCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	session := h.loadSession(w, r.Context(), userID, req.SessionID)
+	if session == nil {
+		return
+	}
+	for i, ex := range session.Exercises {
+		if ex.ID != req.ExerciseID {
+			continue
+		}
+		submitted := store.SessionAnswer{
+			Answer:  strings.TrimSpace(req.Answer),
+			Correct: gradeLessonExercise(session.Language, ex, req.Answer),
+		}
+		first, err := h.sessionStore.RecordAnswer(r.Context(), userID, req.SessionID, i, submitted)
+		if err != nil {
+			log.Printf("grammar/check RecordAnswer error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not record answer"})
+			return
+		}
+		writeJSON(w, http.StatusOK, grammarCheckResponse{
+			Correct:         first.Correct,
+			Answer:          ex.Answer,
+			Explanation:     ex.Explanation,
+			AlreadyAnswered: first != submitted,
+		})
+		return
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "exercise not found"})
+}
+
+// ── Complete ──────────────────────────────────────────────────────────────────
+
+// Complete scores the session once, from the answers recorded by Check.
+func (h *GrammarHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req grammarCompleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	session := h.loadSession(w, r.Context(), userID, req.SessionID)
+	if session == nil {
+		return
+	}
+	first, err := h.sessionStore.MarkCompleted(r.Context(), userID, req.SessionID)
+	if err != nil {
+		log.Printf("grammar/complete error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not complete lesson"})
+		return
+	}
+	if !first {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "lesson already completed"})
+		return
+	}
+
+	// Scored from the first answers recorded by Check; unanswered exercises
+	// count as wrong
+	results := make([]lessonResult, 0, len(session.Exercises))
+	var correctCount int
+	var corrections []string
+	for i, ex := range session.Exercises {
+		correct := session.Answers[i].Correct
+		if correct {
+			correctCount++
+		} else {
+			corrections = append(corrections, fmt.Sprintf("%s → %s", ex.Prompt, ex.Answer))
+		}
+		results = append(results, lessonResult{ExerciseID: ex.ID, Correct: correct, Answer: ex.Answer})
+	}
+	total := len(results)
+
+	fp := correctCount * 5
+	if fp < 10 {
+		fp = 10
+	}
+	if correctCount == total && total > 0 {
+		fp += 10
+	}
+
+	if _, _, err := h.userStore.UpdateActivity(userID, session.Language, fp); err != nil {
+		log.Printf("grammar/complete UpdateActivity error: %v", err)
+	}
+
+	ctx := context.Background()
+	profile, err := h.profileStore.Get(ctx, userID, session.Language)
+	if err != nil || profile == nil {
+		profile = &store.StudentProfile{
+			UserID:   userID,
+			Language: session.Language,
+		}
+	}
+	for _, res := range results {
+		profile.RecordGrammar(session.LessonID, res.Correct)
+	}
+	profile.RecentTopics = prependUnique([]string{"Grammar: " + session.Title}, profile.RecentTopics, 10)
+	profile.SessionCount++
+	if err := h.profileStore.Upsert(ctx, profile); err != nil {
+		log.Printf("grammar/complete Upsert error: %v", err)
+	}
+	mastery := profile.GrammarStats[session.LessonID].Mastery()
+
+	summary := fmt.Sprintf("Completed the grammar lesson %q: %d/%d exercises correct, mastery %d%%.", session.Title, correctCount, total, mastery)
+	var suggestions []string
+	if correctCount < total {
+		suggestions = []string{
+			fmt.Sprintf("Review the explanation of %s and retry the exercises", session.Title),
+			"Use sentence mistakes mode to practise this grammar in context",
+			"Try to use this structure in your next conversation",
+		}
+	} else {
+		suggestions = []string{
+			"Move on to the next grammar lesson for your level",
+			fmt.Sprintf("Use %s in a writing or conversation session", session.Title),
+			"Try a sentence session to practise it in full translations",
+		}
+	}
+
+	topicName, _ := TopicDetails(grammarLessonsTopic)
+	recordID := uuid.New().String()
+	record := &store.ConversationRecord{
+		ID:           recordID,
+		UserID:       userID,
+		Language:     session.Language,
+		Topic:        grammarLessonsTopic,
+		TopicName:    topicName,
+		Level:        session.Level,
+		Personality:  "grammar-teacher",
+		MessageCount: total,
+		FPEarned:     fp,
+		Summary:      summary,
+		Topics:       []string{session.Title},
+		Corrections:  corrections,
+		Suggestions:  suggestions,
+		CreatedAt:    time.Now(),
+		EndedAt:      time.Now(),
+	}
+	h.historyStore.Save(record)
+
+	_ = h.presenceStore.Clear(r.Context(), userID)
+	_ = h.cacheStore.InvalidateUserStats(r.Context(), userID)
+
+	writeJSON(w, http.StatusOK, grammarCompleteResponse{
+		FPEarned:     fp,
+		CorrectCount: correctCount,
+		Total:        total,
+		Mastery:      mastery,
+		Results:      results,
+		RecordID:     recordID,
+	})
+}
+
+// ── AI helper ─────────────────────────────────────────────────────────────────
+
+func (h *GrammarHandler) callAI(ctx context.Context, prompt string, maxTokens int, temperature float64) (string, error) {
+	payload := ionosVocabPayload{
+		Model: h.cfg.IONOSFastModel,
+		Messages: []store.Message{
+			{Role: "user", Content: prompt},
+		},
+		Stream:      false,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", h.cfg.IONOSBaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+h.cfg.IONOSAPIKey)
+
+	client := &http.Client{Timeout: 90 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("AI returned %d: %s", resp.StatusCode, string(raw))
+	}
+
+	var parsed ionosVocabResponse
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return "", err
+	}
+	if len(parsed.Choices) == 0 {
+		return "", fmt.Errorf("no choices in AI response")
+	}
+	content := parsed.Choices[0].Message.Content
+	if content == "" {
+		content = parsed.Choices[0].Message.ReasoningContent
+	}
+	return content, nil
+}
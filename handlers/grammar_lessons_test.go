@@ -0,0 +1,126 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGrammarHandler(t *testing.T) (*handlers.GrammarHandler, *store.ItemPool, *store.GrammarSessionStore) {
+	t.Helper()
+	ai := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := json.Marshal(`{"explanation":"Ser describe, estar sitúa.","examples":[],"exercises":[` +
+			`{"kind":"choice","prompt":"Madrid ___ en España.","options":["es","está"],"answer":"está","explanation":"Location uses estar."},` +
+			`{"kind":"fill","prompt":"Yo ___ cansado.","hint":"estar","answer":"estoy"}]}`)
+		fmt.Fprintf(w, `{"choices":[{"message":{"content":%s}}]}`, content)
+	}))
+	t.Cleanup(ai.Close)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sessions := store.NewGrammarSessionStore(rdb)
+	require.NoError(t, sessions.Save(context.Background(), "user-1", "s-1", store.GrammarSession{
+		Language: "es", Level: 1, LessonID: "es.ser-estar", Title: "Ser vs estar",
+		Exercises: []store.GrammarExercise{
+			{ID: "1", Kind: handlers.LessonChoice, Prompt: "Madrid ___ en España.", Answer: "está", Explanation: "Location uses estar."},
+			{ID: "2", Kind: handlers.LessonFill, Prompt: "Yo ___ cansado.", Answer: "estoy"},
+		},
+	}))
+	pool := store.NewItemPool("")
+	cfg := &config.Config{IONOSBaseURL: ai.URL}
+	h := handlers.NewGrammarHandler(cfg, nil, nil, nil, pool, store.NewPresenceStore(rdb), nil, sessions)
+	return h, pool, sessions
+}
+
+func postGrammar(t *testing.T, handler http.HandlerFunc, body map[string]any) (int, map[string]any) {
+	t.Helper()
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/grammar", bytes.NewReader(raw))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestGrammarCheck_GradesAgainstSession(t *testing.T) {
+	h, _, _ := newTestGrammarHandler(t)
+
+	code, resp := postGrammar(t, h.Check, map[string]any{"session_id": "s-1", "exercise_id": "1", "answer": "es"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, resp["correct"])
+	assert.Equal(t, "está", resp["answer"])
+	assert.Equal(t, "Location uses estar.", resp["explanation"])
+
+	// Only the first answer counts, even once the answer is known
+	_, resp = postGrammar(t, h.Check, map[string]any{"session_id": "s-1", "exercise_id": "1", "answer": "está"})
+	assert.Equal(t, false, resp["correct"])
+	assert.Equal(t, true, resp["already_answered"])
+
+	// Typed answers tolerate a missing accent or a typo.
+	_, resp = postGrammar(t, h.Check, map[string]any{"session_id": "s-1", "exercise_id": "2", "answer": "estoi"})
+	assert.Equal(t, true, resp["correct"])
+	assert.Nil(t, resp["already_answered"])
+
+	code, _ = postGrammar(t, h.Check, map[string]any{"session_id": "s-1", "exercise_id": "9", "answer": "x"})
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = postGrammar(t, h.Check, map[string]any{"session_id": "missing", "exercise_id": "1", "answer": "está"})
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestGrammarLesson_RejectsUnknownLessons(t *testing.T) {
+	h, _, _ := newTestGrammarHandler(t)
+	for _, id := range []string{"es.other", "it.essere-avere", "made-up"} {
+		code, _ := postGrammar(t, h.Lesson, map[string]any{"language": "es", "level": 1, "lesson_id": id})
+		assert.Equal(t, http.StatusBadRequest, code, id)
+	}
+}
+
+func TestGrammarLesson_GradesTheLessonServed(t *testing.T) {
+	h, pool, sessions := newTestGrammarHandler(t)
+
+	code, resp := postGrammar(t, h.Lesson, map[string]any{"language": "es", "level": 1, "lesson_id": "es.ser-estar"})
+	require.Equal(t, http.StatusOK, code)
+	id, _ := resp["session_id"].(string)
+	require.NotEmpty(t, id)
+	lesson := resp["lesson"].(map[string]any)
+	for _, ex := range lesson["exercises"].([]any) {
+		assert.NotContains(t, ex, "answer", "answers never leave the server")
+	}
+
+	// The generated lesson grades out of level and is not pooled. Pooling
+	// another lesson with the same exercise IDs must not change the grading.
+	other, _ := json.Marshal(handlers.GrammarLesson{
+		ID: "es.ser-estar", Language: "es", Level: 1, Title: "Ser vs estar",
+		Exercises: []handlers.LessonExercise{{ID: "1", Kind: handlers.LessonChoice, Prompt: "Yo ___ alto.", Options: []string{"soy", "estoy"}, Answer: "soy"}},
+	})
+	key := pool.Key("es", 1, "es.ser-estar")
+	require.Zero(t, pool.Len(key))
+	pool.Append(key, other)
+
+	code, resp = postGrammar(t, h.Check, map[string]any{"session_id": id, "exercise_id": "1", "answer": "está"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, resp["correct"])
+
+	session, err := sessions.Get(context.Background(), "user-1", id)
+	require.NoError(t, err)
+	require.NotNil(t, session)
+	assert.Equal(t, "es.ser-estar", session.LessonID)
+	require.Len(t, session.Exercises, 2)
+	assert.Equal(t, "estoy", session.Exercises[1].Answer)
+}
@@ -8,12 +8,17 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/difficulty"
+	"github.com/ailanguagetutor/lemma"
 	"github.com/ailanguagetutor/middleware"
 	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/tts"
 	"github.com/google/uuid"
 )
 
@@ -29,6 +34,9 @@ type ListeningHandler struct {
 	sentencePool  *store.ItemPool
 	presenceStore *store.PresenceStore
 	cacheStore    *store.CacheStore
+	renderer      *tts.Renderer
+	sessionStore  *store.ListeningSessionStore
+	misspellings  misspellingRecorder
 }
 
 func NewListeningHandler(
@@ -41,6 +49,10 @@ func NewListeningHandler(
 	sentencePool *store.ItemPool,
 	presence *store.PresenceStore,
 	cache *store.CacheStore,
+	renderer *tts.Renderer,
+	sessions *store.ListeningSessionStore,
+	ms *store.MisspellingStore,
+	cards *store.VocabCardStore,
 ) *ListeningHandler {
 	return &ListeningHandler{
 		cfg:           cfg,
@@ -52,6 +64,9 @@ func NewListeningHandler(
 		sentencePool:  sentencePool,
 		presenceStore: presence,
 		cacheStore:    cache,
+		renderer:      renderer,
+		sessionStore:  sessions,
+		misspellings:  misspellingRecorder{misspellings: ms, cards: cards},
 	}
 }
 
@@ -61,13 +76,17 @@ type StoryQuestion struct {
 	Type        string   `json:"type"`              // "multiple_choice"|"true_false"|"yes_no"
 	Question    string   `json:"question"`
 	Options     []string `json:"options,omitempty"`
-	Answer      any      `json:"answer"`            // int or string
-	Explanation string   `json:"explanation"`
+	Answer      any      `json:"answer,omitempty"`  // int or string; never sent to the client
+	Explanation string   `json:"explanation,omitempty"`
 }
 
 type StorySegment struct {
 	Text     string        `json:"text"`
 	Question StoryQuestion `json:"question"`
+	// TTS cache keys of the narration in the requesting learner's voice,
+	// assigned per session and never stored with the pool entry
+	AudioKey         string `json:"audio_key,omitempty"`
+	QuestionAudioKey string `json:"question_audio_key,omitempty"`
 }
 
 type Story struct {
@@ -83,8 +102,19 @@ type listeningSessionRequest struct {
 }
 
 type listeningSessionResponse struct {
-	Story Story   `json:"story"`
-	Speed float64 `json:"speed"`
+	SessionID string     `json:"session_id"`
+	Story Story          `json:"story"` // without answers or explanations
+	Speed float64        `json:"speed"`
+	Audio []segmentAudio `json:"audio"` // one entry per story segment
+}
+
+// segmentAudio holds playable URLs for one segment's narration and question,
+// plus the narration's word timings for highlighting. Empty when no TTS voice
+// is available for the language.
+type segmentAudio struct {
+	Text          string `json:"text,omitempty"`
+	TextAlignment string `json:"text_alignment,omitempty"`
+	Question      string `json:"question,omitempty"`
 }
 
 type listeningResult struct {
@@ -92,21 +122,29 @@ type listeningResult struct {
 	Correct       bool `json:"correct"`
 }
 
+type listeningCheckRequest struct {
+	SessionID     string `json:"session_id"`
+	QuestionIndex int    `json:"question_index"`
+	Answer        any    `json:"answer"` // option index, or "yes"/"no"/"true"/"false"
+}
+
+type listeningCheckResponse struct {
+	Correct         bool   `json:"correct"`
+	Answer          any    `json:"answer,omitempty"` // the right answer, in the form the client sends
+	Explanation     string `json:"explanation"`
+	AlreadyAnswered bool   `json:"already_answered,omitempty"` // only the first answer counts
+}
+
 type listeningCompleteRequest struct {
-	Language       string            `json:"language"`
-	Level          int               `json:"level"`
-	Topic          string            `json:"topic"`
-	TopicName      string            `json:"topic_name"`
-	Personality    string            `json:"personality"`
-	Results        []listeningResult `json:"results"`
-	WrongQuestions []string          `json:"wrong_questions"`
+	SessionID string `json:"session_id"`
 }
 
 type listeningCompleteResponse struct {
-	FPEarned     int    `json:"fp_earned"`
-	CorrectCount int    `json:"correct_count"`
-	TotalCount   int    `json:"total_count"`
-	RecordID     string `json:"record_id"`
+	FPEarned     int               `json:"fp_earned"`
+	CorrectCount int               `json:"correct_count"`
+	TotalCount   int               `json:"total_count"`
+	Results      []listeningResult `json:"results"`
+	RecordID     string            `json:"record_id"`
 }
 
 // ── Speed + segment count tables ──────────────────────────────────────────────
@@ -185,14 +223,19 @@ func (h *ListeningHandler) Session(w http.ResponseWriter, r *http.Request) {
 		raw, _ := h.pool.Get(key, userIdx)
 		var story Story
 		if err := json.Unmarshal(raw, &story); err == nil {
-			writeJSON(w, http.StatusOK, listeningSessionResponse{Story: story, Speed: speedForLevel(req.Level)})
+			h.prepareAudio(userID, &story, req.Language, req.Level, req.Personality, false)
+			h.writeSession(w, r.Context(), userID, req, key, story)
 			return
 		}
 	}
 
-	// Cache miss: gather vocab words to weave into prompt
+	// Cache miss: gather vocab words to weave into prompt, the student's
+	// weak words first, one entry per lemma
 	vocabKey := h.vocabPool.Key(req.Language, req.Level, req.Topic)
 	var reinforceWords []string
+	if profile != nil {
+		reinforceWords = append(reinforceWords, profile.WeakVocab...)
+	}
 	for _, raw := range h.vocabPool.AllRaw(vocabKey) {
 		var list []VocabWord
 		if err := json.Unmarshal(raw, &list); err == nil {
@@ -201,6 +244,7 @@ func (h *ListeningHandler) Session(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
+	reinforceWords = lemma.Unique(req.Language, reinforceWords)
 	if len(reinforceWords) > 20 {
 		reinforceWords = reinforceWords[:20]
 	}
@@ -209,16 +253,269 @@ func (h *ListeningHandler) Session(w http.ResponseWriter, r *http.Request) {
 	if profile != nil {
 		weakAreas = profile.WeakAreas
 	}
-	story, err := h.generateStory(r.Context(), req.Language, req.Level, req.Topic, req.Personality, reinforceWords, weakAreas)
+	story, score, fits, err := h.generateGradedStory(r.Context(), "listening/session", req.Language, req.Level, req.Topic, req.Personality, reinforceWords, weakAreas)
 	if err != nil {
 		log.Printf("listening/session AI error: %v", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "AI service error"})
 		return
 	}
 
-	raw, _ := json.Marshal(*story)
-	h.pool.Append(key, raw)
-	writeJSON(w, http.StatusOK, listeningSessionResponse{Story: *story, Speed: speedForLevel(req.Level)})
+	if fits {
+		raw, _ := json.Marshal(*story)
+		h.pool.AppendScored(key, raw, score)
+	}
+	// Only a story entering the pool is rendered ahead; the clips of one
+	// served once are rendered if and when they are played.
+	h.prepareAudio(userID, story, req.Language, req.Level, req.Personality, fits)
+	h.writeSession(w, r.Context(), userID, req, key, *story)
+}
+
+// writeSession stores the story's answer key as a new session and sends the
+// story without answers.
+func (h *ListeningHandler) writeSession(w http.ResponseWriter, ctx context.Context, userID string, req listeningSessionRequest, poolKey string, story Story) {
+	session := store.ListeningSession{
+		Language:    req.Language,
+		Level:       req.Level,
+		Topic:       req.Topic,
+		Personality: req.Personality,
+		PoolKey:     poolKey,
+	}
+	public := Story{Title: story.Title, Segments: make([]StorySegment, len(story.Segments))}
+	for i, seg := range story.Segments {
+		q := seg.Question
+		answer, ok := storyAnswer(q, q.Answer)
+		if !ok {
+			log.Printf("listening/session: unusable answer %v for %q", q.Answer, q.Question)
+		}
+		session.Questions = append(session.Questions, store.ListeningQuestion{
+			Type:        q.Type,
+			Question:    q.Question,
+			Options:     q.Options,
+			Answer:      answer,
+			Explanation: q.Explanation,
+		})
+		seg.Question.Answer, seg.Question.Explanation = nil, ""
+		public.Segments[i] = seg
+	}
+
+	id := uuid.New().String()
+	if err := h.sessionStore.Save(ctx, userID, id, session); err != nil {
+		log.Printf("listening/session save error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not start session"})
+		return
+	}
+	writeJSON(w, http.StatusOK, listeningSessionResponse{SessionID: id, Story: public, Speed: speedForLevel(req.Level), Audio: storyAudio(story)})
+}
+
+// ── Answer checking ───────────────────────────────────────────────────────────
+
+// Words accepted for yes/no and true/false answers, in the languages stories
+// are written in: the model sometimes answers "sì" or "verdadeiro".
+var (
+	yesWords = []string{"yes", "true", "y", "sì", "si", "sí", "sim", "vero", "verdadero", "verdadeiro", "verdade", "correct"}
+	noWords  = []string{"no", "false", "n", "não", "nao", "falso", "incorrect"}
+)
+
+// storyAnswer returns answer in the canonical form for question q: the
+// option index for multiple choice, "yes"/"no" or "true"/"false" otherwise.
+// Answers come as JSON numbers, numeric strings, option letters or option
+// text, and booleans or words for the binary types.
+func storyAnswer(q StoryQuestion, answer any) (string, bool) {
+	if q.Type == "multiple_choice" {
+		idx := -1
+		switch v := answer.(type) {
+		case float64:
+			if v == float64(int(v)) {
+				idx = int(v)
+			}
+		case int:
+			idx = v
+		case string:
+			v = strings.TrimSpace(v)
+			if n, err := strconv.Atoi(v); err == nil {
+				idx = n
+			} else if len(v) == 1 && strings.ContainsAny(strings.ToUpper(v), "ABCDEF") {
+				idx = int(strings.ToUpper(v)[0] - 'A')
+			} else {
+				for i, opt := range q.Options {
+					if strings.EqualFold(strings.TrimSpace(opt), v) {
+						idx = i
+					}
+				}
+			}
+		}
+		if idx < 0 || idx >= len(q.Options) {
+			return "", false
+		}
+		return strconv.Itoa(idx), true
+	}
+
+	var yes bool
+	switch v := answer.(type) {
+	case bool:
+		yes = v
+	case string:
+		v = strings.ToLower(strings.Trim(strings.TrimSpace(v), ".!"))
+		switch {
+		case slices.Contains(yesWords, v):
+			yes = true
+		case slices.Contains(noWords, v):
+			yes = false
+		default:
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	switch {
+	case q.Type == "yes_no" && yes:
+		return "yes", true
+	case q.Type == "yes_no":
+		return "no", true
+	case yes:
+		return "true", true
+	}
+	return "false", true
+}
+
+// clientAnswer returns a canonical answer in the form the client sends it:
+// an index for multiple choice, a string otherwise.
+func clientAnswer(q store.ListeningQuestion) any {
+	if q.Type == "multiple_choice" {
+		if n, err := strconv.Atoi(q.Answer); err == nil {
+			return n
+		}
+	}
+	if q.Answer == "" {
+		return nil
+	}
+	return q.Answer
+}
+
+func (h *ListeningHandler) Check(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req listeningCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	session, err := h.sessionStore.Get(r.Context(), userID, req.SessionID)
+	if err != nil {
+		log.Printf("listening/check error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not load session"})
+		return
+	}
+	if session == nil || session.Mode != store.ListeningComprehension {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "session not found or expired"})
+		return
+	}
+	if req.QuestionIndex < 0 || req.QuestionIndex >= len(session.Questions) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid question index"})
+		return
+	}
+	q := session.Questions[req.QuestionIndex]
+
+	if prev, ok := session.Answers[req.QuestionIndex]; ok {
+		writeJSON(w, http.StatusOK, listeningCheckResponse{Correct: prev.Correct, Answer: clientAnswer(q), Explanation: q.Explanation, AlreadyAnswered: true})
+		return
+	}
+	answer, ok := storyAnswer(StoryQuestion{Type: q.Type, Options: q.Options}, req.Answer)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid answer"})
+		return
+	}
+	// A question whose key the model got wrong cannot be held against the
+	// learner: any answer counts.
+	correct := q.Answer == "" || answer == q.Answer
+
+	submitted := store.SessionAnswer{Answer: answer, Correct: correct}
+	first, err := h.sessionStore.RecordAnswer(r.Context(), userID, req.SessionID, req.QuestionIndex, submitted)
+	if err != nil {
+		log.Printf("listening/check record error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not record answer"})
+		return
+	}
+	writeJSON(w, http.StatusOK, listeningCheckResponse{
+		Correct:         first.Correct,
+		Answer:          clientAnswer(q),
+		Explanation:     q.Explanation,
+		AlreadyAnswered: first != submitted, // a concurrent check got there first
+	})
+}
+
+// ── Pre-rendered audio ────────────────────────────────────────────────────────
+
+// prepareAudio assigns TTS cache keys in personality's voice to every segment
+// and question, replacing any keys an older pool entry was stored with, at
+// the level's speed. With queue set the clips are also queued for background
+// rendering, metered against userID; that is done once, when a story is
+// pooled. Other clips are rendered, and metered, on first fetch. Preparing
+// rewrites each clip's recipe, so keys stay playable after the cache evicted
+// them.
+func (h *ListeningHandler) prepareAudio(userID string, story *Story, language string, level int, personality string, queue bool) {
+	var queued []string
+	prepare := func(text string, dst *string) {
+		*dst = ""
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		k, err := h.renderer.Prepare(tts.Request{
+			Text:        text,
+			Language:    language,
+			Personality: personality,
+			Speed:       speedForLevel(level),
+		})
+		if err != nil {
+			log.Printf("listening audio prepare error: %v", err)
+			return
+		}
+		*dst = k
+		queued = append(queued, k)
+	}
+	for i := range story.Segments {
+		seg := &story.Segments[i]
+		prepare(seg.Text, &seg.AudioKey)
+		prepare(spokenQuestion(seg.Question), &seg.QuestionAudioKey)
+	}
+	if queue {
+		h.renderer.Enqueue(userID, queued...)
+	}
+}
+
+// spokenQuestion is the text read aloud for a question: the question itself
+// followed by its options, one sentence each.
+func spokenQuestion(q StoryQuestion) string {
+	parts := append([]string{q.Question}, q.Options...)
+	return strings.Join(parts, "\n")
+}
+
+func storyAudio(story Story) []segmentAudio {
+	out := make([]segmentAudio, len(story.Segments))
+	for i, seg := range story.Segments {
+		out[i] = segmentAudio{
+			Text:          audioURL(seg.AudioKey),
+			TextAlignment: alignmentURL(seg.AudioKey),
+			Question:      audioURL(seg.QuestionAudioKey),
+		}
+	}
+	return out
+}
+
+// audioURL returns the playable URL for a TTS cache key, or "" for no key.
+func audioURL(key string) string {
+	if key == "" {
+		return ""
+	}
+	return "/api/tts/audio/" + key
+}
+
+// alignmentURL returns the word-timing URL for a TTS cache key, or "".
+func alignmentURL(key string) string {
+	if key == "" {
+		return ""
+	}
+	return "/api/tts/audio/" + key + "/alignment"
 }
 
 // ── Complete ───────────────────────────────────────────────────────────────────
@@ -231,14 +528,41 @@ func (h *ListeningHandler) Complete(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
 		return
 	}
+	session, err := h.sessionStore.Get(r.Context(), userID, req.SessionID)
+	if err != nil {
+		log.Printf("listening/complete error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not load session"})
+		return
+	}
+	if session == nil || session.Mode != store.ListeningComprehension {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "session not found or expired"})
+		return
+	}
+	first, err := h.sessionStore.MarkCompleted(r.Context(), userID, req.SessionID)
+	if err != nil {
+		log.Printf("listening/complete error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not complete session"})
+		return
+	}
+	if !first {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "session already completed"})
+		return
+	}
 
+	// Score from the recorded answers; unanswered questions count as wrong
 	correctCount := 0
-	for _, res := range req.Results {
-		if res.Correct {
+	results := make([]listeningResult, len(session.Questions))
+	var wrongQuestions []string
+	for i, q := range session.Questions {
+		a := session.Answers[i]
+		results[i] = listeningResult{QuestionIndex: i, Correct: a.Correct}
+		if a.Correct {
 			correctCount++
+		} else {
+			wrongQuestions = append(wrongQuestions, q.Question)
 		}
 	}
-	totalCount := len(req.Results)
+	totalCount := len(session.Questions)
 
 	fp := correctCount * 15
 	if fp < 20 {
@@ -248,37 +572,38 @@ func (h *ListeningHandler) Complete(w http.ResponseWriter, r *http.Request) {
 		fp += 20
 	}
 
-	if _, _, err := h.userStore.UpdateActivity(userID, req.Language, fp); err != nil {
+	if _, _, err := h.userStore.UpdateActivity(userID, session.Language, fp); err != nil {
 		log.Printf("listening/complete UpdateActivity error: %v", err)
 	}
 
 	ctx := context.Background()
-	profile, err := h.profileStore.Get(ctx, userID, req.Language)
+	profile, err := h.profileStore.Get(ctx, userID, session.Language)
 	if err != nil || profile == nil {
 		profile = &store.StudentProfile{
 			UserID:   userID,
-			Language: req.Language,
+			Language: session.Language,
 		}
 	}
 
-	profile.RecentTopics = prependUnique([]string{req.TopicName}, profile.RecentTopics, 10)
-	profile.WeakGrammar  = prependUnique(req.WrongQuestions, profile.WeakGrammar, 20)
+	// Missed comprehension questions name no grammar concept, so unlike
+	// sentence and writing errors they are not recorded in WeakGrammar;
+	// they only shape the suggestions below.
+	topicName, _ := TopicDetails(session.Topic)
+	profile.RecentTopics = prependUnique([]string{topicName}, profile.RecentTopics, 10)
 	profile.SessionCount++
 
-	key := fmt.Sprintf("%s:%d:%s:%s", req.Language, req.Level, req.Topic, req.Personality)
 	if profile.ListeningListIdx == nil {
 		profile.ListeningListIdx = make(map[string]int)
 	}
-	profile.ListeningListIdx[key]++
+	profile.ListeningListIdx[session.PoolKey]++
 
 	if err := h.profileStore.Upsert(ctx, profile); err != nil {
 		log.Printf("listening/complete Upsert error: %v", err)
 	}
 
-	topicName, _ := TopicDetails(req.Topic)
 	summary := fmt.Sprintf("Completed Listening Comprehension on %s: %d/%d questions answered correctly.", topicName, correctCount, totalCount)
 	var suggestions []string
-	if len(req.WrongQuestions) > 0 {
+	if len(wrongQuestions) > 0 {
 		suggestions = []string{
 			"Review the segments where you got questions wrong",
 			"Try the Writing Coach on this topic to reinforce understanding",
@@ -296,16 +621,16 @@ func (h *ListeningHandler) Complete(w http.ResponseWriter, r *http.Request) {
 	record := &store.ConversationRecord{
 		ID:           recordID,
 		UserID:       userID,
-		Language:     req.Language,
-		Topic:        req.Topic,
+		Language:     session.Language,
+		Topic:        session.Topic,
 		TopicName:    topicName,
-		Level:        req.Level,
+		Level:        session.Level,
 		Personality:  "listening",
 		MessageCount: totalCount,
 		FPEarned:     fp,
 		Summary:      summary,
 		Topics:       []string{topicName},
-		Corrections:  req.WrongQuestions,
+		Corrections:  wrongQuestions,
 		Suggestions:  suggestions,
 		CreatedAt:    time.Now(),
 		EndedAt:      time.Now(),
@@ -319,12 +644,23 @@ func (h *ListeningHandler) Complete(w http.ResponseWriter, r *http.Request) {
 		FPEarned:     fp,
 		CorrectCount: correctCount,
 		TotalCount:   totalCount,
+		Results:      results,
 		RecordID:     recordID,
 	})
 }
 
 // ── AI story generation ────────────────────────────────────────────────────────
 
+// generateGradedStory generates a story with generateGraded, so a story out
+// of level is generated once more and never pooled.
+func (h *ListeningHandler) generateGradedStory(ctx context.Context, mode, language string, level int, topic, personality string, reinforceWords, weakAreas []string) (*Story, *difficulty.Score, bool, error) {
+	return generateGraded(mode, level, func() (*Story, error) {
+		return h.generateStory(ctx, language, level, topic, personality, reinforceWords, weakAreas)
+	}, func(story *Story) (difficulty.Score, bool) {
+		return scoreStory(language, *story)
+	})
+}
+
 func (h *ListeningHandler) generateStory(ctx context.Context, language string, level int, topic string, personality string, reinforceWords []string, weakAreas []string) (*Story, error) {
 	langName := LanguageName(language)
 	topicName, _ := TopicDetails(topic)
@@ -345,7 +681,7 @@ func (h *ListeningHandler) generateStory(ctx context.Context, language string, l
 
 	var reinforceClause string
 	if len(reinforceWords) > 0 {
-		reinforceClause = fmt.Sprintf("\nVocabulary to weave in naturally (use as many as appropriate): %s", strings.Join(reinforceWords, ", "))
+		reinforceClause = fmt.Sprintf("\nVocabulary to weave in naturally, in any inflected form (use as many as appropriate): %s", strings.Join(reinforceWords, ", "))
 	}
 
 	var weakClause string
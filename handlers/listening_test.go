@@ -0,0 +1,91 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestListeningHandler(t *testing.T) *handlers.ListeningHandler {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	sessions := store.NewListeningSessionStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	require.NoError(t, sessions.Save(context.Background(), "user-1", "s-1", store.ListeningSession{
+		Language: "it", Level: 1, Topic: "food",
+		Questions: []store.ListeningQuestion{
+			{Type: "multiple_choice", Question: "Cosa mangia?", Options: []string{"pasta", "pizza", "pane", "riso"}, Answer: "1", Explanation: "Mangia la pizza."},
+			{Type: "yes_no", Question: "È contento?", Answer: "yes"},
+			{Type: "true_false", Question: "Piove.", Answer: "false"},
+		},
+	}))
+	return handlers.NewListeningHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, sessions, nil, nil)
+}
+
+func checkListening(t *testing.T, h *handlers.ListeningHandler, body map[string]any) (int, map[string]any) {
+	t.Helper()
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/listening/check", bytes.NewReader(raw))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+	h.Check(w, req)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestListeningCheck_GradesAgainstStoredKey(t *testing.T) {
+	h := newTestListeningHandler(t)
+
+	code, resp := checkListening(t, h, map[string]any{"session_id": "s-1", "question_index": 0, "answer": 1})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, resp["correct"])
+	assert.Equal(t, float64(1), resp["answer"])
+	assert.Equal(t, "Mangia la pizza.", resp["explanation"])
+
+	// Binary answers may come as words or booleans
+	code, resp = checkListening(t, h, map[string]any{"session_id": "s-1", "question_index": 1, "answer": true})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, resp["correct"])
+	assert.Equal(t, "yes", resp["answer"])
+
+	code, resp = checkListening(t, h, map[string]any{"session_id": "s-1", "question_index": 2, "answer": "True"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, resp["correct"])
+	assert.Equal(t, "false", resp["answer"])
+}
+
+func TestListeningCheck_FirstAnswerCounts(t *testing.T) {
+	h := newTestListeningHandler(t)
+
+	_, resp := checkListening(t, h, map[string]any{"session_id": "s-1", "question_index": 0, "answer": 3})
+	assert.Equal(t, false, resp["correct"])
+
+	_, resp = checkListening(t, h, map[string]any{"session_id": "s-1", "question_index": 0, "answer": 1})
+	assert.Equal(t, false, resp["correct"])
+	assert.Equal(t, true, resp["already_answered"])
+}
+
+func TestListeningCheck_RejectsBadInput(t *testing.T) {
+	h := newTestListeningHandler(t)
+
+	code, _ := checkListening(t, h, map[string]any{"session_id": "nope", "question_index": 0, "answer": 1})
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = checkListening(t, h, map[string]any{"session_id": "s-1", "question_index": 7, "answer": 1})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = checkListening(t, h, map[string]any{"session_id": "s-1", "question_index": 0, "answer": 9})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = checkListening(t, h, map[string]any{"session_id": "s-1", "question_index": 1, "answer": "maybe"})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -99,6 +99,8 @@ var Topics = []Topic{
 	{ID: "grammar-sentences", Name: "Sentence Construction", Icon: "✏️", Description: "Build grammatically correct sentences through word-order and fill-in-the-blank exercises", Category: "Grammar & Skills"},
 	{ID: "grammar-pronunciation", Name: "Pronunciation Practice", Icon: "🗣️", Description: "Perfect your pronunciation with phonetic breakdowns, stress guides, and sound drills", Category: "Grammar & Skills"},
 	{ID: "grammar-listening", Name: "Listening Comprehension", Icon: "👂", Description: "Improve listening skills through short passages and comprehension questions", Category: "Grammar & Skills"},
+	{ID: "grammar-lessons", Name: "Grammar Lessons", Icon: "📐", Description: "Learn one grammar point at a time with clear explanations, worked examples, and graded exercises", Category: "Grammar & Skills"},
+	{ID: "grammar-conjugation", Name: "Verb Conjugation", Icon: "🔁", Description: "Drill verb forms by tense and person, with your weakest tenses coming up most often", Category: "Grammar & Skills"},
 	{ID: "grammar-writing", Name: "Writing Coach", Icon: "📝", Description: "Submit writing for detailed grammar corrections, style upgrades, and encouragement", Category: "Grammar & Skills"},
 
 	// AI Travel Mode
@@ -0,0 +1,30 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/tts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanguages_ResolveToTTSRoute(t *testing.T) {
+	const rachel = "21m00Tcm4TlvDq8ikWAM"
+	cfg := &config.Config{
+		ElevenLabsVoiceIT: rachel, ElevenLabsVoiceES: rachel, ElevenLabsVoicePT: rachel,
+		ElevenLabsVoiceFR: rachel, ElevenLabsVoiceDE: rachel, ElevenLabsVoiceJA: rachel,
+		ElevenLabsVoiceRU: rachel, ElevenLabsVoiceRO: rachel, ElevenLabsVoiceZH: rachel,
+		ElevenLabsVoiceEN: rachel,
+	}
+	services := map[string]*tts.Service{
+		"elevenlabs": tts.NewServiceWith("", nil, tts.NewElevenLabs(cfg)),
+		"espeak-ng":  tts.NewServiceWith("", nil, tts.NewEspeak("espeak-ng")),
+	}
+	for name, s := range services {
+		for _, l := range handlers.Languages {
+			routes := s.Routes(tts.Request{Text: "hello", Language: l.Code})
+			assert.NotEmpty(t, routes, "%s has no voice for %s", name, l.Code)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ailanguagetutor/store"
+)
+
+// misspelledLimit is how many of the user's most misspelled words are listed
+// and reviewed in mistakes mode.
+const misspelledLimit = 20
+
+// misspellingRecorder stores the misspellings found in a session and puts
+// their correct forms in the user's review deck, due now.
+type misspellingRecorder struct {
+	misspellings *store.MisspellingStore
+	cards        *store.VocabCardStore
+}
+
+func (m misspellingRecorder) record(ctx context.Context, userID, language, sessionID, source string, items []store.Misspelling) {
+	if len(items) == 0 || m.misspellings == nil {
+		return
+	}
+	now := time.Now()
+	var cards []store.VocabCard
+	var words []string
+	for i := range items {
+		items[i].SessionID, items[i].Source, items[i].CreatedAt = sessionID, source, now
+		cards = append(cards, store.VocabCard{UserID: userID, Language: language, Word: items[i].Correct, Source: "misspelling"})
+		words = append(words, items[i].Correct)
+	}
+	if err := m.misspellings.Add(ctx, userID, language, items); err != nil {
+		log.Printf("%s misspellings Add error: %v", source, err)
+	}
+	if m.cards == nil {
+		return
+	}
+	if _, err := m.cards.Add(ctx, cards); err != nil {
+		log.Printf("%s misspellings card Add error: %v", source, err)
+	}
+	if err := m.cards.DueNow(ctx, userID, language, words, now); err != nil {
+		log.Printf("%s misspellings DueNow error: %v", source, err)
+	}
+}
+
+// parseMisspellings parses "wrong → correct (note)" strings, skipping any
+// that do not parse and repeats of a wrong form.
+func parseMisspellings(entries []string) []store.Misspelling {
+	var out []store.Misspelling
+	seen := map[string]bool{}
+	for _, e := range entries {
+		m, ok := store.ParseMisspelling(e)
+		if !ok || seen[m.Wrong] {
+			continue
+		}
+		seen[m.Wrong] = true
+		out = append(out, m)
+	}
+	return out
+}
+
+// misspelledWords returns the correct forms of the words the user misspells
+// most, or none if they cannot be loaded.
+func misspelledWords(ctx context.Context, s *store.MisspellingStore, userID, language string) []string {
+	if s == nil {
+		return nil
+	}
+	frequent, err := s.Frequent(ctx, userID, language, misspelledLimit)
+	if err != nil {
+		log.Printf("misspellings Frequent error: %v", err)
+		return nil
+	}
+	words := make([]string, len(frequent))
+	for i, w := range frequent {
+		words[i] = w.Word
+	}
+	return words
+}
@@ -0,0 +1,609 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/speech"
+	"github.com/ailanguagetutor/store"
+	"github.com/google/uuid"
+)
+
+// PronunciationHandler serves the "grammar-pronunciation" practice mode:
+// minimal pairs and tongue-twisters, scored from the learner's recording
+// (server-side recognition) or the browser's speech transcript.
+type PronunciationHandler struct {
+	cfg           *config.Config
+	userStore     *store.UserStore
+	profileStore  *store.StudentProfileStore
+	historyStore  *store.ConversationHistoryStore
+	pool          *store.ItemPool
+	presenceStore *store.PresenceStore
+	cacheStore    *store.CacheStore
+	recognizer    speech.Recognizer // nil when speech recognition is not configured
+}
+
+func NewPronunciationHandler(cfg *config.Config, us *store.UserStore, ps *store.StudentProfileStore, hs *store.ConversationHistoryStore, pool *store.ItemPool, presence *store.PresenceStore, cache *store.CacheStore, rec speech.Recognizer) *PronunciationHandler {
+	return &PronunciationHandler{cfg: cfg, userStore: us, profileStore: ps, historyStore: hs, pool: pool, presenceStore: presence, cacheStore: cache, recognizer: rec}
+}
+
+// ── Types ─────────────────────────────────────────────────────────────────────
+
+// Drill kinds.
+const (
+	DrillMinimalPair   = "minimal_pair"
+	DrillTongueTwister = "tongue_twister"
+)
+
+// pronunciationTopic is the topic ID the mode is listed under in meta.go.
+const pronunciationTopic = "grammar-pronunciation"
+
+// maxPronunciationResults caps the drill results one Complete may report.
+const maxPronunciationResults = 50
+
+type PronunciationDrill struct {
+	ID          string   `json:"id"`
+	Kind        string   `json:"kind"`            // DrillMinimalPair or DrillTongueTwister
+	Sound       string   `json:"sound"`           // target sound, e.g. "rr (trilled r)"
+	Words       []string `json:"words,omitempty"` // minimal pair: the two contrasting words
+	Text        string   `json:"text"`            // minimal pair: the words joined; tongue-twister: the sentence
+	Translation string   `json:"translation"`
+	Phonetic    string   `json:"phonetic"`
+	Tip         string   `json:"tip"`
+}
+
+type pronunciationSessionRequest struct {
+	Language     string `json:"language"`
+	Level        int    `json:"level"`
+	MistakesMode bool   `json:"mistakes_mode"`
+}
+
+type pronunciationSessionResponse struct {
+	Drills []PronunciationDrill `json:"drills"`
+}
+
+type pronunciationCheckRequest struct {
+	Language string `json:"language"`
+	Expected string `json:"expected"`           // text the learner was asked to say
+	Contrast string `json:"contrast,omitempty"` // minimal pair: the other word
+	Spoken   string `json:"spoken"`             // browser transcript, when no recording is sent
+}
+
+type pronunciationCheckResponse struct {
+	Correct    bool               `json:"correct"`
+	Confused   bool               `json:"confused"` // minimal pair: sounded like the contrast word
+	Score      int                `json:"score"`
+	Feedback   string             `json:"feedback"`
+	Transcript string             `json:"transcript"`
+	Words      []speech.WordScore `json:"words"`
+}
+
+type drillResult struct {
+	DrillID string `json:"drill_id"`
+	Sound   string `json:"sound"`
+	Text    string `json:"text"`
+	Correct bool   `json:"correct"`
+	Score   int    `json:"score"`
+}
+
+type pronunciationCompleteRequest struct {
+	Language string        `json:"language"`
+	Level    int           `json:"level"`
+	Results  []drillResult `json:"results"`
+}
+
+type pronunciationCompleteResponse struct {
+	FPEarned     int      `json:"fp_earned"`
+	WeakSounds   []string `json:"weak_sounds"`
+	CorrectCount int      `json:"correct_count"`
+	AverageScore int      `json:"average_score"`
+	RecordID     string   `json:"record_id"`
+}
+
+// ── Session ───────────────────────────────────────────────────────────────────
+
+func (h *PronunciationHandler) Session(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req pronunciationSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	if !IsValidLanguage(req.Language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid language"})
+		return
+	}
+	if req.Level < 1 || req.Level > 5 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "level must be 1-5"})
+		return
+	}
+
+	_ = h.presenceStore.Set(r.Context(), userID, store.LessonPresence{
+		Type:      "pronunciation",
+		Language:  req.Language,
+		Topic:     pronunciationTopic,
+		StartedAt: time.Now(),
+	})
+
+	profile, _ := h.profileStore.Get(r.Context(), userID, req.Language)
+
+	// Mistakes mode: drills exclusively for the sounds the learner missed
+	if req.MistakesMode {
+		var weakSounds []string
+		if profile != nil {
+			weakSounds = profile.WeakSounds
+		}
+		if len(weakSounds) == 0 {
+			writeJSON(w, http.StatusOK, map[string]any{
+				"drills":  []PronunciationDrill{},
+				"message": "No difficult sounds on record yet. Complete some pronunciation sessions first!",
+			})
+			return
+		}
+		if len(weakSounds) > 5 {
+			weakSounds = weakSounds[:5]
+		}
+		drills, err := h.generateDrills(r.Context(), req.Language, req.Level, nil, weakSounds, true, 0.4)
+		if err != nil {
+			log.Printf("pronunciation/session mistakes AI error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "AI service error"})
+			return
+		}
+		store.Shuffle(drills)
+		writeJSON(w, http.StatusOK, pronunciationSessionResponse{Drills: drills})
+		return
+	}
+
+	// Cache-first: serve from pool if the user hasn't exhausted this key
+	key := h.pool.Key(req.Language, req.Level, pronunciationTopic)
+	userIdx := 0
+	if profile != nil && profile.PronunciationListIdx != nil {
+		userIdx = profile.PronunciationListIdx[key]
+	}
+	if userIdx < h.pool.Len(key) {
+		raw, _ := h.pool.Get(key, userIdx)
+		var drills []PronunciationDrill
+		if err := json.Unmarshal(raw, &drills); err == nil {
+			store.Shuffle(drills)
+			writeJSON(w, http.StatusOK, pronunciationSessionResponse{Drills: drills})
+			return
+		}
+	}
+
+	// Cache miss: avoid drills already in the pool for this key
+	var exclude []string
+	seen := map[string]bool{}
+	for _, raw := range h.pool.AllRaw(key) {
+		var list []PronunciationDrill
+		if err := json.Unmarshal(raw, &list); err == nil {
+			for _, d := range list {
+				if !seen[d.ID] {
+					seen[d.ID] = true
+					exclude = append(exclude, d.ID)
+				}
+			}
+		}
+	}
+	var reinforce []string
+	if profile != nil && len(profile.WeakSounds) > 0 {
+		reinforce = profile.WeakSounds[:min(3, len(profile.WeakSounds))]
+	}
+
+	drills, err := h.generateDrills(r.Context(), req.Language, req.Level, exclude, reinforce, false, 0.8)
+	if err != nil {
+		log.Printf("pronunciation/session AI error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "AI service error"})
+		return
+	}
+
+	// Cache the new list, then shuffle before returning. Drills are chosen for
+	// their sounds rather than word frequency, so they are pooled ungraded.
+	if raw, err := json.Marshal(drills); err == nil {
+		h.pool.Append(key, raw)
+	}
+	store.Shuffle(drills)
+	writeJSON(w, http.StatusOK, pronunciationSessionResponse{Drills: drills})
+}
+
+// generateDrills asks the model for minimal pairs and tongue-twisters for
+// the sounds hardest for English speakers at this level. Sounds in focus are
+// reinforced; with focusOnly, every drill targets one of them.
+func (h *PronunciationHandler) generateDrills(ctx context.Context, language string, level int, exclude, focus []string, focusOnly bool, temperature float64) ([]PronunciationDrill, error) {
+	langName := LanguageName(language)
+
+	var focusClause string
+	if len(focus) > 0 {
+		b, _ := json.Marshal(focus)
+		if focusOnly {
+			focusClause = fmt.Sprintf("\n- Every drill must target one of these sounds the student struggles with (copy the label into \"sound\"): %s", string(b))
+		} else {
+			focusClause = fmt.Sprintf("\n- Include 2-3 drills for these sounds the student struggled with before (copy the label into \"sound\"): %s", string(b))
+		}
+	}
+	var excludeClause string
+	if len(exclude) > 0 {
+		if len(exclude) > 60 {
+			exclude = exclude[:60]
+		}
+		b, _ := json.Marshal(exclude)
+		excludeClause = fmt.Sprintf("\n- Do NOT reuse these existing drills: %s", string(b))
+	}
+
+	prompt := fmt.Sprintf(`You are a %s pronunciation coach creating drills for an English-speaking student.
+Level: %s
+
+Generate exactly 6 minimal pairs and 4 tongue-twisters.
+
+Return ONLY valid JSON — no markdown, no code fences, no explanation:
+{"drills":[{"kind":"minimal_pair","sound":"...","words":["...","..."],"translation":"...","phonetic":"...","tip":"..."},{"kind":"tongue_twister","sound":"...","text":"...","translation":"...","phonetic":"...","tip":"..."}]}
+
+Rules:
+- "sound": a short label for the target sound, e.g. "rr (trilled r)" or "gli /ʎ/" — reuse the same label for the same sound
+- minimal pairs: two real %s words that differ ONLY in the target sound
+- tongue-twisters: short (max 12 words for levels 1-2), dense in the target sound
+- "translation": English meaning (for pairs: "word1 = ...; word2 = ...")
+- "phonetic": English-syllable pronunciation guide with the stressed syllable in CAPS
+- "tip": one concrete articulation tip (tongue, lips, voicing) for the target sound
+- Cover at least 4 different sounds, chosen as the hardest for English speakers at this level%s%s`,
+		langName, levelSpec[level], langName, focusClause, excludeClause)
+
+	result, err := h.callAI(ctx, prompt, 1400, temperature)
+	if err != nil {
+		return nil, err
+	}
+	result = strings.TrimSpace(result)
+	if idx := strings.Index(result, "{"); idx > 0 {
+		result = result[idx:]
+	}
+	if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
+		result = result[:idx+1]
+	}
+	var parsed struct {
+		Drills []PronunciationDrill `json:"drills"`
+	}
+	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+		return nil, fmt.Errorf("parse drills: %w (raw: %s)", err, result)
+	}
+	return normalizeDrills(parsed.Drills), nil
+}
+
+// normalizeDrills drops malformed drills and derives Text and ID, which the
+// pool and the mistakes list rely on, instead of trusting the model for them.
+func normalizeDrills(in []PronunciationDrill) []PronunciationDrill {
+	out := make([]PronunciationDrill, 0, len(in))
+	for _, d := range in {
+		d.Sound = strings.TrimSpace(d.Sound)
+		switch d.Kind {
+		case DrillMinimalPair:
+			if len(d.Words) != 2 || d.Words[0] == "" || d.Words[1] == "" {
+				continue
+			}
+			d.Text = d.Words[0] + " / " + d.Words[1]
+		case DrillTongueTwister:
+			d.Words = nil
+			if strings.TrimSpace(d.Text) == "" {
+				continue
+			}
+		default:
+			continue
+		}
+		d.ID = d.Kind + ":" + d.Text
+		out = append(out, d)
+	}
+	return out
+}
+
+// ── Check ─────────────────────────────────────────────────────────────────────
+
+// Check scores one attempt. It takes either JSON (pronunciationCheckRequest
+// with the browser transcript in "spoken") or a multipart form with the same
+// fields and the recording in "audio", which is recognised server-side.
+func (h *PronunciationHandler) Check(w http.ResponseWriter, r *http.Request) {
+	var req pronunciationCheckRequest
+	var transcript *speech.Transcript
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if h.recognizer == nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "speech recognition is not available"})
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxAudioUpload)
+		if err := r.ParseMultipartForm(maxAudioUpload); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "recording too large"})
+				return
+			}
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+		req.Language = r.FormValue("language")
+		req.Expected = r.FormValue("expected")
+		req.Contrast = r.FormValue("contrast")
+		file, hdr, err := r.FormFile("audio")
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "audio file required"})
+			return
+		}
+		defer file.Close()
+		if !IsValidLanguage(req.Language) || strings.TrimSpace(req.Expected) == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid expected text or language"})
+			return
+		}
+		transcript, err = h.recognizer.Transcribe(r.Context(), file, speech.Options{
+			Language:    req.Language,
+			ContentType: hdr.Header.Get("Content-Type"),
+		})
+		if errors.Is(err, speech.ErrNoSpeech) {
+			writeJSON(w, http.StatusOK, pronunciationCheckResponse{Feedback: "We couldn't hear anything — try again a little closer to the microphone.", Words: []speech.WordScore{}})
+			return
+		}
+		if err != nil {
+			log.Printf("pronunciation/check recognition error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "speech recognition failed"})
+			return
+		}
+	} else {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+			return
+		}
+		if !IsValidLanguage(req.Language) || strings.TrimSpace(req.Expected) == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid expected text or language"})
+			return
+		}
+		transcript = spokenTranscript(req.Spoken)
+	}
+
+	writeJSON(w, http.StatusOK, assessDrill(req.Expected, req.Contrast, transcript))
+}
+
+// spokenTranscript wraps a browser speech transcript. The Web Speech API
+// gives no per-word confidence, so every word counts as certain.
+func spokenTranscript(spoken string) *speech.Transcript {
+	t := &speech.Transcript{Text: strings.TrimSpace(spoken)}
+	for _, f := range strings.Fields(spoken) {
+		t.Words = append(t.Words, speech.Word{Text: f, Confidence: 1})
+	}
+	return t
+}
+
+// assessDrill scores t against expected. For a minimal pair, an attempt
+// that matches the contrast word better than the expected one is a
+// confusion of the two sounds and never counts as correct.
+func assessDrill(expected, contrast string, t *speech.Transcript) pronunciationCheckResponse {
+	a := speech.Assess(stripOrthographic(expected), t)
+	resp := pronunciationCheckResponse{
+		Correct:    a.Correct,
+		Score:      a.Score,
+		Feedback:   audioFeedback(a),
+		Transcript: a.Transcript,
+		Words:      a.Words,
+	}
+	if contrast != "" {
+		if c := speech.Assess(stripOrthographic(contrast), t); c.Score > a.Score {
+			resp.Correct = false
+			resp.Confused = true
+			resp.Feedback = fmt.Sprintf("That sounded like %q, not %q — focus on the sound that tells them apart.", contrast, expected)
+		}
+	}
+	return resp
+}
+
+// ── Complete ──────────────────────────────────────────────────────────────────
+
+func (h *PronunciationHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req pronunciationCompleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	if !IsValidLanguage(req.Language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid language"})
+		return
+	}
+	if len(req.Results) == 0 || len(req.Results) > maxPronunciationResults {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("between 1 and %d results required", maxPronunciationResults)})
+		return
+	}
+
+	weakSounds, clearedSounds := soundOutcomes(req.Results)
+	if weakSounds == nil {
+		weakSounds = []string{}
+	}
+	var correctCount, scoreTotal int
+	var practised []string
+	for _, res := range req.Results {
+		if res.Correct {
+			correctCount++
+		}
+		scoreTotal += res.Score
+		practised = append(practised, res.Text)
+	}
+	total := len(req.Results)
+	avgScore := 0
+	if total > 0 {
+		avgScore = scoreTotal / total
+	}
+
+	fp := correctCount * 6
+	if fp < 10 {
+		fp = 10
+	}
+	if correctCount == total && total > 0 {
+		fp += 10
+	}
+
+	if _, _, err := h.userStore.UpdateActivity(userID, req.Language, fp); err != nil {
+		log.Printf("pronunciation/complete UpdateActivity error: %v", err)
+	}
+
+	ctx := context.Background()
+	profile, err := h.profileStore.Get(ctx, userID, req.Language)
+	if err != nil || profile == nil {
+		profile = &store.StudentProfile{
+			UserID:   userID,
+			Language: req.Language,
+		}
+	}
+
+	for _, s := range clearedSounds {
+		profile.WeakSounds = removeFromSlice(s, profile.WeakSounds)
+	}
+	profile.WeakSounds = prependUnique(weakSounds, profile.WeakSounds, 15)
+	profile.WeakAreas = prependUnique(weakSounds, profile.WeakAreas, 20)
+	profile.RecentTopics = prependUnique([]string{"Pronunciation Practice"}, profile.RecentTopics, 10)
+	profile.SessionCount++
+
+	// Advance the user's list index for this pool key
+	key := h.pool.Key(req.Language, req.Level, pronunciationTopic)
+	if profile.PronunciationListIdx == nil {
+		profile.PronunciationListIdx = make(map[string]int)
+	}
+	profile.PronunciationListIdx[key]++
+
+	if err := h.profileStore.Upsert(ctx, profile); err != nil {
+		log.Printf("pronunciation/complete Upsert error: %v", err)
+	}
+
+	topicName, _ := TopicDetails(pronunciationTopic)
+	summary := fmt.Sprintf("Completed Pronunciation Practice: %d/%d drills correct, average score %d.", correctCount, total, avgScore)
+	var suggestions []string
+	if len(weakSounds) > 0 {
+		suggestions = []string{
+			fmt.Sprintf("Drill these sounds again: %s", strings.Join(weakSounds[:min(3, len(weakSounds))], ", ")),
+			"Record yourself and compare with the model audio",
+			"Use mistakes mode to practise only the sounds you missed",
+		}
+	} else {
+		suggestions = []string{
+			"Try the next level for harder sound contrasts",
+			"Practise these sounds in a spoken conversation session",
+			"Read a short text aloud to keep the sounds natural",
+		}
+	}
+
+	recordID := uuid.New().String()
+	record := &store.ConversationRecord{
+		ID:           recordID,
+		UserID:       userID,
+		Language:     req.Language,
+		Topic:        pronunciationTopic,
+		TopicName:    topicName,
+		Level:        req.Level,
+		Personality:  "pronunciation-coach",
+		MessageCount: total,
+		FPEarned:     fp,
+		Summary:      summary,
+		Topics:       []string{topicName},
+		Vocabulary:   practised,
+		Corrections:  weakSounds,
+		Suggestions:  suggestions,
+		CreatedAt:    time.Now(),
+		EndedAt:      time.Now(),
+	}
+	h.historyStore.Save(record)
+
+	_ = h.presenceStore.Clear(r.Context(), userID)
+	_ = h.cacheStore.InvalidateUserStats(r.Context(), userID)
+
+	writeJSON(w, http.StatusOK, pronunciationCompleteResponse{
+		FPEarned:     fp,
+		WeakSounds:   weakSounds,
+		CorrectCount: correctCount,
+		AverageScore: avgScore,
+		RecordID:     recordID,
+	})
+}
+
+// soundOutcomes splits the sounds practised in a session into those missed
+// at least once (weak) and those always produced correctly (cleared).
+func soundOutcomes(results []drillResult) (weak, cleared []string) {
+	missed := map[string]bool{}
+	var order []string
+	for _, res := range results {
+		if res.Sound == "" {
+			continue
+		}
+		if _, ok := missed[res.Sound]; !ok {
+			order = append(order, res.Sound)
+		}
+		missed[res.Sound] = missed[res.Sound] || !res.Correct
+	}
+	for _, s := range order {
+		if missed[s] {
+			weak = append(weak, s)
+		} else {
+			cleared = append(cleared, s)
+		}
+	}
+	return weak, cleared
+}
+
+// ── AI helper ─────────────────────────────────────────────────────────────────
+
+func (h *PronunciationHandler) callAI(ctx context.Context, prompt string, maxTokens int, temperature float64) (string, error) {
+	payload := ionosVocabPayload{
+		Model: h.cfg.IONOSFastModel,
+		Messages: []store.Message{
+			{Role: "user", Content: prompt},
+		},
+		Stream:      false,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", h.cfg.IONOSBaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+h.cfg.IONOSAPIKey)
+
+	client := &http.Client{Timeout: 90 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("AI returned %d: %s", resp.StatusCode, string(raw))
+	}
+
+	var parsed ionosVocabResponse
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return "", err
+	}
+	if len(parsed.Choices) == 0 {
+		return "", fmt.Errorf("no choices in AI response")
+	}
+	content := parsed.Choices[0].Message.Content
+	if content == "" {
+		content = parsed.Choices[0].Message.ReasoningContent
+	}
+	return content, nil
+}
@@ -0,0 +1,81 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkPronunciation(t *testing.T, body map[string]string) (int, map[string]any) {
+	t.Helper()
+	h := handlers.NewPronunciationHandler(nil, nil, nil, nil, nil, nil, nil, nil)
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/pronunciation/check", bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.Check(w, req)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestPronunciationCheck_MinimalPairConfusion(t *testing.T) {
+	code, resp := checkPronunciation(t, map[string]string{
+		"language": "es", "expected": "perro", "contrast": "pero", "spoken": "pero",
+	})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, resp["correct"])
+	assert.Equal(t, true, resp["confused"])
+
+	code, resp = checkPronunciation(t, map[string]string{
+		"language": "es", "expected": "perro", "contrast": "pero", "spoken": "Perro",
+	})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, resp["correct"])
+	assert.Equal(t, false, resp["confused"])
+}
+
+func TestPronunciationCheck_TongueTwister(t *testing.T) {
+	code, resp := checkPronunciation(t, map[string]string{
+		"language": "es", "expected": "Tres tristes tigres comen trigo", "spoken": "tres tristes tigres comen",
+	})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, resp["correct"])
+	assert.Contains(t, resp["feedback"], "trigo")
+
+	code, _ = checkPronunciation(t, map[string]string{"language": "xx", "expected": "hola"})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestPronunciationComplete_RejectsBadInput(t *testing.T) {
+	result := `{"drill_id":"d1","sound":"rr","text":"perro","correct":true,"score":90}`
+	cases := []struct {
+		name, body, wantError string
+	}{
+		{"invalid language", `{"language":"xx","results":[` + result + `]}`, "invalid language"},
+		{"no results", `{"language":"es","results":[]}`, "between 1 and 50 results"},
+		{"too many results", `{"language":"es","results":[` + strings.Repeat(result+",", 50) + result + `]}`, "between 1 and 50 results"},
+	}
+	h := handlers.NewPronunciationHandler(nil, nil, nil, nil, nil, nil, nil, nil)
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/pronunciation/complete", strings.NewReader(c.body))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+		w := httptest.NewRecorder()
+		h.Complete(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, c.name)
+
+		var resp map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Contains(t, resp["error"], c.wantError, c.name)
+	}
+}
@@ -0,0 +1,727 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/difficulty"
+	"github.com/ailanguagetutor/lemma"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/textnorm"
+	"github.com/google/uuid"
+)
+
+// ReadingHandler serves the reading mode: graded texts per language, level
+// and topic, generated or imported by an admin and kept in the pool. Words
+// are glossed on tap and go into the learner's review deck, comprehension
+// questions are checked on the server like listening ones, and reading time
+// runs from the start of the session to the first answer.
+type ReadingHandler struct {
+	cfg           *config.Config
+	userStore     *store.UserStore
+	profileStore  *store.StudentProfileStore
+	historyStore  *store.ConversationHistoryStore
+	pool          *store.ItemPool
+	presenceStore *store.PresenceStore
+	cacheStore    *store.CacheStore
+	cardStore     *store.VocabCardStore
+	sessionStore  *store.ReadingSessionStore
+}
+
+func NewReadingHandler(cfg *config.Config, us *store.UserStore, ps *store.StudentProfileStore, hs *store.ConversationHistoryStore, pool *store.ItemPool, presence *store.PresenceStore, cache *store.CacheStore, cards *store.VocabCardStore, sessions *store.ReadingSessionStore) *ReadingHandler {
+	return &ReadingHandler{cfg: cfg, userStore: us, profileStore: ps, historyStore: hs, pool: pool, presenceStore: presence, cacheStore: cache, cardStore: cards, sessionStore: sessions}
+}
+
+// ── Types ─────────────────────────────────────────────────────────────────────
+
+// ReadingText is a pooled reading text with its answer key.
+type ReadingText struct {
+	Title     string          `json:"title"`
+	Text      string          `json:"text"` // paragraphs separated by blank lines
+	Questions []StoryQuestion `json:"questions"`
+	Imported  bool            `json:"imported,omitempty"` // added by an admin rather than generated
+}
+
+type readingSessionRequest struct {
+	Language string `json:"language"`
+	Level    int    `json:"level"`
+	Topic    string `json:"topic"`
+}
+
+type readingSessionResponse struct {
+	SessionID  string          `json:"session_id"`
+	Title      string          `json:"title"`
+	Paragraphs []string        `json:"paragraphs"`
+	Words      int             `json:"words"`
+	Questions  []StoryQuestion `json:"questions"` // without answers or explanations
+}
+
+type readingGlossRequest struct {
+	SessionID string `json:"session_id"`
+	Word      string `json:"word"` // a word or short phrase from the text
+}
+
+type readingGlossResponse struct {
+	Word        string `json:"word"`
+	Lemma       string `json:"lemma"`
+	Translation string `json:"translation"`
+	Phonetic    string `json:"phonetic"`
+	AddedToDeck bool   `json:"added_to_deck"` // a new card; words already in the deck keep their schedule
+}
+
+type readingCompleteResponse struct {
+	FPEarned       int               `json:"fp_earned"`
+	CorrectCount   int               `json:"correct_count"`
+	TotalCount     int               `json:"total_count"`
+	Results        []listeningResult `json:"results"`
+	ReadingSecs    int               `json:"reading_secs"`
+	WordsPerMinute int               `json:"words_per_minute"` // 0 if the reading time is unknown
+	LookedUp       []string          `json:"looked_up"`        // lemmas, added to the review deck
+	RecordID       string            `json:"record_id"`
+}
+
+// readingWords is the length of generated texts and readingQuestions the
+// number of questions on them, by level.
+var (
+	readingWords     = map[int]int{1: 120, 2: 180, 3: 260, 4: 350, 5: 450}
+	readingQuestions = map[int]int{1: 3, 2: 4, 3: 5, 4: 5, 5: 6}
+)
+
+const (
+	readingMaxChars   = 12000 // longest imported text
+	readingMaxSecs    = 3600  // reading time is capped, since tabs are left open
+	readingGlossWords = 4     // longest phrase glossed at once
+)
+
+// ── Session ───────────────────────────────────────────────────────────────────
+
+func (h *ReadingHandler) Session(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req readingSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	if msg := validReadingKey(req.Language, req.Level, req.Topic); msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+		return
+	}
+
+	_ = h.presenceStore.Set(r.Context(), userID, store.LessonPresence{
+		Type:      "reading",
+		Language:  req.Language,
+		Topic:     req.Topic,
+		StartedAt: time.Now(),
+	})
+
+	profile, _ := h.profileStore.Get(r.Context(), userID, req.Language)
+
+	key := h.pool.Key(req.Language, req.Level, req.Topic)
+	userIdx := 0
+	if profile != nil && profile.ReadingListIdx != nil {
+		userIdx = profile.ReadingListIdx[key]
+	}
+
+	// Cache hit
+	if userIdx < h.pool.Len(key) {
+		raw, _ := h.pool.Get(key, userIdx)
+		var text ReadingText
+		if err := json.Unmarshal(raw, &text); err == nil {
+			h.writeSession(w, r.Context(), userID, req, key, text)
+			return
+		}
+	}
+
+	var weakVocab []string
+	if profile != nil {
+		weakVocab = profile.WeakVocab
+	}
+	text, score, fits, err := generateGraded("reading/session", req.Level, func() (*ReadingText, error) {
+		return h.generateText(r.Context(), req.Language, req.Level, req.Topic, weakVocab)
+	}, func(text *ReadingText) (difficulty.Score, bool) {
+		return scoreReading(req.Language, *text)
+	})
+	if err != nil {
+		log.Printf("reading/session AI error: %v", err)
+		writeGenerationError(w, err)
+		return
+	}
+	if raw, err := json.Marshal(text); err == nil && fits {
+		h.pool.AppendScored(key, raw, score)
+	}
+	h.writeSession(w, r.Context(), userID, req, key, *text)
+}
+
+// validReadingKey checks the language, level and topic of a reading text,
+// returning the error message if any is invalid.
+func validReadingKey(language string, level int, topic string) string {
+	switch {
+	case !IsValidLanguage(language):
+		return "invalid language"
+	case level < 1 || level > 5:
+		return "level must be 1-5"
+	case !IsValidTopic(topic):
+		return "invalid topic"
+	}
+	return ""
+}
+
+// writeSession stores the text's answer key as a new session and sends the
+// text without answers.
+func (h *ReadingHandler) writeSession(w http.ResponseWriter, ctx context.Context, userID string, req readingSessionRequest, poolKey string, text ReadingText) {
+	session := store.ReadingSession{
+		Language:  req.Language,
+		Level:     req.Level,
+		Topic:     req.Topic,
+		PoolKey:   poolKey,
+		Title:     text.Title,
+		Text:      text.Text,
+		Words:     len(textnorm.Words(text.Text, req.Language)),
+		StartedAt: time.Now(),
+	}
+	public := make([]StoryQuestion, len(text.Questions))
+	for i, q := range text.Questions {
+		answer, ok := storyAnswer(q, q.Answer)
+		if !ok {
+			log.Printf("reading/session: unusable answer %v for %q", q.Answer, q.Question)
+		}
+		session.Questions = append(session.Questions, store.ListeningQuestion{
+			Type:        q.Type,
+			Question:    q.Question,
+			Options:     q.Options,
+			Answer:      answer,
+			Explanation: q.Explanation,
+		})
+		q.Answer, q.Explanation = nil, ""
+		public[i] = q
+	}
+
+	id := uuid.New().String()
+	if err := h.sessionStore.Save(ctx, userID, id, session); err != nil {
+		log.Printf("reading/session save error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not start session"})
+		return
+	}
+	writeJSON(w, http.StatusOK, readingSessionResponse{
+		SessionID:  id,
+		Title:      text.Title,
+		Paragraphs: readingParagraphs(text.Text),
+		Words:      session.Words,
+		Questions:  public,
+	})
+}
+
+// readingParagraphs splits text at blank lines.
+func readingParagraphs(text string) []string {
+	var out []string
+	for _, p := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// readingSession loads a reading session, writing the error response if
+// there is none.
+func (h *ReadingHandler) readingSession(w http.ResponseWriter, ctx context.Context, userID, id string) (*store.ReadingSession, bool) {
+	session, err := h.sessionStore.Get(ctx, userID, id)
+	if err != nil {
+		log.Printf("reading session error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not load session"})
+		return nil, false
+	}
+	if session == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "session not found or expired"})
+		return nil, false
+	}
+	return session, true
+}
+
+// ── Gloss ─────────────────────────────────────────────────────────────────────
+
+// Gloss translates a word or short phrase of the session's text and adds its
+// dictionary form to the learner's review deck, once per session. Glosses
+// are cached per lemma with the flashcard details, so a word is only sent to
+// the model once.
+func (h *ReadingHandler) Gloss(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req readingGlossRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	session, ok := h.readingSession(w, r.Context(), userID, req.SessionID)
+	if !ok {
+		return
+	}
+	tokens := textnorm.Tokens(req.Word, session.Language)
+	if len(tokens) == 0 || len(tokens) > readingGlossWords {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "select a word or short phrase"})
+		return
+	}
+	word := strings.Join(tokens, " ")
+	sentence, found := glossSentence(session.Text, word, session.Language)
+	if !found {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "word not in text"})
+		return
+	}
+
+	lookup := store.ReadingLookup{Word: word, Lemma: lemma.Of(session.Language, word)}
+	details, cached := h.cacheStore.GetWordDetails(r.Context(), session.Language, []string{lookup.Lemma})[lookup.Lemma]
+	if !cached {
+		var err error
+		details, err = h.glossWord(r.Context(), session.Language, lookup.Lemma, sentence)
+		if err != nil {
+			log.Printf("reading/gloss error: %v", err)
+			writeGenerationError(w, err)
+			return
+		}
+		if err := h.cacheStore.SetWordDetails(r.Context(), session.Language, map[string]store.WordDetails{lookup.Lemma: details}); err != nil {
+			log.Printf("reading/gloss cache error: %v", err)
+		}
+	}
+	lookup.Translation, lookup.Phonetic = details.Translation, details.Phonetic
+
+	resp := readingGlossResponse{Word: word, Lemma: lookup.Lemma, Translation: details.Translation, Phonetic: details.Phonetic}
+	first, err := h.sessionStore.AddLookup(r.Context(), userID, req.SessionID, lookup)
+	if err != nil {
+		log.Printf("reading/gloss AddLookup error: %v", err)
+	}
+	if first && h.cardStore != nil {
+		n, err := h.cardStore.Add(r.Context(), []store.VocabCard{{
+			UserID:      userID,
+			Language:    session.Language,
+			Word:        lookup.Lemma,
+			Translation: details.Translation,
+			Phonetic:    details.Phonetic,
+			Source:      "reading",
+		}})
+		if err != nil {
+			log.Printf("reading/gloss card Add error: %v", err)
+		}
+		resp.AddedToDeck = n > 0
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// glossSentence returns the first sentence of text containing word (already
+// normalised), and whether there is one.
+func glossSentence(text, word, language string) (string, bool) {
+	for _, p := range readingParagraphs(text) {
+		for _, s := range splitSentences(p) {
+			if strings.Contains(" "+textnorm.Normalize(s, language)+" ", " "+word+" ") {
+				return s, true
+			}
+		}
+	}
+	return "", false
+}
+
+// glossWord asks the model for the flashcard translation and pronunciation
+// guide of a dictionary form, as used in sentence.
+func (h *ReadingHandler) glossWord(ctx context.Context, language, word, sentence string) (store.WordDetails, error) {
+	prompt := fmt.Sprintf(`Give flashcard details for the %s word or phrase %q, as used in this sentence: %q
+
+Return ONLY valid JSON — no markdown, no code fences, no explanation:
+{"translation":"...","phonetic":"..."}
+
+Rules:
+- "translation": concise English translation of the word's meaning in the sentence
+- "phonetic": English-syllable pronunciation guide with stressed syllable in CAPS`,
+		LanguageName(language), word, sentence)
+
+	result, err := h.callAI(ctx, prompt, 200, 0.2)
+	if err != nil {
+		return store.WordDetails{}, err
+	}
+	result = strings.TrimSpace(result)
+	if idx := strings.Index(result, "{"); idx > 0 {
+		result = result[idx:]
+	}
+	if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
+		result = result[:idx+1]
+	}
+	var details store.WordDetails
+	if err := json.Unmarshal([]byte(result), &details); err != nil || details.Translation == "" {
+		log.Printf("reading/gloss JSON parse error: %v\nraw: %s", err, result)
+		return store.WordDetails{}, errBadAIResponse
+	}
+	return details, nil
+}
+
+// ── Check ─────────────────────────────────────────────────────────────────────
+
+// Check grades one comprehension question. Only the first answer to a
+// question is recorded, and the first answer to any question ends the
+// reading time.
+func (h *ReadingHandler) Check(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req listeningCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	session, ok := h.readingSession(w, r.Context(), userID, req.SessionID)
+	if !ok {
+		return
+	}
+	if req.QuestionIndex < 0 || req.QuestionIndex >= len(session.Questions) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid question index"})
+		return
+	}
+	q := session.Questions[req.QuestionIndex]
+
+	if prev, ok := session.Answers[req.QuestionIndex]; ok {
+		writeJSON(w, http.StatusOK, listeningCheckResponse{Correct: prev.Correct, Answer: clientAnswer(q), Explanation: q.Explanation, AlreadyAnswered: true})
+		return
+	}
+	answer, ok := storyAnswer(StoryQuestion{Type: q.Type, Options: q.Options}, req.Answer)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid answer"})
+		return
+	}
+	// As in listening, a question with an unusable key accepts any answer
+	correct := q.Answer == "" || answer == q.Answer
+
+	submitted := store.SessionAnswer{Answer: answer, Correct: correct}
+	first, err := h.sessionStore.RecordAnswer(r.Context(), userID, req.SessionID, req.QuestionIndex, submitted, time.Now())
+	if err != nil {
+		log.Printf("reading/check record error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not record answer"})
+		return
+	}
+	writeJSON(w, http.StatusOK, listeningCheckResponse{
+		Correct:         first.Correct,
+		Answer:          clientAnswer(q),
+		Explanation:     q.Explanation,
+		AlreadyAnswered: first != submitted,
+	})
+}
+
+// ── Complete ──────────────────────────────────────────────────────────────────
+
+func (h *ReadingHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req listeningCompleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	session, ok := h.readingSession(w, r.Context(), userID, req.SessionID)
+	if !ok {
+		return
+	}
+	first, err := h.sessionStore.MarkCompleted(r.Context(), userID, req.SessionID)
+	if err != nil {
+		log.Printf("reading/complete error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not complete session"})
+		return
+	}
+	if !first {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "session already completed"})
+		return
+	}
+
+	// Score from the recorded answers; unanswered questions count as wrong
+	correctCount := 0
+	results := make([]listeningResult, len(session.Questions))
+	var wrongQuestions []string
+	for i, q := range session.Questions {
+		a := session.Answers[i]
+		results[i] = listeningResult{QuestionIndex: i, Correct: a.Correct}
+		if a.Correct {
+			correctCount++
+		} else {
+			wrongQuestions = append(wrongQuestions, q.Question)
+		}
+	}
+	totalCount := len(session.Questions)
+
+	now := time.Now()
+	secs, wpm := readingSpeed(session, now)
+	lookedUp := []string{}
+	for _, l := range session.Lookups {
+		lookedUp = append(lookedUp, l.Lemma)
+	}
+
+	fp := correctCount*15 + session.Words/50
+	if fp < 20 {
+		fp = 20
+	}
+	if correctCount == totalCount && totalCount > 0 {
+		fp += 20
+	}
+
+	if _, _, err := h.userStore.UpdateActivity(userID, session.Language, fp); err != nil {
+		log.Printf("reading/complete UpdateActivity error: %v", err)
+	}
+
+	ctx := context.Background()
+	profile, err := h.profileStore.Get(ctx, userID, session.Language)
+	if err != nil || profile == nil {
+		profile = &store.StudentProfile{
+			UserID:   userID,
+			Language: session.Language,
+		}
+	}
+	topicName, _ := TopicDetails(session.Topic)
+	profile.WeakVocab = prependUnique(lookedUp, profile.WeakVocab, 30)
+	profile.RecentTopics = prependUnique([]string{topicName}, profile.RecentTopics, 10)
+	profile.SessionCount++
+	if profile.ReadingListIdx == nil {
+		profile.ReadingListIdx = make(map[string]int)
+	}
+	profile.ReadingListIdx[session.PoolKey]++
+	if err := h.profileStore.Upsert(ctx, profile); err != nil {
+		log.Printf("reading/complete Upsert error: %v", err)
+	}
+
+	summary := fmt.Sprintf("Completed Reading on %s: %d/%d questions answered correctly.", topicName, correctCount, totalCount)
+	if wpm > 0 {
+		summary = fmt.Sprintf("Completed Reading on %s: %d/%d questions answered correctly, %d words read at %d words per minute.", topicName, correctCount, totalCount, session.Words, wpm)
+	}
+	var suggestions []string
+	if len(wrongQuestions) > 0 || len(lookedUp) > 0 {
+		suggestions = []string{
+			"Reread the paragraphs behind the questions you missed",
+			"Review the words you looked up in your vocabulary deck",
+			"Try Listening Comprehension on this topic",
+		}
+	} else {
+		suggestions = []string{
+			"Try a higher level for longer, richer texts",
+			"Read a text on a new topic",
+			"Write about this topic in the Writing Coach",
+		}
+	}
+
+	recordID := uuid.New().String()
+	record := &store.ConversationRecord{
+		ID:           recordID,
+		UserID:       userID,
+		Language:     session.Language,
+		Topic:        session.Topic,
+		TopicName:    topicName,
+		Level:        session.Level,
+		Personality:  "reading",
+		MessageCount: totalCount,
+		DurationSecs: secs,
+		FPEarned:     fp,
+		Summary:      summary,
+		Topics:       []string{topicName},
+		Vocabulary:   lookedUp,
+		Corrections:  wrongQuestions,
+		Suggestions:  suggestions,
+		CreatedAt:    session.StartedAt,
+		EndedAt:      now,
+	}
+	h.historyStore.Save(record)
+
+	_ = h.presenceStore.Clear(r.Context(), userID)
+	_ = h.cacheStore.InvalidateUserStats(r.Context(), userID)
+
+	writeJSON(w, http.StatusOK, readingCompleteResponse{
+		FPEarned:       fp,
+		CorrectCount:   correctCount,
+		TotalCount:     totalCount,
+		Results:        results,
+		ReadingSecs:    secs,
+		WordsPerMinute: wpm,
+		LookedUp:       lookedUp,
+		RecordID:       recordID,
+	})
+}
+
+// readingSpeed returns the reading time in seconds, from the start of the
+// session to the first answer (or now, if none), capped at readingMaxSecs,
+// and the words read per minute in it.
+func readingSpeed(session *store.ReadingSession, now time.Time) (secs, wpm int) {
+	end := session.ReadAt
+	if end.IsZero() {
+		end = now
+	}
+	secs = int(end.Sub(session.StartedAt).Seconds())
+	secs = max(0, min(secs, readingMaxSecs))
+	if secs > 0 {
+		wpm = session.Words * 60 / secs
+	}
+	return secs, wpm
+}
+
+// ── Import ────────────────────────────────────────────────────────────────────
+
+// readingQuestionTypes are the question types a reading text may use.
+var readingQuestionTypes = []string{"multiple_choice", "true_false", "yes_no"}
+
+// validReadingText checks a text's content and answer key, returning the
+// error message if it cannot be served.
+func validReadingText(text ReadingText) string {
+	switch {
+	case strings.TrimSpace(text.Title) == "" || strings.TrimSpace(text.Text) == "":
+		return "title and text are required"
+	case len(text.Text) > readingMaxChars:
+		return fmt.Sprintf("text is longer than %d characters", readingMaxChars)
+	case len(text.Questions) == 0:
+		return "at least one question is required"
+	}
+	for i, q := range text.Questions {
+		if !slices.Contains(readingQuestionTypes, q.Type) || strings.TrimSpace(q.Question) == "" {
+			return fmt.Sprintf("question %d: type and question are required", i+1)
+		}
+		if q.Type == "multiple_choice" && len(q.Options) < 2 {
+			return fmt.Sprintf("question %d: multiple choice needs at least two options", i+1)
+		}
+		if _, ok := storyAnswer(q, q.Answer); !ok {
+			return fmt.Sprintf("question %d: invalid answer", i+1)
+		}
+	}
+	return ""
+}
+
+// ── AI text generation ────────────────────────────────────────────────────────
+
+func (h *ReadingHandler) generateText(ctx context.Context, language string, level int, topic string, weakVocab []string) (*ReadingText, error) {
+	langName := LanguageName(language)
+	topicName, topicDesc := TopicDetails(topic)
+	spec := levelSpec[level]
+	if spec == "" {
+		spec = "INTERMEDIATE (B1)"
+	}
+	words := readingWords[level]
+	n := readingQuestions[level]
+	cultural := culturalContext[language]
+	if cultural == "" {
+		cultural = "the target language's culture, daily life, and traditions"
+	}
+
+	var vocabClause string
+	if len(weakVocab) > 0 {
+		weak := weakVocab
+		if len(weak) > 10 {
+			weak = weak[:10]
+		}
+		vocabClause = fmt.Sprintf("\nVocabulary to use naturally, in any inflected form (as many as fit): %s", strings.Join(weak, ", "))
+	}
+
+	prompt := fmt.Sprintf(`You are a language tutor writing a graded reading text.
+Language: %s
+Topic: %s — %s
+Level: %s
+Cultural context: %s%s
+
+Write a FAMILY-FRIENDLY text in %s of about %d words that:
+- Uses only vocabulary and grammar a learner at this level can read
+- Is an article, letter, blog post or short story with a clear title
+- Has 2–5 paragraphs separated by a blank line
+
+Then write exactly %d comprehension questions about the text, in %s, mixing these types:
+- "yes_no": yes or no question about a fact in the text
+- "true_false": true/false statement about the text
+- "multiple_choice": 4-option question (exactly 4 options)
+
+Return ONLY valid JSON — no markdown, no code fences, no explanation:
+{
+  "title": "Title in target language",
+  "text": "First paragraph...\n\nSecond paragraph...",
+  "questions": [
+    {
+      "type": "multiple_choice",
+      "question": "Question in target language",
+      "options": ["A","B","C","D"],
+      "answer": 0,
+      "explanation": "Brief explanation in target language"
+    }
+  ]
+}
+For yes_no: omit options, answer is "yes" or "no"
+For true_false: omit options, answer is "true" or "false"`,
+		langName, topicName, topicDesc, spec, cultural, vocabClause,
+		langName, words, n, langName)
+
+	result, err := h.callAI(ctx, prompt, 2500, 0.8)
+	if err != nil {
+		return nil, err
+	}
+	result = strings.TrimSpace(result)
+	if idx := strings.Index(result, "{"); idx > 0 {
+		result = result[idx:]
+	}
+	if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
+		result = result[:idx+1]
+	}
+	var text ReadingText
+	if err := json.Unmarshal([]byte(result), &text); err != nil {
+		log.Printf("reading/session JSON parse error: %v\nraw: %s", err, result)
+		return nil, errBadAIResponse
+	}
+	return &text, nil
+}
+
+// ── AI helper ─────────────────────────────────────────────────────────────────
+
+func (h *ReadingHandler) callAI(ctx context.Context, prompt string, maxTokens int, temperature float64) (string, error) {
+	payload := ionosVocabPayload{
+		Model: h.cfg.IONOSFastModel,
+		Messages: []store.Message{
+			{Role: "user", Content: prompt},
+		},
+		Stream:      false,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", h.cfg.IONOSBaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+h.cfg.IONOSAPIKey)
+
+	client := &http.Client{Timeout: 90 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("AI returned %d: %s", resp.StatusCode, string(raw))
+	}
+
+	var parsed ionosVocabResponse
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return "", err
+	}
+	if len(parsed.Choices) == 0 {
+		return "", fmt.Errorf("no choices in AI response")
+	}
+	content := parsed.Choices[0].Message.Content
+	if content == "" {
+		content = parsed.Choices[0].Message.ReasoningContent
+	}
+	return content, nil
+}
@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReadingHandler(t *testing.T, aiCalls *atomic.Int32) (*handlers.ReadingHandler, *store.ReadingSessionStore) {
+	t.Helper()
+	ai := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		aiCalls.Add(1)
+		content, _ := json.Marshal(`{"translation":"to go","phonetic":"EER"}`)
+		fmt.Fprintf(w, `{"choices":[{"message":{"content":%s}}]}`, content)
+	}))
+	t.Cleanup(ai.Close)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sessions := store.NewReadingSessionStore(rdb)
+	require.NoError(t, sessions.Save(context.Background(), "user-1", "s-1", store.ReadingSession{
+		Language: "es", Level: 1, Topic: "food", PoolKey: "es:1:food",
+		Title: "El mercado", Text: "Hoy voy al mercado.\n\nCompro manzanas rojas y pan.", Words: 9,
+		StartedAt: time.Now(),
+		Questions: []store.ListeningQuestion{
+			{Type: "multiple_choice", Question: "¿Qué compra?", Options: []string{"peras", "manzanas", "leche", "queso"}, Answer: "1", Explanation: "Compra manzanas."},
+			{Type: "yes_no", Question: "¿Va al cine?", Answer: "no"},
+		},
+	}))
+	cfg := &config.Config{IONOSBaseURL: ai.URL}
+	return handlers.NewReadingHandler(cfg, nil, nil, nil, nil, nil, store.NewCacheStore(rdb), nil, sessions), sessions
+}
+
+func postReading(t *testing.T, handler http.HandlerFunc, body map[string]any) (int, map[string]any) {
+	t.Helper()
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/reading", bytes.NewReader(raw))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestReadingGloss_CachesByLemmaAndRecordsLookup(t *testing.T) {
+	var calls atomic.Int32
+	h, sessions := newTestReadingHandler(t, &calls)
+
+	code, resp := postReading(t, h.Gloss, map[string]any{"session_id": "s-1", "word": "Voy,"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "voy", resp["word"])
+	assert.Equal(t, "ir", resp["lemma"])
+	assert.Equal(t, "to go", resp["translation"])
+	assert.Equal(t, int32(1), calls.Load())
+
+	// The gloss is cached per lemma, and the lookup recorded once
+	code, _ = postReading(t, h.Gloss, map[string]any{"session_id": "s-1", "word": "voy"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int32(1), calls.Load())
+
+	session, err := sessions.Get(context.Background(), "user-1", "s-1")
+	require.NoError(t, err)
+	require.Len(t, session.Lookups, 1)
+	assert.Equal(t, store.ReadingLookup{Word: "voy", Lemma: "ir", Translation: "to go", Phonetic: "EER"}, session.Lookups[0])
+}
+
+func TestReadingGloss_RejectsWordsNotInText(t *testing.T) {
+	var calls atomic.Int32
+	h, _ := newTestReadingHandler(t, &calls)
+
+	cases := []struct {
+		name    string
+		session string
+		word    string
+		status  int
+	}{
+		{"unknown session", "missing", "voy", http.StatusNotFound},
+		{"not in text", "s-1", "cine", http.StatusBadRequest},
+		{"across sentences", "s-1", "mercado compro", http.StatusBadRequest},
+		{"too long", "s-1", "compro manzanas rojas y pan", http.StatusBadRequest},
+		{"empty", "s-1", " ¿? ", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		code, _ := postReading(t, h.Gloss, map[string]any{"session_id": c.session, "word": c.word})
+		assert.Equal(t, c.status, code, c.name)
+	}
+	assert.Equal(t, int32(0), calls.Load())
+}
+
+func TestReadingCheck_GradesAgainstStoredKey(t *testing.T) {
+	var calls atomic.Int32
+	h, sessions := newTestReadingHandler(t, &calls)
+
+	code, resp := postReading(t, h.Check, map[string]any{"session_id": "s-1", "question_index": 0, "answer": "manzanas"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, resp["correct"])
+	assert.Equal(t, float64(1), resp["answer"])
+	assert.Equal(t, "Compra manzanas.", resp["explanation"])
+
+	code, resp = postReading(t, h.Check, map[string]any{"session_id": "s-1", "question_index": 1, "answer": "sí"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, resp["correct"])
+
+	_, resp = postReading(t, h.Check, map[string]any{"session_id": "s-1", "question_index": 1, "answer": "no"})
+	assert.Equal(t, false, resp["correct"])
+	assert.Equal(t, true, resp["already_answered"])
+
+	code, _ = postReading(t, h.Check, map[string]any{"session_id": "s-1", "question_index": 2, "answer": "no"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// The first answer ended the reading time
+	session, err := sessions.Get(context.Background(), "user-1", "s-1")
+	require.NoError(t, err)
+	assert.False(t, session.ReadAt.IsZero())
+}
@@ -12,8 +12,11 @@ import (
 	"time"
 
 	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/difficulty"
+	"github.com/ailanguagetutor/grammar"
 	"github.com/ailanguagetutor/middleware"
 	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/textnorm"
 	"github.com/google/uuid"
 )
 
@@ -25,10 +28,11 @@ type SentenceHandler struct {
 	pool          *store.ItemPool
 	presenceStore *store.PresenceStore
 	cacheStore    *store.CacheStore
+	listStore     *store.WordListStore
 }
 
-func NewSentenceHandler(cfg *config.Config, us *store.UserStore, ps *store.StudentProfileStore, hs *store.ConversationHistoryStore, pool *store.ItemPool, presence *store.PresenceStore, cache *store.CacheStore) *SentenceHandler {
-	return &SentenceHandler{cfg: cfg, userStore: us, profileStore: ps, historyStore: hs, pool: pool, presenceStore: presence, cacheStore: cache}
+func NewSentenceHandler(cfg *config.Config, us *store.UserStore, ps *store.StudentProfileStore, hs *store.ConversationHistoryStore, pool *store.ItemPool, presence *store.PresenceStore, cache *store.CacheStore, lists *store.WordListStore) *SentenceHandler {
+	return &SentenceHandler{cfg: cfg, userStore: us, profileStore: ps, historyStore: hs, pool: pool, presenceStore: presence, cacheStore: cache, listStore: lists}
 }
 
 // ── Types ─────────────────────────────────────────────────────────────────────
@@ -38,6 +42,7 @@ type Sentence struct {
 	English    string `json:"english"`
 	Target     string `json:"target"`
 	GrammarTip string `json:"grammar_tip"`
+	Concept    string `json:"concept"` // grammar concept ID the sentence practises
 }
 
 type sentenceSessionRequest struct {
@@ -45,6 +50,7 @@ type sentenceSessionRequest struct {
 	Level        int    `json:"level"`
 	Topic        string `json:"topic"`
 	MistakesMode bool   `json:"mistakes_mode"`
+	ListID       string `json:"list_id"` // build sentences around a word list
 }
 
 type sentenceSessionResponse struct {
@@ -59,14 +65,17 @@ type sentenceCheckRequest struct {
 }
 
 type sentenceCheckResponse struct {
-	Correct   bool   `json:"correct"`
-	Feedback  string `json:"feedback"`
-	Corrected string `json:"corrected"`
+	Correct   bool          `json:"correct"`
+	Feedback  string        `json:"feedback"`
+	Corrected string        `json:"corrected"`
+	Mismatch  textnorm.Kind `json:"mismatch,omitempty"` // set when judged without the LLM
+	Concept   string        `json:"concept,omitempty"`  // grammar concept ID of the error, if any
 }
 
 type sentenceResult struct {
 	SentenceID string `json:"sentence_id"`
 	GrammarTip string `json:"grammar_tip"`
+	Concept    string `json:"concept"` // concept of the error, or of the sentence when correct
 	Correct    bool   `json:"correct"`
 }
 
@@ -75,6 +84,7 @@ type sentenceCompleteRequest struct {
 	Level     int              `json:"level"`
 	Topic     string           `json:"topic"`
 	TopicName string           `json:"topic_name"`
+	ListID    string           `json:"list_id"` // set when the session came from a word list
 	Results   []sentenceResult `json:"results"`
 }
 
@@ -117,11 +127,78 @@ func (h *SentenceHandler) Session(w http.ResponseWriter, r *http.Request) {
 
 	profile, _ := h.profileStore.Get(r.Context(), userID, req.Language)
 
-	// Mistakes mode: generate sentences exclusively from weak grammar patterns
+	// List mode: sentences that use words from a word list
+	if req.ListID != "" {
+		list := loadWordList(w, r, h.listStore, req.ListID, userID)
+		if list == nil {
+			return
+		}
+		if list.Language != req.Language {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "list is in a different language"})
+			return
+		}
+		words := sampleListWords(list, 15)
+		if len(words) == 0 {
+			writeJSON(w, http.StatusOK, map[string]any{
+				"sentences": []Sentence{},
+				"message":   "This list has no words yet.",
+			})
+			return
+		}
+		target := make([]string, 0, len(words))
+		for _, vw := range words {
+			target = append(target, vw.Word)
+		}
+		b, _ := json.Marshal(target)
+		prompt := fmt.Sprintf(`You are a language teacher creating translation exercises from a student's word list.
+Language: %s, Level: %s
+The student is learning these words: %s
+
+Generate exactly 10 English sentences for translation into %s. Each correct translation must use at least one word from the list (any inflected form); use as many different list words as possible.
+Return ONLY valid JSON — no markdown, no code fences, no explanation:
+{"sentences":[{"id":"...","english":"...","target":"...","grammar_tip":"...","concept":"..."},...]}
+Rules:
+- "id": copy the English sentence verbatim
+- "english": the English sentence the student will translate
+- "target": the correct %s translation
+- "grammar_tip": one concise grammar note about the key structure used
+- "concept": the ID of the grammar concept the sentence mainly practises, from this list:
+%s- match complexity to the level
+- Exactly 10 items`,
+			langName, spec, string(b), langName, langName, grammar.PromptList(req.Language))
+
+		result, err := h.callAI(r.Context(), prompt, 1200, 0.7)
+		if err != nil {
+			log.Printf("sentences/session list AI error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "AI service error"})
+			return
+		}
+		result = strings.TrimSpace(result)
+		if idx := strings.Index(result, "{"); idx > 0 {
+			result = result[idx:]
+		}
+		if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
+			result = result[:idx+1]
+		}
+		var parsed struct {
+			Sentences []Sentence `json:"sentences"`
+		}
+		if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+			log.Printf("sentences/session list JSON parse error: %v\nraw: %s", err, result)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to parse AI response"})
+			return
+		}
+		classifySentences(req.Language, parsed.Sentences)
+		store.Shuffle(parsed.Sentences)
+		writeJSON(w, http.StatusOK, sentenceSessionResponse{Sentences: parsed.Sentences})
+		return
+	}
+
+	// Mistakes mode: generate sentences exclusively from the weakest grammar concepts
 	if req.MistakesMode {
 		weakGrammar := []string{}
 		if profile != nil {
-			weakGrammar = profile.WeakGrammar
+			weakGrammar = grammar.Known(profile.WeakGrammar)
 		}
 		if len(weakGrammar) == 0 {
 			writeJSON(w, http.StatusOK, map[string]any{
@@ -131,24 +208,24 @@ func (h *SentenceHandler) Session(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		limit := weakGrammar
-		if len(limit) > 10 {
-			limit = limit[:10]
+		if len(limit) > 5 {
+			limit = limit[:5]
 		}
-		b, _ := json.Marshal(limit)
 		prompt := fmt.Sprintf(`You are a language teacher creating translation exercises targeting specific grammar weaknesses.
 Language: %s, Level: %s
-The student previously struggled with these grammar patterns: %s
-
-Generate exactly 10 English sentences for translation into %s that specifically target and practise EACH of these grammar patterns.
+The student previously struggled with these grammar concepts:
+%s
+Generate exactly 10 English sentences for translation into %s that specifically target and practise EACH of these grammar concepts.
 Return ONLY valid JSON — no markdown, no code fences, no explanation:
-{"sentences":[{"id":"...","english":"...","target":"...","grammar_tip":"..."},...]}
+{"sentences":[{"id":"...","english":"...","target":"...","grammar_tip":"...","concept":"..."},...]}
 Rules:
 - "id": copy the English sentence verbatim
 - "english": the English sentence the student will translate
 - "target": the correct %s translation
 - "grammar_tip": one concise note about the grammar pattern being practised (reference the weak area explicitly)
+- "concept": the ID of the concept above that the sentence practises
 - Exactly 10 items`,
-			langName, spec, string(b), langName, langName)
+			langName, spec, conceptList(limit), langName, langName)
 
 		result, err := h.callAI(r.Context(), prompt, 1200, 0.7)
 		if err != nil {
@@ -171,6 +248,7 @@ Rules:
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to parse AI response"})
 			return
 		}
+		classifySentences(req.Language, parsed.Sentences)
 		store.Shuffle(parsed.Sentences)
 		writeJSON(w, http.StatusOK, sentenceSessionResponse{Sentences: parsed.Sentences})
 		return
@@ -228,7 +306,12 @@ Rules:
 	}
 
 	var reinforceClause string
-	if profile != nil && len(profile.WeakAreas) > 0 {
+	if weak := profileWeakGrammar(profile); len(weak) > 0 {
+		if len(weak) > 5 {
+			weak = weak[:5]
+		}
+		reinforceClause = fmt.Sprintf("\n- TARGET these previously weak grammar concepts in some exercises:\n%s", conceptList(weak))
+	} else if profile != nil && len(profile.WeakAreas) > 0 {
 		weak := profile.WeakAreas
 		if len(weak) > 8 {
 			weak = weak[:8]
@@ -241,47 +324,58 @@ Rules:
 Language: %s, Topic: %s, Level: %s
 Generate exactly 10 English sentences for translation into %s.
 Return ONLY valid JSON — no markdown, no code fences, no explanation:
-{"sentences":[{"id":"...","english":"...","target":"...","grammar_tip":"..."},...]}
+{"sentences":[{"id":"...","english":"...","target":"...","grammar_tip":"...","concept":"..."},...]}
 Rules:
 - "id": copy the English sentence verbatim
 - "english": the English sentence the student will translate
 - "target": the correct %s translation
 - "grammar_tip": one concise grammar note about the key structure used (e.g. "uses subjunctive mood")
-- vary structures: include statements, questions, conditionals, and imperatives
+- "concept": the ID of the grammar concept the sentence mainly practises, from this list:
+%s- vary structures: include statements, questions, conditionals, and imperatives
 - match complexity to the level: %s%s%s
 - Exactly 10 items`,
-		langName, topicName, spec, langName, langName, spec, excludeClause, reinforceClause)
+		langName, topicName, spec, langName, langName, grammar.PromptList(req.Language), spec, excludeClause, reinforceClause)
 
-	result, err := h.callAI(r.Context(), prompt, 1200, 0.8)
-	if err != nil {
-		log.Printf("sentences/session AI error: %v", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "AI service error"})
-		return
-	}
+	generate := func() ([]Sentence, error) {
+		result, err := h.callAI(r.Context(), prompt, 1200, 0.8)
+		if err != nil {
+			log.Printf("sentences/session AI error: %v", err)
+			return nil, err
+		}
 
-	result = strings.TrimSpace(result)
-	if idx := strings.Index(result, "{"); idx > 0 {
-		result = result[idx:]
-	}
-	if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
-		result = result[:idx+1]
-	}
+		result = strings.TrimSpace(result)
+		if idx := strings.Index(result, "{"); idx > 0 {
+			result = result[idx:]
+		}
+		if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
+			result = result[:idx+1]
+		}
 
-	var parsed struct {
-		Sentences []Sentence `json:"sentences"`
+		var parsed struct {
+			Sentences []Sentence `json:"sentences"`
+		}
+		if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+			log.Printf("sentences/session JSON parse error: %v\nraw: %s", err, result)
+			return nil, errBadAIResponse
+		}
+		return parsed.Sentences, nil
 	}
-	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
-		log.Printf("sentences/session JSON parse error: %v\nraw: %s", err, result)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to parse AI response"})
+	sentences, score, fits, err := generateGraded("sentences/session", req.Level, generate, func(sentences []Sentence) (difficulty.Score, bool) {
+		return scoreSentences(req.Language, sentences)
+	})
+	if err != nil {
+		writeGenerationError(w, err)
 		return
 	}
 
-	// Cache the new list, then shuffle before returning
-	if raw, err := json.Marshal(parsed.Sentences); err == nil {
-		h.pool.Append(key, raw)
+	classifySentences(req.Language, sentences)
+
+	// Cache the new list if it is in level, then shuffle before returning
+	if raw, err := json.Marshal(sentences); err == nil && fits {
+		h.pool.AppendScored(key, raw, score)
 	}
-	store.Shuffle(parsed.Sentences)
-	writeJSON(w, http.StatusOK, sentenceSessionResponse{Sentences: parsed.Sentences})
+	store.Shuffle(sentences)
+	writeJSON(w, http.StatusOK, sentenceSessionResponse{Sentences: sentences})
 }
 
 // ── Check ─────────────────────────────────────────────────────────────────────
@@ -293,22 +387,32 @@ func (h *SentenceHandler) Check(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Answers matching the expected translation up to script or accents are
+	// judged here. Anything else goes to the LLM, which can tell whether it is
+	// still a valid translation: a "typo" or "article" slip across a whole
+	// sentence is often a real error (soy/estoy, comemos/comimos, el/la).
+	match := textnorm.Match(req.TargetExpected, req.UserAnswer, req.Language, textnorm.Standard)
+	switch match.Kind {
+	case textnorm.KindExact, textnorm.KindScript, textnorm.KindAccent:
+		writeJSON(w, http.StatusOK, sentenceCheckResult(req, match))
+		return
+	}
+
 	langName := LanguageName(req.Language)
 	prompt := fmt.Sprintf(`Evaluate this %s translation:
 English: "%s"
 Expected: "%s"
 Student: "%s"
-Reply ONLY with valid JSON: {"correct":true/false,"feedback":"grammar note if wrong, empty string if correct","corrected":"corrected form if wrong, empty string if correct"}
-Minor spelling variants are OK if grammatically equivalent.`,
-		langName, req.English, req.TargetExpected, req.UserAnswer)
+Reply ONLY with valid JSON: {"correct":true/false,"feedback":"grammar note if wrong, empty string if correct","corrected":"corrected form if wrong, empty string if correct","concept":"ID of the grammar concept of the main error if wrong, empty string if correct"}
+Minor spelling variants are OK if grammatically equivalent.
+Grammar concepts:
+%s`,
+		langName, req.English, req.TargetExpected, req.UserAnswer, grammar.PromptList(req.Language))
 
 	result, err := h.callAI(r.Context(), prompt, 200, 0.1)
 	if err != nil {
-		// Fallback: edit-distance check
-		answer := strings.ToLower(strings.TrimSpace(req.UserAnswer))
-		expected := strings.ToLower(strings.TrimSpace(req.TargetExpected))
-		correct := answer == expected || editDistance(answer, expected) <= 3
-		writeJSON(w, http.StatusOK, sentenceCheckResponse{Correct: correct})
+		// Fallback: deterministic match only, which accepts typos and articles
+		writeJSON(w, http.StatusOK, sentenceCheckResult(req, match))
 		return
 	}
 
@@ -322,16 +426,78 @@ Minor spelling variants are OK if grammatically equivalent.`,
 
 	var parsed sentenceCheckResponse
 	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
-		answer := strings.ToLower(strings.TrimSpace(req.UserAnswer))
-		expected := strings.ToLower(strings.TrimSpace(req.TargetExpected))
-		correct := answer == expected || editDistance(answer, expected) <= 3
-		writeJSON(w, http.StatusOK, sentenceCheckResponse{Correct: correct})
+		writeJSON(w, http.StatusOK, sentenceCheckResult(req, match))
 		return
 	}
+	if parsed.Correct {
+		parsed.Concept = ""
+	} else {
+		parsed.Concept = grammar.Classify(req.Language, parsed.Concept)
+	}
 
 	writeJSON(w, http.StatusOK, parsed)
 }
 
+// sentenceCheckResult builds a response from a deterministic match, noting
+// the kind of slip when the answer was accepted but not exact.
+func sentenceCheckResult(req sentenceCheckRequest, m textnorm.Result) sentenceCheckResponse {
+	res := sentenceCheckResponse{Correct: m.Correct, Mismatch: m.Kind}
+	switch m.Kind {
+	case textnorm.KindExact, textnorm.KindScript:
+		return res
+	case textnorm.KindAccent:
+		res.Feedback = "Watch the accents."
+		res.Concept = grammar.Spelling(req.Language)
+	case textnorm.KindArticle:
+		res.Feedback = "Check the articles."
+		res.Concept = grammar.Articles(req.Language)
+	case textnorm.KindTypo:
+		res.Feedback = "Check your spelling."
+		res.Concept = grammar.Spelling(req.Language)
+	}
+	res.Corrected = req.TargetExpected
+	return res
+}
+
+// profileWeakGrammar returns the profile's weak grammar concept IDs.
+func profileWeakGrammar(p *store.StudentProfile) []string {
+	if p == nil {
+		return nil
+	}
+	return grammar.Known(p.WeakGrammar)
+}
+
+// classifySentences maps each sentence's concept to the catalogue of lang,
+// so unknown IDs from the model are recorded as <lang>.other.
+func classifySentences(lang string, sentences []Sentence) {
+	for i := range sentences {
+		sentences[i].Concept = grammar.Classify(lang, sentences[i].Concept)
+	}
+}
+
+// conceptList formats grammar concept IDs for a prompt, one
+// "- id: name — description" line each.
+func conceptList(ids []string) string {
+	var b strings.Builder
+	for _, id := range ids {
+		if c, ok := grammar.Lookup(id); ok {
+			b.WriteString("- " + c.ID + ": " + c.Name + " — " + c.Description + "\n")
+		}
+	}
+	return b.String()
+}
+
+// recordSentenceConcepts counts each result as an error or success on its
+// grammar concept. Results without a concept (older clients) are skipped.
+func recordSentenceConcepts(p *store.StudentProfile, lang string, results []sentenceResult) {
+	for _, res := range results {
+		if res.Concept == "" {
+			continue
+		}
+		p.RecordGrammar(grammar.Classify(lang, res.Concept), res.Correct)
+	}
+}
+
 // ── Complete ──────────────────────────────────────────────────────────────────
 
 func (h *SentenceHandler) Complete(w http.ResponseWriter, r *http.Request) {
@@ -376,17 +542,20 @@ func (h *SentenceHandler) Complete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	profile.WeakAreas       = prependUnique(weakGrammar, profile.WeakAreas, 20)
-	profile.WeakGrammar     = prependUnique(weakGrammar, profile.WeakGrammar, 20)
+	recordSentenceConcepts(profile, req.Language, req.Results)
 	profile.RecentSentences = prependUnique(learnedIDs, profile.RecentSentences, 20)
 	profile.RecentTopics    = prependUnique([]string{req.TopicName}, profile.RecentTopics, 10)
 	profile.SessionCount++
 
-	// Advance the user's list index for this pool key
-	key := h.pool.Key(req.Language, req.Level, req.Topic)
-	if profile.SentenceListIdx == nil {
-		profile.SentenceListIdx = make(map[string]int)
+	// Advance the user's list index for this pool key (word-list sessions
+	// don't come from the pool)
+	if req.ListID == "" {
+		key := h.pool.Key(req.Language, req.Level, req.Topic)
+		if profile.SentenceListIdx == nil {
+			profile.SentenceListIdx = make(map[string]int)
+		}
+		profile.SentenceListIdx[key]++
 	}
-	profile.SentenceListIdx[key]++
 
 	if err := h.profileStore.Upsert(ctx, profile); err != nil {
 		log.Printf("sentences/complete Upsert error: %v", err)
@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkSentence(t *testing.T, h *handlers.SentenceHandler, expected, answer string) map[string]any {
+	t.Helper()
+	raw, _ := json.Marshal(map[string]string{"english": "-", "target_expected": expected, "user_answer": answer, "language": "es"})
+	w := httptest.NewRecorder()
+	h.Check(w, httptest.NewRequest(http.MethodPost, "/api/sentences/check", bytes.NewReader(raw)))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestSentenceCheck_NearMissesGoToTheModel(t *testing.T) {
+	var aiCalls atomic.Int32
+	ai := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		aiCalls.Add(1)
+		content, _ := json.Marshal(`{"correct":false,"feedback":"Wrong verb.","corrected":"-","concept":"es.ser-estar"}`)
+		fmt.Fprintf(w, `{"choices":[{"message":{"content":%s}}]}`, content)
+	}))
+	t.Cleanup(ai.Close)
+	h := handlers.NewSentenceHandler(&config.Config{IONOSBaseURL: ai.URL}, nil, nil, nil, nil, nil, nil, nil)
+
+	for _, tc := range []struct{ expected, answer string }{
+		{"Yo estoy cansado", "Yo soy cansado"},
+		{"Ella es alta", "Ella está alta"},
+		{"Nosotros comemos", "Nosotros comimos"},
+		{"La casa", "El casa"},
+	} {
+		calls := aiCalls.Load()
+		resp := checkSentence(t, h, tc.expected, tc.answer)
+		assert.Equal(t, false, resp["correct"], tc.answer)
+		assert.Equal(t, calls+1, aiCalls.Load(), "%s is judged by the model", tc.answer)
+	}
+
+	// Accents alone are still judged without the model
+	calls := aiCalls.Load()
+	resp := checkSentence(t, h, "Él está aquí", "El esta aqui")
+	assert.Equal(t, true, resp["correct"])
+	assert.Equal(t, "accent", resp["mismatch"])
+	assert.Equal(t, calls, aiCalls.Load())
+}
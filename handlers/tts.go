@@ -1,27 +1,51 @@
 package handlers
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/ailanguagetutor/config"
 	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/tts"
+	"github.com/go-chi/chi/v5"
 )
 
 type TTSHandler struct {
-	cfg    *config.Config
-	client *http.Client
+	cfg         *config.Config
+	tts         *tts.Service
+	renderer    *tts.Renderer
+	cache       *store.AudioCache
+	userStore   *store.UserStore
+	usageStore  *store.TTSUsageStore
+	rateLimiter *store.RateLimiter
 }
 
-func NewTTSHandler(cfg *config.Config) *TTSHandler {
+func NewTTSHandler(
+	cfg *config.Config,
+	svc *tts.Service,
+	renderer *tts.Renderer,
+	cache *store.AudioCache,
+	us *store.UserStore,
+	usage *store.TTSUsageStore,
+	rl *store.RateLimiter,
+) *TTSHandler {
 	return &TTSHandler{
-		cfg:    cfg,
-		client: &http.Client{Timeout: 30 * time.Second},
+		cfg:         cfg,
+		tts:         svc,
+		renderer:    renderer,
+		cache:       cache,
+		userStore:   us,
+		usageStore:  usage,
+		rateLimiter: rl,
 	}
 }
 
@@ -29,111 +53,488 @@ type ttsRequest struct {
 	Text        string  `json:"text"`
 	Language    string  `json:"language"`
 	Personality string  `json:"personality"` // optional; empty = language-default voice
+	VoiceID     string  `json:"voice_id"`    // optional; a voice from GET /api/tts/voices
 	Speed       float64 `json:"speed"`       // optional; 0 = default (treated as 1.0)
+	SSML        bool    `json:"ssml"`        // optional; text uses the markup subset in tts.ParseMarkup
 }
 
-type elevenLabsBody struct {
-	Text          string        `json:"text"`
-	ModelID       string        `json:"model_id"`
-	VoiceSettings voiceSettings `json:"voice_settings"`
-	Speed         float64       `json:"speed,omitempty"`
-}
-
-type voiceSettings struct {
-	Stability       float64 `json:"stability"`
-	SimilarityBoost float64 `json:"similarity_boost"`
-	Style           float64 `json:"style"`
-	UseSpeakerBoost bool    `json:"use_speaker_boost"`
+func (req ttsRequest) toTTS() tts.Request {
+	return tts.Request{
+		Text:        req.Text,
+		Language:    req.Language,
+		Personality: req.Personality,
+		VoiceID:     req.VoiceID,
+		Speed:       req.Speed,
+		Markup:      req.SSML,
+	}
 }
 
 func (h *TTSHandler) Convert(w http.ResponseWriter, r *http.Request) {
-	// Verify auth (context value set by middleware)
-	_ = r.Context().Value(middleware.UserIDKey)
+	userID := r.Context().Value(middleware.UserIDKey).(string)
 
 	var req ttsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
 		return
 	}
-	if req.Text == "" {
-		http.Error(w, `{"error":"text is required"}`, http.StatusBadRequest)
+	treq := req.toTTS()
+	if !h.admit(w, r, userID, treq) {
 		return
 	}
+	chars := utf8.RuneCountInString(tts.DisplayText(treq))
 
-	voiceID := h.cfg.VoiceForPersonality(req.Personality, req.Language)
-	speed := req.Speed
-	if speed == 0 {
-		speed = 1.0
+	routes := h.tts.Routes(treq)
+	if len(routes) == 0 {
+		http.Error(w, `{"error":"no voice available for this language"}`, http.StatusServiceUnavailable)
+		return
 	}
-	elBody := elevenLabsBody{
-		Text:    req.Text,
-		ModelID: h.cfg.ElevenLabsModel,
-		VoiceSettings: voiceSettings{
-			Stability:       0.5,
-			SimilarityBoost: 0.75,
-			Style:           0.0,
-			UseSpeakerBoost: true,
-		},
-		Speed: speed,
+
+	// Cache hit: the preferred provider rendered identical text/voice/settings
+	// before. Fallback renders are not looked up here, so the preferred
+	// provider gets another chance once it recovers.
+	if h.serveCached(w, r, routes[0].Key) {
+		h.record(userID, routes[0].Provider, chars, true)
+		return
 	}
 
-	bodyBytes, err := json.Marshal(elBody)
+	rsv, ok, err := h.reserve(r.Context(), userID, treq)
 	if err != nil {
-		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "user not found"})
 		return
 	}
+	// Out of allowance: only offline engines may render new audio
+	if !ok {
+		treq.Unmetered = true
+		if len(h.tts.Routes(treq)) == 0 {
+			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": errTTSAllowanceUsed.Error()})
+			return
+		}
+	}
 
-	url := fmt.Sprintf("https://api.elevenlabs.io/v1/text-to-speech/%s/stream", voiceID)
-	elReq, err := http.NewRequestWithContext(r.Context(), "POST", url, bytes.NewReader(bodyBytes))
+	// Detached from the client so a dropped connection still completes the
+	// (already billed) render and lands it in the cache.
+	res, err := h.tts.Synthesize(context.WithoutCancel(r.Context()), treq)
 	if err != nil {
-		http.Error(w, `{"error":"failed to build TTS request"}`, http.StatusInternalServerError)
+		h.settle(rsv, nil)
+		log.Printf("TTS error: %v", err)
+		http.Error(w, `{"error":"TTS service unavailable"}`, http.StatusBadGateway)
 		return
 	}
-	elReq.Header.Set("Content-Type", "application/json")
-	elReq.Header.Set("xi-api-key", h.cfg.ElevenLabsAPIKey)
-	elReq.Header.Set("Accept", "audio/mpeg")
+	defer res.Body.Close()
+	h.settle(rsv, res.Provider)
 
-	resp, err := h.client.Do(elReq)
+	w.Header().Set("Content-Type", res.ContentType)
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.Header().Set("X-TTS-Cache", "MISS")
+	w.Header().Set("X-TTS-Cache-Key", res.Key)
+	w.Header().Set("X-TTS-Provider", res.Provider.Name())
+
+	// Stream to the client and into the cache at the same time. The entry is
+	// only published if the whole upstream body was received.
+	cw, err := h.cache.Create(res.Key, res.ContentType)
 	if err != nil {
-		log.Printf("ElevenLabs request error: %v", err)
-		http.Error(w, `{"error":"TTS service error"}`, http.StatusBadGateway)
+		log.Printf("tts cache create error: %v", err)
+		_, _ = io.Copy(w, res.Body)
+		return
+	}
+	tee := &clientTee{cache: cw, client: w}
+	if _, err := io.Copy(tee, res.Body); err != nil {
+		cw.Abort()
+		return
+	}
+	if tee.cacheErr != nil {
+		log.Printf("tts cache write error: %v", tee.cacheErr)
+		cw.Abort()
+		return
+	}
+	if err := cw.Commit(); err != nil {
+		log.Printf("tts cache commit error: %v", err)
 		return
 	}
-	defer resp.Body.Close()
+	// Lets GET /api/tts/audio/{key}/alignment estimate timings for this clip
+	if err := h.renderer.Remember(res.Key, treq); err != nil {
+		log.Printf("tts recipe write error: %v", err)
+	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		log.Printf("ElevenLabs error %d: %s", resp.StatusCode, string(body))
-		http.Error(w, `{"error":"TTS service unavailable"}`, http.StatusBadGateway)
+type alignedResponse struct {
+	Key       string         `json:"key"`
+	AudioURL  string         `json:"audio_url"`
+	Alignment *tts.Alignment `json:"alignment"`
+}
+
+// Aligned is the timing-aware variant of Convert: it renders the clip into
+// the cache (using provider timestamps where available) and returns its URL
+// together with word and sentence timings for karaoke-style highlighting.
+func (h *TTSHandler) Aligned(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req ttsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	treq := req.toTTS()
+	if !h.admit(w, r, userID, treq) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "audio/mpeg")
-	w.Header().Set("Transfer-Encoding", "chunked")
-	_, _ = io.Copy(w, resp.Body)
-}
-
-func (h *TTSHandler) voiceFor(lang string) string {
-	switch lang {
-	case "it":
-		return h.cfg.ElevenLabsVoiceIT
-	case "es":
-		return h.cfg.ElevenLabsVoiceES
-	case "pt":
-		return h.cfg.ElevenLabsVoicePT
-	case "fr":
-		return h.cfg.ElevenLabsVoiceFR
-	case "de":
-		return h.cfg.ElevenLabsVoiceDE
-	case "ja":
-		return h.cfg.ElevenLabsVoiceJA
-	case "ru":
-		return h.cfg.ElevenLabsVoiceRU
-	case "ro":
-		return h.cfg.ElevenLabsVoiceRO
-	case "zh":
-		return h.cfg.ElevenLabsVoiceZH
-	default:
-		return h.cfg.ElevenLabsVoiceIT
+	routes := h.tts.Routes(treq)
+	if len(routes) == 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "no voice available for this language"})
+		return
+	}
+	// Out of allowance: only offline engines may render new audio. Decided
+	// before Prepare, so no billable recipe is stored for a lazy fetch.
+	rsv := reservation{userID: userID, chars: utf8.RuneCountInString(tts.DisplayText(treq))}
+	if f, err := h.cache.Open(routes[0].Key); err == nil {
+		f.Close()
+	} else {
+		var ok bool
+		if rsv, ok, err = h.reserve(r.Context(), userID, treq); err != nil {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "user not found"})
+			return
+		}
+		treq.Unmetered = !ok
+	}
+	key, err := h.renderer.Prepare(treq)
+	if err != nil {
+		h.settle(rsv, nil)
+		if treq.Unmetered {
+			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": errTTSAllowanceUsed.Error()})
+			return
+		}
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "no voice available for this language"})
+		return
+	}
+
+	// Detached from the client so a dropped connection still completes the
+	// (already billed) render and lands it in the cache.
+	ctx := context.WithoutCancel(r.Context())
+	rendered, err := h.renderer.Render(ctx, key)
+	if err != nil {
+		h.settle(rsv, nil)
+		log.Printf("TTS aligned error: %v", err)
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "TTS service unavailable"})
+		return
+	}
+	if rendered != nil {
+		h.settle(rsv, rendered.Provider)
+	} else {
+		h.settle(rsv, nil)
+		h.record(userID, routes[0].Provider, rsv.chars, true)
+	}
+	alignment, err := h.renderer.Alignment(ctx, key)
+	if err != nil {
+		log.Printf("TTS aligned error: %v", err)
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "TTS service unavailable"})
+		return
+	}
+	writeJSON(w, http.StatusOK, alignedResponse{Key: key, AudioURL: audioURL(key), Alignment: alignment})
+}
+
+// Alignment returns the word and sentence timings of a cached or prepared
+// clip, e.g. one streamed by Convert (see its X-TTS-Cache-Key header).
+// Prepared clips are rendered first and metered like Audio.
+func (h *TTSHandler) Alignment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	key := chi.URLParam(r, "key")
+	if !store.ValidAudioKey(key) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid audio key"})
+		return
+	}
+	err := h.renderLazily(r.Context(), userID, key)
+	var alignment *tts.Alignment
+	if err == nil {
+		alignment, err = h.renderer.Alignment(r.Context(), key)
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrAudioNotCached) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "audio not found"})
+			return
+		}
+		if errors.Is(err, errTTSRateLimited) || errors.Is(err, errTTSAllowanceUsed) {
+			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+			return
+		}
+		log.Printf("tts alignment %s: %v", key, err)
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "TTS service unavailable"})
+		return
+	}
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	writeJSON(w, http.StatusOK, alignment)
+}
+
+// ── Quotas ────────────────────────────────────────────────────────────────────
+
+// admit validates markup and enforces the per-user rate limit and maximum
+// text length. It writes the error response and returns false when the
+// request must be rejected. The allowance is checked by reserve once it is
+// known that the request needs synthesis.
+func (h *TTSHandler) admit(w http.ResponseWriter, r *http.Request, userID string, req tts.Request) bool {
+	if req.Markup {
+		if _, err := tts.ParseMarkup(req.Text); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return false
+		}
+	}
+	chars := utf8.RuneCountInString(strings.TrimSpace(tts.DisplayText(req)))
+	if chars == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "text is required"})
+		return false
+	}
+	if chars > h.cfg.TTSMaxChars {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{
+			"error": fmt.Sprintf("text too long (max %d characters)", h.cfg.TTSMaxChars),
+		})
+		return false
+	}
+	if !h.rateLimiter.Allow(r.Context(), "ratelimit:tts:"+userID, h.cfg.TTSRatePerMinute, time.Minute) {
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "too many TTS requests — slow down"})
+		return false
+	}
+	return true
+}
+
+// reservation is a synthesis charged to a user's allowance in advance.
+// provider is "" when nothing was reserved.
+type reservation struct {
+	userID   string
+	provider string
+	chars    int
+}
+
+// reserve charges the characters of req to userID's allowance before it is
+// synthesized, under the first metered provider req may be routed to. It
+// reports false when that would exceed the allowance, and only fails when the
+// user cannot be loaded. Requests that cannot be billed reserve nothing.
+func (h *TTSHandler) reserve(ctx context.Context, userID string, req tts.Request) (reservation, bool, error) {
+	rsv := reservation{userID: userID, chars: utf8.RuneCountInString(tts.DisplayText(req))}
+	provider := h.meteredProvider(req)
+	if provider == "" {
+		return rsv, true, nil
+	}
+	user, err := h.userStore.GetByID(userID)
+	if err != nil {
+		return rsv, false, err
+	}
+	ok, err := h.usageStore.Reserve(ctx, userID, provider, rsv.chars, user.TTSAllowance())
+	if err != nil {
+		log.Printf("tts usage reserve error: %v", err)
+		return rsv, true, nil // fail open, like the rate limiter
+	}
+	if ok {
+		rsv.provider = provider
+	}
+	return rsv, ok, nil
+}
+
+// settle meters a synthesis once it is known which provider produced the
+// clip (nil if none did). The reservation stands when its provider produced
+// the clip; otherwise it is refunded and the producing provider, e.g. a
+// fallback, is recorded instead.
+func (h *TTSHandler) settle(rsv reservation, p tts.Provider) {
+	if rsv.provider != "" && p != nil && p.Name() == rsv.provider {
+		return
+	}
+	if rsv.provider != "" {
+		if err := h.usageStore.Refund(context.Background(), rsv.userID, rsv.provider, rsv.chars); err != nil {
+			log.Printf("tts usage refund error: %v", err)
+		}
+	}
+	if p != nil {
+		h.record(rsv.userID, p, rsv.chars, false)
+	}
+}
+
+// Errors from renderLazily, answered with 429.
+var (
+	errTTSRateLimited   = errors.New("too many TTS requests — slow down")
+	errTTSAllowanceUsed = errors.New("TTS allowance used up — try again tomorrow")
+)
+
+// RenderFor renders a prepared clip queued by userID and meters it like a
+// clip rendered on first fetch. It is the renderer's background meter.
+func (h *TTSHandler) RenderFor(ctx context.Context, userID, key string) error {
+	return h.renderLazily(ctx, userID, key)
+}
+
+// renderLazily renders a prepared clip that is not in the cache yet and
+// meters the render against userID. A clip that may be billed is held to the
+// same rate limit and allowance as Convert.
+func (h *TTSHandler) renderLazily(ctx context.Context, userID, key string) error {
+	if f, err := h.cache.Open(key); err == nil {
+		f.Close()
+		return nil
+	}
+	req, err := h.renderer.Recipe(key)
+	if err != nil {
+		return err
+	}
+	if h.meteredProvider(req) != "" && !h.rateLimiter.Allow(ctx, "ratelimit:tts:"+userID, h.cfg.TTSRatePerMinute, time.Minute) {
+		return errTTSRateLimited
+	}
+	rsv, ok, err := h.reserve(ctx, userID, req)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errTTSAllowanceUsed
+	}
+	rendered, err := h.renderer.Render(ctx, key)
+	if rendered != nil {
+		h.settle(rsv, rendered.Provider)
+	} else {
+		h.settle(rsv, nil) // failed, or rendered by a concurrent call
+	}
+	return err
+}
+
+// meteredProvider returns the name of the first metered provider req may be
+// routed to, or "" if rendering req cannot be billed.
+func (h *TTSHandler) meteredProvider(req tts.Request) string {
+	for _, route := range h.tts.Routes(req) {
+		if tts.IsMetered(route.Provider) {
+			return route.Provider.Name()
+		}
+	}
+	return ""
+}
+
+// record meters one request. Cache hits and offline engines are not billed.
+func (h *TTSHandler) record(userID string, p tts.Provider, chars int, cached bool) {
+	provider := p.Name()
+	if cached {
+		provider = "cache"
+	}
+	billed := !cached && tts.IsMetered(p)
+	if err := h.usageStore.Record(context.Background(), userID, provider, chars, billed); err != nil {
+		log.Printf("tts usage record error: %v", err)
+	}
+}
+
+type ttsUsageResponse struct {
+	Allowance      store.TTSAllowance `json:"allowance"`
+	MaxChars       int                `json:"max_chars"`
+	RemainingDay   int                `json:"remaining_day_chars"`
+	RemainingMonth int                `json:"remaining_month_chars"`
+	*store.TTSUsage
+}
+
+// Usage returns the caller's allowance, remaining characters and a 30-day
+// per-provider breakdown.
+func (h *TTSHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	user, err := h.userStore.GetByID(userID)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "user not found"})
+		return
+	}
+	writeTTSUsage(w, r, h.usageStore, user, h.cfg.TTSMaxChars)
+}
+
+func writeTTSUsage(w http.ResponseWriter, r *http.Request, us *store.TTSUsageStore, user *store.User, maxChars int) {
+	usage, err := us.Usage(r.Context(), user.ID, 30)
+	if err != nil {
+		log.Printf("tts usage error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load usage"})
+		return
+	}
+	allowance := user.TTSAllowance()
+	writeJSON(w, http.StatusOK, ttsUsageResponse{
+		Allowance:      allowance,
+		MaxChars:       maxChars,
+		RemainingDay:   max(allowance.DailyChars-usage.DayBilled, 0),
+		RemainingMonth: max(allowance.MonthlyChars-usage.MonthBilled, 0),
+		TTSUsage:       usage,
+	})
+}
+
+// Voices returns the voice catalog of every configured provider, optionally
+// filtered with ?language=xx.
+func (h *TTSHandler) Voices(w http.ResponseWriter, r *http.Request) {
+	voices := h.tts.Voices(r.URL.Query().Get("language"))
+	if voices == nil {
+		voices = []tts.Voice{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"voices": voices})
+}
+
+// Audio serves a previously rendered clip by cache key. Unlike Convert it is
+// a GET, so <audio> elements can seek with Range requests. Prepared clips
+// (e.g. listening stories) that are not rendered yet are synthesized on demand
+// and metered against the caller.
+func (h *TTSHandler) Audio(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	key := chi.URLParam(r, "key")
+	if !store.ValidAudioKey(key) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid audio key"})
+		return
+	}
+	if h.serveCached(w, r, key) {
+		return
+	}
+	if err := h.renderLazily(r.Context(), userID, key); err != nil {
+		if errors.Is(err, store.ErrAudioNotCached) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "audio not found"})
+			return
+		}
+		if errors.Is(err, errTTSRateLimited) || errors.Is(err, errTTSAllowanceUsed) {
+			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+			return
+		}
+		log.Printf("tts lazy render %s: %v", key, err)
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "TTS service unavailable"})
+		return
+	}
+	if !h.serveCached(w, r, key) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "audio not found"})
+	}
+}
+
+// serveCached writes the cached clip for key with Content-Length and Range
+// support. Returns false on a cache miss without writing anything.
+func (h *TTSHandler) serveCached(w http.ResponseWriter, r *http.Request, key string) bool {
+	f, err := h.cache.Open(key)
+	if err != nil {
+		if err != store.ErrAudioNotCached {
+			log.Printf("tts cache open error: %v", err)
+		}
+		return false
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", f.ContentType)
+	w.Header().Set("X-TTS-Cache-Key", key)
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	w.Header().Set("X-TTS-Cache", "HIT")
+	http.ServeContent(w, r, "", f.Info.ModTime(), f)
+	return true
+}
+
+// clientTee writes every chunk to the cache and forwards it to the client.
+// The two sides fail independently: a client that disconnects does not stop
+// the cache write, and a failed cache write (the cache is best-effort) does
+// not cut off the client. The copy only stops once both have failed.
+type clientTee struct {
+	cache     io.Writer
+	client    http.ResponseWriter
+	cacheErr  error
+	clientErr error
+}
+
+func (t *clientTee) Write(p []byte) (int, error) {
+	if t.cacheErr == nil {
+		_, t.cacheErr = t.cache.Write(p)
+	}
+	if t.clientErr == nil {
+		if _, t.clientErr = t.client.Write(p); t.clientErr == nil {
+			if f, ok := t.client.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+	}
+	if t.cacheErr != nil && t.clientErr != nil {
+		return 0, t.cacheErr
 	}
+	return len(p), nil
 }
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingWriter accepts limit bytes and then fails, like a cache file on a
+// disk that has filled up.
+type failingWriter struct {
+	limit int
+	buf   bytes.Buffer
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.buf.Len()+len(p) > f.limit {
+		return 0, errors.New("no space left on device")
+	}
+	return f.buf.Write(p)
+}
+
+func TestClientTee_CacheFailureDoesNotTruncateClient(t *testing.T) {
+	body := bytes.Repeat([]byte("audio-chunk "), 10_000)
+	cache := &failingWriter{limit: 1024}
+	w := httptest.NewRecorder()
+
+	tee := &clientTee{cache: cache, client: w}
+	n, err := io.Copy(tee, bytes.NewReader(body))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(body)), n)
+	assert.Equal(t, body, w.Body.Bytes(), "the client receives the whole render")
+	assert.Error(t, tee.cacheErr, "the cache failure is recorded for the caller to abort the entry")
+}
@@ -0,0 +1,59 @@
+package handlers_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/tts"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// billedProvider is a metered provider that counts its renders.
+type billedProvider struct{ calls int }
+
+func (p *billedProvider) Name() string                        { return "elevenlabs" }
+func (p *billedProvider) Metered() bool                       { return true }
+func (p *billedProvider) CacheKey(tts.Request, tts.Voice) any { return nil }
+func (p *billedProvider) Voices() []tts.Voice {
+	return []tts.Voice{{ID: "rachel", Provider: "elevenlabs", Languages: []string{"it"}}}
+}
+
+func (p *billedProvider) Synthesize(_ context.Context, req tts.Request, _ tts.Voice) (*tts.Audio, error) {
+	p.calls++
+	return &tts.Audio{Body: io.NopCloser(strings.NewReader(req.Text)), ContentType: "audio/mpeg"}, nil
+}
+
+func TestTTSAudio_LazyRenderIsRateLimited(t *testing.T) {
+	provider := &billedProvider{}
+	svc := tts.NewServiceWith("", nil, provider)
+	cache := store.NewAudioCache(t.TempDir(), 0)
+	renderer := tts.NewRenderer(svc, cache, 0)
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	h := handlers.NewTTSHandler(&config.Config{TTSRatePerMinute: 0}, svc, renderer, cache, nil, nil, store.NewRateLimiter(rdb))
+
+	key, err := renderer.Prepare(tts.Request{Text: "C'era una volta", Language: "it"})
+	require.NoError(t, err)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("key", key)
+	ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, middleware.UserIDKey, "user-1")
+	req := httptest.NewRequest(http.MethodGet, "/api/tts/audio/"+key, nil).WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	h.Audio(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Zero(t, provider.calls, "nothing is billed past the rate limit")
+}
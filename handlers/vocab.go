@@ -4,16 +4,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/ailanguagetutor/anki"
 	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/difficulty"
+	"github.com/ailanguagetutor/lemma"
 	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/speech"
+	"github.com/ailanguagetutor/srs"
 	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/textnorm"
+	"github.com/ailanguagetutor/tts"
 	"github.com/google/uuid"
 )
 
@@ -25,10 +35,16 @@ type VocabHandler struct {
 	pool          *store.ItemPool
 	presenceStore *store.PresenceStore
 	cacheStore    *store.CacheStore
+	cardStore     *store.VocabCardStore
+	listStore     *store.WordListStore
+	practiceStore *store.PracticeStore
+	renderer      *tts.Renderer     // prepares dictation audio
+	recognizer    speech.Recognizer // nil when speech recognition is not configured
+	misspellings  *store.MisspellingStore
 }
 
-func NewVocabHandler(cfg *config.Config, us *store.UserStore, ps *store.StudentProfileStore, hs *store.ConversationHistoryStore, pool *store.ItemPool, presence *store.PresenceStore, cache *store.CacheStore) *VocabHandler {
-	return &VocabHandler{cfg: cfg, userStore: us, profileStore: ps, historyStore: hs, pool: pool, presenceStore: presence, cacheStore: cache}
+func NewVocabHandler(cfg *config.Config, us *store.UserStore, ps *store.StudentProfileStore, hs *store.ConversationHistoryStore, pool *store.ItemPool, presence *store.PresenceStore, cache *store.CacheStore, cards *store.VocabCardStore, lists *store.WordListStore, practice *store.PracticeStore, renderer *tts.Renderer, rec speech.Recognizer, ms *store.MisspellingStore) *VocabHandler {
+	return &VocabHandler{cfg: cfg, userStore: us, profileStore: ps, historyStore: hs, pool: pool, presenceStore: presence, cacheStore: cache, cardStore: cards, listStore: lists, practiceStore: practice, renderer: renderer, recognizer: rec, misspellings: ms}
 }
 
 // ── Types ─────────────────────────────────────────────────────────────────────
@@ -40,14 +56,18 @@ type VocabWord struct {
 }
 
 type vocabSessionRequest struct {
-	Language     string `json:"language"`
-	Level        int    `json:"level"`
-	Topic        string `json:"topic"`
-	MistakesMode bool   `json:"mistakes_mode"`
+	Language     string   `json:"language"`
+	Level        int      `json:"level"`
+	Topic        string   `json:"topic"`
+	MistakesMode bool     `json:"mistakes_mode"`
+	DeckMode     bool     `json:"deck_mode"` // practise words from the user's own deck (saved or imported)
+	ListID       string   `json:"list_id"`   // practise words from a word list
+	Exercises    []string `json:"exercises"` // exercise types to mix, e.g. ["recognition","cloze"]; default flashcards only
 }
 
 type vocabSessionResponse struct {
-	Words []VocabWord `json:"words"`
+	Words     []VocabWord     `json:"words"`
+	Exercises []VocabExercise `json:"exercises,omitempty"`
 }
 
 type vocabCheckRequest struct {
@@ -57,8 +77,17 @@ type vocabCheckRequest struct {
 }
 
 type vocabCheckResponse struct {
-	Correct  bool   `json:"correct"`
-	Feedback string `json:"feedback"`
+	Correct  bool          `json:"correct"`
+	Feedback string        `json:"feedback"`
+	Mismatch textnorm.Kind `json:"mismatch,omitempty"` // set when judged without the LLM
+}
+
+type vocabAudioCheckResponse struct {
+	Correct    bool               `json:"correct"`
+	Feedback   string             `json:"feedback"`
+	Score      int                `json:"score"`
+	Transcript string             `json:"transcript"`
+	Words      []speech.WordScore `json:"words"`
 }
 
 type wordResult struct {
@@ -72,6 +101,7 @@ type vocabCompleteRequest struct {
 	Level     int          `json:"level"`
 	Topic     string       `json:"topic"`
 	TopicName string       `json:"topic_name"`
+	ListID    string       `json:"list_id"` // set when the session came from a word list
 	Results   []wordResult `json:"results"`
 }
 
@@ -83,9 +113,28 @@ type vocabCompleteResponse struct {
 }
 
 type vocabWordResultRequest struct {
-	Word     string `json:"word"`
+	Word        string `json:"word"`
+	Language    string `json:"language"`
+	Correct     bool   `json:"correct"`
+	Grade       string `json:"grade"`       // optional "again"|"hard"|"good"|"easy"; default from correct
+	Translation string `json:"translation"` // stored on the review card when the word is new
+	Phonetic    string `json:"phonetic"`
+}
+
+type vocabReviewsRequest struct {
 	Language string `json:"language"`
-	Correct  bool   `json:"correct"`
+	Limit    int    `json:"limit"`
+}
+
+type vocabReviewsResponse struct {
+	Words []VocabWord      `json:"words"`
+	Stats *store.DeckStats `json:"stats"`
+}
+
+type vocabDeckRequest struct {
+	Language string   `json:"language"`
+	Words    []string `json:"words"`     // "word" or "word: meaning"
+	RecordID string   `json:"record_id"` // add the vocabulary of a conversation record instead
 }
 
 // ── Session ───────────────────────────────────────────────────────────────────
@@ -115,6 +164,10 @@ func (h *VocabHandler) Session(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "level must be 1-5"})
 		return
 	}
+	if !validExercises(req.Exercises) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown exercise type"})
+		return
+	}
 
 	langName := LanguageName(req.Language)
 	topicName, _ := TopicDetails(req.Topic)
@@ -129,12 +182,63 @@ func (h *VocabHandler) Session(w http.ResponseWriter, r *http.Request) {
 
 	profile, _ := h.profileStore.Get(r.Context(), userID, req.Language)
 
-	// Mistakes mode: generate flashcards exclusively from weak vocab
+	// List mode: flashcards from a word list the user created or was shared
+	if req.ListID != "" {
+		list := loadWordList(w, r, h.listStore, req.ListID, userID)
+		if list == nil {
+			return
+		}
+		if list.Language != req.Language {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "list is in a different language"})
+			return
+		}
+		words := sampleListWords(list, 12)
+		if len(words) == 0 {
+			writeJSON(w, http.StatusOK, map[string]any{
+				"words":   []VocabWord{},
+				"message": "This list has no words yet.",
+			})
+			return
+		}
+		if h.fillWordDetails(r.Context(), list.Language, words) {
+			saveListDetails(r.Context(), h.listStore, list, userID, words)
+		}
+		h.respondSession(w, r, userID, req, words)
+		return
+	}
+
+	// Deck mode: flashcards from the user's own deck, due cards first
+	if req.DeckMode {
+		cards, err := h.cardStore.Sample(r.Context(), userID, req.Language, time.Now(), 12)
+		if err != nil {
+			log.Printf("vocab/session deck Sample error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load deck"})
+			return
+		}
+		if len(cards) == 0 {
+			writeJSON(w, http.StatusOK, map[string]any{
+				"words":   []VocabWord{},
+				"message": "Your deck is empty. Save or import some words first!",
+			})
+			return
+		}
+		h.fillCardDetails(r.Context(), cards)
+		words := make([]VocabWord, 0, len(cards))
+		for _, c := range cards {
+			words = append(words, VocabWord{Word: c.Word, Translation: c.Translation, Phonetic: c.Phonetic})
+		}
+		h.respondSession(w, r, userID, req, words)
+		return
+	}
+
+	// Mistakes mode: generate flashcards exclusively from weak vocab and
+	// the words the user keeps misspelling
 	if req.MistakesMode {
-		weakVocab := []string{}
+		var weakVocab []string
 		if profile != nil {
 			weakVocab = profile.WeakVocab
 		}
+		weakVocab = prependUnique(misspelledWords(r.Context(), h.misspellings, userID, req.Language), weakVocab, 0)
 		if len(weakVocab) == 0 {
 			writeJSON(w, http.StatusOK, map[string]any{
 				"words":   []VocabWord{},
@@ -186,7 +290,7 @@ Rules:
 			return
 		}
 		store.Shuffle(parsed.Words)
-		writeJSON(w, http.StatusOK, vocabSessionResponse{Words: parsed.Words})
+		h.respondSession(w, r, userID, req, parsed.Words)
 		return
 	}
 
@@ -202,7 +306,8 @@ Rules:
 		var words []VocabWord
 		if err := json.Unmarshal(raw, &words); err == nil {
 			store.Shuffle(words)
-			writeJSON(w, http.StatusOK, vocabSessionResponse{Words: words})
+			words = h.withCoreWords(r.Context(), req.Language, req.Level, profile, words)
+			h.respondSession(w, r, userID, req, words)
 			return
 		}
 	}
@@ -224,21 +329,16 @@ Rules:
 		}
 		excludeWords = append(excludeWords, seen...)
 	}
-	// Deduplicate
-	seen := map[string]bool{}
-	deduped := excludeWords[:0]
-	for _, w := range excludeWords {
-		if !seen[w] {
-			seen[w] = true
-			deduped = append(deduped, w)
-		}
+	if profile != nil {
+		excludeWords = append(excludeWords, knownLemmas(profile, 100)...)
 	}
-	excludeWords = deduped
+	// Deduplicate by lemma, so inflected forms of one word count once
+	excludeWords = lemma.Unique(req.Language, excludeWords)
 
 	var excludeClause string
 	if len(excludeWords) > 0 {
 		seenJSON, _ := json.Marshal(excludeWords)
-		excludeClause = fmt.Sprintf("\n- Do NOT use any of these already-learned words: %s", string(seenJSON))
+		excludeClause = fmt.Sprintf("\n- Do NOT use any of these already-learned words, or any inflected form of them: %s", string(seenJSON))
 	}
 
 	var reinforceClause string
@@ -271,37 +371,46 @@ Rules:
 - Exactly 12 items`,
 		langName, topicName, spec, langName, langName, excludeClause, reinforceClause)
 
-	result, err := h.callAI(r.Context(), prompt, 900, 0.8)
-	if err != nil {
-		log.Printf("vocab/session AI error: %v", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "AI service error"})
-		return
-	}
+	generate := func() ([]VocabWord, error) {
+		result, err := h.callAI(r.Context(), prompt, 900, 0.8)
+		if err != nil {
+			log.Printf("vocab/session AI error: %v", err)
+			return nil, err
+		}
 
-	// Strip markdown fences if present
-	result = strings.TrimSpace(result)
-	if idx := strings.Index(result, "{"); idx > 0 {
-		result = result[idx:]
-	}
-	if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
-		result = result[:idx+1]
-	}
+		// Strip markdown fences if present
+		result = strings.TrimSpace(result)
+		if idx := strings.Index(result, "{"); idx > 0 {
+			result = result[idx:]
+		}
+		if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
+			result = result[:idx+1]
+		}
 
-	var parsed struct {
-		Words []VocabWord `json:"words"`
+		var parsed struct {
+			Words []VocabWord `json:"words"`
+		}
+		if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+			log.Printf("vocab/session JSON parse error: %v\nraw: %s", err, result)
+			return nil, errBadAIResponse
+		}
+		return parsed.Words, nil
 	}
-	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
-		log.Printf("vocab/session JSON parse error: %v\nraw: %s", err, result)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to parse AI response"})
+	generated, score, fits, err := generateGraded("vocab/session", req.Level, generate, func(words []VocabWord) (difficulty.Score, bool) {
+		return scoreVocab(req.Language, words)
+	})
+	if err != nil {
+		writeGenerationError(w, err)
 		return
 	}
 
-	// Cache the new list, then shuffle before returning
-	if raw, err := json.Marshal(parsed.Words); err == nil {
-		h.pool.Append(key, raw)
+	// Cache the new list if it is in level, then shuffle before returning
+	if raw, err := json.Marshal(generated); err == nil && fits {
+		h.pool.AppendScored(key, raw, score)
 	}
-	store.Shuffle(parsed.Words)
-	writeJSON(w, http.StatusOK, vocabSessionResponse{Words: parsed.Words})
+	store.Shuffle(generated)
+	words := h.withCoreWords(r.Context(), req.Language, req.Level, profile, generated)
+	h.respondSession(w, r, userID, req, words)
 }
 
 // ── Check ─────────────────────────────────────────────────────────────────────
@@ -317,6 +426,15 @@ func (h *VocabHandler) Check(w http.ResponseWriter, r *http.Request) {
 	// Strip orthographic punctuation (¿, ¡, etc.) — speech recognition never produces these.
 	cleanWord := strings.TrimSpace(stripOrthographic(req.Word))
 	cleanSpoken := strings.TrimSpace(stripOrthographic(req.Spoken))
+
+	// A match after normalisation (accents, kana, articles, a slip of the
+	// recogniser) needs no LLM; only a different word is worth a second opinion.
+	match := textnorm.Match(cleanWord, cleanSpoken, req.Language, textnorm.Lenient)
+	if match.Correct {
+		writeJSON(w, http.StatusOK, vocabCheckResponse{Correct: true, Mismatch: match.Kind})
+		return
+	}
+
 	prompt := fmt.Sprintf(`A language student was asked to pronounce the %s word "%s".
 Speech recognition captured: "%s".
 
@@ -329,15 +447,8 @@ Reply with ONLY valid JSON: {"correct": true/false, "feedback": "one short pronu
 
 	result, err := h.callAI(r.Context(), prompt, 100, 0.1)
 	if err != nil {
-		// Fallback: edit distance only (on cleaned strings)
-		spoken := strings.ToLower(cleanSpoken)
-		word := strings.ToLower(cleanWord)
-		threshold := 1
-		if len([]rune(word)) > 10 {
-			threshold = 2
-		}
-		correct := editDistance(spoken, word) <= threshold
-		writeJSON(w, http.StatusOK, vocabCheckResponse{Correct: correct, Feedback: ""})
+		// Fallback: deterministic match only
+		writeJSON(w, http.StatusOK, vocabCheckResponse{Correct: match.Correct, Mismatch: match.Kind})
 		return
 	}
 
@@ -351,21 +462,102 @@ Reply with ONLY valid JSON: {"correct": true/false, "feedback": "one short pronu
 
 	var parsed vocabCheckResponse
 	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
-		// Fallback: edit distance only (on cleaned strings)
-		spoken := strings.ToLower(cleanSpoken)
-		word := strings.ToLower(cleanWord)
-		threshold := 1
-		if len([]rune(word)) > 10 {
-			threshold = 2
-		}
-		correct := editDistance(spoken, word) <= threshold
-		writeJSON(w, http.StatusOK, vocabCheckResponse{Correct: correct, Feedback: ""})
+		// Fallback: deterministic match only
+		writeJSON(w, http.StatusOK, vocabCheckResponse{Correct: match.Correct, Mismatch: match.Kind})
 		return
 	}
 
 	writeJSON(w, http.StatusOK, parsed)
 }
 
+// ── Check (audio) ─────────────────────────────────────────────────────────────
+
+// maxAudioUpload caps recordings sent to CheckAudio; a spoken phrase is a few
+// seconds, well under this even uncompressed.
+const maxAudioUpload = 5 << 20
+
+// CheckAudio assesses a recorded pronunciation server-side. It takes a
+// multipart form with the recording in "audio", plus "word", "language" and
+// optionally "expected" (the full text to say; defaults to word).
+func (h *VocabHandler) CheckAudio(w http.ResponseWriter, r *http.Request) {
+	if h.recognizer == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "speech recognition is not available"})
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxAudioUpload)
+	if err := r.ParseMultipartForm(maxAudioUpload); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "recording too large"})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	word := strings.TrimSpace(r.FormValue("word"))
+	language := r.FormValue("language")
+	if word == "" || !IsValidLanguage(language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid word or language"})
+		return
+	}
+	expected := strings.TrimSpace(r.FormValue("expected"))
+	if expected == "" {
+		expected = word
+	}
+	file, hdr, err := r.FormFile("audio")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "audio file required"})
+		return
+	}
+	defer file.Close()
+
+	transcript, err := h.recognizer.Transcribe(r.Context(), file, speech.Options{
+		Language:    language,
+		ContentType: hdr.Header.Get("Content-Type"),
+	})
+	if errors.Is(err, speech.ErrNoSpeech) {
+		writeJSON(w, http.StatusOK, vocabAudioCheckResponse{Feedback: "We couldn't hear anything — try again a little closer to the microphone.", Words: []speech.WordScore{}})
+		return
+	}
+	if err != nil {
+		log.Printf("vocab/check-audio recognition error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "speech recognition failed"})
+		return
+	}
+
+	a := speech.Assess(stripOrthographic(expected), transcript)
+	writeJSON(w, http.StatusOK, vocabAudioCheckResponse{
+		Correct:    a.Correct,
+		Feedback:   audioFeedback(a),
+		Score:      a.Score,
+		Transcript: a.Transcript,
+		Words:      a.Words,
+	})
+}
+
+// audioFeedback names the words that need work, empty when all were good.
+func audioFeedback(a speech.Assessment) string {
+	var missed, unclear []string
+	for _, ws := range a.Words {
+		switch ws.Status {
+		case speech.StatusMissing:
+			missed = append(missed, ws.Word)
+		case speech.StatusWrong, speech.StatusClose:
+			unclear = append(unclear, fmt.Sprintf("%q (heard %q)", ws.Word, ws.Heard))
+		}
+	}
+	var parts []string
+	if len(unclear) > 0 {
+		parts = append(parts, "Work on "+strings.Join(unclear, ", ")+".")
+	}
+	if len(missed) > 0 {
+		parts = append(parts, "Don't skip "+strings.Join(missed, ", ")+".")
+	}
+	return strings.Join(parts, " ")
+}
+
 // ── Complete ──────────────────────────────────────────────────────────────────
 
 func (h *VocabHandler) Complete(w http.ResponseWriter, r *http.Request) {
@@ -412,15 +604,19 @@ func (h *VocabHandler) Complete(w http.ResponseWriter, r *http.Request) {
 	profile.WeakAreas    = prependUnique(weakWords, profile.WeakAreas, 20)
 	profile.WeakVocab    = prependUnique(weakWords, profile.WeakVocab, 30)
 	profile.RecentVocab  = prependUnique(learnedWords, profile.RecentVocab, 30)
+	recordLemmas(profile, learnedWords)
 	profile.RecentTopics = prependUnique([]string{req.TopicName}, profile.RecentTopics, 10)
 	profile.SessionCount++
 
-	// Advance the user's list index for this pool key
-	key := h.pool.Key(req.Language, req.Level, req.Topic)
-	if profile.VocabListIdx == nil {
-		profile.VocabListIdx = make(map[string]int)
+	// Advance the user's list index for this pool key (word-list sessions
+	// don't come from the pool)
+	if req.ListID == "" {
+		key := h.pool.Key(req.Language, req.Level, req.Topic)
+		if profile.VocabListIdx == nil {
+			profile.VocabListIdx = make(map[string]int)
+		}
+		profile.VocabListIdx[key]++
 	}
-	profile.VocabListIdx[key]++
 
 	if err := h.profileStore.Upsert(ctx, profile); err != nil {
 		log.Printf("vocab/complete Upsert error: %v", err)
@@ -492,29 +688,531 @@ func (h *VocabHandler) WordResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := context.Background()
-	profile, err := h.profileStore.Get(ctx, userID, req.Language)
+	grade := srs.Again
+	if req.Correct {
+		grade = srs.Good
+	}
+	if g, ok := srs.ParseGrade(req.Grade); ok {
+		grade = g
+	}
+	card, err := h.recordWordResult(context.Background(), userID, req.Language, req.Word, req.Correct, grade, req.Translation, req.Phonetic)
+	if err != nil {
+		log.Printf("vocab/word-result card Get error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load review card"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "next_review": card.Due.UTC().Format(time.RFC3339)})
+}
+
+// recordWordResult applies one word result to the student profile (recent,
+// weak and known words) and reschedules the word's review card, adding it to
+// the deck if new. It returns the updated card, or an error if the existing
+// card could not be loaded: rescheduling from a fresh card would wipe its
+// review history.
+func (h *VocabHandler) recordWordResult(ctx context.Context, userID, language, word string, correct bool, grade srs.Grade, translation, phonetic string) (*store.VocabCard, error) {
+	// Load the card first so a failed lookup changes nothing
+	card, err := h.cardStore.Get(ctx, userID, language, word)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := h.profileStore.Get(ctx, userID, language)
 	if err != nil || profile == nil {
 		profile = &store.StudentProfile{
 			UserID:   userID,
-			Language: req.Language,
+			Language: language,
 		}
 	}
 
-	if req.Correct {
-		profile.RecentVocab = prependUnique([]string{req.Word}, profile.RecentVocab, 30)
+	if correct {
+		profile.RecentVocab = prependUnique([]string{word}, profile.RecentVocab, 30)
+		recordLemmas(profile, []string{word})
 		// Remove from weak lists if the user now knows it
-		profile.WeakVocab = removeFromSlice(req.Word, profile.WeakVocab)
+		profile.WeakVocab = removeFromSlice(word, profile.WeakVocab)
 	} else {
-		profile.WeakVocab  = prependUnique([]string{req.Word}, profile.WeakVocab, 30)
-		profile.WeakAreas  = prependUnique([]string{req.Word}, profile.WeakAreas, 20)
+		profile.WeakVocab  = prependUnique([]string{word}, profile.WeakVocab, 30)
+		profile.WeakAreas  = prependUnique([]string{word}, profile.WeakAreas, 20)
 	}
 
 	if err := h.profileStore.Upsert(ctx, profile); err != nil {
 		log.Printf("vocab/word-result Upsert error: %v", err)
 	}
 
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	// Reschedule the word's review card, adding it to the deck if new
+	now := time.Now()
+	if card == nil {
+		card = &store.VocabCard{UserID: userID, Language: language, Word: word, Source: "vocab", State: srs.New(now)}
+	}
+	if card.Translation == "" {
+		card.Translation = translation
+	}
+	if card.Phonetic == "" {
+		card.Phonetic = phonetic
+	}
+	card.State = srs.Review(card.State, grade, now)
+	if err := h.cardStore.Save(ctx, card); err != nil {
+		log.Printf("vocab/word-result card Save error: %v", err)
+	}
+	return card, nil
+}
+
+// ── Spaced repetition ─────────────────────────────────────────────────────────
+
+// Reviews returns the user's due review cards as flashcards, most overdue
+// first. Results are reported through WordResult, which reschedules them.
+func (h *VocabHandler) Reviews(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req vocabReviewsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	if !IsValidLanguage(req.Language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid language"})
+		return
+	}
+	if req.Limit <= 0 || req.Limit > 30 {
+		req.Limit = 15
+	}
+
+	now := time.Now()
+	cards, err := h.cardStore.Due(r.Context(), userID, req.Language, now, req.Limit)
+	if err != nil {
+		log.Printf("vocab/reviews Due error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load reviews"})
+		return
+	}
+	h.fillCardDetails(r.Context(), cards)
+
+	stats, err := h.cardStore.Stats(r.Context(), userID, req.Language, now)
+	if err != nil {
+		log.Printf("vocab/reviews Stats error: %v", err)
+		stats = &store.DeckStats{}
+	}
+
+	if len(cards) > 0 {
+		_ = h.presenceStore.Set(r.Context(), userID, store.LessonPresence{
+			Type:      "vocab",
+			Language:  req.Language,
+			Topic:     "reviews",
+			StartedAt: now,
+		})
+	}
+
+	words := make([]VocabWord, 0, len(cards))
+	for _, c := range cards {
+		words = append(words, VocabWord{Word: c.Word, Translation: c.Translation, Phonetic: c.Phonetic})
+	}
+	writeJSON(w, http.StatusOK, vocabReviewsResponse{Words: words, Stats: stats})
+}
+
+// fillCardDetails asks the model for translations and phonetics of cards
+// that entered the deck without them (e.g. from conversation summaries) and
+// stores the answers, so each word is looked up only once.
+func (h *VocabHandler) fillCardDetails(ctx context.Context, cards []store.VocabCard) {
+	var missing []string
+	for _, c := range cards {
+		if c.Translation == "" || c.Phonetic == "" {
+			missing = append(missing, c.Word)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	details := h.wordDetails(ctx, cards[0].Language, missing)
+	for i := range cards {
+		c := &cards[i]
+		d, ok := details[strings.ToLower(c.Word)]
+		if !ok {
+			continue
+		}
+		if c.Translation == "" {
+			c.Translation = d.Translation
+		}
+		if c.Phonetic == "" {
+			c.Phonetic = d.Phonetic
+		}
+		if err := h.cardStore.Save(ctx, c); err != nil {
+			log.Printf("vocab/reviews card Save error: %v", err)
+		}
+	}
+}
+
+// fillWordDetails completes the translation and phonetic of words in place,
+// reporting whether any were looked up.
+func (h *VocabHandler) fillWordDetails(ctx context.Context, language string, words []VocabWord) bool {
+	var missing []string
+	for _, vw := range words {
+		if vw.Translation == "" || vw.Phonetic == "" {
+			missing = append(missing, vw.Word)
+		}
+	}
+	if len(missing) == 0 {
+		return false
+	}
+	details := h.wordDetails(ctx, language, missing)
+	for i := range words {
+		vw := &words[i]
+		d, ok := details[strings.ToLower(vw.Word)]
+		if !ok {
+			continue
+		}
+		if vw.Translation == "" {
+			vw.Translation = d.Translation
+		}
+		if vw.Phonetic == "" {
+			vw.Phonetic = d.Phonetic
+		}
+	}
+	return true
+}
+
+// wordDetails asks the model for flashcard details of words, keyed by the
+// lower-cased word. It returns nil if the model fails.
+func (h *VocabHandler) wordDetails(ctx context.Context, language string, words []string) map[string]VocabWord {
+	list, _ := json.Marshal(words)
+	prompt := fmt.Sprintf(`Give flashcard details for these %s words or phrases: %s
+
+Return ONLY valid JSON — no markdown, no code fences, no explanation:
+{"words":[{"word":"...","translation":"...","phonetic":"..."},...]}
+
+Rules:
+- "word": copied exactly from the list
+- "translation": concise English translation
+- "phonetic": English-syllable pronunciation guide with stressed syllable in CAPS`,
+		LanguageName(language), string(list))
+
+	result, err := h.callAI(ctx, prompt, 600, 0.2)
+	if err != nil {
+		log.Printf("vocab details AI error: %v", err)
+		return nil
+	}
+	result = strings.TrimSpace(result)
+	if idx := strings.Index(result, "{"); idx > 0 {
+		result = result[idx:]
+	}
+	if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
+		result = result[:idx+1]
+	}
+	var parsed struct {
+		Words []VocabWord `json:"words"`
+	}
+	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+		log.Printf("vocab details JSON parse error: %v\nraw: %s", err, result)
+		return nil
+	}
+	details := map[string]VocabWord{}
+	for _, vw := range parsed.Words {
+		details[strings.ToLower(vw.Word)] = vw
+	}
+	return details
+}
+
+// AddToDeck adds words to the user's review deck, either listed directly or
+// taken from the vocabulary of one of the user's conversation records.
+func (h *VocabHandler) AddToDeck(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req vocabDeckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+
+	source := "manual"
+	if req.RecordID != "" {
+		record, err := h.historyStore.GetRecord(req.RecordID)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "record not found"})
+			return
+		}
+		if record.UserID != userID {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+			return
+		}
+		req.Language = record.Language
+		req.Words = record.Vocabulary
+		source = "conversation"
+	}
+	if !IsValidLanguage(req.Language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid language"})
+		return
+	}
+	if len(req.Words) > 100 {
+		req.Words = req.Words[:100]
+	}
+
+	cards := make([]store.VocabCard, 0, len(req.Words))
+	for _, entry := range req.Words {
+		word, meaning := splitVocabEntry(entry)
+		cards = append(cards, store.VocabCard{UserID: userID, Language: req.Language, Word: word, Translation: meaning, Source: source})
+	}
+	added, err := h.cardStore.Add(r.Context(), cards)
+	if err != nil {
+		log.Printf("vocab/deck Add error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to add words"})
+		return
+	}
+	stats, err := h.cardStore.Stats(r.Context(), userID, req.Language, time.Now())
+	if err != nil {
+		log.Printf("vocab/deck Stats error: %v", err)
+		stats = &store.DeckStats{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"added": added, "stats": stats})
+}
+
+// DeckStats returns the size of the user's review deck and how many cards
+// are due (?language=it).
+func (h *VocabHandler) DeckStats(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	language := r.URL.Query().Get("language")
+	if !IsValidLanguage(language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "valid language param required"})
+		return
+	}
+	stats, err := h.cardStore.Stats(r.Context(), userID, language, time.Now())
+	if err != nil {
+		log.Printf("vocab/deck Stats error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load deck"})
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// ── Import / export ───────────────────────────────────────────────────────────
+
+// maxDeckUpload caps imported deck files; a few thousand notes without
+// media fit comfortably.
+const maxDeckUpload = 20 << 20
+
+// ImportDeck adds the notes of an Anki package (.apkg) or CSV file to the
+// user's review deck. It takes a multipart form with the file in "file",
+// "language", and optional "word_field", "translation_field" and
+// "phonetic_field" (field names or 1-based positions). Anki review cards
+// keep their schedule, and those that are dictionary words count as known
+// words. The readers cap note count and field lengths.
+func (h *VocabHandler) ImportDeck(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxDeckUpload)
+	if err := r.ParseMultipartForm(maxDeckUpload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid upload (max 20 MB)"})
+		return
+	}
+	language := r.FormValue("language")
+	if !IsValidLanguage(language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid language"})
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "file is required"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to read file"})
+		return
+	}
+
+	mapping := anki.Mapping{
+		Word:        r.FormValue("word_field"),
+		Translation: r.FormValue("translation_field"),
+		Phonetic:    r.FormValue("phonetic_field"),
+	}
+	source := "csv"
+	var notes []anki.Note
+	if strings.HasSuffix(strings.ToLower(header.Filename), ".apkg") || bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		source = "anki"
+		notes, err = anki.ReadPackage(data, mapping)
+	} else {
+		notes, err = anki.ReadCSV(bytes.NewReader(data), mapping)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	cards := make([]store.VocabCard, 0, len(notes))
+	var known []string
+	for _, n := range notes {
+		c := store.VocabCard{UserID: userID, Language: language, Word: n.Word, Translation: n.Translation, Phonetic: n.Phonetic, Source: source}
+		if n.Schedule != nil {
+			c.State = *n.Schedule
+			// Only dictionary words count as known: an imported deck can
+			// hold anything, and the profile should not fill with it.
+			if lemma.Lookup(language, n.Word) != nil {
+				known = append(known, n.Word)
+			}
+		}
+		cards = append(cards, c)
+	}
+	added, err := h.cardStore.Add(r.Context(), cards)
+	if err != nil {
+		log.Printf("vocab/import Add error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to import words"})
+		return
+	}
+
+	if len(known) > 0 {
+		ctx := context.Background()
+		profile, err := h.profileStore.Get(ctx, userID, language)
+		if err != nil || profile == nil {
+			profile = &store.StudentProfile{UserID: userID, Language: language}
+		}
+		recordLemmas(profile, known)
+		if err := h.profileStore.Upsert(ctx, profile); err != nil {
+			log.Printf("vocab/import profile upsert error: %v", err)
+		}
+	}
+
+	stats, err := h.cardStore.Stats(r.Context(), userID, language, time.Now())
+	if err != nil {
+		log.Printf("vocab/import Stats error: %v", err)
+		stats = &store.DeckStats{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"imported": len(notes), "added": added, "stats": stats})
+}
+
+// ExportDeck downloads the user's deck, weak words and conversation
+// vocabulary for a language (?language=it&format=csv|apkg). Words are
+// tagged "vocab", "weak" and "conversation" by where they came from.
+func (h *VocabHandler) ExportDeck(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	language := r.URL.Query().Get("language")
+	if !IsValidLanguage(language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "valid language param required"})
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "apkg" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be csv or apkg"})
+		return
+	}
+
+	cards, err := h.cardStore.List(r.Context(), userID, language)
+	if err != nil {
+		log.Printf("vocab/export List error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load deck"})
+		return
+	}
+
+	var notes []anki.Note
+	index := map[string]int{}
+	add := func(word, translation, phonetic, tag string, sched *srs.State) {
+		key := strings.ToLower(strings.TrimSpace(word))
+		if key == "" {
+			return
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(notes)
+			index[key] = i
+			notes = append(notes, anki.Note{Word: strings.TrimSpace(word)})
+		}
+		n := &notes[i]
+		if n.Translation == "" {
+			n.Translation = translation
+		}
+		if n.Phonetic == "" {
+			n.Phonetic = phonetic
+		}
+		if n.Schedule == nil {
+			n.Schedule = sched
+		}
+		if !slices.Contains(n.Tags, tag) {
+			n.Tags = append(n.Tags, tag)
+		}
+	}
+	for _, c := range cards {
+		var sched *srs.State
+		if !c.LastReview.IsZero() {
+			st := c.State
+			sched = &st
+		}
+		add(c.Word, c.Translation, c.Phonetic, "vocab", sched)
+	}
+	if profile, _ := h.profileStore.Get(r.Context(), userID, language); profile != nil {
+		for _, word := range profile.WeakVocab {
+			add(word, "", "", "weak", nil)
+		}
+	}
+	vocabulary, err := h.historyStore.VocabularyForUser(r.Context(), userID, language)
+	if err != nil {
+		log.Printf("vocab/export VocabularyForUser error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load deck"})
+		return
+	}
+	for _, entry := range vocabulary {
+		word, meaning := splitVocabEntry(entry)
+		add(word, meaning, "", "conversation", nil)
+	}
+
+	var buf bytes.Buffer
+	filename := "vocab-" + language + "." + format
+	if format == "apkg" {
+		err = anki.WritePackage(&buf, "AI Language Tutor::"+LanguageName(language), notes, time.Now())
+		w.Header().Set("Content-Type", "application/octet-stream")
+	} else {
+		err = anki.WriteCSV(&buf, notes)
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	}
+	if err != nil {
+		log.Printf("vocab/export %s error: %v", format, err)
+		w.Header().Del("Content-Type")
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to build export"})
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Write(buf.Bytes())
+}
+
+// splitVocabEntry splits conversation-summary entries of the form
+// "word: meaning" into word and meaning; plain words have no meaning.
+func splitVocabEntry(entry string) (word, meaning string) {
+	word, meaning, _ = strings.Cut(entry, ":")
+	return strings.TrimSpace(word), strings.TrimSpace(meaning)
+}
+
+// recordLemmas counts words as practised at the lemma level, so later
+// sessions treat "comí" and "comiendo" as the already-known "comer".
+// Conversation-summary entries ("word: meaning") are accepted.
+func recordLemmas(p *store.StudentProfile, words []string) {
+	if p.KnownLemmas == nil {
+		p.KnownLemmas = make(map[string]int)
+	}
+	for _, w := range words {
+		word, _ := splitVocabEntry(w)
+		if l := lemma.Of(p.Language, word); l != "" {
+			p.KnownLemmas[l]++
+		}
+	}
+}
+
+// knownLemmas returns up to limit of the profile's known lemmas, most
+// practised first.
+func knownLemmas(p *store.StudentProfile, limit int) []string {
+	out := make([]string, 0, len(p.KnownLemmas))
+	for l := range p.KnownLemmas {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ci, cj := p.KnownLemmas[out[i]], p.KnownLemmas[out[j]]
+		if ci != cj {
+			return ci > cj
+		}
+		return out[i] < out[j]
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
 }
 
 // removeFromSlice returns a new slice with the target string removed.
@@ -599,7 +1297,7 @@ func (h *VocabHandler) callAI(ctx context.Context, prompt string, maxTokens int,
 	return content, nil
 }
 
-// ── Edit distance (Levenshtein) fallback ──────────────────────────────────────
+// ── Orthographic punctuation ──────────────────────────────────────────────────
 
 // stripOrthographic removes punctuation that speech recognition never produces
 // (¿, ¡, ?, !, ., ,) so comparisons are not thrown off by Spanish inverted marks.
@@ -612,39 +1310,3 @@ func stripOrthographic(s string) string {
 		return r
 	}, s)
 }
-
-func editDistance(a, b string) int {
-	ra, rb := []rune(a), []rune(b)
-	la, lb := len(ra), len(rb)
-	dp := make([][]int, la+1)
-	for i := range dp {
-		dp[i] = make([]int, lb+1)
-		dp[i][0] = i
-	}
-	for j := 0; j <= lb; j++ {
-		dp[0][j] = j
-	}
-	for i := 1; i <= la; i++ {
-		for j := 1; j <= lb; j++ {
-			if ra[i-1] == rb[j-1] {
-				dp[i][j] = dp[i-1][j-1]
-			} else {
-				dp[i][j] = 1 + min3(dp[i-1][j], dp[i][j-1], dp[i-1][j-1])
-			}
-		}
-	}
-	return dp[la][lb]
-}
-
-func min3(a, b, c int) int {
-	if a < b {
-		if a < c {
-			return a
-		}
-		return c
-	}
-	if b < c {
-		return b
-	}
-	return c
-}
@@ -0,0 +1,61 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/speech"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func audioCheckRequest(t *testing.T, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		require.NoError(t, mw.WriteField(k, v))
+	}
+	fw, err := mw.CreateFormFile("audio", "attempt.webm")
+	require.NoError(t, err)
+	_, _ = fw.Write([]byte("not really audio"))
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/vocab/check-audio", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestCheckAudio_ScoresTranscript(t *testing.T) {
+	rec := &speech.Fake{Transcript: &speech.Transcript{
+		Text:  "buongiorno signora",
+		Words: []speech.Word{{Text: "buongiorno", Confidence: 0.95}, {Text: "signora", Confidence: 0.9}},
+	}}
+	h := handlers.NewVocabHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, rec, nil)
+
+	w := httptest.NewRecorder()
+	h.CheckAudio(w, audioCheckRequest(t, map[string]string{"word": "buongiorno", "language": "it", "expected": "Buongiorno, signora!"}))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Correct bool               `json:"correct"`
+		Score   int                `json:"score"`
+		Words   []speech.WordScore `json:"words"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Correct)
+	assert.GreaterOrEqual(t, resp.Score, 90)
+	assert.Len(t, resp.Words, 2)
+}
+
+func TestCheckAudio_Unavailable(t *testing.T) {
+	h := handlers.NewVocabHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	w := httptest.NewRecorder()
+	h.CheckAudio(w, audioCheckRequest(t, map[string]string{"word": "ciao", "language": "it"}))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
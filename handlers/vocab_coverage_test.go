@@ -0,0 +1,23 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverage_RejectsBadInput(t *testing.T) {
+	h := handlers.NewVocabHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	for _, query := range []string{"", "?language=xx", "?language=es&level=9"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/vocab/coverage"+query, nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+		w := httptest.NewRecorder()
+		h.Coverage(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
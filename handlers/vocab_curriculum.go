@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/ailanguagetutor/curriculum"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+)
+
+// coreWordsPerSession is how many words of a regular session are swapped for
+// the most frequent core words the user has not met yet at their level.
+const coreWordsPerSession = 4
+
+// coverageMilestones are the "top N words" figures coverage is reported for.
+var coverageMilestones = []int{100, 250, 500, 1000, 2000, 5000}
+
+type coverageMilestone struct {
+	Top   int `json:"top"`
+	Known int `json:"known"`
+}
+
+type coverageBand struct {
+	Band  string `json:"band"`
+	Total int    `json:"total"`
+	Known int    `json:"known"`
+}
+
+type vocabCoverageResponse struct {
+	Language   string              `json:"language"`
+	ListSize   int                 `json:"list_size"`
+	Known      int                 `json:"known"`
+	Milestones []coverageMilestone `json:"milestones"`
+	Bands      []coverageBand      `json:"bands"`
+	Next       []curriculum.Word   `json:"next"` // the next core words to learn at the level
+	Summary    string              `json:"summary"`
+}
+
+// ── Session ───────────────────────────────────────────────────────────────────
+
+// seenWords returns the words the user has met: known lemmas, recently
+// learned and weak words.
+func seenWords(language string, p *store.StudentProfile) *curriculum.Set {
+	s := curriculum.NewSet(language)
+	if p == nil {
+		return s
+	}
+	for l := range p.KnownLemmas {
+		s.Add(l)
+	}
+	for _, list := range [][]string{p.RecentVocab, p.WeakVocab} {
+		for _, w := range list {
+			word, _ := splitVocabEntry(w)
+			s.Add(word)
+		}
+	}
+	return s
+}
+
+// withCoreWords swaps up to coreWordsPerSession of a session's words for the
+// most frequent core words of the level the user has not met, so the most
+// useful words come first whatever the topic. Core words the model cannot
+// translate are left out.
+func (h *VocabHandler) withCoreWords(ctx context.Context, language string, level int, profile *store.StudentProfile, words []VocabWord) []VocabWord {
+	if !curriculum.Supported(language) {
+		return words
+	}
+	seen := seenWords(language, profile)
+	for _, vw := range words {
+		seen.Add(vw.Word)
+	}
+	next := curriculum.Next(language, level, coreWordsPerSession, seen)
+	if len(next) == 0 {
+		return words
+	}
+	core := h.coreWordDetails(ctx, language, next)
+	keep := max(len(words)-len(core), 0)
+	out := append(core, words[:keep]...)
+	store.Shuffle(out)
+	return out
+}
+
+// coreWordDetails makes flashcards of core words. Their translations and
+// phonetics are shared by all users, so they are cached once looked up.
+func (h *VocabHandler) coreWordDetails(ctx context.Context, language string, next []curriculum.Word) []VocabWord {
+	lemmas := make([]string, len(next))
+	for i, w := range next {
+		lemmas[i] = w.Lemma
+	}
+	cached := h.cacheStore.GetWordDetails(ctx, language, lemmas)
+	words := make([]VocabWord, len(lemmas))
+	for i, l := range lemmas {
+		d := cached[l]
+		words[i] = VocabWord{Word: l, Translation: d.Translation, Phonetic: d.Phonetic}
+	}
+	if h.fillWordDetails(ctx, language, words) {
+		fresh := make(map[string]store.WordDetails)
+		for _, vw := range words {
+			if vw.Translation != "" && vw.Phonetic != "" {
+				fresh[vw.Word] = store.WordDetails{Translation: vw.Translation, Phonetic: vw.Phonetic}
+			}
+		}
+		if err := h.cacheStore.SetWordDetails(ctx, language, fresh); err != nil {
+			log.Printf("vocab/session core details cache error: %v", err)
+		}
+	}
+	out := words[:0]
+	for _, vw := range words {
+		if vw.Translation != "" {
+			out = append(out, vw)
+		}
+	}
+	return out
+}
+
+// ── Coverage ──────────────────────────────────────────────────────────────────
+
+// Coverage reports how much of the language's core vocabulary the user
+// knows (?language=es, optional &level=1-5 for the next words to learn).
+func (h *VocabHandler) Coverage(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	language := r.URL.Query().Get("language")
+	if !IsValidLanguage(language) || !curriculum.Supported(language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "valid language param required"})
+		return
+	}
+	level := 5
+	if v := r.URL.Query().Get("level"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 5 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "level must be 1-5"})
+			return
+		}
+		level = n
+	}
+
+	profile, err := h.profileStore.Get(r.Context(), userID, language)
+	if err != nil {
+		log.Printf("vocab/coverage profile Get error: %v", err)
+	}
+	known := curriculum.NewSet(language)
+	if profile != nil {
+		for l := range profile.KnownLemmas {
+			known.Add(l)
+		}
+	}
+
+	words := curriculum.Words(language)
+	resp := vocabCoverageResponse{
+		Language: language,
+		ListSize: len(words),
+		Known:    curriculum.Coverage(language, len(words), known),
+		Next:     curriculum.Next(language, level, 10, seenWords(language, profile)),
+	}
+	for _, top := range coverageMilestones {
+		if top > len(words) {
+			break
+		}
+		resp.Milestones = append(resp.Milestones, coverageMilestone{Top: top, Known: curriculum.Coverage(language, top, known)})
+	}
+	for _, cw := range words {
+		if len(resp.Bands) == 0 || resp.Bands[len(resp.Bands)-1].Band != cw.Band {
+			resp.Bands = append(resp.Bands, coverageBand{Band: cw.Band})
+		}
+		b := &resp.Bands[len(resp.Bands)-1]
+		b.Total++
+		if known.Has(cw.Lemma) {
+			b.Known++
+		}
+	}
+	resp.Summary = fmt.Sprintf("You know ~%d of the %s most common %s words.", resp.Known, groupThousands(len(words)), LanguageName(language))
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// groupThousands formats n with comma thousands separators ("1,000").
+func groupThousands(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
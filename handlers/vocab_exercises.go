@@ -0,0 +1,446 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ailanguagetutor/lemma"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/srs"
+	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/textnorm"
+	"github.com/ailanguagetutor/tts"
+	"github.com/google/uuid"
+)
+
+// Exercise types selectable in vocabSessionRequest.Exercises. Flashcards are
+// spoken and checked by Check/CheckAudio; the others are checked by
+// CheckExercise against an answer key kept in the PracticeStore.
+const (
+	ExerciseFlashcard   = "flashcard"   // say the word
+	ExerciseRecognition = "recognition" // pick the meaning among distractors
+	ExerciseReverse     = "reverse"     // type the word from its meaning
+	ExerciseCloze       = "cloze"       // type the word into a sentence gap
+	ExerciseDictation   = "dictation"   // type the word from its audio
+	ExerciseMatching    = "matching"    // pair a group of words with their meanings
+)
+
+var exerciseTypes = map[string]bool{
+	ExerciseFlashcard: true, ExerciseRecognition: true, ExerciseReverse: true,
+	ExerciseCloze: true, ExerciseDictation: true, ExerciseMatching: true,
+}
+
+// matchingGroup is how many words a matching exercise pairs at most; fewer
+// than matchingMin left over are practised one by one instead.
+const (
+	matchingGroup = 5
+	matchingMin   = 3
+)
+
+// maxDictationRunes is the longest word or phrase dictated; longer entries
+// (e.g. sentences imported as deck cards) are practised in reverse instead.
+const maxDictationRunes = 60
+
+// mismatchForm marks a cloze answer that is the right word in the wrong
+// inflected form.
+const mismatchForm textnorm.Kind = "word_form"
+
+// ── Types ─────────────────────────────────────────────────────────────────────
+
+// VocabExercise is one exercise of a session, built from the session's
+// VocabWords. Answers are never included; flashcards have no ID.
+type VocabExercise struct {
+	ID      string   `json:"id,omitempty"`
+	Type    string   `json:"type"`
+	Prompt  string   `json:"prompt,omitempty"`  // word, meaning or cloze sentence ("___" marks the gap)
+	Hint    string   `json:"hint,omitempty"`    // English meaning of a cloze sentence
+	Audio   string   `json:"audio,omitempty"`   // dictation clip URL
+	Options []string `json:"options,omitempty"` // recognition choices
+	Words   []string `json:"words,omitempty"`   // matching: the words, in order
+	Matches []string `json:"matches,omitempty"` // matching: their meanings, shuffled
+}
+
+type vocabExerciseCheckRequest struct {
+	ExerciseID string            `json:"exercise_id"`
+	Answer     string            `json:"answer"`
+	Pairs      map[string]string `json:"pairs"` // matching: word -> chosen meaning
+}
+
+type vocabExerciseCheckResponse struct {
+	Correct  bool              `json:"correct"`
+	Feedback string            `json:"feedback"`
+	Expected string            `json:"expected,omitempty"`
+	Pairs    map[string]string `json:"pairs,omitempty"` // matching: the right pairs
+	Mismatch textnorm.Kind     `json:"mismatch,omitempty"`
+	Results  []wordResult      `json:"results"` // one per practised word, to send to vocab/complete
+}
+
+// validExercises reports whether every requested exercise type is known.
+func validExercises(types []string) bool {
+	for _, t := range types {
+		if !exerciseTypes[t] {
+			return false
+		}
+	}
+	return true
+}
+
+// ── Building ──────────────────────────────────────────────────────────────────
+
+// respondSession writes a session's words and, when exercise types were
+// requested, the exercises built from them.
+func (h *VocabHandler) respondSession(w http.ResponseWriter, r *http.Request, userID string, req vocabSessionRequest, words []VocabWord) {
+	resp := vocabSessionResponse{Words: words}
+	if len(req.Exercises) > 0 {
+		resp.Exercises = h.buildExercises(r.Context(), userID, req.Language, req.Level, words, req.Exercises)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// exerciseMaterial is what the model contributes to exercises: cloze
+// sentences and plausible wrong meanings for recognition.
+type exerciseMaterial struct {
+	Cloze []struct {
+		Word        string `json:"word"`
+		Sentence    string `json:"sentence"`
+		Answer      string `json:"answer"`
+		Translation string `json:"translation"`
+	} `json:"cloze"`
+	Distractors []struct {
+		Word    string   `json:"word"`
+		Options []string `json:"options"`
+	} `json:"distractors"`
+}
+
+// buildExercises assigns the requested types to the words in turn and
+// builds each exercise. A matching exercise takes a group of words. When a
+// type cannot be built for a word (no translation, no cloze sentence, no
+// audio, too long to dictate) the word falls back to reverse translation, then to a flashcard.
+func (h *VocabHandler) buildExercises(ctx context.Context, userID, language string, level int, words []VocabWord, types []string) []VocabExercise {
+	type plan struct {
+		typ   string
+		words []VocabWord
+	}
+	var plans []plan
+	var clozeWords []string
+	var recognitionWords []string
+	for i, t := 0, 0; i < len(words); t++ {
+		typ := types[t%len(types)]
+		if typ == ExerciseMatching && len(words)-i >= matchingMin {
+			n := min(matchingGroup, len(words)-i)
+			plans = append(plans, plan{typ, words[i : i+n]})
+			i += n
+			continue
+		}
+		if typ == ExerciseMatching {
+			typ = ExerciseReverse
+		}
+		switch typ {
+		case ExerciseCloze:
+			clozeWords = append(clozeWords, words[i].Word)
+		case ExerciseRecognition:
+			recognitionWords = append(recognitionWords, words[i].Word+": "+words[i].Translation)
+		}
+		plans = append(plans, plan{typ, words[i : i+1]})
+		i++
+	}
+
+	material := h.exerciseMaterial(ctx, language, level, clozeWords, recognitionWords)
+	cloze := map[string]int{}
+	for i, c := range material.Cloze {
+		cloze[strings.ToLower(c.Word)] = i
+	}
+	distractors := map[string][]string{}
+	for _, d := range material.Distractors {
+		distractors[strings.ToLower(d.Word)] = d.Options
+	}
+
+	var exercises []VocabExercise
+	for _, p := range plans {
+		vw := p.words[0]
+		ex := VocabExercise{Type: p.typ}
+		item := store.PracticeItem{Type: p.typ, Language: language, Pairs: map[string]string{}}
+		for _, pw := range p.words {
+			item.Words = append(item.Words, pw.Word)
+			item.Pairs[pw.Word] = pw.Translation
+		}
+
+		switch p.typ {
+		case ExerciseRecognition:
+			options := recognitionOptions(vw, distractors[strings.ToLower(vw.Word)], words)
+			if options == nil {
+				ex.Type = ExerciseReverse
+				break
+			}
+			ex.Prompt, ex.Options = vw.Word, options
+			item.Answers = []string{vw.Translation}
+		case ExerciseCloze:
+			i, ok := cloze[strings.ToLower(vw.Word)]
+			if !ok || !strings.Contains(material.Cloze[i].Sentence, "___") || material.Cloze[i].Answer == "" {
+				ex.Type = ExerciseReverse
+				break
+			}
+			c := material.Cloze[i]
+			ex.Prompt, ex.Hint = c.Sentence, c.Translation
+			item.Answers = []string{c.Answer}
+		case ExerciseDictation:
+			if utf8.RuneCountInString(vw.Word) > maxDictationRunes {
+				ex.Type = ExerciseReverse
+				break
+			}
+			key, err := h.dictationAudio(vw.Word, language, level)
+			if err != nil {
+				log.Printf("vocab/exercises dictation audio error: %v", err)
+				ex.Type = ExerciseReverse
+				break
+			}
+			ex.Audio = audioURL(key)
+			item.Answers = []string{vw.Word}
+		case ExerciseMatching:
+			for _, pw := range p.words {
+				if pw.Translation == "" {
+					ex.Type = ExerciseFlashcard
+				}
+				ex.Words = append(ex.Words, pw.Word)
+				ex.Matches = append(ex.Matches, pw.Translation)
+			}
+			store.Shuffle(ex.Matches)
+		}
+
+		if ex.Type == ExerciseReverse {
+			if vw.Translation == "" {
+				ex.Type = ExerciseFlashcard
+			} else {
+				ex = VocabExercise{Type: ExerciseReverse, Prompt: vw.Translation}
+				item.Type, item.Answers = ExerciseReverse, []string{vw.Word}
+			}
+		}
+		if ex.Type == ExerciseFlashcard {
+			for _, pw := range p.words {
+				exercises = append(exercises, VocabExercise{Type: ExerciseFlashcard, Prompt: pw.Word})
+			}
+			continue
+		}
+
+		ex.ID = uuid.New().String()
+		if err := h.practiceStore.Save(ctx, userID, ex.ID, item); err != nil {
+			log.Printf("vocab/exercises Save error: %v", err)
+			for _, pw := range p.words {
+				exercises = append(exercises, VocabExercise{Type: ExerciseFlashcard, Prompt: pw.Word})
+			}
+			continue
+		}
+		exercises = append(exercises, ex)
+	}
+	return exercises
+}
+
+// exerciseMaterial asks the model for cloze sentences and recognition
+// distractors in one call. On failure it returns no material and the
+// exercises fall back to other types.
+func (h *VocabHandler) exerciseMaterial(ctx context.Context, language string, level int, clozeWords, recognitionWords []string) exerciseMaterial {
+	var material exerciseMaterial
+	if len(clozeWords) == 0 && len(recognitionWords) == 0 {
+		return material
+	}
+	langName := LanguageName(language)
+	cw, _ := json.Marshal(clozeWords)
+	rw, _ := json.Marshal(recognitionWords)
+	prompt := fmt.Sprintf(`You are a language teacher preparing vocabulary exercises.
+Language: %s, Level: %s
+
+Cloze words: %s
+For each cloze word write one short, natural %s sentence at the student's level that uses the word, with the word replaced by "___". "answer" is the exact form of the word that fills the gap; "translation" is the English translation of the whole sentence.
+
+Recognition words (word: meaning): %s
+For each recognition word give 3 wrong English meanings a learner could plausibly confuse with the right one (same topic, look-alike words, false friends) but that are clearly wrong.
+
+Return ONLY valid JSON — no markdown, no code fences, no explanation:
+{"cloze":[{"word":"...","sentence":"...","answer":"...","translation":"..."}],"distractors":[{"word":"...","options":["...","...","..."]}]}
+
+Rules:
+- "word": copied exactly from the lists above
+- every cloze sentence contains "___" exactly once`,
+		langName, levelSpec[level], string(cw), langName, string(rw))
+
+	result, err := h.callAI(ctx, prompt, 1200, 0.5)
+	if err != nil {
+		log.Printf("vocab/exercises AI error: %v", err)
+		return material
+	}
+	result = strings.TrimSpace(result)
+	if idx := strings.Index(result, "{"); idx > 0 {
+		result = result[idx:]
+	}
+	if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
+		result = result[:idx+1]
+	}
+	if err := json.Unmarshal([]byte(result), &material); err != nil {
+		log.Printf("vocab/exercises JSON parse error: %v\nraw: %s", err, result)
+		return exerciseMaterial{}
+	}
+	return material
+}
+
+// recognitionOptions returns the right meaning of vw shuffled among up to
+// three distractors: the model's, topped up with the meanings of the
+// session's other words closest in length. It returns nil when vw has no
+// meaning or fewer than two distractors are available.
+func recognitionOptions(vw VocabWord, suggested []string, words []VocabWord) []string {
+	if vw.Translation == "" {
+		return nil
+	}
+	seen := map[string]bool{strings.ToLower(vw.Translation): true}
+	var wrong []string
+	add := func(s string) {
+		s = strings.TrimSpace(s)
+		if s != "" && !seen[strings.ToLower(s)] && len(wrong) < 3 {
+			seen[strings.ToLower(s)] = true
+			wrong = append(wrong, s)
+		}
+	}
+	for _, s := range suggested {
+		add(s)
+	}
+	others := make([]string, 0, len(words))
+	for _, o := range words {
+		others = append(others, o.Translation)
+	}
+	size := func(s string) int {
+		d := len(s) - len(vw.Translation)
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	sort.SliceStable(others, func(i, j int) bool { return size(others[i]) < size(others[j]) })
+	for _, s := range others {
+		add(s)
+	}
+	if len(wrong) < 2 {
+		return nil
+	}
+	options := append([]string{vw.Translation}, wrong...)
+	store.Shuffle(options)
+	return options
+}
+
+// dictationAudio prepares the clip of word read at the level's speed and
+// returns its cache key. The clip is not queued: it is rendered, and metered
+// against the learner, when the exercise first fetches it.
+func (h *VocabHandler) dictationAudio(word, language string, level int) (string, error) {
+	if h.renderer == nil {
+		return "", tts.ErrNoVoice
+	}
+	return h.renderer.Prepare(tts.Request{Text: word, Language: language, Speed: speedForLevel(level)})
+}
+
+// ── Checking ──────────────────────────────────────────────────────────────────
+
+// CheckExercise checks the answer to a generated exercise. The first attempt
+// at an exercise counts as the word's result for the profile and the review
+// deck, as WordResult does for flashcards; retries only get feedback.
+func (h *VocabHandler) CheckExercise(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req vocabExerciseCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ExerciseID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+
+	item, attempts, err := h.practiceStore.Attempt(r.Context(), userID, req.ExerciseID)
+	if err != nil {
+		log.Printf("vocab/exercise Attempt error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load exercise"})
+		return
+	}
+	if item == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "exercise not found or expired"})
+		return
+	}
+
+	resp := gradeExercise(item, req)
+	for i := range resp.Results {
+		res := &resp.Results[i]
+		res.Attempts = attempts
+		if attempts == 1 {
+			grade := srs.Again
+			if res.Correct {
+				grade = srs.Good
+			}
+			if _, err := h.recordWordResult(context.Background(), userID, item.Language, res.Word, res.Correct, grade, item.Pairs[res.Word], ""); err != nil {
+				log.Printf("vocab/exercise card Get error: %v", err)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// gradeExercise judges an answer against the exercise's answer key.
+// Typed answers are matched like typed sentences: accent, article and typo
+// slips count as correct but are pointed out.
+func gradeExercise(item *store.PracticeItem, req vocabExerciseCheckRequest) vocabExerciseCheckResponse {
+	var resp vocabExerciseCheckResponse
+	switch item.Type {
+	case ExerciseMatching:
+		right := 0
+		for _, word := range item.Words {
+			ok := strings.TrimSpace(req.Pairs[word]) == item.Pairs[word]
+			if ok {
+				right++
+			}
+			resp.Results = append(resp.Results, wordResult{Word: word, Correct: ok})
+		}
+		resp.Correct = right == len(item.Words)
+		resp.Pairs = item.Pairs
+		if resp.Correct {
+			resp.Feedback = "All pairs match!"
+		} else {
+			resp.Feedback = fmt.Sprintf("%d of %d pairs are right.", right, len(item.Words))
+		}
+		return resp
+
+	case ExerciseRecognition:
+		resp.Expected = item.Answers[0]
+		resp.Correct = strings.TrimSpace(req.Answer) == resp.Expected
+		if resp.Correct {
+			resp.Feedback = "Correct!"
+		} else {
+			resp.Feedback = fmt.Sprintf("Not quite — %q means %q.", item.Words[0], resp.Expected)
+		}
+
+	default: // reverse, cloze, dictation
+		m := textnorm.MatchAny(item.Answers, req.Answer, item.Language, textnorm.Standard)
+		resp.Expected = item.Answers[0]
+		resp.Correct = m.Correct
+		if m.Kind != textnorm.KindExact {
+			resp.Mismatch = m.Kind
+		}
+		switch m.Kind {
+		case textnorm.KindExact, textnorm.KindScript:
+			resp.Feedback = "Correct!"
+		case textnorm.KindAccent:
+			resp.Feedback = "Correct — watch the accents."
+		case textnorm.KindArticle:
+			resp.Feedback = "Correct — check the articles."
+		case textnorm.KindTypo:
+			resp.Feedback = "Almost — check your spelling."
+		default:
+			resp.Feedback = fmt.Sprintf("The answer is %q.", resp.Expected)
+			if item.Type == ExerciseCloze && strings.TrimSpace(req.Answer) != "" &&
+				lemma.Of(item.Language, req.Answer) == lemma.Of(item.Language, item.Words[0]) {
+				resp.Mismatch = mismatchForm
+				resp.Feedback = fmt.Sprintf("Right word, wrong form — the sentence needs %q.", resp.Expected)
+			}
+		}
+	}
+	resp.Results = []wordResult{{Word: item.Words[0], Correct: resp.Correct}}
+	return resp
+}
@@ -0,0 +1,105 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exerciseCheckResponse struct {
+	Correct  bool              `json:"correct"`
+	Feedback string            `json:"feedback"`
+	Expected string            `json:"expected"`
+	Mismatch string            `json:"mismatch"`
+	Pairs    map[string]string `json:"pairs"`
+	Results  []struct {
+		Word     string `json:"word"`
+		Correct  bool   `json:"correct"`
+		Attempts int    `json:"attempts"`
+	} `json:"results"`
+}
+
+// checkRetry saves an exercise, uses up its first attempt (which would
+// record word results) and checks body as a retry.
+func checkRetry(t *testing.T, item store.PracticeItem, body string) exerciseCheckResponse {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	ps := store.NewPracticeStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+	require.NoError(t, ps.Save(ctx, "user-1", "ex-1", item))
+	_, _, err := ps.Attempt(ctx, "user-1", "ex-1")
+	require.NoError(t, err)
+
+	h := handlers.NewVocabHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, ps, nil, nil, nil)
+	req := httptest.NewRequest(http.MethodPost, "/api/vocab/exercise", strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+	h.CheckExercise(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp exerciseCheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestCheckExercise_Reverse(t *testing.T) {
+	item := store.PracticeItem{Type: "reverse", Language: "es", Words: []string{"canción"}, Answers: []string{"canción"}}
+
+	resp := checkRetry(t, item, `{"exercise_id":"ex-1","answer":"cancion"}`)
+	assert.True(t, resp.Correct)
+	assert.Equal(t, "accent", resp.Mismatch)
+	require.Len(t, resp.Results, 1)
+	assert.Equal(t, 2, resp.Results[0].Attempts)
+
+	resp = checkRetry(t, item, `{"exercise_id":"ex-1","answer":"casa"}`)
+	assert.False(t, resp.Correct)
+	assert.Equal(t, "canción", resp.Expected)
+}
+
+func TestCheckExercise_ClozeWrongForm(t *testing.T) {
+	item := store.PracticeItem{Type: "cloze", Language: "es", Words: []string{"comer"}, Answers: []string{"comimos"}}
+
+	resp := checkRetry(t, item, `{"exercise_id":"ex-1","answer":"comieron"}`)
+	assert.False(t, resp.Correct)
+	assert.Equal(t, "word_form", resp.Mismatch)
+	assert.Contains(t, resp.Feedback, "comimos")
+}
+
+func TestCheckExercise_RecognitionAndMatching(t *testing.T) {
+	rec := store.PracticeItem{Type: "recognition", Language: "it", Words: []string{"cane"}, Answers: []string{"dog"}}
+	assert.True(t, checkRetry(t, rec, `{"exercise_id":"ex-1","answer":"dog"}`).Correct)
+	assert.False(t, checkRetry(t, rec, `{"exercise_id":"ex-1","answer":"cat"}`).Correct)
+
+	match := store.PracticeItem{Type: "matching", Language: "it", Words: []string{"cane", "gatto", "casa"},
+		Pairs: map[string]string{"cane": "dog", "gatto": "cat", "casa": "house"}}
+	resp := checkRetry(t, match, `{"exercise_id":"ex-1","pairs":{"cane":"dog","gatto":"house","casa":"cat"}}`)
+	assert.False(t, resp.Correct)
+	assert.Equal(t, "1 of 3 pairs are right.", resp.Feedback)
+	require.Len(t, resp.Results, 3)
+	assert.True(t, resp.Results[0].Correct)
+	assert.False(t, resp.Results[1].Correct)
+	assert.Equal(t, match.Pairs, resp.Pairs)
+}
+
+func TestCheckExercise_Unknown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ps := store.NewPracticeStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	h := handlers.NewVocabHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, ps, nil, nil, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/vocab/exercise", strings.NewReader(`{"exercise_id":"nope","answer":"x"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+	h.CheckExercise(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
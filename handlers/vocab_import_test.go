@@ -0,0 +1,61 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func importRequest(t *testing.T, language, filename string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("language", language))
+	fw, err := mw.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, _ = fw.Write(data)
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/vocab/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+}
+
+func TestImportDeck_RejectsBadInput(t *testing.T) {
+	// A package exported by recent Anki without the legacy collection.
+	var apkg bytes.Buffer
+	zw := zip.NewWriter(&apkg)
+	f, _ := zw.Create("collection.anki21b")
+	_, _ = f.Write([]byte("zstd"))
+	require.NoError(t, zw.Close())
+
+	cases := []struct {
+		name, language, filename string
+		data                     []byte
+		wantError                string
+	}{
+		{"invalid language", "xx", "deck.csv", []byte("casa,house\n"), "invalid language"},
+		{"empty csv", "es", "deck.csv", []byte("\n\n"), "no notes"},
+		{"new anki format", "es", "deck.apkg", apkg.Bytes(), "Support older Anki versions"},
+	}
+	h := handlers.NewVocabHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		h.ImportDeck(w, importRequest(t, c.language, c.filename, c.data))
+		assert.Equal(t, http.StatusBadRequest, w.Code, c.name)
+
+		var resp map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Contains(t, resp["error"], c.wantError, c.name)
+	}
+}
@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// WordListHandler serves user-created word lists. Lists belong to their
+// creator; private lists are visible to the owner only, "link" lists to
+// anyone who has the list id, and public lists are also browsable. Admins
+// may delete any list.
+type WordListHandler struct {
+	userStore    *store.UserStore
+	historyStore *store.ConversationHistoryStore
+	listStore    *store.WordListStore
+}
+
+func NewWordListHandler(us *store.UserStore, hs *store.ConversationHistoryStore, ls *store.WordListStore) *WordListHandler {
+	return &WordListHandler{userStore: us, historyStore: hs, listStore: ls}
+}
+
+const (
+	maxListsPerUser    = 100
+	maxListWords       = 500
+	maxListName        = 80
+	maxListDescription = 500
+	maxListWordRunes   = 100 // word and phonetic
+	maxListTranslation = 300
+	publicListsLimit   = 50
+)
+
+// ── Types ─────────────────────────────────────────────────────────────────────
+
+type wordListCreateRequest struct {
+	Name        string      `json:"name"`
+	Language    string      `json:"language"`
+	Description string      `json:"description"`
+	Visibility  string      `json:"visibility"` // "private" (default), "link" or "public"
+	Words       []VocabWord `json:"words"`
+}
+
+type wordListUpdateRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Visibility  *string `json:"visibility"`
+}
+
+type wordListWordsRequest struct {
+	Words    []VocabWord `json:"words"`
+	Entries  []string    `json:"entries"`   // "word" or "word: meaning"
+	RecordID string      `json:"record_id"` // add the vocabulary of a conversation record
+}
+
+// validateWordList checks the user-editable fields of a list.
+func validateWordList(name, description, visibility string) string {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return "name is required"
+	case utf8.RuneCountInString(name) > maxListName:
+		return "name is too long"
+	case utf8.RuneCountInString(description) > maxListDescription:
+		return "description is too long"
+	case visibility != store.ListPrivate && visibility != store.ListLink && visibility != store.ListPublic:
+		return "visibility must be private, link or public"
+	}
+	return ""
+}
+
+// validateListItem checks the field lengths of one list word; blank words
+// are skipped by the store.
+func validateListItem(it store.WordListItem) string {
+	switch {
+	case utf8.RuneCountInString(it.Word) > maxListWordRunes:
+		return "word is too long"
+	case utf8.RuneCountInString(it.Translation) > maxListTranslation:
+		return "translation is too long"
+	case utf8.RuneCountInString(it.Phonetic) > maxListWordRunes:
+		return "phonetic is too long"
+	}
+	return ""
+}
+
+// validateListItems checks each word of items.
+func validateListItems(items []store.WordListItem) string {
+	for _, it := range items {
+		if msg := validateListItem(it); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// ── CRUD ──────────────────────────────────────────────────────────────────────
+
+// List returns the caller's lists, or the public catalogue with
+// ?scope=public. ?language=it narrows either to one language.
+func (h *WordListHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	language := r.URL.Query().Get("language")
+	if language != "" && !IsValidLanguage(language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid language"})
+		return
+	}
+
+	var lists []store.WordList
+	var err error
+	if r.URL.Query().Get("scope") == "public" {
+		lists, err = h.listStore.ListPublic(r.Context(), language, publicListsLimit)
+	} else {
+		lists, err = h.listStore.ListByOwner(r.Context(), userID, language)
+	}
+	if err != nil {
+		log.Printf("lists/list error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load lists"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"lists": lists})
+}
+
+// Create makes a new list owned by the caller, optionally with words.
+func (h *WordListHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req wordListCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	if req.Visibility == "" {
+		req.Visibility = store.ListPrivate
+	}
+	if !IsValidLanguage(req.Language) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid language"})
+		return
+	}
+	if msg := validateWordList(req.Name, req.Description, req.Visibility); msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+		return
+	}
+	if len(req.Words) > maxListWords {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "too many words"})
+		return
+	}
+	items := listItems(req.Words)
+	if msg := validateListItems(items); msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+		return
+	}
+
+	list := &store.WordList{
+		ID:          uuid.New().String(),
+		OwnerID:     userID,
+		Name:        strings.TrimSpace(req.Name),
+		Language:    req.Language,
+		Description: strings.TrimSpace(req.Description),
+		Visibility:  req.Visibility,
+		Words:       items,
+	}
+	err := h.listStore.Create(r.Context(), list, maxListsPerUser)
+	if errors.Is(err, store.ErrListLimit) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "list limit reached"})
+		return
+	}
+	if err != nil {
+		log.Printf("lists/create error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create list"})
+		return
+	}
+	h.respondList(w, r, list.ID, http.StatusCreated)
+}
+
+// Get returns a list with its words, if the caller may see it.
+func (h *WordListHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	list := loadWordList(w, r, h.listStore, chi.URLParam(r, "id"), userID)
+	if list == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// Update changes the name, description or visibility of the caller's list.
+func (h *WordListHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req wordListUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	list := h.ownedList(w, r, userID, false)
+	if list == nil {
+		return
+	}
+	if req.Name != nil {
+		list.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		list.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Visibility != nil {
+		list.Visibility = *req.Visibility
+	}
+	if msg := validateWordList(list.Name, list.Description, list.Visibility); msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+		return
+	}
+	if err := h.listStore.Update(r.Context(), list); err != nil {
+		log.Printf("lists/update error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update list"})
+		return
+	}
+	h.respondList(w, r, list.ID, http.StatusOK)
+}
+
+// Delete removes a list; its owner or an admin may delete it.
+func (h *WordListHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	list := h.ownedList(w, r, userID, true)
+	if list == nil {
+		return
+	}
+	if err := h.listStore.Delete(r.Context(), list.ID); err != nil {
+		log.Printf("lists/delete error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete list"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ── Words ─────────────────────────────────────────────────────────────────────
+
+// AddWords adds words to the caller's list: flashcards, "word: meaning"
+// entries, or the vocabulary of one of the caller's conversation records.
+func (h *WordListHandler) AddWords(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req wordListWordsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	items := listItems(req.Words)
+	for _, entry := range req.Entries {
+		word, meaning := splitVocabEntry(entry)
+		if word != "" {
+			items = append(items, store.WordListItem{Word: word, Translation: meaning})
+		}
+	}
+	if msg := validateListItems(items); msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+		return
+	}
+	list := h.ownedList(w, r, userID, false)
+	if list == nil {
+		return
+	}
+
+	if req.RecordID != "" {
+		record, err := h.historyStore.GetRecord(req.RecordID)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "record not found"})
+			return
+		}
+		if record.UserID != userID {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+			return
+		}
+		if record.Language != list.Language {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "record is in a different language"})
+			return
+		}
+		// Conversation vocabulary is the model's; entries that do not fit
+		// a list are left out rather than failing the request.
+		for _, entry := range record.Vocabulary {
+			word, meaning := splitVocabEntry(entry)
+			if it := (store.WordListItem{Word: word, Translation: meaning}); validateListItem(it) == "" {
+				items = append(items, it)
+			}
+		}
+	}
+	if len(items) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "no words given"})
+		return
+	}
+	if list.WordCount >= maxListWords {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "list is full"})
+		return
+	}
+
+	// Words past the limit are dropped by the store
+	added, err := h.listStore.AddWords(r.Context(), list.ID, items, maxListWords)
+	if err != nil {
+		log.Printf("lists/words AddWords error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to add words"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"added": added})
+}
+
+// RemoveWord deletes one word from the caller's list.
+func (h *WordListHandler) RemoveWord(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	list := h.ownedList(w, r, userID, false)
+	if list == nil {
+		return
+	}
+	removed, err := h.listStore.RemoveWord(r.Context(), list.ID, chi.URLParam(r, "word"))
+	if err != nil {
+		log.Printf("lists/words RemoveWord error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to remove word"})
+		return
+	}
+	if !removed {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "word not in list"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "removed"})
+}
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+// ownedList loads the {id} list for a change by its owner (or, with
+// allowAdmin, by an admin). It writes the error response and returns nil
+// when the caller may not change it.
+func (h *WordListHandler) ownedList(w http.ResponseWriter, r *http.Request, userID string, allowAdmin bool) *store.WordList {
+	list := loadWordList(w, r, h.listStore, chi.URLParam(r, "id"), userID)
+	if list == nil {
+		return nil
+	}
+	if list.OwnerID == userID {
+		return list
+	}
+	if allowAdmin {
+		if u, err := h.userStore.GetByID(userID); err == nil && u.IsAdmin {
+			return list
+		}
+	}
+	writeJSON(w, http.StatusForbidden, map[string]string{"error": "only the owner can change this list"})
+	return nil
+}
+
+func (h *WordListHandler) respondList(w http.ResponseWriter, r *http.Request, id string, status int) {
+	list, err := h.listStore.Get(r.Context(), id)
+	if err != nil || list == nil {
+		log.Printf("lists Get error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load list"})
+		return
+	}
+	writeJSON(w, status, list)
+}
+
+// loadWordList fetches a list the user may view and practise. Lists the
+// user cannot see are reported as missing. It writes the error response and
+// returns nil on failure; vocab and sentence sessions share it.
+func loadWordList(w http.ResponseWriter, r *http.Request, ls *store.WordListStore, id, userID string) *store.WordList {
+	list, err := ls.Get(r.Context(), id)
+	if err != nil {
+		log.Printf("lists Get error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load list"})
+		return nil
+	}
+	if list == nil || !list.VisibleTo(userID) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "list not found"})
+		return nil
+	}
+	return list
+}
+
+func listItems(words []VocabWord) []store.WordListItem {
+	items := make([]store.WordListItem, 0, len(words))
+	for _, vw := range words {
+		items = append(items, store.WordListItem(vw))
+	}
+	return items
+}
+
+// sampleListWords returns up to n words of the list in random order.
+func sampleListWords(list *store.WordList, n int) []VocabWord {
+	words := make([]VocabWord, 0, len(list.Words))
+	for _, it := range list.Words {
+		words = append(words, VocabWord(it))
+	}
+	store.Shuffle(words)
+	if len(words) > n {
+		words = words[:n]
+	}
+	return words
+}
+
+// saveListDetails writes translations and phonetics looked up for list
+// words back to the list, so each word is looked up only once. AddWords
+// only fills fields that are still empty. Only the owner's sessions write:
+// a shared list is not changed by the people it is shared with.
+func saveListDetails(ctx context.Context, ls *store.WordListStore, list *store.WordList, userID string, words []VocabWord) {
+	if list.OwnerID != userID {
+		return
+	}
+	items := make([]store.WordListItem, 0, len(words))
+	for _, it := range listItems(words) {
+		if validateListItem(it) == "" {
+			items = append(items, it)
+		}
+	}
+	if _, err := ls.AddWords(ctx, list.ID, items, maxListWords); err != nil {
+		log.Printf("lists details save error: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWordList_RejectsBadInput(t *testing.T) {
+	cases := []struct {
+		name, body, wantError string
+	}{
+		{"invalid language", `{"name":"Kitchen","language":"xx"}`, "invalid language"},
+		{"missing name", `{"name":"  ","language":"es"}`, "name is required"},
+		{"long name", `{"name":"` + strings.Repeat("a", 81) + `","language":"es"}`, "name is too long"},
+		{"bad visibility", `{"name":"Kitchen","language":"es","visibility":"friends"}`, "visibility must be"},
+		{"long word", `{"name":"Kitchen","language":"es","words":[{"word":"` + strings.Repeat("a", 101) + `"}]}`, "word is too long"},
+		{"long translation", `{"name":"Kitchen","language":"es","words":[{"word":"mesa","translation":"` + strings.Repeat("t", 301) + `"}]}`, "translation is too long"},
+		{"long phonetic", `{"name":"Kitchen","language":"es","words":[{"word":"mesa","phonetic":"` + strings.Repeat("p", 101) + `"}]}`, "phonetic is too long"},
+	}
+	h := handlers.NewWordListHandler(nil, nil, nil)
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/lists", strings.NewReader(c.body))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+		w := httptest.NewRecorder()
+		h.Create(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, c.name)
+
+		var resp map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Contains(t, resp["error"], c.wantError, c.name)
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -28,6 +29,7 @@ type WritingHandler struct {
 	pool          *store.ItemPool
 	presenceStore *store.PresenceStore
 	cacheStore    *store.CacheStore
+	misspellings  misspellingRecorder
 }
 
 func NewWritingHandler(
@@ -39,6 +41,8 @@ func NewWritingHandler(
 	pool *store.ItemPool,
 	presence *store.PresenceStore,
 	cache *store.CacheStore,
+	ms *store.MisspellingStore,
+	cards *store.VocabCardStore,
 ) *WritingHandler {
 	return &WritingHandler{
 		cfg:           cfg,
@@ -49,6 +53,7 @@ func NewWritingHandler(
 		pool:          pool,
 		presenceStore: presence,
 		cacheStore:    cache,
+		misspellings:  misspellingRecorder{misspellings: ms, cards: cards},
 	}
 }
 
@@ -75,16 +80,10 @@ type writingMessageRequest struct {
 	Message   string `json:"message"`
 }
 
-type writingMessageResponse struct {
-	Reply        string   `json:"reply"`
-	Misspellings []string `json:"misspellings"`
-}
-
 type writingCompleteRequest struct {
-	SessionID    string   `json:"session_id"`
-	DurationSecs int      `json:"duration_secs"`
-	TopicName    string   `json:"topic_name"`
-	Misspellings []string `json:"misspellings"`
+	SessionID    string `json:"session_id"`
+	DurationSecs int    `json:"duration_secs"`
+	TopicName    string `json:"topic_name"`
 }
 
 type writingCompleteResponse struct {
@@ -127,21 +126,8 @@ func (h *WritingHandler) Session(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	u, err := h.userStore.GetByID(userID)
-	if err == nil {
-		if !u.HasConversationAccess() {
-			writeJSON(w, http.StatusForbidden, map[string]string{
-				"error": "Your subscription has ended. Please visit your profile to resubscribe.",
-				"code":  "subscription_ended",
-			})
-			return
-		}
-		if !u.HasFullAccess() && req.Level > 3 {
-			writeJSON(w, http.StatusForbidden, map[string]string{
-				"error": "Levels 4 and 5 require a full subscription.",
-			})
-			return
-		}
+	if !h.checkAccess(w, userID, req.Level) {
+		return
 	}
 
 	langName := LanguageName(req.Language)
@@ -192,15 +178,7 @@ Output ONLY the message text — no JSON, no quotes, no explanation.`,
 	}
 
 	// Build system prompt for the session
-	systemPrompt := fmt.Sprintf(
-		`You are a language tutor texting a student in %s about %s.
-Level: %s. Reply naturally in 1–3 sentences. Stay in %s only.
-Also check the student's LAST message for clear spelling errors.
-Return ONLY valid JSON (no markdown):
-{ "reply": "your reply in %s", "misspellings": ["wrong → correct (brief note)"] }
-If no misspellings, return empty array.`,
-		langName, topicName, spec, langName, langName,
-	)
+	systemPrompt := writingSystemPrompt(langName, topicName, spec)
 
 	session := h.sessionStore.Create(userID, req.Language, req.Topic, req.Level, "writing-coach", systemPrompt)
 	_ = h.sessionStore.AddMessage(session.ID, store.Message{Role: "assistant", Content: firstMessage})
@@ -222,8 +200,35 @@ If no misspellings, return empty array.`,
 	})
 }
 
+// checkAccess checks the user's subscription allows writing at level; it
+// returns false and writes 403 if not.
+func (h *WritingHandler) checkAccess(w http.ResponseWriter, userID string, level int) bool {
+	u, err := h.userStore.GetByID(userID)
+	if err != nil {
+		return true
+	}
+	if !u.HasConversationAccess() {
+		writeJSON(w, http.StatusForbidden, map[string]string{
+			"error": "Your subscription has ended. Please visit your profile to resubscribe.",
+			"code":  "subscription_ended",
+		})
+		return false
+	}
+	if !u.HasFullAccess() && level > 3 {
+		writeJSON(w, http.StatusForbidden, map[string]string{
+			"error": "Levels 4 and 5 require a full subscription.",
+		})
+		return false
+	}
+	return true
+}
+
 // ── Message ────────────────────────────────────────────────────────────────────
 
+// Message streams the tutor's reply over SSE as {"content"} chunks, while
+// the student's message is checked for misspellings separately; those are
+// sent as one {"misspellings"} event. A final {"done", "reply"} event follows
+// once both are saved to the session.
 func (h *WritingHandler) Message(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(middleware.UserIDKey).(string)
 
@@ -242,7 +247,7 @@ func (h *WritingHandler) Message(w http.ResponseWriter, r *http.Request) {
 	}
 
 	session, err := h.sessionStore.Get(req.SessionID)
-	if err != nil {
+	if err != nil || session.Personality == essayPersonality {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "session not found"})
 		return
 	}
@@ -255,11 +260,6 @@ func (h *WritingHandler) Message(w http.ResponseWriter, r *http.Request) {
 
 	msgs, _ := h.sessionStore.GetMessages(req.SessionID)
 
-	// Build messages for AI: system prompt + all conversation messages
-	aiMessages := make([]store.Message, 0, len(session.Messages))
-	aiMessages = append(aiMessages, session.Messages[0]) // system prompt
-	aiMessages = append(aiMessages, msgs...)
-
 	langName := LanguageName(session.Language)
 	topicName, _ := TopicDetails(session.Topic)
 	spec := levelSpec[session.Level]
@@ -267,28 +267,95 @@ func (h *WritingHandler) Message(w http.ResponseWriter, r *http.Request) {
 		spec = "INTERMEDIATE (B1)"
 	}
 
-	// Override the system message to include full instructions
-	aiMessages[0] = store.Message{
-		Role: "system",
-		Content: fmt.Sprintf(
-			`You are a language tutor texting a student in %s about %s.
-Level: %s. Reply naturally in 1–3 sentences. Stay in %s only.
-Also check the student's LAST message for clear spelling errors.
-Return ONLY valid JSON (no markdown):
-{ "reply": "your reply in %s", "misspellings": ["wrong → correct (brief note)"] }
-If no misspellings, return empty array.`,
-			langName, topicName, spec, langName, langName,
-		),
-	}
+	// Build messages for AI: system prompt + all conversation messages
+	aiMessages := make([]store.Message, 0, len(msgs)+1)
+	aiMessages = append(aiMessages, store.Message{Role: "system", Content: writingSystemPrompt(langName, topicName, spec)})
+	aiMessages = append(aiMessages, msgs...)
 
-	result, err := h.callAIMessages(r.Context(), aiMessages, 400, 0.75)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	send := func(event any) {
+		data, _ := json.Marshal(event)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+
+	// Misspellings are checked alongside the streamed reply and sent as soon
+	// as they are ready
+	checked := make(chan []string, 1)
+	go func() {
+		checked <- h.checkMisspellings(r.Context(), session.Language, req.Message)
+	}()
+	var misspellings []string
+	sent := false
+	sendMisspellings := func(m []string) {
+		misspellings, sent = m, true
+		send(map[string][]string{"misspellings": m})
+	}
+
+	reply, err := h.streamAIMessages(r.Context(), aiMessages, 400, 0.75, func(content string) {
+		send(map[string]string{"content": content})
+		if !sent {
+			select {
+			case m := <-checked:
+				sendMisspellings(m)
+			default:
+			}
+		}
+	})
 	if err != nil {
 		log.Printf("writing/message AI error: %v", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "AI service error"})
+	}
+	reply = strings.TrimSpace(reply)
+	if reply == "" {
+		send(map[string]string{"error": "AI service error"})
 		return
 	}
+	if !sent {
+		sendMisspellings(<-checked)
+	}
+
+	_ = h.sessionStore.AddMessage(req.SessionID, store.Message{Role: "assistant", Content: reply})
+	if err := h.sessionStore.AddMisspellings(req.SessionID, misspellings); err != nil {
+		log.Printf("writing/message AddMisspellings error: %v", err)
+	}
 
-	// Parse JSON response
+	send(map[string]any{"done": true, "reply": reply})
+}
+
+// writingSystemPrompt is the tutor's instructions for a writing session.
+func writingSystemPrompt(langName, topicName, spec string) string {
+	return fmt.Sprintf(
+		`You are a language tutor texting a student in %s about %s.
+Level: %s. Reply naturally in 1–3 sentences. Stay in %s only.
+Output ONLY the message text — no JSON, no quotes, no corrections.`,
+		langName, topicName, spec, langName,
+	)
+}
+
+// checkMisspellings returns the clear spelling errors in a student's
+// message as "wrong → correct (note)", or none if the check fails.
+func (h *WritingHandler) checkMisspellings(ctx context.Context, language, message string) []string {
+	prompt := fmt.Sprintf(`Check this %s message written by a language student for clear spelling errors (including missing or wrong accents). Ignore grammar, style and casual punctuation.
+Return ONLY valid JSON (no markdown):
+{ "misspellings": ["wrong → correct (brief note)"] }
+If there are no misspellings, return an empty array.
+
+Message: %s`, LanguageName(language), message)
+
+	result, err := h.callAI(ctx, prompt, 300, 0.1)
+	if err != nil {
+		log.Printf("writing/message misspelling check error: %v", err)
+		return []string{}
+	}
 	result = strings.TrimSpace(result)
 	if idx := strings.Index(result, "{"); idx > 0 {
 		result = result[idx:]
@@ -296,26 +363,20 @@ If no misspellings, return empty array.`,
 	if idx := strings.LastIndex(result, "}"); idx >= 0 && idx < len(result)-1 {
 		result = result[:idx+1]
 	}
-
 	var parsed struct {
-		Reply        string   `json:"reply"`
 		Misspellings []string `json:"misspellings"`
 	}
 	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
-		// Fallback: treat raw content as reply
-		parsed.Reply = strings.TrimSpace(result)
-		parsed.Misspellings = []string{}
+		log.Printf("writing/message misspelling parse error: %v — raw: %s", err, result)
+		return []string{}
 	}
-	if parsed.Misspellings == nil {
-		parsed.Misspellings = []string{}
+	out := []string{}
+	for _, m := range parsed.Misspellings {
+		if strings.Contains(m, "→") {
+			out = append(out, strings.TrimSpace(m))
+		}
 	}
-
-	_ = h.sessionStore.AddMessage(req.SessionID, store.Message{Role: "assistant", Content: parsed.Reply})
-
-	writeJSON(w, http.StatusOK, writingMessageResponse{
-		Reply:        parsed.Reply,
-		Misspellings: parsed.Misspellings,
-	})
+	return out
 }
 
 // ── Complete ───────────────────────────────────────────────────────────────────
@@ -330,7 +391,7 @@ func (h *WritingHandler) Complete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	session, err := h.sessionStore.Get(req.SessionID)
-	if err != nil {
+	if err != nil || session.Personality == essayPersonality {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "session not found"})
 		return
 	}
@@ -338,6 +399,16 @@ func (h *WritingHandler) Complete(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
 		return
 	}
+	first, err := h.sessionStore.Claim(session.ID, "completed")
+	if err != nil {
+		log.Printf("writing/complete error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not complete session"})
+		return
+	}
+	if !first {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "session already completed"})
+		return
+	}
 
 	msgs, _ := h.sessionStore.GetMessages(req.SessionID)
 	userMsgCount := 0
@@ -369,8 +440,10 @@ func (h *WritingHandler) Complete(w http.ResponseWriter, r *http.Request) {
 		totalFP = u.TotalFP
 	}
 
-	if req.Misspellings == nil {
-		req.Misspellings = []string{}
+	// Misspellings are recorded in the session as they are found
+	misspellings := session.Misspellings
+	if misspellings == nil {
+		misspellings = []string{}
 	}
 	if newBadges == nil {
 		newBadges = []string{}
@@ -393,11 +466,12 @@ func (h *WritingHandler) Complete(w http.ResponseWriter, r *http.Request) {
 		Vocabulary:   summaryRes.Vocabulary,
 		Corrections:  summaryRes.Corrections,
 		Suggestions:  summaryRes.Suggestions,
-		Misspellings: req.Misspellings,
+		Misspellings: misspellings,
 		CreatedAt:    session.CreatedAt,
 		EndedAt:      time.Now(),
 	}
 	h.historyStore.Save(record)
+	h.misspellings.record(r.Context(), userID, session.Language, session.ID, store.MisspellingWriting, parseMisspellings(misspellings))
 
 	_ = h.presenceStore.Clear(r.Context(), userID)
 	_ = h.cacheStore.InvalidateUserStats(r.Context(), userID)
@@ -440,7 +514,7 @@ func (h *WritingHandler) Complete(w http.ResponseWriter, r *http.Request) {
 		Personality:     "writing-coach",
 		MessageCount:    len(msgs),
 		DurationSecs:    req.DurationSecs,
-		Misspellings:    req.Misspellings,
+		Misspellings:    misspellings,
 	})
 }
 
@@ -545,6 +619,71 @@ type ionosWritingResponse struct {
 	} `json:"choices"`
 }
 
+// streamAIMessages streams a chat completion, calling onChunk with each
+// piece of content as it arrives, and returns the full text. The text
+// received so far is returned with any error.
+func (h *WritingHandler) streamAIMessages(ctx context.Context, messages []store.Message, maxTokens int, temperature float64, onChunk func(string)) (string, error) {
+	payload := ionosWritingPayload{
+		Model:       h.cfg.IONOSFastModel,
+		Messages:    messages,
+		Stream:      true,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", h.cfg.IONOSBaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+h.cfg.IONOSAPIKey)
+
+	client := &http.Client{Timeout: 90 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("AI returned %d: %s", resp.StatusCode, string(raw))
+	}
+
+	var full strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil || len(chunk.Choices) == 0 {
+			continue
+		}
+		content := chunk.Choices[0].Delta.Content
+		if content == "" {
+			continue
+		}
+		full.WriteString(content)
+		onChunk(content)
+	}
+	return full.String(), scanner.Err()
+}
+
 func (h *WritingHandler) callAI(ctx context.Context, prompt string, maxTokens int, temperature float64) (string, error) {
 	return h.callAIMessages(ctx, []store.Message{{Role: "user", Content: prompt}}, maxTokens, temperature)
 }
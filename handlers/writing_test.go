@@ -0,0 +1,165 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/handlers"
+	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWritingAI streams the reply in chunks and answers the misspelling
+// check with a JSON completion.
+func fakeWritingAI(t *testing.T, chunks []string, misspellings string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Stream bool `json:"stream"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		if !payload.Stream {
+			content, _ := json.Marshal(misspellings)
+			fmt.Fprintf(w, `{"choices":[{"message":{"content":%s}}]}`, content)
+			return
+		}
+		for _, c := range chunks {
+			data, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"delta": map[string]string{"content": c}}}})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func sseEvents(t *testing.T, body string) []map[string]any {
+	t.Helper()
+	var events []map[string]any
+	for _, line := range strings.Split(body, "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var e map[string]any
+		require.NoError(t, json.Unmarshal([]byte(data), &e))
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestWritingMessage_StreamsReplyAndMisspellings(t *testing.T) {
+	ai := fakeWritingAI(t, []string{"Che bello! ", "Dove sei ", "andato?"}, `{"misspellings":["grazzie → grazie (double z)"]}`)
+	mr := miniredis.RunT(t)
+	sessions := store.NewSessionStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), time.Hour)
+	cfg := &config.Config{IONOSBaseURL: ai.URL}
+	h := handlers.NewWritingHandler(cfg, nil, nil, nil, sessions, nil, nil, nil, nil, nil)
+
+	session := sessions.Create("user-1", "it", "travel", 2, "writing-coach", "system")
+	raw, _ := json.Marshal(map[string]string{"session_id": session.ID, "message": "Grazzie, sono tornato dal mare!"})
+	req := httptest.NewRequest(http.MethodPost, "/api/writing/message", bytes.NewReader(raw))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+	h.Message(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+	var content strings.Builder
+	var misspellings []any
+	events := sseEvents(t, w.Body.String())
+	for _, e := range events {
+		if c, ok := e["content"].(string); ok {
+			content.WriteString(c)
+		}
+		if m, ok := e["misspellings"].([]any); ok {
+			misspellings = m
+		}
+	}
+	assert.Equal(t, "Che bello! Dove sei andato?", content.String())
+	assert.Equal(t, []any{"grazzie → grazie (double z)"}, misspellings)
+	last := events[len(events)-1]
+	assert.Equal(t, true, last["done"])
+	assert.Equal(t, "Che bello! Dove sei andato?", last["reply"])
+
+	saved, err := sessions.Get(session.ID)
+	require.NoError(t, err)
+	require.Len(t, saved.Messages, 3)
+	assert.Equal(t, "assistant", saved.Messages[2].Role)
+	assert.Equal(t, "Che bello! Dove sei andato?", saved.Messages[2].Content)
+	assert.Equal(t, []string{"grazzie → grazie (double z)"}, saved.Misspellings)
+}
+
+func TestWritingMessage_EmptyReplyIsAnError(t *testing.T) {
+	ai := fakeWritingAI(t, nil, `not json`)
+	mr := miniredis.RunT(t)
+	sessions := store.NewSessionStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), time.Hour)
+	h := handlers.NewWritingHandler(&config.Config{IONOSBaseURL: ai.URL}, nil, nil, nil, sessions, nil, nil, nil, nil, nil)
+
+	session := sessions.Create("user-1", "it", "travel", 2, "writing-coach", "system")
+	raw, _ := json.Marshal(map[string]string{"session_id": session.ID, "message": "Ciao!"})
+	req := httptest.NewRequest(http.MethodPost, "/api/writing/message", bytes.NewReader(raw))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+	h.Message(w, req)
+
+	events := sseEvents(t, w.Body.String())
+	require.NotEmpty(t, events)
+	assert.Equal(t, "AI service error", events[len(events)-1]["error"])
+
+	saved, err := sessions.Get(session.ID)
+	require.NoError(t, err)
+	assert.Len(t, saved.Messages, 2) // the student's message only
+}
+
+func TestWritingComplete_ScoresSessionOnce(t *testing.T) {
+	mr := miniredis.RunT(t)
+	sessions := store.NewSessionStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), time.Hour)
+	h := handlers.NewWritingHandler(nil, nil, nil, nil, sessions, nil, nil, nil, nil, nil)
+
+	// The first completion claimed the session; a replay must not score it
+	// or record its misspellings again.
+	session := sessions.Create("user-1", "it", "travel", 2, "writing-coach", "system")
+	first, err := sessions.Claim(session.ID, "completed")
+	require.NoError(t, err)
+	require.True(t, first)
+
+	raw, _ := json.Marshal(map[string]string{"session_id": session.ID})
+	req := httptest.NewRequest(http.MethodPost, "/api/writing/complete", bytes.NewReader(raw))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+	h.Complete(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "session already completed")
+}
+
+func TestWritingComplete_RejectsEssaySessions(t *testing.T) {
+	mr := miniredis.RunT(t)
+	sessions := store.NewSessionStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), time.Hour)
+	h := handlers.NewWritingHandler(&config.Config{}, nil, nil, nil, sessions, nil, nil, nil, nil, nil)
+
+	session := sessions.Create("user-1", "it", "travel", 2, "essay-coach", "system")
+	raw, _ := json.Marshal(map[string]string{"session_id": session.ID})
+	req := httptest.NewRequest(http.MethodPost, "/api/writing/complete", bytes.NewReader(raw))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+	h.Complete(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	first, err := sessions.Claim(session.ID, "completed")
+	require.NoError(t, err)
+	assert.True(t, first, "the essay session is left for the essay flow to complete")
+}
@@ -0,0 +1,137 @@
+// Package lemma maps inflected words to their dictionary form (lemma) and
+// part of speech, so "comí", "comiendo" and "comer" count as one word.
+//
+// The dictionaries are shipped as data files (data/<lang>.tsv), one
+// form<TAB>lemma<TAB>pos line per inflected form. Words missing from a
+// dictionary are their own lemma.
+package lemma
+
+import (
+	"bufio"
+	"embed"
+	"log"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/ailanguagetutor/textnorm"
+)
+
+// Parts of speech used in the dictionaries (Universal Dependencies tags).
+const (
+	Verb       = "VERB"
+	Noun       = "NOUN"
+	Adjective  = "ADJ"
+	Determiner = "DET"
+)
+
+// Entry is one reading of a word form.
+type Entry struct {
+	Lemma string `json:"lemma"`
+	POS   string `json:"pos"`
+}
+
+//go:embed data/*.tsv
+var data embed.FS
+
+type dictionary struct {
+	forms  map[string][]Entry // lower-case form → entries, preferred first
+	folded map[string][]Entry // same, keyed by the accent-folded form
+}
+
+var (
+	loadOnce sync.Once
+	dicts    map[string]*dictionary
+)
+
+func load() {
+	dicts = make(map[string]*dictionary)
+	files, _ := data.ReadDir("data")
+	for _, f := range files {
+		lang := strings.TrimSuffix(f.Name(), ".tsv")
+		file, err := data.Open(path.Join("data", f.Name()))
+		if err != nil {
+			log.Printf("lemma: %v", err)
+			continue
+		}
+		d := &dictionary{forms: make(map[string][]Entry), folded: make(map[string][]Entry)}
+		sc := bufio.NewScanner(file)
+		for sc.Scan() {
+			line := sc.Text()
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			parts := strings.Split(line, "\t")
+			if len(parts) != 3 {
+				continue
+			}
+			form, e := parts[0], Entry{Lemma: parts[1], POS: parts[2]}
+			d.forms[form] = append(d.forms[form], e)
+			key := textnorm.FoldAccents(form, lang)
+			d.folded[key] = append(d.folded[key], e)
+		}
+		file.Close()
+		dicts[lang] = d
+	}
+}
+
+func dict(lang string) *dictionary {
+	loadOnce.Do(load)
+	return dicts[lang]
+}
+
+// Supported reports whether a dictionary ships for lang.
+func Supported(lang string) bool {
+	return dict(lang) != nil
+}
+
+// Lookup returns the readings of a single word, preferred first, or nil if
+// the word is unknown. Case is ignored, and a spelling that differs only in
+// accents ("comi" for "comí") is tried when the exact one is not found.
+func Lookup(lang, word string) []Entry {
+	d := dict(lang)
+	if d == nil {
+		return nil
+	}
+	word = strings.ToLower(strings.TrimSpace(word))
+	if e := d.forms[word]; e != nil {
+		return e
+	}
+	return d.folded[textnorm.FoldAccents(word, lang)]
+}
+
+// Of returns the lemma of a word or short phrase. Phrases are lemmatised
+// word by word with leading determiners dropped, so "los libros" gives
+// "libro". Unknown words are kept as written, in lower case.
+func Of(lang, text string) string {
+	tokens := textnorm.Tokens(text, lang)
+	out := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		entries := Lookup(lang, t)
+		if len(entries) == 0 {
+			out = append(out, t)
+			continue
+		}
+		if len(out) == 0 && entries[0].POS == Determiner && len(tokens) > 1 {
+			continue
+		}
+		out = append(out, entries[0].Lemma)
+	}
+	return strings.Join(out, " ")
+}
+
+// Unique returns the lemmas of words in first-seen order, without repeats
+// or empty entries.
+func Unique(lang string, words []string) []string {
+	seen := make(map[string]bool, len(words))
+	var out []string
+	for _, w := range words {
+		l := Of(lang, w)
+		if l == "" || seen[l] {
+			continue
+		}
+		seen[l] = true
+		out = append(out, l)
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package lemma
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	cases := []struct{ lang, in, want string }{
+		{"es", "comer", "comer"},
+		{"es", "comí", "comer"},
+		{"es", "Comiendo", "comer"},
+		{"es", "comi", "comer"}, // accent missing
+		{"es", "tuvieron", "tener"},
+		{"es", "los libros", "libro"},
+		{"es", "ciudades", "ciudad"},
+		{"it", "mangerò", "mangiare"},
+		{"it", "finiscono", "finire"},
+		{"it", "l'acqua", "acqua"},
+		{"pt", "fizemos", "fazer"},
+		{"pt", "aviões", "avião"},
+		{"en", "went", "go"},
+		{"en", "children", "child"},
+		{"en", "stopped", "stop"},
+		{"es", "zanahoria", "zanahoria"}, // unknown word is its own lemma
+		{"es", "tener hambre", "tener hambre"},
+	}
+	for _, c := range cases {
+		if got := Of(c.lang, c.in); got != c.want {
+			t.Errorf("Of(%s, %q) = %q, want %q", c.lang, c.in, got, c.want)
+		}
+	}
+}
+
+func TestLookup_Ambiguous(t *testing.T) {
+	got := Lookup("es", "fui")
+	want := []Entry{{"ser", Verb}, {"ir", Verb}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lookup(es, fui) = %v, want %v", got, want)
+	}
+	if Lookup("xx", "fui") != nil {
+		t.Error("unsupported language should have no entries")
+	}
+}
+
+func TestUnique(t *testing.T) {
+	got := Unique("es", []string{"comer", "comí", "Comiendo", "beber", "bebimos", ""})
+	want := []string{"comer", "beber"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unique = %q, want %q", got, want)
+	}
+}
+
+func TestDictionariesLoad(t *testing.T) {
+	for _, lang := range []string{"es", "it", "pt", "en"} {
+		if !Supported(lang) {
+			t.Errorf("no dictionary for %s", lang)
+		}
+	}
+}
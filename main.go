@@ -10,7 +10,9 @@ import (
 	"github.com/ailanguagetutor/database"
 	"github.com/ailanguagetutor/handlers"
 	"github.com/ailanguagetutor/middleware"
+	"github.com/ailanguagetutor/speech"
 	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/tts"
 	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/redis/go-redis/v9"
@@ -47,14 +49,31 @@ func main() {
 	resetStore    := store.NewResetTokenStore(rdb)
 	cacheStore    := store.NewCacheStore(rdb)
 	presenceStore := store.NewPresenceStore(rdb)
+	practiceStore := store.NewPracticeStore(rdb)
+	listeningStore := store.NewListeningSessionStore(rdb)
+	readingStore  := store.NewReadingSessionStore(rdb)
+	grammarStore  := store.NewGrammarSessionStore(rdb)
+	ttsUsageStore := store.NewTTSUsageStore(pool)
+	audioCache    := store.NewAudioCache(cfg.TTSCacheDir, int64(cfg.TTSCacheMaxMB)<<20)
+	audioCache.Load()
+	cardStore     := store.NewVocabCardStore(pool)
+	misspellingStore := store.NewMisspellingStore(pool)
+	listStore     := store.NewWordListStore(pool)
+	lexiconStore  := store.NewLexiconStore(pool)
+	if err := lexiconStore.Load(ctx); err != nil {
+		log.Printf("lexicon: load: %v", err)
+	}
+	ttsService    := tts.NewService(cfg, lexiconStore)
+	ttsRenderer   := tts.NewRenderer(ttsService, audioCache, 2)
+	recognizer    := speech.NewRecognizer(cfg)
 
 	billingHandler      := handlers.NewBillingHandler(cfg, userStore)
 	authHandler         := handlers.NewAuthHandler(cfg, userStore, billingHandler, blocklist, rateLimiter, resetStore)
 	convHandler         := handlers.NewConversationHandler(cfg, sessionStore, contextStore, userStore, historyStore, profileStore, presenceStore, cacheStore)
-	ttsHandler          := handlers.NewTTSHandler(cfg)
-	adminHandler        := handlers.NewAdminHandler(cfg, userStore, billingHandler, historyStore, resetStore)
-	gamificationHandler := handlers.NewGamificationHandler(userStore, historyStore, profileStore, cacheStore)
-	agentHandler        := handlers.NewAgentHandler(cfg, sessionStore, profileStore)
+	ttsHandler          := handlers.NewTTSHandler(cfg, ttsService, ttsRenderer, audioCache, userStore, ttsUsageStore, rateLimiter)
+	ttsRenderer.MeterWith(ttsHandler.RenderFor)
+	gamificationHandler := handlers.NewGamificationHandler(userStore, historyStore, profileStore, cacheStore, misspellingStore)
+	agentHandler        := handlers.NewAgentHandler(cfg, sessionStore, profileStore, ttsService)
 	vocabPool           := store.NewItemPool("data/vocab_pool.json")
 	vocabPool.Load()
 	sentencePool        := store.NewItemPool("data/sentence_pool.json")
@@ -63,10 +82,24 @@ func main() {
 	listeningPool.Load()
 	writingPool         := store.NewItemPool("data/writing_pool.json")
 	writingPool.Load()
-	vocabHandler        := handlers.NewVocabHandler(cfg, userStore, profileStore, historyStore, vocabPool, presenceStore, cacheStore)
-	sentenceHandler     := handlers.NewSentenceHandler(cfg, userStore, profileStore, historyStore, sentencePool, presenceStore, cacheStore)
-	listeningHandler    := handlers.NewListeningHandler(cfg, userStore, profileStore, historyStore, listeningPool, vocabPool, sentencePool, presenceStore, cacheStore)
-	writingHandler      := handlers.NewWritingHandler(cfg, userStore, profileStore, historyStore, sessionStore, writingPool, presenceStore, cacheStore)
+	pronunciationPool   := store.NewItemPool("data/pronunciation_pool.json")
+	pronunciationPool.Load()
+	grammarPool         := store.NewItemPool("data/grammar_pool.json")
+	grammarPool.Load()
+	readingPool         := store.NewItemPool("data/reading_pool.json")
+	readingPool.Load()
+	adminHandler        := handlers.NewAdminHandler(cfg, userStore, billingHandler, historyStore, resetStore, ttsUsageStore, lexiconStore, handlers.GradedPools{
+		Vocab: vocabPool, Sentences: sentencePool, Listening: listeningPool, Grammar: grammarPool, Reading: readingPool,
+	})
+	vocabHandler        := handlers.NewVocabHandler(cfg, userStore, profileStore, historyStore, vocabPool, presenceStore, cacheStore, cardStore, listStore, practiceStore, ttsRenderer, recognizer, misspellingStore)
+	sentenceHandler     := handlers.NewSentenceHandler(cfg, userStore, profileStore, historyStore, sentencePool, presenceStore, cacheStore, listStore)
+	listeningHandler    := handlers.NewListeningHandler(cfg, userStore, profileStore, historyStore, listeningPool, vocabPool, sentencePool, presenceStore, cacheStore, ttsRenderer, listeningStore, misspellingStore, cardStore)
+	pronunciationHandler := handlers.NewPronunciationHandler(cfg, userStore, profileStore, historyStore, pronunciationPool, presenceStore, cacheStore, recognizer)
+	grammarHandler      := handlers.NewGrammarHandler(cfg, userStore, profileStore, historyStore, grammarPool, presenceStore, cacheStore, grammarStore)
+	conjugationHandler  := handlers.NewConjugationHandler(userStore, profileStore, historyStore, presenceStore, cacheStore)
+	readingHandler      := handlers.NewReadingHandler(cfg, userStore, profileStore, historyStore, readingPool, presenceStore, cacheStore, cardStore, readingStore)
+	wordListHandler     := handlers.NewWordListHandler(userStore, historyStore, listStore)
+	writingHandler      := handlers.NewWritingHandler(cfg, userStore, profileStore, historyStore, sessionStore, writingPool, presenceStore, cacheStore, misspellingStore, cardStore)
 
 	auth := middleware.NewAuthMiddleware(cfg, blocklist)
 
@@ -143,12 +176,34 @@ func main() {
 
 		// TTS
 		r.Post("/api/tts", ttsHandler.Convert)
+		r.Post("/api/tts/aligned", ttsHandler.Aligned)
+		r.Get("/api/tts/audio/{key}", ttsHandler.Audio)
+		r.Get("/api/tts/audio/{key}/alignment", ttsHandler.Alignment)
+		r.Get("/api/tts/voices", ttsHandler.Voices)
+		r.Get("/api/tts/usage", ttsHandler.Usage)
 
 		// Vocab builder
 		r.Post("/api/vocab/session",     vocabHandler.Session)
 		r.Post("/api/vocab/check",       vocabHandler.Check)
+		r.Post("/api/vocab/check-audio", vocabHandler.CheckAudio)
+		r.Post("/api/vocab/exercise",    vocabHandler.CheckExercise)
 		r.Post("/api/vocab/complete",    vocabHandler.Complete)
 		r.Post("/api/vocab/word-result", vocabHandler.WordResult)
+		r.Post("/api/vocab/reviews",     vocabHandler.Reviews)
+		r.Post("/api/vocab/deck",        vocabHandler.AddToDeck)
+		r.Get("/api/vocab/deck",         vocabHandler.DeckStats)
+		r.Get("/api/vocab/coverage",     vocabHandler.Coverage)
+		r.Post("/api/vocab/import",      vocabHandler.ImportDeck)
+		r.Get("/api/vocab/export",       vocabHandler.ExportDeck)
+
+		// Word lists
+		r.Get("/api/lists",                    wordListHandler.List)
+		r.Post("/api/lists",                   wordListHandler.Create)
+		r.Get("/api/lists/{id}",               wordListHandler.Get)
+		r.Put("/api/lists/{id}",               wordListHandler.Update)
+		r.Delete("/api/lists/{id}",            wordListHandler.Delete)
+		r.Post("/api/lists/{id}/words",        wordListHandler.AddWords)
+		r.Delete("/api/lists/{id}/words/{word}", wordListHandler.RemoveWord)
 
 		// Sentence builder
 		r.Post("/api/sentences/session",  sentenceHandler.Session)
@@ -157,12 +212,43 @@ func main() {
 
 		// Listening comprehension
 		r.Post("/api/listening/session",  listeningHandler.Session)
+		r.Post("/api/listening/check",    listeningHandler.Check)
 		r.Post("/api/listening/complete", listeningHandler.Complete)
 
+		// Dictation (on listening stories)
+		r.Post("/api/dictation/session",  listeningHandler.DictationSession)
+		r.Post("/api/dictation/check",    listeningHandler.DictationCheck)
+		r.Post("/api/dictation/complete", listeningHandler.DictationComplete)
+
+		// Reading comprehension
+		r.Post("/api/reading/session",  readingHandler.Session)
+		r.Post("/api/reading/gloss",    readingHandler.Gloss)
+		r.Post("/api/reading/check",    readingHandler.Check)
+		r.Post("/api/reading/complete", readingHandler.Complete)
+
+		// Pronunciation practice
+		r.Post("/api/pronunciation/session",  pronunciationHandler.Session)
+		r.Post("/api/pronunciation/check",    pronunciationHandler.Check)
+		r.Post("/api/pronunciation/complete", pronunciationHandler.Complete)
+
+		// Grammar lessons
+		r.Get("/api/grammar/lessons",    grammarHandler.Lessons)
+		r.Post("/api/grammar/lesson",    grammarHandler.Lesson)
+		r.Post("/api/grammar/check",     grammarHandler.Check)
+		r.Post("/api/grammar/complete",  grammarHandler.Complete)
+
+		// Verb conjugation drills
+		r.Get("/api/conjugation/table",      conjugationHandler.Table)
+		r.Post("/api/conjugation/session",   conjugationHandler.Session)
+		r.Post("/api/conjugation/check",     conjugationHandler.Check)
+		r.Post("/api/conjugation/complete",  conjugationHandler.Complete)
+
 		// Writing coach
 		r.Post("/api/writing/session",  writingHandler.Session)
 		r.Post("/api/writing/message",  writingHandler.Message)
 		r.Post("/api/writing/complete", writingHandler.Complete)
+		r.Post("/api/writing/essay/session", writingHandler.EssaySession)
+		r.Post("/api/writing/essay/submit",  writingHandler.EssaySubmit)
 
 		// Gamification
 		r.Get("/api/user/stats",              gamificationHandler.Stats)
@@ -184,6 +270,14 @@ func main() {
 		r.Post("/api/admin/invite-user",              adminHandler.InviteUser)
 		r.Post("/api/admin/users/{id}/promote",       adminHandler.PromoteToAdmin)
 		r.Delete("/api/admin/users/{id}",             adminHandler.DeleteUser)
+		r.Get("/api/admin/users/{id}/tts-usage",      adminHandler.UserTTSUsage)
+		r.Get("/api/admin/tts-usage",                 adminHandler.TTSUsage)
+		r.Get("/api/admin/lexicon",                   adminHandler.ListLexicon)
+		r.Put("/api/admin/lexicon",                   adminHandler.UpsertLexicon)
+		r.Delete("/api/admin/lexicon",                adminHandler.DeleteLexicon)
+		r.Get("/api/admin/pool-difficulty",           adminHandler.PoolDifficulty)
+		r.Post("/api/admin/pool-difficulty",          adminHandler.BackfillPoolDifficulty)
+		r.Post("/api/admin/reading",                  adminHandler.ImportReading)
 		// One-time setup: creates the ElevenLabs Conversational AI agent
 		r.Post("/api/admin/setup-agent", agentHandler.SetupAgent)
 	})
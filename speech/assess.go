@@ -0,0 +1,185 @@
+package speech
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// PassScore is the overall score at or above which an attempt counts as
+// correct, provided no expected word was missed.
+const PassScore = 70
+
+// Word statuses in an Assessment.
+const (
+	StatusGood    = "good"    // said clearly and recognised as expected
+	StatusClose   = "close"   // recognised as a similar word, or with low confidence
+	StatusWrong   = "wrong"   // recognised as something else
+	StatusMissing = "missing" // not said at all
+)
+
+// WordScore is the assessment of one expected word.
+type WordScore struct {
+	Word       string  `json:"word"`            // expected word
+	Heard      string  `json:"heard,omitempty"` // what the recogniser heard in its place
+	Confidence float64 `json:"confidence"`      // recogniser confidence, 0-1
+	Score      int     `json:"score"`           // 0-100
+	Status     string  `json:"status"`
+}
+
+// Assessment scores a transcript against the expected text.
+type Assessment struct {
+	Score      int         `json:"score"` // 0-100, mean of the word scores
+	Correct    bool        `json:"correct"`
+	Transcript string      `json:"transcript"`
+	Words      []WordScore `json:"words"`
+}
+
+// Assess aligns the recognised words with the words of expected and scores
+// each expected word by spelling similarity weighted by the recogniser's
+// confidence. Extra words the learner said are ignored.
+func Assess(expected string, t *Transcript) Assessment {
+	exp := splitWords(expected)
+	var heard []Word
+	for _, w := range t.Words {
+		for _, part := range splitWords(w.Text) {
+			heard = append(heard, Word{Text: part, Start: w.Start, End: w.End, Confidence: w.Confidence})
+		}
+	}
+
+	a := Assessment{Transcript: t.Text, Words: make([]WordScore, len(exp))}
+	match := align(exp, heard)
+	total, missing := 0, false
+	for i, e := range exp {
+		ws := WordScore{Word: e, Status: StatusMissing}
+		if j := match[i]; j >= 0 {
+			h := heard[j]
+			ws.Heard = h.Text
+			ws.Confidence = h.Confidence
+			ws.Score = int(math.Round(100 * similarity(normalizeWord(e), normalizeWord(h.Text)) * h.Confidence))
+			switch {
+			case ws.Score >= 80:
+				ws.Status = StatusGood
+			case ws.Score >= 50:
+				ws.Status = StatusClose
+			default:
+				ws.Status = StatusWrong
+			}
+		} else {
+			missing = true
+		}
+		total += ws.Score
+		a.Words[i] = ws
+	}
+	if len(exp) > 0 {
+		a.Score = int(math.Round(float64(total) / float64(len(exp))))
+	}
+	a.Correct = len(exp) > 0 && !missing && a.Score >= PassScore
+	return a
+}
+
+// align pairs expected words with heard words using an edit-distance
+// alignment where substituting costs the words' dissimilarity. It returns,
+// for each expected word, the index of its heard word or -1.
+func align(exp []string, heard []Word) []int {
+	n, m := len(exp), len(heard)
+	cost := make([][]float64, n+1)
+	for i := range cost {
+		cost[i] = make([]float64, m+1)
+		cost[i][0] = float64(i)
+	}
+	for j := 0; j <= m; j++ {
+		cost[0][j] = float64(j)
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			sub := cost[i-1][j-1] + 1 - similarity(normalizeWord(exp[i-1]), normalizeWord(heard[j-1].Text))
+			cost[i][j] = math.Min(sub, math.Min(cost[i-1][j]+1, cost[i][j-1]+1))
+		}
+	}
+
+	match := make([]int, n)
+	for i := range match {
+		match[i] = -1
+	}
+	for i, j := n, m; i > 0 && j > 0; {
+		sim := similarity(normalizeWord(exp[i-1]), normalizeWord(heard[j-1].Text))
+		switch {
+		case cost[i][j] == cost[i-1][j-1]+1-sim && sim > 0:
+			match[i-1] = j - 1
+			i, j = i-1, j-1
+		case cost[i][j] == cost[i-1][j]+1:
+			i--
+		default:
+			j--
+		}
+	}
+	return match
+}
+
+// splitWords splits text into words on whitespace. CJK ideographs and kana
+// are written without spaces, so each one counts as a word.
+func splitWords(text string) []string {
+	var words []string
+	for _, f := range strings.Fields(text) {
+		var cur []rune
+		for _, r := range f {
+			if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+				if len(cur) > 0 {
+					words = append(words, string(cur))
+					cur = nil
+				}
+				words = append(words, string(r))
+				continue
+			}
+			cur = append(cur, r)
+		}
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+		}
+	}
+	out := words[:0]
+	for _, w := range words {
+		if normalizeWord(w) != "" {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// normalizeWord lowercases w and strips punctuation, which speech recognition
+// adds or omits unpredictably. Accents are kept: they change pronunciation.
+func normalizeWord(w string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, w)
+}
+
+// similarity is 1 minus the normalised Levenshtein distance between a and b.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			c := 1
+			if ra[i-1] == rb[j-1] {
+				c = 0
+			}
+			cur[j] = min(prev[j-1]+c, prev[j]+1, cur[j-1]+1)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
@@ -0,0 +1,76 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func transcript(words ...Word) *Transcript {
+	t := &Transcript{}
+	for _, w := range words {
+		t.Text += w.Text + " "
+		t.Words = append(t.Words, w)
+	}
+	return t
+}
+
+func TestAssess_AllWordsClear(t *testing.T) {
+	a := Assess("¿Dónde está la estación?", transcript(
+		Word{Text: "Dónde", Confidence: 0.95},
+		Word{Text: "está", Confidence: 0.9},
+		Word{Text: "la", Confidence: 0.99},
+		Word{Text: "estación?", Confidence: 0.92},
+	))
+	assert.True(t, a.Correct)
+	assert.GreaterOrEqual(t, a.Score, 90)
+	require.Len(t, a.Words, 4)
+	for _, w := range a.Words {
+		assert.Equal(t, StatusGood, w.Status, w.Word)
+	}
+}
+
+func TestAssess_MispronouncedAndMissing(t *testing.T) {
+	a := Assess("la estación de tren", transcript(
+		Word{Text: "la", Confidence: 0.98},
+		Word{Text: "estasion", Confidence: 0.6},
+		Word{Text: "tren", Confidence: 0.97},
+	))
+	assert.False(t, a.Correct)
+	require.Len(t, a.Words, 4)
+	assert.Equal(t, StatusGood, a.Words[0].Status)
+	assert.Equal(t, "estasion", a.Words[1].Heard)
+	assert.Equal(t, StatusWrong, a.Words[1].Status)
+	assert.Equal(t, StatusMissing, a.Words[2].Status)
+	assert.Equal(t, StatusGood, a.Words[3].Status)
+}
+
+func TestAssess_CJKCharactersAreWords(t *testing.T) {
+	a := Assess("你好", transcript(Word{Text: "你好。", Confidence: 0.9}))
+	require.Len(t, a.Words, 2)
+	assert.Equal(t, "你", a.Words[0].Heard)
+	assert.Equal(t, "好", a.Words[1].Heard)
+	assert.True(t, a.Correct)
+}
+
+func TestParseWhisperJSON_MergesTokens(t *testing.T) {
+	raw := []byte(`{"transcription":[{"text":" Buongiorno, signora.","tokens":[
+		{"text":"[_BEG_]","p":0.99,"offsets":{"from":0,"to":0}},
+		{"text":" Buon","p":0.95,"offsets":{"from":0,"to":300}},
+		{"text":"giorno","p":0.7,"offsets":{"from":300,"to":700}},
+		{"text":",","p":0.9,"offsets":{"from":700,"to":720}},
+		{"text":" signora","p":0.88,"offsets":{"from":800,"to":1300}},
+		{"text":".","p":0.99,"offsets":{"from":1300,"to":1320}},
+		{"text":"[_TT_66]","p":0.5,"offsets":{"from":1320,"to":1320}}
+	]}]}`)
+	tr, err := parseWhisperJSON(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "Buongiorno, signora.", tr.Text)
+	require.Len(t, tr.Words, 2)
+	assert.Equal(t, Word{Text: "Buongiorno,", Start: 0, End: 0.72, Confidence: 0.7}, tr.Words[0])
+	assert.Equal(t, "signora.", tr.Words[1].Text)
+
+	_, err = parseWhisperJSON([]byte(`{"transcription":[{"text":"","tokens":[{"text":"[_BEG_]","p":1}]}]}`))
+	assert.ErrorIs(t, err, ErrNoSpeech)
+}
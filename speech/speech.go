@@ -0,0 +1,75 @@
+// Package speech transcribes learner recordings and scores them against the
+// text the learner was asked to say. Recognition sits behind the Recognizer
+// interface; the production implementation runs a local whisper.cpp binary.
+package speech
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os/exec"
+
+	"github.com/ailanguagetutor/config"
+)
+
+// ErrNoSpeech is returned when a recording contains no recognisable speech.
+var ErrNoSpeech = errors.New("speech: no speech detected")
+
+// Word is one recognised word with the recogniser's confidence in it.
+type Word struct {
+	Text       string  `json:"text"`
+	Start      float64 `json:"start"`      // seconds
+	End        float64 `json:"end"`        // seconds
+	Confidence float64 `json:"confidence"` // 0-1
+}
+
+// Transcript is the result of recognising one recording.
+type Transcript struct {
+	Text  string `json:"text"`
+	Words []Word `json:"words"`
+}
+
+// Options describe the recording being recognised.
+type Options struct {
+	Language    string // app language code, e.g. "it"
+	ContentType string // MIME type of the upload, e.g. "audio/webm"
+}
+
+// Recognizer converts recorded speech to text.
+type Recognizer interface {
+	Transcribe(ctx context.Context, audio io.Reader, opts Options) (*Transcript, error)
+}
+
+// NewRecognizer returns the recogniser configured in cfg, or nil when speech
+// recognition is disabled or its binaries are not installed.
+func NewRecognizer(cfg *config.Config) Recognizer {
+	if cfg.WhisperModel == "" {
+		return nil
+	}
+	if _, err := exec.LookPath(cfg.WhisperBinary); err != nil {
+		log.Printf("speech: WHISPER_MODEL set but %q not found", cfg.WhisperBinary)
+		return nil
+	}
+	if _, err := exec.LookPath(cfg.FFmpegBinary); err != nil {
+		log.Printf("speech: %q not found; recordings cannot be converted for whisper", cfg.FFmpegBinary)
+		return nil
+	}
+	return NewWhisper(cfg.WhisperBinary, cfg.WhisperModel, cfg.FFmpegBinary)
+}
+
+// Fake is a Recognizer that returns a fixed transcript, for tests.
+type Fake struct {
+	Transcript *Transcript
+	Err        error
+}
+
+func (f *Fake) Transcribe(ctx context.Context, audio io.Reader, opts Options) (*Transcript, error) {
+	if _, err := io.Copy(io.Discard, audio); err != nil {
+		return nil, err
+	}
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.Transcript, nil
+}
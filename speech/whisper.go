@@ -0,0 +1,158 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// whisperTimeout bounds one recognition, conversion included. Uploads are
+	// short phrases, so a run this long means the process is stuck.
+	whisperTimeout = 60 * time.Second
+	// whisperConcurrency limits parallel runs; each one uses several cores.
+	whisperConcurrency = 2
+)
+
+// Whisper recognises speech with a local whisper.cpp binary (whisper-cli).
+// Uploads are converted to 16 kHz mono WAV with ffmpeg first, since that is
+// the only input whisper.cpp reads.
+type Whisper struct {
+	binary string
+	model  string
+	ffmpeg string
+	sem    chan struct{}
+}
+
+func NewWhisper(binary, model, ffmpeg string) *Whisper {
+	return &Whisper{binary: binary, model: model, ffmpeg: ffmpeg, sem: make(chan struct{}, whisperConcurrency)}
+}
+
+func (w *Whisper) Transcribe(ctx context.Context, audio io.Reader, opts Options) (*Transcript, error) {
+	ctx, cancel := context.WithTimeout(ctx, whisperTimeout)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "whisper-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "input")
+	f, err := os.Create(in)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(f, audio); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	select {
+	case w.sem <- struct{}{}:
+		defer func() { <-w.sem }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	wav := filepath.Join(dir, "input.wav")
+	if err := run(ctx, w.ffmpeg, "-nostdin", "-loglevel", "error", "-i", in, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", wav); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w", err)
+	}
+
+	lang := opts.Language
+	if lang == "" {
+		lang = "auto"
+	}
+	out := filepath.Join(dir, "out")
+	// -ojf: full JSON output, which includes per-token probabilities
+	if err := run(ctx, w.binary, "-m", w.model, "-l", lang, "-f", wav, "-ojf", "-of", out, "-np", "-nt"); err != nil {
+		return nil, fmt.Errorf("whisper: %w", err)
+	}
+	raw, err := os.ReadFile(out + ".json")
+	if err != nil {
+		return nil, err
+	}
+	return parseWhisperJSON(raw)
+}
+
+func run(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// whisperOutput is the subset of whisper.cpp's -ojf output we read.
+type whisperOutput struct {
+	Transcription []struct {
+		Text   string `json:"text"`
+		Tokens []struct {
+			Text    string  `json:"text"`
+			P       float64 `json:"p"`
+			Offsets struct {
+				From int `json:"from"` // milliseconds
+				To   int `json:"to"`
+			} `json:"offsets"`
+		} `json:"tokens"`
+	} `json:"transcription"`
+}
+
+// parseWhisperJSON merges whisper's sub-word tokens into words. A token that
+// starts with a space begins a new word; special tokens ([_BEG_], [_TT_…])
+// are dropped. A word's confidence is the lowest probability among its
+// tokens, so one badly recognised syllable marks the whole word.
+func parseWhisperJSON(raw []byte) (*Transcript, error) {
+	var out whisperOutput
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("whisper: parse output: %w", err)
+	}
+
+	t := &Transcript{}
+	var text []string
+	for _, seg := range out.Transcription {
+		text = append(text, strings.TrimSpace(seg.Text))
+		for _, tok := range seg.Tokens {
+			if strings.HasPrefix(tok.Text, "[_") || tok.Text == "" {
+				continue
+			}
+			start := float64(tok.Offsets.From) / 1000
+			end := float64(tok.Offsets.To) / 1000
+			if strings.HasPrefix(tok.Text, " ") || len(t.Words) == 0 {
+				t.Words = append(t.Words, Word{Text: strings.TrimSpace(tok.Text), Start: start, End: end, Confidence: tok.P})
+				continue
+			}
+			last := &t.Words[len(t.Words)-1]
+			last.Text += tok.Text
+			last.End = end
+			last.Confidence = min(last.Confidence, tok.P)
+		}
+	}
+	t.Text = strings.TrimSpace(strings.Join(text, " "))
+
+	// Drop words that are only punctuation or blank (e.g. a lone " ").
+	words := t.Words[:0]
+	for _, w := range t.Words {
+		if normalizeWord(w.Text) != "" {
+			words = append(words, w)
+		}
+	}
+	t.Words = words
+	if len(t.Words) == 0 {
+		return nil, ErrNoSpeech
+	}
+	return t, nil
+}
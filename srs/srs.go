@@ -0,0 +1,103 @@
+// Package srs schedules flashcard reviews with a variant of the SM-2
+// algorithm: each successful review multiplies the interval by the card's
+// ease factor, and the ease drifts with how hard the learner found the card.
+package srs
+
+import (
+	"math"
+	"time"
+)
+
+// Grade is the learner's answer quality for one review.
+type Grade int
+
+const (
+	Again Grade = iota // forgotten or wrong
+	Hard               // recalled with serious difficulty
+	Good               // recalled correctly
+	Easy               // recalled instantly
+)
+
+// ParseGrade parses "again", "hard", "good" or "easy".
+func ParseGrade(s string) (Grade, bool) {
+	switch s {
+	case "again":
+		return Again, true
+	case "hard":
+		return Hard, true
+	case "good":
+		return Good, true
+	case "easy":
+		return Easy, true
+	}
+	return Again, false
+}
+
+const (
+	InitialEase = 2.5
+	MinEase     = 1.3
+	// MaxInterval caps the gap between reviews, in days.
+	MaxInterval = 365.0
+	// RelearnDelay is how soon a forgotten card comes back.
+	RelearnDelay = 10 * time.Minute
+
+	hardFactor = 1.2 // interval multiplier for Hard, instead of the ease
+	easyBonus  = 1.3 // extra multiplier for Easy
+)
+
+// State is a card's review state.
+type State struct {
+	Reps       int       `json:"reps"`     // consecutive successful reviews
+	Lapses     int       `json:"lapses"`   // times the card was forgotten after being learned
+	Ease       float64   `json:"ease"`     // SM-2 ease factor, at least MinEase
+	Interval   float64   `json:"interval"` // days until the next review; 0 while (re)learning
+	Due        time.Time `json:"due"`
+	LastReview time.Time `json:"last_review,omitempty"`
+}
+
+// New returns the state of a card that has never been reviewed: due now.
+func New(now time.Time) State {
+	return State{Ease: InitialEase, Due: now}
+}
+
+// quality maps grades onto SM-2's 0-5 answer quality.
+var quality = [...]float64{Again: 1, Hard: 3, Good: 4, Easy: 5}
+
+// Review returns s after a review graded g at now.
+func Review(s State, g Grade, now time.Time) State {
+	if s.Ease == 0 {
+		s.Ease = InitialEase
+	}
+	s.LastReview = now
+
+	if g == Again {
+		if s.Reps > 0 {
+			s.Lapses++
+		}
+		s.Reps = 0
+		s.Interval = 0
+		s.Ease = math.Max(MinEase, s.Ease-0.2)
+		s.Due = now.Add(RelearnDelay)
+		return s
+	}
+
+	q := quality[g]
+	s.Ease = math.Max(MinEase, s.Ease+0.1-(5-q)*(0.08+(5-q)*0.02))
+	s.Reps++
+	switch {
+	case s.Reps == 1:
+		s.Interval = 1
+	case s.Reps == 2:
+		s.Interval = 6
+	case g == Hard:
+		s.Interval *= hardFactor
+	default:
+		s.Interval *= s.Ease
+	}
+	if g == Easy {
+		s.Interval *= easyBonus
+	}
+	s.Interval = math.Min(MaxInterval, math.Round(s.Interval*100)/100)
+	s.Due = now.Add(time.Duration(s.Interval * 24 * float64(time.Hour)))
+	return s
+}
@@ -0,0 +1,61 @@
+package srs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var t0 = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func TestReview_GoodIntervalsGrow(t *testing.T) {
+	s := New(t0)
+	now := t0
+	var intervals []float64
+	for i := 0; i < 4; i++ {
+		s = Review(s, Good, now)
+		intervals = append(intervals, s.Interval)
+		now = s.Due
+	}
+	assert.Equal(t, []float64{1, 6, 15, 37.5}, intervals)
+	assert.Equal(t, InitialEase, s.Ease)
+	assert.Equal(t, 4, s.Reps)
+}
+
+func TestReview_AgainResetsAndCountsLapse(t *testing.T) {
+	s := Review(New(t0), Good, t0)
+	s = Review(s, Good, s.Due)
+
+	lapsed := Review(s, Again, s.Due)
+	assert.Equal(t, 0, lapsed.Reps)
+	assert.Equal(t, 1, lapsed.Lapses)
+	assert.Equal(t, 0.0, lapsed.Interval)
+	assert.Equal(t, s.Due.Add(RelearnDelay), lapsed.Due)
+	assert.Less(t, lapsed.Ease, s.Ease)
+
+	// A card never learned does not count a lapse.
+	assert.Equal(t, 0, Review(New(t0), Again, t0).Lapses)
+}
+
+func TestReview_EaseBounds(t *testing.T) {
+	s := New(t0)
+	for i := 0; i < 20; i++ {
+		s = Review(s, Hard, t0)
+	}
+	assert.Equal(t, MinEase, s.Ease)
+
+	e := New(t0)
+	for i := 0; i < 30; i++ {
+		e = Review(e, Easy, e.Due)
+	}
+	assert.Equal(t, MaxInterval, e.Interval)
+}
+
+func TestParseGrade(t *testing.T) {
+	g, ok := ParseGrade("hard")
+	assert.True(t, ok)
+	assert.Equal(t, Hard, g)
+	_, ok = ParseGrade("meh")
+	assert.False(t, ok)
+}
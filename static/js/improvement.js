@@ -38,6 +38,10 @@ let mistakesData = null;
 
   const weakVocab   = mistakesData.weak_vocab   || [];
   const weakGrammar = mistakesData.weak_grammar || [];
+  const concepts    = mistakesData.grammar_concepts || [];
+  const grammarItems = concepts.length
+    ? concepts.map(c => `${c.name} — ${c.errors} wrong, ${c.successes} right`)
+    : weakGrammar;
 
   if (weakVocab.length > 0) {
     const section = document.getElementById('weakVocabSection');
@@ -50,7 +54,7 @@ let mistakesData = null;
     const section = document.getElementById('weakGrammarSection');
     section.classList.remove('hidden');
     document.getElementById('weakGrammarList').innerHTML =
-      `<ul class="summary-list">${weakGrammar.map(g => `<li>${escapeHtml(g)}</li>`).join('')}</ul>`;
+      `<ul class="summary-list">${grammarItems.map(g => `<li>${escapeHtml(g)}</li>`).join('')}</ul>`;
   }
 
   // Wire up buttons
@@ -9,7 +9,9 @@ const topicName   = params.get('topicName')   || 'General';
 const personality = params.get('personality') || 'professor';
 
 /* ── State ──────────────────────────────────────────────────────────────────── */
-let story        = null;   // Story object from API
+let story        = null;   // Story object from API (without answers)
+let sessionId    = null;   // server-side session holding the answer key
+let completed    = false;  // results already submitted for this session
 let speed        = 1.0;    // TTS speed for this level
 let currentIdx   = 0;      // current segment index
 let results      = [];     // {question_index, correct}[]
@@ -30,6 +32,7 @@ async function loadSession() {
   try {
     const data = await API.post('/api/listening/session', { language, level, topic, personality });
     story = data.story;
+    sessionId = data.session_id;
     speed = data.speed || 1.0;
 
     if (!story || !story.segments || story.segments.length === 0) {
@@ -164,20 +167,24 @@ function showQuestion() {
 }
 
 /* ── Submit answer ──────────────────────────────────────────────────────────── */
-function submitAnswer(userAnswer) {
-  const seg = story.segments[currentIdx];
-  const q   = seg.question;
-
+async function submitAnswer(userAnswer) {
   // Disable all answer buttons
   document.querySelectorAll('#answerBtns button').forEach(b => b.disabled = true);
 
-  // Check correctness
-  let correct = false;
-  if (q.type === 'multiple_choice') {
-    correct = (userAnswer === q.answer);
-  } else {
-    correct = (String(userAnswer).toLowerCase() === String(q.answer).toLowerCase());
+  // The server holds the answer key; only the first answer to a question counts
+  let data;
+  try {
+    data = await API.post('/api/listening/check', {
+      session_id: sessionId,
+      question_index: currentIdx,
+      answer: userAnswer,
+    });
+  } catch (err) {
+    document.querySelectorAll('#answerBtns button').forEach(b => b.disabled = false);
+    alert('Could not check your answer. ' + (err.message || ''));
+    return;
   }
+  const correct = !!data.correct;
 
   results.push({ question_index: currentIdx, correct });
 
@@ -186,7 +193,7 @@ function submitAnswer(userAnswer) {
   const fbExpl   = document.getElementById('explanationText');
   fbStatus.textContent = correct ? '✓ Correct!' : '✗ Incorrect';
   fbStatus.style.color = correct ? '#10b981' : '#f87171';
-  fbExpl.textContent   = q.explanation || '';
+  fbExpl.textContent   = data.explanation || '';
   document.getElementById('feedbackZone').classList.remove('hidden');
 
   const isLast = (currentIdx >= story.segments.length - 1);
@@ -210,24 +217,21 @@ async function showResults() {
   stopAudio();
 
   let fp = 0;
-  let correctCount = 0;
-  const totalCount = results.length;
-
-  // Call complete endpoint
-  try {
-    const data = await API.post('/api/listening/complete', {
-      language,
-      level,
-      topic,
-      topic_name: topicName,
-      personality,
-      results,
-    });
-    fp           = data.fp_earned || 0;
-    correctCount = data.correct_count !== undefined ? data.correct_count : results.filter(r => r.correct).length;
-  } catch {
-    correctCount = results.filter(r => r.correct).length;
-    fp = Math.max(20, correctCount * 15) + (correctCount === totalCount && totalCount > 0 ? 20 : 0);
+  let correctCount = results.filter(r => r.correct).length;
+  let totalCount   = results.length;
+
+  // The server scores the session from the answers it checked; a replay
+  // after completing is not scored again
+  if (!completed) {
+    try {
+      const data = await API.post('/api/listening/complete', { session_id: sessionId });
+      fp           = data.fp_earned || 0;
+      correctCount = data.correct_count;
+      totalCount   = data.total_count;
+      completed    = true;
+    } catch {
+      // keep the local tally for display
+    }
   }
 
   document.getElementById('statScore').textContent = `${correctCount} / ${totalCount}`;
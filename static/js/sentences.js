@@ -12,7 +12,7 @@ const mistakesMode = params.get('mistakes_mode') === 'true';
 let sentences   = [];    // Sentence[]
 let currentIdx  = 0;
 let attempts    = 0;     // 1-2 max per sentence
-let results     = [];    // {sentence_id, grammar_tip, correct}[]
+let results     = [];    // {sentence_id, grammar_tip, concept, correct}[]
 let isListening = false;
 let recognition = null;
 let hasSpeechAPI = false;
@@ -153,7 +153,7 @@ function showFeedback(result, s) {
     statusEl.textContent = '✓ Correct!';
     statusEl.className   = 'sentence-feedback-status correct';
     textEl.textContent   = result.feedback || '';
-    results.push({ sentence_id: s.id, grammar_tip: s.grammar_tip, correct: true });
+    results.push({ sentence_id: s.id, grammar_tip: s.grammar_tip, concept: s.concept, correct: true });
     // Play the correct sentence audio then auto-advance
     const target = result.corrected || s.target || '';
     if (target) {
@@ -181,7 +181,7 @@ function showFeedback(result, s) {
       playCorrectBtn.classList.remove('hidden');
       playCorrectSentence();
     }
-    results.push({ sentence_id: s.id, grammar_tip: s.grammar_tip, correct: false });
+    results.push({ sentence_id: s.id, grammar_tip: s.grammar_tip, concept: result.concept || s.concept, correct: false });
     nextBtn.classList.remove('hidden');
   }
 }
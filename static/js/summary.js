@@ -91,12 +91,14 @@ async function loadSummary() {
 function correctionsTitle(personality) {
   if (personality === 'vocab-builder') return '📝 Words to Practice';
   if (personality === 'listening')     return '🎧 Comprehension to Review';
+  if (personality === 'pronunciation-coach') return '🗣️ Sounds to Practice';
   return '✏️ Grammar Corrections';
 }
 
 function correctionsEmpty(personality) {
   if (personality === 'vocab-builder') return 'No weak words — great job!';
   if (personality === 'listening')     return 'All questions answered correctly!';
+  if (personality === 'pronunciation-coach') return 'Every sound came out clearly!';
   return 'No major corrections — great job!';
 }
 
@@ -113,6 +115,7 @@ function renderSummary(r) {
     'business-executive': '💼 Business Executive',
     'travel-guide':     '🗺️ Travel Guide',
     'writing-coach':    '✍️ Writing Coach',
+    'pronunciation-coach': '🗣️ Pronunciation Coach',
   };
   const personalityLabel = r.personality ? PERSONALITY_NAMES[r.personality] || r.personality : null;
 
@@ -12,7 +12,6 @@ let sessionId        = null;
 let isSending        = false;
 let sessionStartTime = null;
 let timerInterval    = null;
-let allMisspellings  = []; // accumulated across session, sent with complete
 // Track the last user bubble element for retroactive misspelling highlights
 let lastUserBubble   = null;
 let lastUserText     = '';
@@ -245,25 +244,66 @@ async function sendMessage() {
   // Show typing indicator
   const typingEl = showTypingIndicator();
 
+  // The reply streams in while misspellings arrive as a separate event
+  let streamEl = null;
+  let replyText = '';
   try {
-    const data = await API.post('/api/writing/message', {
+    const res = await API.stream('/api/writing/message', {
       session_id: sessionId,
       message:    text,
     });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({ error: 'Failed to get reply' }));
+      throw new Error(err.error || 'Failed to get reply');
+    }
 
-    typingEl.remove();
-
-    // Retroactively highlight misspellings on the user bubble
-    if (data.misspellings && data.misspellings.length > 0) {
-      if (lastUserBubble) {
-        lastUserBubble.innerHTML = highlightMisspellings(escapeHtml(lastUserText), data.misspellings);
+    const reader  = res.body.getReader();
+    const decoder = new TextDecoder();
+    let   buffer  = '';
+    let   done    = false;
+
+    while (!done) {
+      const chunk = await reader.read();
+      if (chunk.done) break;
+      buffer += decoder.decode(chunk.value, { stream: true });
+      const lines = buffer.split('\n');
+      buffer = lines.pop(); // keep incomplete last line
+
+      for (const line of lines) {
+        if (!line.startsWith('data: ')) continue;
+        let data;
+        try { data = JSON.parse(line.slice(6)); } catch { continue; }
+
+        if (data.error) throw new Error(data.error);
+        if (data.content) {
+          if (!streamEl) {
+            typingEl.remove();
+            streamEl = appendStreamingReply();
+          }
+          replyText += data.content;
+          streamEl.querySelector('.msg-bubble').textContent = replyText;
+          scrollToBottom();
+        }
+        // Retroactively highlight misspellings on the user bubble
+        if (data.misspellings && data.misspellings.length > 0) {
+          if (lastUserBubble) {
+            lastUserBubble.innerHTML = highlightMisspellings(escapeHtml(lastUserText), data.misspellings);
+          }
+        }
+        if (data.done) {
+          replyText = data.reply || replyText;
+          done = true;
+        }
       }
-      allMisspellings.push(...data.misspellings);
     }
 
-    appendMessage('assistant', data.reply);
+    typingEl.remove();
+    if (streamEl) streamEl.remove();
+    if (!replyText) throw new Error('No response received. Please try again.');
+    appendMessage('assistant', replyText);
   } catch (err) {
     typingEl.remove();
+    if (streamEl) streamEl.remove();
     appendMessage('assistant', '⚠️ ' + escapeHtml(err.message || 'Failed to get reply'));
   } finally {
     isSending = false;
@@ -272,6 +312,23 @@ async function sendMessage() {
   }
 }
 
+/* ── Streaming reply ────────────────────────────────────────────────────────── */
+// Plain bubble shown while the reply streams; replaced by a full message
+// (with play and translate actions) once it is complete.
+function appendStreamingReply() {
+  const container = document.getElementById('messagesContainer');
+  const el = document.createElement('div');
+  el.className = 'message assistant streaming';
+  el.innerHTML = `
+    <div class="msg-avatar">🤖</div>
+    <div class="msg-body">
+      <div class="msg-bubble"></div>
+    </div>`;
+  container.appendChild(el);
+  scrollToBottom();
+  return el;
+}
+
 /* ── Typing indicator ───────────────────────────────────────────────────────── */
 function showTypingIndicator() {
   const container = document.getElementById('messagesContainer');
@@ -316,7 +373,6 @@ async function endSession() {
       session_id:    sessionId,
       duration_secs: duration,
       topic_name:    topicName,
-      misspellings:  allMisspellings,
     });
 
     // Stash full record data for summary.js
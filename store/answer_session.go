@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SessionAnswer is a learner's first answer to one item of an answer-key
+// session: a question, dictation sentence or lesson exercise.
+type SessionAnswer struct {
+	Answer  string `json:"answer"`
+	Correct bool   `json:"correct"`
+}
+
+// answerSessions keeps sessions whose answer key stays on the server, such
+// as listening stories, grammar lessons and reading texts. Each session is a
+// Redis hash under prefix+userID+":"+id that expires ttl after it was saved:
+// the session itself is stored JSON-encoded under "session", and answers
+// under "answer:<index>". Fields are written at most once and only while the
+// session exists: only the first answer to an item counts, a session is only
+// completed once, and no write outlives the session's TTL.
+//
+// Mode stores embed it and wrap save and load with their session type.
+type answerSessions struct {
+	rdb    *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+func (s answerSessions) key(userID, id string) string {
+	return s.prefix + userID + ":" + id
+}
+
+// save stores session id.
+func (s answerSessions) save(ctx context.Context, userID, id string, session any) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	key := s.key(userID, id)
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "session", data)
+		pipe.Expire(ctx, key, s.ttl)
+		return nil
+	})
+	return err
+}
+
+// load decodes session id into session and returns all fields of its hash.
+// Fields are nil if the session is unknown or expired.
+func (s answerSessions) load(ctx context.Context, userID, id string, session any) (map[string]string, error) {
+	fields, err := s.rdb.HGetAll(ctx, s.key(userID, id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	data, ok := fields["session"]
+	if !ok {
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(data), session); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// ErrSessionExpired is returned when a write reaches a session that expired
+// after it was loaded.
+var ErrSessionExpired = errors.New("session expired")
+
+// setOnceScript sets a hash field unless it is already set, like HSETNX, but
+// only while the hash still holds a session: a plain HSETNX on an expired
+// session would recreate the hash without a TTL. Returns -1 if the session
+// is gone, else 1 if the field was set.
+var setOnceScript = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], "session") == 0 then
+	return -1
+end
+return redis.call("HSETNX", KEYS[1], ARGV[1], ARGV[2])
+`)
+
+// setOnce sets field of session id to value unless it is already set, and
+// reports whether it did.
+func (s answerSessions) setOnce(ctx context.Context, userID, id, field string, value any) (bool, error) {
+	n, err := setOnceScript.Run(ctx, s.rdb, []string{s.key(userID, id)}, field, value).Int()
+	if err != nil {
+		return false, err
+	}
+	if n < 0 {
+		return false, ErrSessionExpired
+	}
+	return n == 1, nil
+}
+
+// RecordAnswer stores the answer to item idx unless one is already recorded,
+// and returns the answer that counts: the first one.
+func (s answerSessions) RecordAnswer(ctx context.Context, userID, id string, idx int, answer SessionAnswer) (SessionAnswer, error) {
+	field := "answer:" + strconv.Itoa(idx)
+	data, _ := json.Marshal(answer)
+	set, err := s.setOnce(ctx, userID, id, field, data)
+	if err != nil || set {
+		return answer, err
+	}
+	raw, err := s.rdb.HGet(ctx, s.key(userID, id), field).Bytes()
+	if err != nil {
+		return answer, err
+	}
+	var first SessionAnswer
+	if err := json.Unmarshal(raw, &first); err != nil {
+		return answer, err
+	}
+	return first, nil
+}
+
+// MarkCompleted marks session id completed. It reports false if the session
+// was already completed, so a session is only scored once.
+func (s answerSessions) MarkCompleted(ctx context.Context, userID, id string) (bool, error) {
+	return s.setOnce(ctx, userID, id, "completed", 1)
+}
+
+// answerFields returns the answers among the fields of a session hash.
+func answerFields(fields map[string]string) map[int]SessionAnswer {
+	answers := make(map[int]SessionAnswer)
+	for f, v := range fields {
+		idx, ok := strings.CutPrefix(f, "answer:")
+		if !ok {
+			continue
+		}
+		i, err := strconv.Atoi(idx)
+		if err != nil {
+			continue
+		}
+		var a SessionAnswer
+		if err := json.Unmarshal([]byte(v), &a); err == nil {
+			answers[i] = a
+		}
+	}
+	return answers
+}
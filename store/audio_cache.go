@@ -0,0 +1,365 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrAudioNotCached = errors.New("audio not cached")
+
+// AudioCache is a content-addressed on-disk cache of rendered TTS audio.
+// Entries are keyed by a hash of everything that affects the rendered output
+// (provider, text, voice, model, speed, voice settings), so identical requests
+// from any user share one file. Files live under dir/<first two key chars>/
+// as <key>.mp3 or <key>.wav depending on the producing engine.
+//
+// The cache is bounded by maxBytes: when a commit pushes the total over the
+// limit, the least-recently-used files are removed until it drops to 90% of
+// the limit. Hits refresh the file's mtime, which is what eviction sorts on.
+//
+// Small JSON sidecars (<key>.<name>.json) can be stored next to an entry,
+// e.g. the recipe needed to re-render it. Sidecars do not count towards the
+// size limit and are removed together with their audio on eviction.
+type AudioCache struct {
+	dir      string
+	maxBytes int64
+
+	mu       sync.Mutex
+	size     int64
+	evicting bool
+}
+
+// NewAudioCache creates a cache rooted at dir. maxBytes <= 0 disables eviction.
+func NewAudioCache(dir string, maxBytes int64) *AudioCache {
+	return &AudioCache{dir: dir, maxBytes: maxBytes}
+}
+
+// Load creates the cache directory if needed and measures its current size.
+// Leftover temp files from an interrupted write are removed.
+func (c *AudioCache) Load() {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		log.Printf("audio cache: mkdir %s: %v", c.dir, err)
+		return
+	}
+	var total int64
+	_ = filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(path, ".tmp") {
+			_ = os.Remove(path)
+			return nil
+		}
+		if !isAudioFile(path) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	c.mu.Lock()
+	c.size = total
+	c.mu.Unlock()
+}
+
+// AudioKey returns the cache key for the given request parts. Parts are JSON
+// encoded before hashing, so structs (e.g. voice settings) can be passed as-is.
+func AudioKey(parts ...any) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, p := range parts {
+		_ = enc.Encode(p) // parts are plain values and structs
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ValidAudioKey reports whether key looks like a key produced by AudioKey.
+// Used to reject path tricks before a key from a URL touches the filesystem.
+func ValidAudioKey(key string) bool {
+	if len(key) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+	return err == nil
+}
+
+// audioExts maps the content types the cache stores to file extensions.
+var audioExts = []struct{ contentType, ext string }{
+	{"audio/mpeg", ".mp3"},
+	{"audio/wav", ".wav"},
+}
+
+func audioExt(contentType string) string {
+	for _, e := range audioExts {
+		if e.contentType == contentType {
+			return e.ext
+		}
+	}
+	return ".mp3"
+}
+
+func isAudioFile(path string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range audioExts {
+		if e.ext == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *AudioCache) path(key, ext string) string {
+	return filepath.Join(c.dir, key[:2], key+ext)
+}
+
+// CachedAudio is an open cache entry. The caller must Close it.
+type CachedAudio struct {
+	*os.File
+	Info        os.FileInfo
+	ContentType string
+}
+
+// Open returns the cached entry for key, or ErrAudioNotCached on a miss.
+func (c *AudioCache) Open(key string) (*CachedAudio, error) {
+	if !ValidAudioKey(key) {
+		return nil, ErrAudioNotCached
+	}
+	for _, e := range audioExts {
+		p := c.path(key, e.ext)
+		f, err := os.Open(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		now := time.Now()
+		_ = os.Chtimes(p, now, now) // mark as recently used for eviction
+		return &CachedAudio{File: f, Info: info, ContentType: e.contentType}, nil
+	}
+	return nil, ErrAudioNotCached
+}
+
+// Create starts writing a new entry for key with the given content type.
+// Data is written to a temp file and only becomes visible to Open after Commit.
+func (c *AudioCache) Create(key, contentType string) (*AudioCacheWriter, error) {
+	if !ValidAudioKey(key) {
+		return nil, errors.New("audio cache: invalid key")
+	}
+	if err := os.MkdirAll(filepath.Join(c.dir, key[:2]), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(filepath.Join(c.dir, key[:2]), key+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &AudioCacheWriter{cache: c, key: key, ext: audioExt(contentType), f: f}, nil
+}
+
+// WriteSidecar stores v as JSON next to the entry for key under name.
+func (c *AudioCache) WriteSidecar(key, name string, v any) error {
+	if !ValidAudioKey(key) {
+		return errors.New("audio cache: invalid key")
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(c.dir, key[:2])
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Each write gets its own temp file: recipes and alignments for one key
+	// may be written concurrently.
+	f, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, c.path(key, "."+name+".json"))
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
+
+// ReadSidecar decodes the sidecar name for key into v. It returns
+// ErrAudioNotCached when the sidecar does not exist.
+func (c *AudioCache) ReadSidecar(key, name string, v any) error {
+	if !ValidAudioKey(key) {
+		return ErrAudioNotCached
+	}
+	data, err := os.ReadFile(c.path(key, "."+name+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrAudioNotCached
+		}
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// AudioCacheWriter receives the bytes of one cache entry.
+type AudioCacheWriter struct {
+	cache *AudioCache
+	key   string
+	ext   string
+	f     *os.File
+	n     int64
+	done  bool
+}
+
+func (aw *AudioCacheWriter) Write(p []byte) (int, error) {
+	n, err := aw.f.Write(p)
+	aw.n += int64(n)
+	return n, err
+}
+
+// Commit atomically publishes the entry and triggers eviction if the cache
+// has grown past its limit.
+func (aw *AudioCacheWriter) Commit() error {
+	if aw.done {
+		return nil
+	}
+	aw.done = true
+	tmp := aw.f.Name()
+	if err := aw.f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if aw.n == 0 {
+		_ = os.Remove(tmp)
+		return errors.New("audio cache: empty entry")
+	}
+	dst := aw.cache.path(aw.key, aw.ext)
+	var replaced int64
+	if info, err := os.Stat(dst); err == nil {
+		replaced = info.Size()
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	aw.cache.added(aw.n - replaced)
+	return nil
+}
+
+// Abort discards the partially written entry. Safe to call after Commit.
+func (aw *AudioCacheWriter) Abort() {
+	if aw.done {
+		return
+	}
+	aw.done = true
+	tmp := aw.f.Name()
+	aw.f.Close()
+	_ = os.Remove(tmp)
+}
+
+func (c *AudioCache) added(n int64) {
+	c.mu.Lock()
+	c.size += n
+	over := c.maxBytes > 0 && c.size > c.maxBytes && !c.evicting
+	if over {
+		c.evicting = true
+	}
+	c.mu.Unlock()
+	if over {
+		go func() {
+			c.Evict()
+			c.mu.Lock()
+			c.evicting = false
+			c.mu.Unlock()
+		}()
+	}
+}
+
+// Size returns the approximate number of bytes currently cached.
+func (c *AudioCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Evict removes least-recently-used entries until the cache is at or below
+// 90% of maxBytes. It is a no-op when the cache is under its limit.
+func (c *AudioCache) Evict() {
+	if c.maxBytes <= 0 {
+		return
+	}
+	type entry struct {
+		path  string
+		size  int64
+		mtime time.Time
+	}
+	var entries []entry
+	var total int64
+	_ = filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isAudioFile(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		entries = append(entries, entry{path: path, size: info.Size(), mtime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if total <= c.maxBytes {
+		c.mu.Lock()
+		c.size = total
+		c.mu.Unlock()
+		return
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].mtime.Before(entries[j].mtime) })
+	target := c.maxBytes * 9 / 10
+	removed := 0
+	for _, e := range entries {
+		if total <= target {
+			break
+		}
+		if err := os.Remove(e.path); err != nil {
+			continue
+		}
+		removeSidecars(e.path)
+		total -= e.size
+		removed++
+	}
+	c.mu.Lock()
+	c.size = total
+	c.mu.Unlock()
+	log.Printf("audio cache: evicted %d files, %d bytes remain", removed, total)
+}
+
+// removeSidecars deletes the <key>.<name>.json files next to the audio file
+// at path.
+func removeSidecars(path string) {
+	key := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), key+".*.json"))
+	for _, m := range matches {
+		_ = os.Remove(m)
+	}
+}
@@ -0,0 +1,177 @@
+package store_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAudio(t *testing.T, c *store.AudioCache, key, data string) {
+	t.Helper()
+	aw, err := c.Create(key, "audio/mpeg")
+	require.NoError(t, err)
+	_, err = aw.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, aw.Commit())
+}
+
+func TestAudioKey_StableAndDistinct(t *testing.T) {
+	a := store.AudioKey("voice1", "ciao", 1.0)
+	b := store.AudioKey("voice1", "ciao", 1.0)
+	c := store.AudioKey("voice1", "ciao", 0.85)
+
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+	assert.True(t, store.ValidAudioKey(a))
+	assert.False(t, store.ValidAudioKey("../../etc/passwd"))
+}
+
+func TestAudioCache_MissThenHit(t *testing.T) {
+	c := store.NewAudioCache(t.TempDir(), 0)
+	c.Load()
+	key := store.AudioKey("voice1", "hola")
+
+	_, err := c.Open(key)
+	assert.ErrorIs(t, err, store.ErrAudioNotCached)
+
+	writeAudio(t, c, key, "mp3-bytes")
+
+	f, err := c.Open(key)
+	require.NoError(t, err)
+	defer f.Close()
+	data, _ := io.ReadAll(f)
+	assert.Equal(t, "mp3-bytes", string(data))
+	assert.Equal(t, int64(9), f.Info.Size())
+	assert.Equal(t, "audio/mpeg", f.ContentType)
+	assert.Equal(t, int64(9), c.Size())
+}
+
+func TestAudioCache_KeepsContentType(t *testing.T) {
+	c := store.NewAudioCache(t.TempDir(), 0)
+	key := store.AudioKey("espeak-ng", "ciao")
+
+	aw, err := c.Create(key, "audio/wav")
+	require.NoError(t, err)
+	_, _ = aw.Write([]byte("RIFF"))
+	require.NoError(t, aw.Commit())
+
+	f, err := c.Open(key)
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, "audio/wav", f.ContentType)
+	assert.Equal(t, ".wav", filepath.Ext(f.Name()))
+}
+
+func TestAudioCache_AbortLeavesNothing(t *testing.T) {
+	dir := t.TempDir()
+	c := store.NewAudioCache(dir, 0)
+	key := store.AudioKey("voice1", "obrigado")
+
+	aw, err := c.Create(key, "audio/mpeg")
+	require.NoError(t, err)
+	_, _ = aw.Write([]byte("partial"))
+	aw.Abort()
+
+	_, err = c.Open(key)
+	assert.ErrorIs(t, err, store.ErrAudioNotCached)
+
+	var files []string
+	_ = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	assert.Empty(t, files, "aborted write should remove its temp file")
+}
+
+func TestAudioCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := store.NewAudioCache(t.TempDir(), 25)
+	old := store.AudioKey("old")
+	recent := store.AudioKey("recent")
+
+	writeAudio(t, c, old, strings.Repeat("a", 10))
+	writeAudio(t, c, recent, strings.Repeat("b", 10))
+
+	// Backdate "old" so it is clearly the least recently used entry.
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(filepathDir(t, c, old), old+".mp3"), past, past))
+
+	writeAudio(t, c, store.AudioKey("new"), strings.Repeat("c", 10))
+	c.Evict()
+
+	_, err := c.Open(old)
+	assert.ErrorIs(t, err, store.ErrAudioNotCached, "oldest entry should be evicted")
+	f, err := c.Open(recent)
+	require.NoError(t, err)
+	f.Close()
+	assert.LessOrEqual(t, c.Size(), int64(25))
+}
+
+// filepathDir locates the shard directory holding key by opening it.
+func filepathDir(t *testing.T, c *store.AudioCache, key string) string {
+	t.Helper()
+	f, err := c.Open(key)
+	require.NoError(t, err)
+	defer f.Close()
+	return filepath.Dir(f.Name())
+}
+
+func TestAudioCache_SidecarsEvictedWithAudio(t *testing.T) {
+	c := store.NewAudioCache(t.TempDir(), 15)
+	key := store.AudioKey("story", "segment 1")
+	other := store.AudioKey("other")
+
+	var missing map[string]string
+	assert.ErrorIs(t, c.ReadSidecar(key, "recipe", &missing), store.ErrAudioNotCached)
+
+	require.NoError(t, c.WriteSidecar(key, "recipe", map[string]string{"text": "C'era una volta"}))
+	require.NoError(t, c.WriteSidecar(key, "alignment", []int{1, 2}))
+	writeAudio(t, c, key, strings.Repeat("a", 10))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(filepathDir(t, c, key), key+".mp3"), past, past))
+	require.NoError(t, c.WriteSidecar(other, "recipe", map[string]string{"text": "altro"}))
+	writeAudio(t, c, other, strings.Repeat("b", 10))
+	c.Evict()
+
+	_, err := c.Open(key)
+	assert.ErrorIs(t, err, store.ErrAudioNotCached)
+	var recipe map[string]string
+	assert.ErrorIs(t, c.ReadSidecar(key, "recipe", &recipe), store.ErrAudioNotCached)
+	var alignment []int
+	assert.ErrorIs(t, c.ReadSidecar(key, "alignment", &alignment), store.ErrAudioNotCached)
+
+	require.NoError(t, c.ReadSidecar(other, "recipe", &recipe))
+	assert.Equal(t, "altro", recipe["text"])
+}
+
+func TestAudioCache_ConcurrentSidecarWrites(t *testing.T) {
+	dir := t.TempDir()
+	c := store.NewAudioCache(dir, 0)
+	key := store.AudioKey("story", "segment 1")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, c.WriteSidecar(key, "recipe", map[string]string{"text": strings.Repeat("x", i*100)}))
+		}(i)
+	}
+	wg.Wait()
+
+	var recipe map[string]string
+	require.NoError(t, c.ReadSidecar(key, "recipe", &recipe), "the sidecar is never torn")
+	_ = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		assert.False(t, strings.HasSuffix(p, ".tmp"), "temp file left behind: %s", p)
+		return nil
+	})
+}
@@ -12,8 +12,11 @@ const leaderboardCacheKey = "cache:leaderboard"
 const statsKeyPrefix = "cache:stats:"
 const leaderboardTTL = 5 * time.Minute
 const statsTTL = 2 * time.Minute
+const wordDetailsKeyPrefix = "cache:words:"
+const wordDetailsTTL = 30 * 24 * time.Hour
 
-// CacheStore caches leaderboard results and per-user stats in Redis.
+// CacheStore caches leaderboard results, per-user stats and flashcard details
+// of shared words in Redis.
 type CacheStore struct {
 	rdb *redis.Client
 }
@@ -62,3 +65,52 @@ func (c *CacheStore) GetUserStats(ctx context.Context, userID string, dest any)
 func (c *CacheStore) InvalidateUserStats(ctx context.Context, userID string) error {
 	return c.rdb.Del(ctx, statsKeyPrefix+userID).Err()
 }
+
+// WordDetails is the flashcard translation and pronunciation guide of a word.
+type WordDetails struct {
+	Translation string `json:"translation"`
+	Phonetic    string `json:"phonetic"`
+}
+
+// GetWordDetails returns the cached details of words in language, keyed by
+// word. Words without cached details are left out.
+func (c *CacheStore) GetWordDetails(ctx context.Context, language string, words []string) map[string]WordDetails {
+	out := make(map[string]WordDetails)
+	if len(words) == 0 {
+		return out
+	}
+	vals, err := c.rdb.HMGet(ctx, wordDetailsKeyPrefix+language, words...).Result()
+	if err != nil {
+		return out
+	}
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var d WordDetails
+		if json.Unmarshal([]byte(s), &d) == nil {
+			out[words[i]] = d
+		}
+	}
+	return out
+}
+
+// SetWordDetails caches details keyed by word for 30 days.
+func (c *CacheStore) SetWordDetails(ctx context.Context, language string, details map[string]WordDetails) error {
+	if len(details) == 0 {
+		return nil
+	}
+	fields := make(map[string]any, len(details))
+	for w, d := range details {
+		data, _ := json.Marshal(d) // WordDetails is always serialisable
+		fields[w] = data
+	}
+	key := wordDetailsKeyPrefix + language
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, fields)
+		pipe.Expire(ctx, key, wordDetailsTTL)
+		return nil
+	})
+	return err
+}
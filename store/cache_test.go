@@ -124,3 +124,17 @@ func TestCacheStore_SetUserStats_RedisError(t *testing.T) {
 	err := cs.SetUserStats(context.Background(), "user-y", map[string]any{"streak": 1})
 	assert.Error(t, err)
 }
+
+func TestCacheStore_WordDetails_RoundTrip(t *testing.T) {
+	cs, _ := newTestCacheStore(t)
+	ctx := context.Background()
+
+	assert.Empty(t, cs.GetWordDetails(ctx, "es", []string{"casa"}))
+	require.NoError(t, cs.SetWordDetails(ctx, "es", map[string]store.WordDetails{
+		"casa": {Translation: "house", Phonetic: "KAH-sah"},
+	}))
+
+	got := cs.GetWordDetails(ctx, "es", []string{"casa", "perro"})
+	assert.Equal(t, map[string]store.WordDetails{"casa": {Translation: "house", Phonetic: "KAH-sah"}}, got)
+	assert.Empty(t, cs.GetWordDetails(ctx, "it", []string{"casa"}), "details are per language")
+}
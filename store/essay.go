@@ -0,0 +1,42 @@
+package store
+
+// Essay correction categories.
+const (
+	EssaySpelling   = "spelling"
+	EssayGrammar    = "grammar"
+	EssayWordChoice = "word_choice"
+	EssayStyle      = "style"
+)
+
+// EssayCorrection is one correction of an essay: the text from Start to End
+// (character offsets into the original) and what should replace it.
+type EssayCorrection struct {
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	Original    string `json:"original"`
+	Suggestion  string `json:"suggestion"`
+	Category    string `json:"category"`          // EssaySpelling, EssayGrammar, EssayWordChoice or EssayStyle
+	Concept     string `json:"concept,omitempty"` // grammar concept ID of grammar corrections
+	Explanation string `json:"explanation"`
+}
+
+// EssayCriterion is the CEFR band an essay shows on one assessment
+// criterion.
+type EssayCriterion struct {
+	Criterion string `json:"criterion"`
+	Band      string `json:"band"`
+	Comment   string `json:"comment"`
+}
+
+// EssayReview is a corrected essay, stored with its ConversationRecord. The
+// corrections, in text order and not overlapping, are the diff from Text to
+// Corrected.
+type EssayReview struct {
+	Prompt      string            `json:"prompt"`
+	Text        string            `json:"text"`
+	Corrected   string            `json:"corrected"`
+	WordCount   int               `json:"word_count"`
+	Corrections []EssayCorrection `json:"corrections"`
+	Rubric      []EssayCriterion  `json:"rubric"`
+	Band        string            `json:"band"` // overall, from the rubric
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const grammarKeyPrefix = "grammar:"
+const grammarTTL = 2 * time.Hour
+
+// GrammarExercise is the answer key of one lesson exercise.
+type GrammarExercise struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind"`
+	Prompt      string `json:"prompt"`
+	Answer      string `json:"answer"`
+	Explanation string `json:"explanation"`
+}
+
+// GrammarSession is a grammar lesson in progress. It holds the answer key of
+// the lesson that was served, which need not be the one pooled for the
+// concept: lessons out of level are served without being pooled. Like a
+// listening session, answers are recorded one exercise at a time and only
+// the first answer to each counts.
+type GrammarSession struct {
+	Language  string            `json:"language"`
+	Level     int               `json:"level"`
+	LessonID  string            `json:"lesson_id"` // grammar concept ID
+	Title     string            `json:"title"`
+	Exercises []GrammarExercise `json:"exercises"`
+
+	Answers map[int]SessionAnswer `json:"-"` // by exercise index; filled in by Get
+}
+
+// GrammarSessionStore keeps grammar lesson sessions per user in Redis for two
+// hours.
+type GrammarSessionStore struct {
+	answerSessions
+}
+
+func NewGrammarSessionStore(rdb *redis.Client) *GrammarSessionStore {
+	return &GrammarSessionStore{answerSessions{rdb: rdb, prefix: grammarKeyPrefix, ttl: grammarTTL}}
+}
+
+// Save stores session id.
+func (s *GrammarSessionStore) Save(ctx context.Context, userID, id string, session GrammarSession) error {
+	return s.save(ctx, userID, id, session)
+}
+
+// Get returns session id with the answers recorded so far, or nil if it is
+// unknown or expired.
+func (s *GrammarSessionStore) Get(ctx context.Context, userID, id string) (*GrammarSession, error) {
+	var session GrammarSession
+	fields, err := s.load(ctx, userID, id, &session)
+	if fields == nil {
+		return nil, err
+	}
+	session.Answers = answerFields(fields)
+	return &session, nil
+}
@@ -0,0 +1,47 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ailanguagetutor/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrammarSessionStore_FirstAnswerAndCompletion(t *testing.T) {
+	mr := miniredis.RunT(t)
+	gs := store.NewGrammarSessionStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+	session := store.GrammarSession{
+		Language: "es", Level: 1, LessonID: "es.ser-estar", Title: "Ser vs estar",
+		Exercises: []store.GrammarExercise{{ID: "1", Kind: "fill", Prompt: "Yo ___ cansado.", Answer: "estoy"}},
+	}
+	require.NoError(t, gs.Save(ctx, "user-1", "s-1", session))
+
+	got, err := gs.RecordAnswer(ctx, "user-1", "s-1", 0, store.SessionAnswer{Answer: "soy"})
+	require.NoError(t, err)
+	assert.False(t, got.Correct)
+	got, err = gs.RecordAnswer(ctx, "user-1", "s-1", 0, store.SessionAnswer{Answer: "estoy", Correct: true})
+	require.NoError(t, err)
+	assert.Equal(t, store.SessionAnswer{Answer: "soy"}, got, "the first answer counts")
+
+	loaded, err := gs.Get(ctx, "user-1", "s-1")
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, session.Exercises, loaded.Exercises)
+	assert.Equal(t, map[int]store.SessionAnswer{0: {Answer: "soy"}}, loaded.Answers)
+
+	first, err := gs.MarkCompleted(ctx, "user-1", "s-1")
+	require.NoError(t, err)
+	assert.True(t, first)
+	first, err = gs.MarkCompleted(ctx, "user-1", "s-1")
+	require.NoError(t, err)
+	assert.False(t, first)
+
+	other, err := gs.Get(ctx, "user-2", "s-1")
+	require.NoError(t, err)
+	assert.Nil(t, other)
+}
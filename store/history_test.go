@@ -0,0 +1,48 @@
+package store_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/database"
+	"github.com/ailanguagetutor/store"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Runs against the Postgres database at TEST_DATABASE_URL, if set.
+func TestConversationHistory_VocabularyForUserCoversAllRecords(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := database.Connect(ctx, url)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	users := store.NewUserStore(pool)
+	user, err := users.Create(uuid.NewString()+"@example.com", "learner", "password123", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { users.Delete(user.ID) })
+
+	hs := store.NewConversationHistoryStore(pool)
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 12; i++ {
+		hs.Save(&store.ConversationRecord{
+			ID: uuid.NewString(), UserID: user.ID, Language: "es",
+			Vocabulary: []string{fmt.Sprintf("palabra%d - word %d", i, i)},
+			CreatedAt:  start, EndedAt: start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	hs.Save(&store.ConversationRecord{ID: uuid.NewString(), UserID: user.ID, Language: "it", Vocabulary: []string{"parola - word"}})
+
+	vocab, err := hs.VocabularyForUser(ctx, user.ID, "es")
+	require.NoError(t, err)
+	require.Len(t, vocab, 12, "not limited to the last 10 sessions")
+	assert.Equal(t, "palabra11 - word 11", vocab[0], "newest first")
+}
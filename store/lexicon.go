@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// LexiconEntry tells TTS how to pronounce a term in one language: either an
+// alias spelled the way it should sound, or an IPA transcription for
+// providers that accept phonemes. Matching is whole-word and case-insensitive.
+type LexiconEntry struct {
+	Language  string    `json:"language"`
+	Term      string    `json:"term"`
+	Alias     string    `json:"alias,omitempty"`
+	Phoneme   string    `json:"phoneme,omitempty"` // IPA
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LexiconStore keeps pronunciation lexicons in Postgres (table tts_lexicon)
+// with an in-memory copy, since every synthesis request reads them.
+type LexiconStore struct {
+	pool *pgxpool.Pool
+
+	mu      sync.RWMutex
+	entries map[string][]LexiconEntry // language → entries, longest term first
+}
+
+func NewLexiconStore(pool *pgxpool.Pool) *LexiconStore {
+	return &LexiconStore{pool: pool, entries: map[string][]LexiconEntry{}}
+}
+
+// Load reads all lexicons into memory. Call once at startup; Upsert and
+// Delete keep the copy current afterwards.
+func (ls *LexiconStore) Load(ctx context.Context) error {
+	rows, err := ls.pool.Query(ctx, `SELECT language, term, alias, phoneme, updated_at FROM tts_lexicon`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	entries := map[string][]LexiconEntry{}
+	for rows.Next() {
+		var e LexiconEntry
+		if err := rows.Scan(&e.Language, &e.Term, &e.Alias, &e.Phoneme, &e.UpdatedAt); err != nil {
+			return err
+		}
+		entries[e.Language] = append(entries[e.Language], e)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, list := range entries {
+		sortLexicon(list)
+	}
+	ls.mu.Lock()
+	ls.entries = entries
+	ls.mu.Unlock()
+	return nil
+}
+
+// Entries returns the lexicon for language, longest term first so that
+// multi-word terms win over their parts. The slice must not be modified.
+func (ls *LexiconStore) Entries(language string) []LexiconEntry {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.entries[language]
+}
+
+// Upsert adds or replaces the entry for (language, term).
+func (ls *LexiconStore) Upsert(ctx context.Context, e LexiconEntry) error {
+	e.Term = strings.TrimSpace(e.Term)
+	e.UpdatedAt = time.Now()
+	_, err := ls.pool.Exec(ctx, `
+INSERT INTO tts_lexicon (language, term, alias, phoneme, updated_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (language, lower(term)) DO UPDATE SET
+    term = EXCLUDED.term, alias = EXCLUDED.alias, phoneme = EXCLUDED.phoneme, updated_at = EXCLUDED.updated_at`,
+		e.Language, e.Term, e.Alias, e.Phoneme, e.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	list := removeTerm(ls.entries[e.Language], e.Term)
+	list = append(list, e)
+	sortLexicon(list)
+	ls.entries[e.Language] = list
+	return nil
+}
+
+// Delete removes the entry for (language, term). Missing entries are ignored.
+func (ls *LexiconStore) Delete(ctx context.Context, language, term string) error {
+	if _, err := ls.pool.Exec(ctx, `DELETE FROM tts_lexicon WHERE language=$1 AND lower(term)=lower($2)`, language, term); err != nil {
+		return err
+	}
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.entries[language] = removeTerm(ls.entries[language], term)
+	return nil
+}
+
+// removeTerm returns a copy of list without term, so readers holding the old
+// slice are unaffected.
+func removeTerm(list []LexiconEntry, term string) []LexiconEntry {
+	out := make([]LexiconEntry, 0, len(list))
+	for _, e := range list {
+		if !strings.EqualFold(e.Term, term) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func sortLexicon(list []LexiconEntry) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return len([]rune(list[i].Term)) > len([]rune(list[j].Term))
+	})
+}
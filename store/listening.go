@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const listeningKeyPrefix = "listening:"
+const listeningTTL = 2 * time.Hour
+
+// ListeningQuestion is the answer key of one story question.
+type ListeningQuestion struct {
+	Type        string   `json:"type"`
+	Question    string   `json:"question"`
+	Options     []string `json:"options,omitempty"`
+	Answer      string   `json:"answer"` // canonical: option index, "yes"/"no" or "true"/"false"; "" if unusable
+	Explanation string   `json:"explanation"`
+}
+
+// Listening session modes.
+const (
+	ListeningComprehension = ""          // questions after each segment
+	ListeningDictation     = "dictation" // type sentences of the story
+)
+
+// ListeningSession is a listening story in progress. The answer key stays on
+// the server; answers are checked and recorded one question (or dictation
+// sentence) at a time.
+type ListeningSession struct {
+	Mode        string              `json:"mode,omitempty"`
+	Language    string              `json:"language"`
+	Level       int                 `json:"level"`
+	Topic       string              `json:"topic"`
+	Personality string              `json:"personality"`
+	PoolKey     string              `json:"pool_key"`
+	Questions   []ListeningQuestion `json:"questions,omitempty"`
+	Sentences   []string            `json:"sentences,omitempty"` // dictation: the sentences to type
+
+	Answers map[int]SessionAnswer `json:"-"` // filled in by Get
+}
+
+// ListeningSessionStore keeps listening sessions per user in Redis for two
+// hours.
+type ListeningSessionStore struct {
+	answerSessions
+}
+
+func NewListeningSessionStore(rdb *redis.Client) *ListeningSessionStore {
+	return &ListeningSessionStore{answerSessions{rdb: rdb, prefix: listeningKeyPrefix, ttl: listeningTTL}}
+}
+
+// Save stores session id.
+func (s *ListeningSessionStore) Save(ctx context.Context, userID, id string, session ListeningSession) error {
+	return s.save(ctx, userID, id, session)
+}
+
+// Get returns session id with the answers recorded so far, or nil if it is
+// unknown or expired.
+func (s *ListeningSessionStore) Get(ctx context.Context, userID, id string) (*ListeningSession, error) {
+	var session ListeningSession
+	fields, err := s.load(ctx, userID, id, &session)
+	if fields == nil {
+		return nil, err
+	}
+	session.Answers = answerFields(fields)
+	return &session, nil
+}
@@ -0,0 +1,74 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestListeningStore(t *testing.T) *store.ListeningSessionStore {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return store.NewListeningSessionStore(rdb)
+}
+
+func TestListeningSessionStore_FirstAnswerCounts(t *testing.T) {
+	ls := newTestListeningStore(t)
+	ctx := context.Background()
+	session := store.ListeningSession{
+		Language: "it", Level: 2, Topic: "food", PoolKey: "it:2:food:professor",
+		Questions: []store.ListeningQuestion{{Type: "yes_no", Question: "Pizza?", Answer: "yes"}},
+	}
+	require.NoError(t, ls.Save(ctx, "user-1", "s-1", session))
+
+	got, err := ls.RecordAnswer(ctx, "user-1", "s-1", 0, store.SessionAnswer{Answer: "no", Correct: false})
+	require.NoError(t, err)
+	assert.False(t, got.Correct)
+	got, err = ls.RecordAnswer(ctx, "user-1", "s-1", 0, store.SessionAnswer{Answer: "yes", Correct: true})
+	require.NoError(t, err)
+	assert.Equal(t, store.SessionAnswer{Answer: "no", Correct: false}, got)
+
+	loaded, err := ls.Get(ctx, "user-1", "s-1")
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, session.Questions, loaded.Questions)
+	assert.Equal(t, map[int]store.SessionAnswer{0: {Answer: "no"}}, loaded.Answers)
+
+	other, err := ls.Get(ctx, "user-2", "s-1")
+	require.NoError(t, err)
+	assert.Nil(t, other)
+}
+
+func TestListeningSessionStore_CompletesOnce(t *testing.T) {
+	ls := newTestListeningStore(t)
+	ctx := context.Background()
+	require.NoError(t, ls.Save(ctx, "user-1", "s-1", store.ListeningSession{Language: "es"}))
+
+	first, err := ls.MarkCompleted(ctx, "user-1", "s-1")
+	require.NoError(t, err)
+	assert.True(t, first)
+	first, err = ls.MarkCompleted(ctx, "user-1", "s-1")
+	require.NoError(t, err)
+	assert.False(t, first)
+}
+
+func TestListeningSessionStore_ExpiredSessionIsNotRecreated(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ls := store.NewListeningSessionStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+	require.NoError(t, ls.Save(ctx, "user-1", "s-1", store.ListeningSession{Language: "es"}))
+	mr.FastForward(3 * time.Hour)
+
+	_, err := ls.RecordAnswer(ctx, "user-1", "s-1", 0, store.SessionAnswer{Answer: "yes"})
+	assert.ErrorIs(t, err, store.ErrSessionExpired)
+	_, err = ls.MarkCompleted(ctx, "user-1", "s-1")
+	assert.ErrorIs(t, err, store.ErrSessionExpired)
+	assert.Empty(t, mr.Keys(), "no hash without a TTL is left behind")
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Misspelling sources.
+const (
+	MisspellingWriting   = "writing"
+	MisspellingEssay     = "essay"
+	MisspellingDictation = "dictation"
+)
+
+// Misspelling is one misspelled word or phrase found in a session.
+type Misspelling struct {
+	Wrong     string    `json:"wrong"`
+	Correct   string    `json:"correct"`
+	Note      string    `json:"note,omitempty"`
+	SessionID string    `json:"session_id"`
+	Source    string    `json:"source"` // MisspellingWriting, MisspellingEssay or MisspellingDictation
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MisspelledWord aggregates a user's misspellings of one correct form.
+type MisspelledWord struct {
+	Word     string    `json:"word"`  // correct form
+	Count    int       `json:"count"` // times misspelled
+	Forms    []string  `json:"forms"` // distinct wrong forms
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// ParseMisspelling parses the "wrong → correct (note)" form the tutors
+// return misspellings in. It reports false if there is no arrow, either side
+// is empty or both are the same.
+func ParseMisspelling(s string) (Misspelling, bool) {
+	wrong, rest, ok := strings.Cut(s, "→")
+	if !ok {
+		return Misspelling{}, false
+	}
+	m := Misspelling{Wrong: strings.TrimSpace(wrong), Correct: strings.TrimSpace(rest)}
+	if i := strings.Index(m.Correct, "("); i >= 0 && strings.HasSuffix(m.Correct, ")") {
+		m.Note = strings.TrimSpace(m.Correct[i+1 : len(m.Correct)-1])
+		m.Correct = strings.TrimSpace(m.Correct[:i])
+	}
+	if m.Wrong == "" || m.Correct == "" || m.Wrong == m.Correct {
+		return Misspelling{}, false
+	}
+	return m, true
+}
+
+// MisspellingStore keeps misspellings in Postgres (table misspellings), one
+// row per occurrence.
+type MisspellingStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewMisspellingStore(pool *pgxpool.Pool) *MisspellingStore {
+	return &MisspellingStore{pool: pool}
+}
+
+// Add records misspellings by userID in language.
+func (s *MisspellingStore) Add(ctx context.Context, userID, language string, items []Misspelling) error {
+	if len(items) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, m := range items {
+		createdAt := m.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		batch.Queue(`
+INSERT INTO misspellings (user_id, language, wrong, correct, note, session_id, source, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			userID, language, m.Wrong, m.Correct, m.Note, m.SessionID, m.Source, createdAt)
+	}
+	return s.pool.SendBatch(ctx, batch).Close()
+}
+
+// Frequent returns the words userID misspells most in language, most often
+// misspelled first, then most recent.
+func (s *MisspellingStore) Frequent(ctx context.Context, userID, language string, limit int) ([]MisspelledWord, error) {
+	rows, err := s.pool.Query(ctx, `
+SELECT min(correct), count(*), array_agg(DISTINCT wrong), max(created_at)
+FROM misspellings WHERE user_id=$1 AND language=$2
+GROUP BY lower(correct)
+ORDER BY count(*) DESC, max(created_at) DESC
+LIMIT $3`, userID, language, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	words := []MisspelledWord{}
+	for rows.Next() {
+		var w MisspelledWord
+		if err := rows.Scan(&w.Word, &w.Count, &w.Forms, &w.LastSeen); err != nil {
+			return nil, err
+		}
+		words = append(words, w)
+	}
+	return words, rows.Err()
+}
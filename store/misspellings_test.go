@@ -0,0 +1,29 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/ailanguagetutor/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMisspelling(t *testing.T) {
+	cases := []struct {
+		in   string
+		want store.Misspelling
+		ok   bool
+	}{
+		{"grazzie → grazie", store.Misspelling{Wrong: "grazzie", Correct: "grazie"}, true},
+		{"perche → perché (missing accent)", store.Misspelling{Wrong: "perche", Correct: "perché", Note: "missing accent"}, true},
+		{" a menudo→ amenudo ", store.Misspelling{Wrong: "a menudo", Correct: "amenudo"}, true},
+		{"dificil → difícil (accent (stress))", store.Misspelling{Wrong: "dificil", Correct: "difícil", Note: "accent (stress)"}, true},
+		{"no arrow here", store.Misspelling{}, false},
+		{"→ grazie", store.Misspelling{}, false},
+		{"ciao → ciao (fine)", store.Misspelling{}, false},
+	}
+	for _, c := range cases {
+		got, ok := store.ParseMisspelling(c.in)
+		assert.Equal(t, c.ok, ok, c.in)
+		assert.Equal(t, c.want, got, c.in)
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/ailanguagetutor/difficulty"
 )
 
 // ItemPool is a thread-safe in-memory pool of ordered item lists keyed by
@@ -15,19 +18,30 @@ import (
 // Handlers append freshly-generated lists; users consume them sequentially by index
 // tracked in their StudentProfile.
 //
+// Each list may carry the difficulty score it was pooled with.
+//
 // If savePath is set, the pool is persisted to disk as JSON on every Append and
 // can be reloaded on startup via Load(). This survives container restarts when the
 // file lives on a mounted Docker volume.
 type ItemPool struct {
 	mu       sync.RWMutex
 	lists    map[string][]json.RawMessage
+	scores   map[string][]*difficulty.Score // parallel to lists; nil = not scored
 	savePath string
 }
 
+// poolFile is the on-disk form of a pool. Files written before scores were
+// kept hold the lists map alone.
+type poolFile struct {
+	Lists  map[string][]json.RawMessage   `json:"lists"`
+	Scores map[string][]*difficulty.Score `json:"scores,omitempty"`
+}
+
 // NewItemPool creates a pool. savePath may be empty to disable persistence.
 func NewItemPool(savePath string) *ItemPool {
 	return &ItemPool{
 		lists:    make(map[string][]json.RawMessage),
+		scores:   make(map[string][]*difficulty.Score),
 		savePath: savePath,
 	}
 }
@@ -47,9 +61,26 @@ func (p *ItemPool) Load() {
 	}
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	if err := json.Unmarshal(data, &p.lists); err != nil {
+	var file poolFile
+	if err := json.Unmarshal(data, &file); err == nil && file.Lists != nil {
+		p.lists = file.Lists
+		if file.Scores != nil {
+			p.scores = file.Scores
+		}
+	} else if err := json.Unmarshal(data, &p.lists); err != nil {
 		log.Printf("pool: parse %s: %v", p.savePath, err)
 	}
+	// Keep scores parallel to lists whatever the file held
+	for key, lists := range p.lists {
+		sc := p.scores[key]
+		if len(sc) > len(lists) {
+			sc = sc[:len(lists)]
+		}
+		for len(sc) < len(lists) {
+			sc = append(sc, nil)
+		}
+		p.scores[key] = sc
+	}
 }
 
 // Key returns the canonical cache key for a (language, level, topic) triple.
@@ -78,12 +109,60 @@ func (p *ItemPool) Get(key string, idx int) (json.RawMessage, bool) {
 // Append adds a JSON-encoded list to the end of the pool for key and persists
 // the pool to disk if a savePath was configured.
 func (p *ItemPool) Append(key string, data json.RawMessage) {
+	p.AppendScored(key, data, nil)
+}
+
+// AppendScored appends a list with its difficulty score (nil if unscored).
+func (p *ItemPool) AppendScored(key string, data json.RawMessage, score *difficulty.Score) {
 	p.mu.Lock()
 	p.lists[key] = append(p.lists[key], data)
+	p.scores[key] = append(p.scores[key], score)
+	p.mu.Unlock()
+	p.save()
+}
+
+// Score returns the difficulty score of the list at idx for key, or nil if
+// it was pooled without one.
+func (p *ItemPool) Score(key string, idx int) *difficulty.Score {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	sc := p.scores[key]
+	if idx < 0 || idx >= len(sc) {
+		return nil
+	}
+	return sc[idx]
+}
+
+// SetScores records difficulty scores by index for the lists under key, e.g.
+// for lists pooled before scoring, and persists the pool once. Out-of-range
+// indexes are ignored.
+func (p *ItemPool) SetScores(key string, scores map[int]*difficulty.Score) {
+	if len(scores) == 0 {
+		return
+	}
+	p.mu.Lock()
+	sc := p.scores[key]
+	for idx, score := range scores {
+		if idx >= 0 && idx < len(sc) {
+			sc[idx] = score
+		}
+	}
 	p.mu.Unlock()
 	p.save()
 }
 
+// Keys returns the pool's keys in sorted order.
+func (p *ItemPool) Keys() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	keys := make([]string, 0, len(p.lists))
+	for k := range p.lists {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // AllRaw returns copies of all raw list blobs for key.
 // Used to build exclusion lists before generating new content.
 func (p *ItemPool) AllRaw(key string) []json.RawMessage {
@@ -104,7 +183,7 @@ func (p *ItemPool) save() {
 		return
 	}
 	p.mu.RLock()
-	data, err := json.Marshal(p.lists)
+	data, err := json.Marshal(poolFile{Lists: p.lists, Scores: p.scores})
 	p.mu.RUnlock()
 	if err != nil {
 		log.Printf("pool: marshal %s: %v", p.savePath, err)
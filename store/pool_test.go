@@ -0,0 +1,44 @@
+package store_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ailanguagetutor/difficulty"
+	"github.com/ailanguagetutor/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemPool_PersistsScores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.json")
+	p := store.NewItemPool(path)
+	key := p.Key("es", 1, "food")
+	p.AppendScored(key, json.RawMessage(`["a"]`), &difficulty.Score{Band: "A1"})
+	p.Append(key, json.RawMessage(`["b"]`))
+	p.SetScores(key, map[int]*difficulty.Score{1: {Band: "A2"}, 5: {Band: "C2"}})
+
+	loaded := store.NewItemPool(path)
+	loaded.Load()
+	assert.Equal(t, 2, loaded.Len(key))
+	require.NotNil(t, loaded.Score(key, 0))
+	assert.Equal(t, "A1", loaded.Score(key, 0).Band)
+	assert.Equal(t, "A2", loaded.Score(key, 1).Band)
+	assert.Equal(t, []string{key}, loaded.Keys())
+}
+
+func TestItemPool_LoadsUnscoredFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"es:1:food":[["a"],["b"]]}`), 0o644))
+
+	p := store.NewItemPool(path)
+	p.Load()
+	assert.Equal(t, 2, p.Len("es:1:food"))
+	assert.Nil(t, p.Score("es:1:food", 1))
+
+	// Scores can be attached to lists loaded without them
+	p.SetScores("es:1:food", map[int]*difficulty.Score{1: {Band: "B1"}})
+	assert.Equal(t, "B1", p.Score("es:1:food", 1).Band)
+}
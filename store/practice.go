@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const practiceKeyPrefix = "practice:"
+const practiceTTL = 2 * time.Hour
+
+// PracticeItem is the answer key of one generated exercise. It stays on the
+// server so answers are never sent to the client before they are checked.
+type PracticeItem struct {
+	Type     string            `json:"type"`
+	Language string            `json:"language"`
+	Words    []string          `json:"words"`           // words the exercise practises
+	Answers  []string          `json:"answers"`         // accepted answers, preferred first
+	Pairs    map[string]string `json:"pairs,omitempty"` // matching exercises: word -> translation
+}
+
+// PracticeStore keeps exercise answer keys per user in Redis for two hours,
+// with a count of check attempts.
+type PracticeStore struct {
+	rdb *redis.Client
+}
+
+func NewPracticeStore(rdb *redis.Client) *PracticeStore {
+	return &PracticeStore{rdb: rdb}
+}
+
+func practiceKey(userID, id string) string {
+	return practiceKeyPrefix + userID + ":" + id
+}
+
+// Save stores the answer key of exercise id.
+func (p *PracticeStore) Save(ctx context.Context, userID, id string, item PracticeItem) error {
+	data, _ := json.Marshal(item) // PracticeItem is always serialisable
+	key := practiceKey(userID, id)
+	_, err := p.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "item", data)
+		pipe.Expire(ctx, key, practiceTTL)
+		return nil
+	})
+	return err
+}
+
+// Attempt returns the answer key of exercise id and counts one more check
+// attempt, returning the count including this one. It returns nil if the
+// exercise is unknown or expired.
+func (p *PracticeStore) Attempt(ctx context.Context, userID, id string) (*PracticeItem, int, error) {
+	key := practiceKey(userID, id)
+	data, err := p.rdb.HGet(ctx, key, "item").Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	var item PracticeItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, 0, err
+	}
+	n, err := p.rdb.HIncrBy(ctx, key, "attempts", 1).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	return &item, int(n), nil
+}
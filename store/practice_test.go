@@ -0,0 +1,58 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPracticeStore(t *testing.T) (*store.PracticeStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return store.NewPracticeStore(rdb), mr
+}
+
+func TestPracticeStore_AttemptCounts(t *testing.T) {
+	ps, _ := newTestPracticeStore(t)
+	ctx := context.Background()
+
+	item := store.PracticeItem{Type: "reverse", Language: "es", Words: []string{"casa"}, Answers: []string{"casa"}}
+	require.NoError(t, ps.Save(ctx, "user-1", "ex-1", item))
+
+	got, n, err := ps.Attempt(ctx, "user-1", "ex-1")
+	require.NoError(t, err)
+	assert.Equal(t, &item, got)
+	assert.Equal(t, 1, n)
+
+	_, n, err = ps.Attempt(ctx, "user-1", "ex-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestPracticeStore_UnknownOrOtherUser(t *testing.T) {
+	ps, _ := newTestPracticeStore(t)
+	ctx := context.Background()
+	require.NoError(t, ps.Save(ctx, "user-1", "ex-1", store.PracticeItem{Type: "reverse"}))
+
+	got, _, err := ps.Attempt(ctx, "user-2", "ex-1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestPracticeStore_Expires(t *testing.T) {
+	ps, mr := newTestPracticeStore(t)
+	ctx := context.Background()
+	require.NoError(t, ps.Save(ctx, "user-1", "ex-1", store.PracticeItem{Type: "reverse"}))
+
+	mr.FastForward(3 * time.Hour)
+	got, _, err := ps.Attempt(ctx, "user-1", "ex-1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
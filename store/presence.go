@@ -14,7 +14,7 @@ const presenceTTL = 30 * time.Minute
 
 // LessonPresence records what lesson a user is currently doing.
 type LessonPresence struct {
-	Type      string    `json:"type"`      // "conversation","writing","vocab","sentence","listening"
+	Type      string    `json:"type"`      // "conversation","writing","vocab","sentence","listening","pronunciation","reading"
 	Language  string    `json:"language"`
 	Topic     string    `json:"topic"`
 	StartedAt time.Time `json:"started_at"`
@@ -0,0 +1,41 @@
+package store_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/ailanguagetutor/database"
+	"github.com/ailanguagetutor/store"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Runs against the Postgres database at TEST_DATABASE_URL, if set.
+func TestStudentProfile_LegacyWeakGrammarIsClassified(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := database.Connect(ctx, url)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	users := store.NewUserStore(pool)
+	user, err := users.Create(uuid.NewString()+"@example.com", "learner", "password123", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { users.Delete(user.ID) })
+
+	profiles := store.NewStudentProfileStore(pool)
+	require.NoError(t, profiles.Upsert(ctx, &store.StudentProfile{
+		UserID: user.ID, Language: "es",
+		WeakGrammar: []string{"Ser vs estar", "What did Ana buy at the market?"},
+	}))
+
+	p, err := profiles.Get(ctx, user.ID, "es")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"es.ser-estar"}, p.WeakGrammar)
+	assert.Equal(t, 1, p.GrammarStats["es.ser-estar"].Errors)
+}
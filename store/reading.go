@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const readingKeyPrefix = "reading:"
+const readingTTL = 2 * time.Hour
+
+// ReadingLookup is a word the learner tapped for a gloss while reading.
+type ReadingLookup struct {
+	Word        string `json:"word"`  // as it appears in the text
+	Lemma       string `json:"lemma"` // dictionary form, added to the review deck
+	Translation string `json:"translation"`
+	Phonetic    string `json:"phonetic,omitempty"`
+}
+
+// ReadingSession is a reading text in progress. Like a listening session,
+// the answer key stays on the server and answers are recorded one question
+// at a time.
+type ReadingSession struct {
+	Language  string              `json:"language"`
+	Level     int                 `json:"level"`
+	Topic     string              `json:"topic"`
+	PoolKey   string              `json:"pool_key"`
+	Title     string              `json:"title"`
+	Text      string              `json:"text"`
+	Words     int                 `json:"words"` // words in Text, for reading speed
+	Questions []ListeningQuestion `json:"questions"`
+	StartedAt time.Time           `json:"started_at"`
+
+	// Filled in by Get
+	Answers map[int]SessionAnswer `json:"-"`
+	Lookups []ReadingLookup       `json:"-"` // by lemma
+	ReadAt  time.Time             `json:"-"` // first question answered; zero if none yet
+}
+
+// ReadingSessionStore keeps reading sessions per user in Redis for two hours.
+// Besides answers, a session records the words looked up under
+// "lookup:<lemma>" and when the first question was answered under "read_at".
+type ReadingSessionStore struct {
+	answerSessions
+}
+
+func NewReadingSessionStore(rdb *redis.Client) *ReadingSessionStore {
+	return &ReadingSessionStore{answerSessions{rdb: rdb, prefix: readingKeyPrefix, ttl: readingTTL}}
+}
+
+// Save stores session id.
+func (s *ReadingSessionStore) Save(ctx context.Context, userID, id string, session ReadingSession) error {
+	return s.save(ctx, userID, id, session)
+}
+
+// Get returns session id with the answers and lookups recorded so far, or
+// nil if it is unknown or expired.
+func (s *ReadingSessionStore) Get(ctx context.Context, userID, id string) (*ReadingSession, error) {
+	var session ReadingSession
+	fields, err := s.load(ctx, userID, id, &session)
+	if fields == nil {
+		return nil, err
+	}
+	session.Answers = answerFields(fields)
+	for f, v := range fields {
+		if _, ok := strings.CutPrefix(f, "lookup:"); !ok {
+			continue
+		}
+		var l ReadingLookup
+		if err := json.Unmarshal([]byte(v), &l); err == nil {
+			session.Lookups = append(session.Lookups, l)
+		}
+	}
+	sort.Slice(session.Lookups, func(i, j int) bool { return session.Lookups[i].Lemma < session.Lookups[j].Lemma })
+	if unix, err := strconv.ParseInt(fields["read_at"], 10, 64); err == nil {
+		session.ReadAt = time.Unix(unix, 0)
+	}
+	return &session, nil
+}
+
+// RecordAnswer stores the answer to question idx unless one is already
+// recorded, and returns the answer that counts: the first one. The first
+// answer to any question also marks the text as read at now.
+func (s *ReadingSessionStore) RecordAnswer(ctx context.Context, userID, id string, idx int, answer SessionAnswer, now time.Time) (SessionAnswer, error) {
+	if _, err := s.setOnce(ctx, userID, id, "read_at", now.Unix()); err != nil {
+		return answer, err
+	}
+	return s.answerSessions.RecordAnswer(ctx, userID, id, idx, answer)
+}
+
+// AddLookup records a looked-up word under its lemma. It reports false if
+// the lemma was already looked up in the session.
+func (s *ReadingSessionStore) AddLookup(ctx context.Context, userID, id string, l ReadingLookup) (bool, error) {
+	data, _ := json.Marshal(l)
+	return s.setOnce(ctx, userID, id, "lookup:"+strings.ToLower(l.Lemma), data)
+}
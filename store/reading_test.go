@@ -0,0 +1,78 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReadingStore(t *testing.T) *store.ReadingSessionStore {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return store.NewReadingSessionStore(rdb)
+}
+
+func TestReadingSessionStore_AnswersAndLookups(t *testing.T) {
+	rs := newTestReadingStore(t)
+	ctx := context.Background()
+	started := time.Unix(1_700_000_000, 0)
+	session := store.ReadingSession{
+		Language: "es", Level: 1, Topic: "food", PoolKey: "es:1:food",
+		Title: "El mercado", Text: "Hoy voy al mercado.", Words: 4, StartedAt: started,
+		Questions: []store.ListeningQuestion{{Type: "yes_no", Question: "¿Va al mercado?", Answer: "yes"}},
+	}
+	require.NoError(t, rs.Save(ctx, "user-1", "s-1", session))
+
+	read := started.Add(90 * time.Second)
+	got, err := rs.RecordAnswer(ctx, "user-1", "s-1", 0, store.SessionAnswer{Answer: "yes", Correct: true}, read)
+	require.NoError(t, err)
+	assert.True(t, got.Correct)
+	got, err = rs.RecordAnswer(ctx, "user-1", "s-1", 0, store.SessionAnswer{Answer: "no"}, read.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, got.Correct) // the first answer counts
+
+	added, err := rs.AddLookup(ctx, "user-1", "s-1", store.ReadingLookup{Word: "voy", Lemma: "ir", Translation: "to go"})
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, err = rs.AddLookup(ctx, "user-1", "s-1", store.ReadingLookup{Word: "Voy", Lemma: "ir", Translation: "to go"})
+	require.NoError(t, err)
+	assert.False(t, added)
+	_, err = rs.AddLookup(ctx, "user-1", "s-1", store.ReadingLookup{Word: "mercado", Lemma: "mercado", Translation: "market"})
+	require.NoError(t, err)
+
+	loaded, err := rs.Get(ctx, "user-1", "s-1")
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, session.Questions, loaded.Questions)
+	assert.True(t, loaded.StartedAt.Equal(started))
+	assert.True(t, loaded.ReadAt.Equal(read), "read at the first answer")
+	assert.Equal(t, map[int]store.SessionAnswer{0: {Answer: "yes", Correct: true}}, loaded.Answers)
+	require.Len(t, loaded.Lookups, 2)
+	assert.Equal(t, "ir", loaded.Lookups[0].Lemma)
+	assert.Equal(t, "voy", loaded.Lookups[0].Word)
+	assert.Equal(t, "mercado", loaded.Lookups[1].Lemma)
+
+	other, err := rs.Get(ctx, "user-2", "s-1")
+	require.NoError(t, err)
+	assert.Nil(t, other)
+}
+
+func TestReadingSessionStore_CompletesOnce(t *testing.T) {
+	rs := newTestReadingStore(t)
+	ctx := context.Background()
+	require.NoError(t, rs.Save(ctx, "user-1", "s-1", store.ReadingSession{Language: "it"}))
+
+	first, err := rs.MarkCompleted(ctx, "user-1", "s-1")
+	require.NoError(t, err)
+	assert.True(t, first)
+	first, err = rs.MarkCompleted(ctx, "user-1", "s-1")
+	require.NoError(t, err)
+	assert.False(t, first)
+}
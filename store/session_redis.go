@@ -70,6 +70,36 @@ func (ss *SessionStore) AddMessage(id string, msg Message) error {
 	return ss.rdb.Set(context.Background(), sessionKey(id), data, ss.ttl).Err()
 }
 
+// AddMisspellings records misspellings found in the student's messages.
+func (ss *SessionStore) AddMisspellings(id string, misspellings []string) error {
+	if len(misspellings) == 0 {
+		return nil
+	}
+	s, err := ss.Get(id)
+	if err != nil {
+		return err
+	}
+	s.Misspellings = append(s.Misspellings, misspellings...)
+	s.UpdatedAt = time.Now()
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("session encode: %w", err)
+	}
+	return ss.rdb.Set(context.Background(), sessionKey(id), data, ss.ttl).Err()
+}
+
+// Claim marks action as done for session id. It reports false if the action
+// was already claimed, so actions such as submitting an essay run once per
+// session even when requests race.
+func (ss *SessionStore) Claim(id, action string) (bool, error) {
+	return ss.rdb.SetNX(context.Background(), sessionKey(id)+":"+action, 1, ss.ttl).Result()
+}
+
+// Unclaim releases a claim so that an action which failed can be retried.
+func (ss *SessionStore) Unclaim(id, action string) error {
+	return ss.rdb.Del(context.Background(), sessionKey(id)+":"+action).Err()
+}
+
 func (ss *SessionStore) GetMessages(id string) ([]Message, error) {
 	s, err := ss.Get(id)
 	if err != nil {
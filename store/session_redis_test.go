@@ -158,3 +158,40 @@ func TestSessionStore_MultipleUsers(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "user2", got2.UserID)
 }
+
+func TestSessionStore_AddMisspellings(t *testing.T) {
+	ss, _ := newTestSessionStore(t)
+
+	s := ss.Create("user1", "it", "food", 2, "writing-coach", "system")
+	require.NoError(t, ss.AddMisspellings(s.ID, []string{"grazzie → grazie"}))
+	require.NoError(t, ss.AddMisspellings(s.ID, nil))
+	require.NoError(t, ss.AddMessage(s.ID, store.Message{Role: "user", Content: "ciao"}))
+	require.NoError(t, ss.AddMisspellings(s.ID, []string{"perche → perché (accent)"}))
+
+	got, err := ss.Get(s.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"grazzie → grazie", "perche → perché (accent)"}, got.Misspellings)
+	assert.Len(t, got.Messages, 2)
+
+	assert.ErrorIs(t, ss.AddMisspellings("nonexistent-id", []string{"x → y"}), store.ErrSessionNotFound)
+}
+
+func TestSessionStore_ClaimOnce(t *testing.T) {
+	ss, mr := newTestSessionStore(t)
+	s := ss.Create("user1", "it", "food", 2, "essay", "")
+
+	ok, err := ss.Claim(s.ID, "submitted")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = ss.Claim(s.ID, "submitted")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Greater(t, mr.TTL("conv_session:"+s.ID+":submitted"), time.Duration(0))
+
+	// Other actions are claimed separately, and a released claim can be retaken
+	ok, _ = ss.Claim(s.ID, "completed")
+	assert.True(t, ok)
+	require.NoError(t, ss.Unclaim(s.ID, "submitted"))
+	ok, _ = ss.Claim(s.ID, "submitted")
+	assert.True(t, ok)
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/ailanguagetutor/grammar"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -114,8 +115,11 @@ type Session struct {
 	Level       int       `json:"level"`
 	Personality string    `json:"personality,omitempty"`
 	Messages    []Message `json:"messages"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	// Writing coach: misspellings found in the student's messages, as
+	// "wrong → correct (note)"
+	Misspellings []string  `json:"misspellings,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 // ── Gamification ──────────────────────────────────────────────────────────────
@@ -693,25 +697,26 @@ func (cs *ContextStore) Get(userID, language string, level int) []Message {
 // ── Conversation History Store ────────────────────────────────────────────────
 
 type ConversationRecord struct {
-	ID           string    `json:"id"`
-	UserID       string    `json:"user_id"`
-	SessionID    string    `json:"session_id"`
-	Language     string    `json:"language"`
-	Topic        string    `json:"topic"`
-	TopicName    string    `json:"topic_name"`
-	Level        int       `json:"level"`
-	Personality  string    `json:"personality,omitempty"`
-	MessageCount int       `json:"message_count"`
-	DurationSecs int       `json:"duration_secs"`
-	FPEarned     int       `json:"fp_earned"`
-	Summary      string    `json:"summary"`
-	Topics       []string  `json:"topics_discussed,omitempty"`
-	Vocabulary   []string  `json:"vocabulary_learned,omitempty"`
-	Corrections  []string  `json:"grammar_corrections,omitempty"`
-	Suggestions  []string  `json:"suggested_next_lessons,omitempty"`
-	Misspellings []string  `json:"misspellings,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	EndedAt      time.Time `json:"ended_at"`
+	ID           string       `json:"id"`
+	UserID       string       `json:"user_id"`
+	SessionID    string       `json:"session_id"`
+	Language     string       `json:"language"`
+	Topic        string       `json:"topic"`
+	TopicName    string       `json:"topic_name"`
+	Level        int          `json:"level"`
+	Personality  string       `json:"personality,omitempty"`
+	MessageCount int          `json:"message_count"`
+	DurationSecs int          `json:"duration_secs"`
+	FPEarned     int          `json:"fp_earned"`
+	Summary      string       `json:"summary"`
+	Topics       []string     `json:"topics_discussed,omitempty"`
+	Vocabulary   []string     `json:"vocabulary_learned,omitempty"`
+	Corrections  []string     `json:"grammar_corrections,omitempty"`
+	Suggestions  []string     `json:"suggested_next_lessons,omitempty"`
+	Misspellings []string     `json:"misspellings,omitempty"`
+	Essay        *EssayReview `json:"essay,omitempty"` // essay mode only
+	CreatedAt    time.Time    `json:"created_at"`
+	EndedAt      time.Time    `json:"ended_at"`
 }
 
 type ConversationHistoryStore struct {
@@ -729,16 +734,20 @@ func (hs *ConversationHistoryStore) Save(record *ConversationRecord) {
 	corrections, _ := json.Marshal(nilSafe(record.Corrections))
 	suggestions, _ := json.Marshal(nilSafe(record.Suggestions))
 	misspellings, _ := json.Marshal(nilSafe(record.Misspellings))
+	var essay []byte
+	if record.Essay != nil {
+		essay, _ = json.Marshal(record.Essay)
+	}
 
 	_, _ = hs.pool.Exec(ctx, `
 INSERT INTO conversation_history (id, user_id, session_id, language, topic, topic_name, level,
     personality, message_count, duration_secs, fp_earned, summary,
-    topics, vocabulary, corrections, suggestions, created_at, ended_at, misspellings)
-VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19)
+    topics, vocabulary, corrections, suggestions, created_at, ended_at, misspellings, essay)
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20)
 ON CONFLICT (id) DO NOTHING`,
 		record.ID, record.UserID, record.SessionID, record.Language, record.Topic, record.TopicName,
 		record.Level, record.Personality, record.MessageCount, record.DurationSecs, record.FPEarned,
-		record.Summary, topics, vocab, corrections, suggestions, record.CreatedAt, record.EndedAt, misspellings,
+		record.Summary, topics, vocab, corrections, suggestions, record.CreatedAt, record.EndedAt, misspellings, essay,
 	)
 }
 
@@ -747,7 +756,7 @@ func (hs *ConversationHistoryStore) GetForUser(userID string) []*ConversationRec
 	rows, err := hs.pool.Query(ctx, `
 SELECT id, user_id, session_id, language, topic, topic_name, level, personality,
     message_count, duration_secs, fp_earned, summary,
-    topics, vocabulary, corrections, suggestions, created_at, ended_at, misspellings
+    topics, vocabulary, corrections, suggestions, created_at, ended_at, misspellings, essay
 FROM conversation_history WHERE user_id=$1 ORDER BY ended_at DESC LIMIT 10`, userID)
 	if err != nil {
 		return []*ConversationRecord{}
@@ -766,6 +775,30 @@ FROM conversation_history WHERE user_id=$1 ORDER BY ended_at DESC LIMIT 10`, use
 	return records
 }
 
+// VocabularyForUser returns the vocabulary entries of all the user's records
+// in language, newest record first. Unlike GetForUser it is not limited to
+// the last few sessions.
+func (hs *ConversationHistoryStore) VocabularyForUser(ctx context.Context, userID, language string) ([]string, error) {
+	rows, err := hs.pool.Query(ctx, `
+SELECT v.entry
+FROM conversation_history h, jsonb_array_elements_text(h.vocabulary) WITH ORDINALITY AS v(entry, n)
+WHERE h.user_id=$1 AND h.language=$2
+ORDER BY h.ended_at DESC, v.n`, userID, language)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := []string{}
+	for rows.Next() {
+		var e string
+		if err := rows.Scan(&e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
 func (hs *ConversationHistoryStore) DeleteForUser(userID string) {
 	ctx := context.Background()
 	_, _ = hs.pool.Exec(ctx, "DELETE FROM conversation_history WHERE user_id=$1", userID)
@@ -776,7 +809,7 @@ func (hs *ConversationHistoryStore) GetRecord(id string) (*ConversationRecord, e
 	row := hs.pool.QueryRow(ctx, `
 SELECT id, user_id, session_id, language, topic, topic_name, level, personality,
     message_count, duration_secs, fp_earned, summary,
-    topics, vocabulary, corrections, suggestions, created_at, ended_at, misspellings
+    topics, vocabulary, corrections, suggestions, created_at, ended_at, misspellings, essay
 FROM conversation_history WHERE id=$1`, id)
 	r, err := scanRecord(row)
 	if err != nil {
@@ -787,11 +820,11 @@ FROM conversation_history WHERE id=$1`, id)
 
 func scanRecord(row pgx.Row) (*ConversationRecord, error) {
 	var r ConversationRecord
-	var topics, vocab, corrections, suggestions, misspellings []byte
+	var topics, vocab, corrections, suggestions, misspellings, essay []byte
 	err := row.Scan(
 		&r.ID, &r.UserID, &r.SessionID, &r.Language, &r.Topic, &r.TopicName, &r.Level,
 		&r.Personality, &r.MessageCount, &r.DurationSecs, &r.FPEarned, &r.Summary,
-		&topics, &vocab, &corrections, &suggestions, &r.CreatedAt, &r.EndedAt, &misspellings,
+		&topics, &vocab, &corrections, &suggestions, &r.CreatedAt, &r.EndedAt, &misspellings, &essay,
 	)
 	if err != nil {
 		return nil, err
@@ -801,6 +834,7 @@ func scanRecord(row pgx.Row) (*ConversationRecord, error) {
 	_ = scanJSONB(corrections, &r.Corrections)
 	_ = scanJSONB(suggestions, &r.Suggestions)
 	_ = scanJSONB(misspellings, &r.Misspellings)
+	_ = scanJSONB(essay, &r.Essay)
 	return &r, nil
 }
 
@@ -828,12 +862,64 @@ type StudentProfile struct {
 	SentenceListIdx  map[string]int `json:"sentence_list_idx"`  // pool key → next list index
 	ListeningListIdx map[string]int `json:"listening_list_idx"` // pool key → next list index
 	WritingListIdx   map[string]int `json:"writing_list_idx"`   // pool key → next list index
+	PronunciationListIdx map[string]int `json:"pronunciation_list_idx"` // pool key → next list index
+	ReadingListIdx   map[string]int `json:"reading_list_idx"`   // pool key → next text index
 	// Mistake tracking (separate from mixed WeakAreas)
 	WeakVocab   []string `json:"weak_vocab"`   // words missed in vocab sessions
-	WeakGrammar []string `json:"weak_grammar"` // grammar tips from sentence/listening sessions
+	WeakGrammar []string `json:"weak_grammar"` // weakest grammar concept IDs, worst first
+	WeakSounds  []string `json:"weak_sounds"`  // sounds missed in pronunciation sessions
+	// Lemma-level knowledge: dictionary form → times practised correctly,
+	// so inflected forms of a known word are not taught as new words
+	KnownLemmas map[string]int `json:"known_lemmas"`
+	// Per grammar concept ID error and success counts; WeakGrammar is derived from it
+	GrammarStats grammar.Stats `json:"grammar_stats"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// maxWeakGrammar caps how many weak grammar concepts a profile lists.
+const maxWeakGrammar = 20
+
+// RecordGrammar counts an error or success on a grammar concept and
+// refreshes WeakGrammar from the updated stats.
+func (p *StudentProfile) RecordGrammar(conceptID string, correct bool) {
+	if p.GrammarStats == nil {
+		p.GrammarStats = make(grammar.Stats)
+	}
+	p.GrammarStats.Record(conceptID, correct, time.Now())
+	p.WeakGrammar = p.GrammarStats.Weakest(maxWeakGrammar)
+}
+
+// upgradeWeakGrammar converts the free-text tips WeakGrammar held before
+// grammar concepts existed. A tip that names a concept counts as one error
+// on it, unless the concept already has stats; other tips are dropped. The
+// result is saved with the profile's next Upsert.
+func (p *StudentProfile) upgradeWeakGrammar() {
+	legacy := false
+	for _, entry := range p.WeakGrammar {
+		if _, ok := grammar.Lookup(entry); !ok {
+			legacy = true
+			break
+		}
+	}
+	if !legacy {
+		return
+	}
+	if p.GrammarStats == nil {
+		p.GrammarStats = make(grammar.Stats)
+	}
+	for _, entry := range p.WeakGrammar {
+		if _, ok := grammar.Lookup(entry); ok {
+			continue
+		}
+		if id, ok := grammar.Match(p.Language, entry); ok {
+			if _, seen := p.GrammarStats[id]; !seen {
+				p.GrammarStats.Record(id, false, p.UpdatedAt)
+			}
+		}
+	}
+	p.WeakGrammar = p.GrammarStats.Weakest(maxWeakGrammar)
+}
+
 type StudentProfileStore struct {
 	pool *pgxpool.Pool
 }
@@ -845,19 +931,19 @@ func NewStudentProfileStore(pool *pgxpool.Pool) *StudentProfileStore {
 func (s *StudentProfileStore) Get(ctx context.Context, userID, language string) (*StudentProfile, error) {
 	var p StudentProfile
 	var weakAreas, strongAreas, recentTopics, recentVocab, recentSentences, nextSuggestions []byte
-	var vocabIdx, sentenceIdx, listeningIdx, writingIdx []byte
-	var weakVocab, weakGrammar []byte
+	var vocabIdx, sentenceIdx, listeningIdx, writingIdx, pronunciationIdx, readingIdx []byte
+	var weakVocab, weakGrammar, weakSounds, knownLemmas, grammarStats []byte
 	err := s.pool.QueryRow(ctx, `
 SELECT user_id, language, name, weak_areas, strong_areas, recent_topics, recent_vocab,
     recent_sentences, next_suggestions, session_count, updated_at,
-    vocab_list_idx, sentence_list_idx, listening_list_idx, writing_list_idx,
-    weak_vocab, weak_grammar
+    vocab_list_idx, sentence_list_idx, listening_list_idx, writing_list_idx, pronunciation_list_idx,
+    weak_vocab, weak_grammar, weak_sounds, known_lemmas, grammar_stats, reading_list_idx
 FROM student_profiles WHERE user_id=$1 AND language=$2`, userID, language).Scan(
 		&p.UserID, &p.Language, &p.Name,
 		&weakAreas, &strongAreas, &recentTopics, &recentVocab, &recentSentences, &nextSuggestions,
 		&p.SessionCount, &p.UpdatedAt,
-		&vocabIdx, &sentenceIdx, &listeningIdx, &writingIdx,
-		&weakVocab, &weakGrammar,
+		&vocabIdx, &sentenceIdx, &listeningIdx, &writingIdx, &pronunciationIdx,
+		&weakVocab, &weakGrammar, &weakSounds, &knownLemmas, &grammarStats, &readingIdx,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -875,12 +961,22 @@ FROM student_profiles WHERE user_id=$1 AND language=$2`, userID, language).Scan(
 	p.SentenceListIdx = make(map[string]int)
 	p.ListeningListIdx = make(map[string]int)
 	p.WritingListIdx = make(map[string]int)
+	p.PronunciationListIdx = make(map[string]int)
+	p.ReadingListIdx = make(map[string]int)
 	_ = scanJSONB(vocabIdx, &p.VocabListIdx)
 	_ = scanJSONB(sentenceIdx, &p.SentenceListIdx)
 	_ = scanJSONB(listeningIdx, &p.ListeningListIdx)
 	_ = scanJSONB(writingIdx, &p.WritingListIdx)
+	_ = scanJSONB(pronunciationIdx, &p.PronunciationListIdx)
+	_ = scanJSONB(readingIdx, &p.ReadingListIdx)
 	_ = scanJSONB(weakVocab, &p.WeakVocab)
 	_ = scanJSONB(weakGrammar, &p.WeakGrammar)
+	_ = scanJSONB(weakSounds, &p.WeakSounds)
+	p.KnownLemmas = make(map[string]int)
+	_ = scanJSONB(knownLemmas, &p.KnownLemmas)
+	p.GrammarStats = make(grammar.Stats)
+	_ = scanJSONB(grammarStats, &p.GrammarStats)
+	p.upgradeWeakGrammar()
 	return &p, nil
 }
 
@@ -895,23 +991,31 @@ func (s *StudentProfileStore) Upsert(ctx context.Context, p *StudentProfile) err
 	sentenceListIdx, _ := json.Marshal(nilSafeMap(p.SentenceListIdx))
 	listeningListIdx, _ := json.Marshal(nilSafeMap(p.ListeningListIdx))
 	writingListIdx, _ := json.Marshal(nilSafeMap(p.WritingListIdx))
+	pronunciationListIdx, _ := json.Marshal(nilSafeMap(p.PronunciationListIdx))
+	readingListIdx, _ := json.Marshal(nilSafeMap(p.ReadingListIdx))
 	weakVocab, _ := json.Marshal(nilSafe(p.WeakVocab))
 	weakGrammar, _ := json.Marshal(nilSafe(p.WeakGrammar))
+	weakSounds, _ := json.Marshal(nilSafe(p.WeakSounds))
+	knownLemmas, _ := json.Marshal(nilSafeMap(p.KnownLemmas))
+	grammarStats, _ := json.Marshal(p.GrammarStats)
+	if p.GrammarStats == nil {
+		grammarStats = []byte("{}")
+	}
 
 	_, err := s.pool.Exec(ctx, `
 INSERT INTO student_profiles (user_id, language, name, weak_areas, strong_areas, recent_topics,
     recent_vocab, recent_sentences, next_suggestions, session_count, vocab_list_idx, sentence_list_idx,
-    listening_list_idx, writing_list_idx, weak_vocab, weak_grammar, updated_at)
-VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,NOW())
+    listening_list_idx, writing_list_idx, weak_vocab, weak_grammar, pronunciation_list_idx, weak_sounds, known_lemmas, grammar_stats, reading_list_idx, updated_at)
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,NOW())
 ON CONFLICT (user_id, language) DO UPDATE SET
     name=$3, weak_areas=$4, strong_areas=$5, recent_topics=$6,
     recent_vocab=$7, recent_sentences=$8, next_suggestions=$9, session_count=$10,
     vocab_list_idx=$11, sentence_list_idx=$12, listening_list_idx=$13, writing_list_idx=$14,
-    weak_vocab=$15, weak_grammar=$16, updated_at=NOW()`,
+    weak_vocab=$15, weak_grammar=$16, pronunciation_list_idx=$17, weak_sounds=$18, known_lemmas=$19, grammar_stats=$20, reading_list_idx=$21, updated_at=NOW()`,
 		p.UserID, p.Language, p.Name, weakAreas, strongAreas, recentTopics,
 		recentVocab, recentSentences, nextSuggestions, p.SessionCount,
 		vocabListIdx, sentenceListIdx, listeningListIdx, writingListIdx,
-		weakVocab, weakGrammar,
+		weakVocab, weakGrammar, pronunciationListIdx, weakSounds, knownLemmas, grammarStats, readingListIdx,
 	)
 	return err
 }
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ── TTS allowances ────────────────────────────────────────────────────────────
+
+// TTSAllowance is the number of billed characters a user may synthesize.
+// Clips served from the audio cache and clips rendered by an offline engine
+// are not billed and do not count against it.
+type TTSAllowance struct {
+	Plan         string `json:"plan"`
+	DailyChars   int    `json:"daily_chars"`
+	MonthlyChars int    `json:"monthly_chars"`
+}
+
+var (
+	ttsAllowanceFull    = TTSAllowance{Plan: "full", DailyChars: 20_000, MonthlyChars: 300_000}
+	ttsAllowanceTrial   = TTSAllowance{Plan: "trial", DailyChars: 5_000, MonthlyChars: 50_000}
+	ttsAllowanceLimited = TTSAllowance{Plan: "limited", DailyChars: 1_000, MonthlyChars: 10_000}
+)
+
+// TTSAllowance returns the user's allowance, derived from subscription status.
+// Admins always get the full allowance.
+func (u *User) TTSAllowance() TTSAllowance {
+	switch {
+	case u.IsAdmin || u.HasFullAccess():
+		return ttsAllowanceFull
+	case u.HasConversationAccess():
+		return ttsAllowanceTrial
+	default:
+		return ttsAllowanceLimited
+	}
+}
+
+// ── Usage metering ────────────────────────────────────────────────────────────
+
+// TTSUsageStore meters synthesized characters per user, day and provider in
+// Postgres (table tts_usage).
+type TTSUsageStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewTTSUsageStore(pool *pgxpool.Pool) *TTSUsageStore {
+	return &TTSUsageStore{pool: pool}
+}
+
+// TTSUsageRow is one user's usage for one day and provider.
+type TTSUsageRow struct {
+	Day         string `json:"day"` // YYYY-MM-DD (UTC)
+	Provider    string `json:"provider"`
+	Requests    int    `json:"requests"`
+	Chars       int    `json:"chars"`        // all characters requested
+	BilledChars int    `json:"billed_chars"` // characters sent to a paid provider
+}
+
+// TTSUsage is a user's usage summary.
+type TTSUsage struct {
+	DayBilled   int           `json:"day_billed_chars"`
+	MonthBilled int           `json:"month_billed_chars"`
+	Rows        []TTSUsageRow `json:"breakdown"` // newest day first
+}
+
+// TTSUserTotal is one user's totals over a period, for the admin report.
+type TTSUserTotal struct {
+	UserID      string `json:"user_id"`
+	Email       string `json:"email"`
+	Requests    int    `json:"requests"`
+	Chars       int    `json:"chars"`
+	BilledChars int    `json:"billed_chars"`
+}
+
+// Record adds one request to the user's usage for today. billed is false for
+// cache hits and offline engines.
+func (s *TTSUsageStore) Record(ctx context.Context, userID, provider string, chars int, billed bool) error {
+	billedChars := 0
+	if billed {
+		billedChars = chars
+	}
+	_, err := s.pool.Exec(ctx, recordQuery, userID, provider, chars, billedChars)
+	return err
+}
+
+const recordQuery = `
+INSERT INTO tts_usage (user_id, day, provider, requests, chars, billed_chars)
+VALUES ($1, (NOW() AT TIME ZONE 'UTC')::date, $2, 1, $3, $4)
+ON CONFLICT (user_id, day, provider) DO UPDATE SET
+    requests     = tts_usage.requests + 1,
+    chars        = tts_usage.chars + EXCLUDED.chars,
+    billed_chars = tts_usage.billed_chars + EXCLUDED.billed_chars`
+
+// Reserve records a billed request of chars characters for provider, like
+// Record, if that keeps the user within allowance, and reports whether it
+// did. The check and the write hold a per-user lock, so concurrent requests
+// cannot overshoot the allowance together. A reservation whose synthesis
+// fails or is produced by another provider is taken back with Refund.
+func (s *TTSUsageStore) Reserve(ctx context.Context, userID, provider string, chars int, allowance TTSAllowance) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('tts_usage:' || $1))`, userID); err != nil {
+		return false, err
+	}
+	var day, month int
+	if err := tx.QueryRow(ctx, billedQuery, userID).Scan(&day, &month); err != nil {
+		return false, err
+	}
+	if day+chars > allowance.DailyChars || month+chars > allowance.MonthlyChars {
+		return false, nil
+	}
+	if _, err := tx.Exec(ctx, recordQuery, userID, provider, chars, chars); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// Refund takes back a request reserved for provider today.
+func (s *TTSUsageStore) Refund(ctx context.Context, userID, provider string, chars int) error {
+	_, err := s.pool.Exec(ctx, `
+UPDATE tts_usage SET
+    requests     = GREATEST(requests - 1, 0),
+    chars        = GREATEST(chars - $3, 0),
+    billed_chars = GREATEST(billed_chars - $3, 0)
+WHERE user_id = $1 AND day = (NOW() AT TIME ZONE 'UTC')::date AND provider = $2`,
+		userID, provider, chars)
+	return err
+}
+
+const billedQuery = `
+SELECT
+    COALESCE(SUM(billed_chars) FILTER (WHERE day = (NOW() AT TIME ZONE 'UTC')::date), 0),
+    COALESCE(SUM(billed_chars), 0)
+FROM tts_usage
+WHERE user_id = $1 AND day >= date_trunc('month', NOW() AT TIME ZONE 'UTC')::date`
+
+// Billed returns the user's billed characters today and this calendar month (UTC).
+func (s *TTSUsageStore) Billed(ctx context.Context, userID string) (day, month int, err error) {
+	err = s.pool.QueryRow(ctx, billedQuery, userID).Scan(&day, &month)
+	return day, month, err
+}
+
+// Usage returns the user's billed totals and a per-day, per-provider
+// breakdown for the last days days.
+func (s *TTSUsageStore) Usage(ctx context.Context, userID string, days int) (*TTSUsage, error) {
+	day, month, err := s.Billed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	u := &TTSUsage{DayBilled: day, MonthBilled: month, Rows: []TTSUsageRow{}}
+
+	rows, err := s.pool.Query(ctx, `
+SELECT day, provider, requests, chars, billed_chars
+FROM tts_usage
+WHERE user_id = $1 AND day > (NOW() AT TIME ZONE 'UTC')::date - $2::int
+ORDER BY day DESC, provider`, userID, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r TTSUsageRow
+		var d time.Time
+		if err := rows.Scan(&d, &r.Provider, &r.Requests, &r.Chars, &r.BilledChars); err != nil {
+			return nil, err
+		}
+		r.Day = d.Format("2006-01-02")
+		u.Rows = append(u.Rows, r)
+	}
+	return u, rows.Err()
+}
+
+// TopUsers returns per-user totals for the last days days, heaviest billed
+// usage first.
+func (s *TTSUsageStore) TopUsers(ctx context.Context, days, limit int) ([]TTSUserTotal, error) {
+	rows, err := s.pool.Query(ctx, `
+SELECT t.user_id, COALESCE(u.email, ''), SUM(t.requests), SUM(t.chars), SUM(t.billed_chars)
+FROM tts_usage t
+LEFT JOIN users u ON u.id = t.user_id
+WHERE t.day > (NOW() AT TIME ZONE 'UTC')::date - $1::int
+GROUP BY t.user_id, u.email
+ORDER BY SUM(t.billed_chars) DESC, SUM(t.chars) DESC
+LIMIT $2`, days, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []TTSUserTotal{}
+	for rows.Next() {
+		var t TTSUserTotal
+		if err := rows.Scan(&t.UserID, &t.Email, &t.Requests, &t.Chars, &t.BilledChars); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
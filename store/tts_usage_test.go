@@ -0,0 +1,35 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_TTSAllowanceFollowsSubscription(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour)
+	past := time.Now().Add(-24 * time.Hour)
+
+	cases := []struct {
+		name string
+		user store.User
+		plan string
+	}{
+		{"active", store.User{SubscriptionStatus: store.SubActive}, "full"},
+		{"free", store.User{SubscriptionStatus: store.SubFree}, "full"},
+		{"admin", store.User{IsAdmin: true, SubscriptionStatus: store.SubSuspended}, "full"},
+		{"trialing", store.User{SubscriptionStatus: store.SubTrialing}, "trial"},
+		{"cancelled in grace", store.User{SubscriptionStatus: store.SubCancelled, TrialEndsAt: &future}, "trial"},
+		{"expired beta", store.User{SubscriptionStatus: store.SubBetaTrial, TrialEndsAt: &past}, "limited"},
+		{"suspended", store.User{SubscriptionStatus: store.SubSuspended}, "limited"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := tc.user.TTSAllowance()
+			assert.Equal(t, tc.plan, a.Plan)
+			assert.LessOrEqual(t, a.DailyChars, a.MonthlyChars)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ailanguagetutor/srs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// VocabCard is one word in a user's spaced-repetition deck for a language.
+type VocabCard struct {
+	UserID      string    `json:"-"`
+	Language    string    `json:"language"`
+	Word        string    `json:"word"`
+	Translation string    `json:"translation"`
+	Phonetic    string    `json:"phonetic"`
+	Source      string    `json:"source"` // "vocab", "conversation", ...
+	CreatedAt   time.Time `json:"created_at"`
+	srs.State
+}
+
+// DeckStats summarises a user's deck for one language.
+type DeckStats struct {
+	Total   int        `json:"total"`
+	Due     int        `json:"due"`
+	NextDue *time.Time `json:"next_due,omitempty"` // earliest due date of a card not yet due
+}
+
+// VocabCardStore keeps review decks in Postgres (table vocab_cards). Words
+// are unique per user and language, case-insensitively.
+type VocabCardStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewVocabCardStore(pool *pgxpool.Pool) *VocabCardStore {
+	return &VocabCardStore{pool: pool}
+}
+
+const vocabCardColumns = `user_id, language, word, translation, phonetic, source, created_at,
+    reps, lapses, ease, interval_days, due_at, last_review_at`
+
+func scanVocabCard(row pgx.Row) (*VocabCard, error) {
+	var c VocabCard
+	var lastReview *time.Time
+	err := row.Scan(&c.UserID, &c.Language, &c.Word, &c.Translation, &c.Phonetic, &c.Source, &c.CreatedAt,
+		&c.Reps, &c.Lapses, &c.Ease, &c.Interval, &c.Due, &lastReview)
+	if err != nil {
+		return nil, err
+	}
+	if lastReview != nil {
+		c.LastReview = *lastReview
+	}
+	return &c, nil
+}
+
+// Add inserts new cards, due immediately unless they carry a review state
+// (e.g. imported from Anki). Words already in the deck keep their schedule;
+// only a missing translation or phonetic is filled in. It returns how many
+// cards were new.
+func (s *VocabCardStore) Add(ctx context.Context, cards []VocabCard) (int, error) {
+	added := 0
+	now := time.Now()
+	for _, c := range cards {
+		c.Word = strings.TrimSpace(c.Word)
+		if c.Word == "" {
+			continue
+		}
+		st := c.State
+		if st.Due.IsZero() {
+			st = srs.New(now)
+		}
+		var lastReview *time.Time
+		if !st.LastReview.IsZero() {
+			lastReview = &st.LastReview
+		}
+		var inserted bool
+		err := s.pool.QueryRow(ctx, `
+INSERT INTO vocab_cards (user_id, language, word, translation, phonetic, source,
+    reps, lapses, ease, interval_days, due_at, last_review_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (user_id, language, lower(word)) DO UPDATE SET
+    translation = CASE WHEN vocab_cards.translation = '' THEN EXCLUDED.translation ELSE vocab_cards.translation END,
+    phonetic    = CASE WHEN vocab_cards.phonetic = '' THEN EXCLUDED.phonetic ELSE vocab_cards.phonetic END
+RETURNING (xmax = 0)`,
+			c.UserID, c.Language, c.Word, c.Translation, c.Phonetic, c.Source,
+			st.Reps, st.Lapses, st.Ease, st.Interval, st.Due, lastReview).Scan(&inserted)
+		if err != nil {
+			return added, err
+		}
+		if inserted {
+			added++
+		}
+	}
+	return added, nil
+}
+
+// Get returns the card for word, or nil if it is not in the deck.
+func (s *VocabCardStore) Get(ctx context.Context, userID, language, word string) (*VocabCard, error) {
+	c, err := scanVocabCard(s.pool.QueryRow(ctx, `
+SELECT `+vocabCardColumns+`
+FROM vocab_cards WHERE user_id=$1 AND language=$2 AND lower(word)=lower($3)`, userID, language, word))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// Save writes the card's details and review state, inserting it if needed.
+func (s *VocabCardStore) Save(ctx context.Context, c *VocabCard) error {
+	var lastReview *time.Time
+	if !c.LastReview.IsZero() {
+		lastReview = &c.LastReview
+	}
+	_, err := s.pool.Exec(ctx, `
+INSERT INTO vocab_cards (user_id, language, word, translation, phonetic, source,
+    reps, lapses, ease, interval_days, due_at, last_review_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (user_id, language, lower(word)) DO UPDATE SET
+    translation=$4, phonetic=$5, reps=$7, lapses=$8, ease=$9,
+    interval_days=$10, due_at=$11, last_review_at=$12`,
+		c.UserID, c.Language, strings.TrimSpace(c.Word), c.Translation, c.Phonetic, c.Source,
+		c.Reps, c.Lapses, c.Ease, c.Interval, c.Due, lastReview)
+	return err
+}
+
+// DueNow brings the cards for words forward so they are due at now, e.g.
+// after the user misspelled them. Cards already due are left alone.
+func (s *VocabCardStore) DueNow(ctx context.Context, userID, language string, words []string, now time.Time) error {
+	lower := make([]string, len(words))
+	for i, w := range words {
+		lower[i] = strings.ToLower(strings.TrimSpace(w))
+	}
+	_, err := s.pool.Exec(ctx, `
+UPDATE vocab_cards SET due_at=$4
+WHERE user_id=$1 AND language=$2 AND lower(word) = ANY($3) AND due_at > $4`,
+		userID, language, lower, now)
+	return err
+}
+
+// Due returns up to limit cards due at now, most overdue first.
+func (s *VocabCardStore) Due(ctx context.Context, userID, language string, now time.Time, limit int) ([]VocabCard, error) {
+	return s.query(ctx, `
+SELECT `+vocabCardColumns+`
+FROM vocab_cards WHERE user_id=$1 AND language=$2 AND due_at <= $3
+ORDER BY due_at LIMIT $4`, userID, language, now, limit)
+}
+
+// Sample returns up to limit random cards for a practice session, cards due
+// at now first.
+func (s *VocabCardStore) Sample(ctx context.Context, userID, language string, now time.Time, limit int) ([]VocabCard, error) {
+	return s.query(ctx, `
+SELECT `+vocabCardColumns+`
+FROM vocab_cards WHERE user_id=$1 AND language=$2
+ORDER BY (due_at <= $3) DESC, random() LIMIT $4`, userID, language, now, limit)
+}
+
+// List returns the user's whole deck for a language, oldest cards first.
+func (s *VocabCardStore) List(ctx context.Context, userID, language string) ([]VocabCard, error) {
+	return s.query(ctx, `
+SELECT `+vocabCardColumns+`
+FROM vocab_cards WHERE user_id=$1 AND language=$2
+ORDER BY created_at, word`, userID, language)
+}
+
+func (s *VocabCardStore) query(ctx context.Context, sql string, args ...any) ([]VocabCard, error) {
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cards := []VocabCard{}
+	for rows.Next() {
+		c, err := scanVocabCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, *c)
+	}
+	return cards, rows.Err()
+}
+
+// Stats returns deck totals for userID and language at now.
+func (s *VocabCardStore) Stats(ctx context.Context, userID, language string, now time.Time) (*DeckStats, error) {
+	var st DeckStats
+	err := s.pool.QueryRow(ctx, `
+SELECT COUNT(*), COUNT(*) FILTER (WHERE due_at <= $3), MIN(due_at) FILTER (WHERE due_at > $3)
+FROM vocab_cards WHERE user_id=$1 AND language=$2`, userID, language, now).Scan(&st.Total, &st.Due, &st.NextDue)
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
@@ -0,0 +1,271 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Word list visibility.
+const (
+	ListPrivate = "private" // owner only
+	ListLink    = "link"    // anyone signed in who has the list's link (its id)
+	ListPublic  = "public"  // also listed in the public catalogue
+)
+
+// WordListItem is one word of a list; it has the shape of a vocab flashcard.
+type WordListItem struct {
+	Word        string `json:"word"`
+	Translation string `json:"translation"`
+	Phonetic    string `json:"phonetic"`
+}
+
+// WordList is a user-created, named list of words in one language.
+type WordList struct {
+	ID          string         `json:"id"`
+	OwnerID     string         `json:"owner_id"`
+	OwnerName   string         `json:"owner_name"`
+	Name        string         `json:"name"`
+	Language    string         `json:"language"`
+	Description string         `json:"description"`
+	Visibility  string         `json:"visibility"`
+	WordCount   int            `json:"word_count"`
+	Words       []WordListItem `json:"words,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// VisibleTo reports whether userID may view and practise the list.
+func (l *WordList) VisibleTo(userID string) bool {
+	return l.OwnerID == userID || l.Visibility == ListLink || l.Visibility == ListPublic
+}
+
+// WordListStore keeps word lists in Postgres (tables word_lists and
+// word_list_items). Words are unique per list, case-insensitively, and keep
+// the order they were added in.
+type WordListStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewWordListStore(pool *pgxpool.Pool) *WordListStore {
+	return &WordListStore{pool: pool}
+}
+
+const wordListColumns = `l.id, l.owner_id, COALESCE(u.username, ''), l.name, l.language, l.description, l.visibility,
+    (SELECT COUNT(*) FROM word_list_items i WHERE i.list_id = l.id), l.created_at, l.updated_at`
+
+func scanWordList(row pgx.Row) (*WordList, error) {
+	var l WordList
+	err := row.Scan(&l.ID, &l.OwnerID, &l.OwnerName, &l.Name, &l.Language, &l.Description, &l.Visibility,
+		&l.WordCount, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// ErrListLimit is returned by Create when the owner already has the
+// maximum number of lists.
+var ErrListLimit = errors.New("list limit reached")
+
+// Create inserts l (its ID set by the caller) together with its words, in
+// one transaction so a failure leaves no empty list behind. It returns
+// ErrListLimit if the owner already has limit lists; the count is taken
+// under a per-owner lock, so concurrent creates cannot exceed it.
+func (s *WordListStore) Create(ctx context.Context, l *WordList, limit int) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('word_lists:' || $1))`, l.OwnerID); err != nil {
+		return err
+	}
+	var n int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM word_lists WHERE owner_id = $1`, l.OwnerID).Scan(&n); err != nil {
+		return err
+	}
+	if n >= limit {
+		return ErrListLimit
+	}
+
+	_, err = tx.Exec(ctx, `
+INSERT INTO word_lists (id, owner_id, name, language, description, visibility)
+VALUES ($1, $2, $3, $4, $5, $6)`,
+		l.ID, l.OwnerID, l.Name, l.Language, l.Description, l.Visibility)
+	if err != nil {
+		return err
+	}
+	if len(l.Words) > 0 {
+		if _, err := addWords(ctx, tx, l.ID, l.Words, len(l.Words)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Get returns the list with its words, or nil if it does not exist.
+func (s *WordListStore) Get(ctx context.Context, id string) (*WordList, error) {
+	l, err := scanWordList(s.pool.QueryRow(ctx, `
+SELECT `+wordListColumns+`
+FROM word_lists l LEFT JOIN users u ON u.id = l.owner_id
+WHERE l.id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, `
+SELECT word, translation, phonetic FROM word_list_items WHERE list_id = $1 ORDER BY position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	l.Words = []WordListItem{}
+	for rows.Next() {
+		var it WordListItem
+		if err := rows.Scan(&it.Word, &it.Translation, &it.Phonetic); err != nil {
+			return nil, err
+		}
+		l.Words = append(l.Words, it)
+	}
+	return l, rows.Err()
+}
+
+// ListByOwner returns the owner's lists, without their words, most recently
+// updated first. An empty language matches all languages.
+func (s *WordListStore) ListByOwner(ctx context.Context, ownerID, language string) ([]WordList, error) {
+	return s.query(ctx, `
+SELECT `+wordListColumns+`
+FROM word_lists l LEFT JOIN users u ON u.id = l.owner_id
+WHERE l.owner_id = $1 AND ($2 = '' OR l.language = $2)
+ORDER BY l.updated_at DESC`, ownerID, language)
+}
+
+// ListPublic returns up to limit public lists, without their words, most
+// recently updated first.
+func (s *WordListStore) ListPublic(ctx context.Context, language string, limit int) ([]WordList, error) {
+	return s.query(ctx, `
+SELECT `+wordListColumns+`
+FROM word_lists l LEFT JOIN users u ON u.id = l.owner_id
+WHERE l.visibility = 'public' AND ($1 = '' OR l.language = $1)
+ORDER BY l.updated_at DESC LIMIT $2`, language, limit)
+}
+
+func (s *WordListStore) query(ctx context.Context, sql string, args ...any) ([]WordList, error) {
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lists := []WordList{}
+	for rows.Next() {
+		l, err := scanWordList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, *l)
+	}
+	return lists, rows.Err()
+}
+
+// Update saves the list's name, description and visibility.
+func (s *WordListStore) Update(ctx context.Context, l *WordList) error {
+	_, err := s.pool.Exec(ctx, `
+UPDATE word_lists SET name=$2, description=$3, visibility=$4, updated_at=NOW() WHERE id=$1`,
+		l.ID, l.Name, l.Description, l.Visibility)
+	return err
+}
+
+// Delete removes the list and its words.
+func (s *WordListStore) Delete(ctx context.Context, id string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM word_lists WHERE id=$1`, id)
+	return err
+}
+
+// AddWords appends words to the list. Words already in it keep their place;
+// only a missing translation or phonetic is filled in. New words that would
+// take the list past limit words are skipped. It returns how many words were
+// new.
+func (s *WordListStore) AddWords(ctx context.Context, id string, items []WordListItem, limit int) (int, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	added, err := addWords(ctx, tx, id, items, limit)
+	if err != nil {
+		return 0, err
+	}
+	return added, tx.Commit(ctx)
+}
+
+// addWords is AddWords within tx, which the caller commits.
+func addWords(ctx context.Context, tx pgx.Tx, id string, items []WordListItem, limit int) (int, error) {
+	// Concurrent additions to one list queue here, so the count checked by
+	// each INSERT below includes the other's words.
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM word_lists WHERE id=$1 FOR UPDATE`, id); err != nil {
+		return 0, err
+	}
+	var next int
+	if err := tx.QueryRow(ctx, `
+SELECT COALESCE(MAX(position), 0) + 1 FROM word_list_items WHERE list_id=$1`, id).Scan(&next); err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, it := range items {
+		word := strings.TrimSpace(it.Word)
+		if word == "" {
+			continue
+		}
+		// A full list still gets details filled in for words it already has.
+		var inserted bool
+		err := tx.QueryRow(ctx, `
+INSERT INTO word_list_items (list_id, position, word, translation, phonetic)
+SELECT $1, $2, $3, $4, $5
+WHERE (SELECT COUNT(*) FROM word_list_items WHERE list_id = $1) < $6
+   OR EXISTS (SELECT 1 FROM word_list_items WHERE list_id = $1 AND lower(word) = lower($3))
+ON CONFLICT (list_id, lower(word)) DO UPDATE SET
+    translation = CASE WHEN word_list_items.translation = '' THEN EXCLUDED.translation ELSE word_list_items.translation END,
+    phonetic    = CASE WHEN word_list_items.phonetic = '' THEN EXCLUDED.phonetic ELSE word_list_items.phonetic END
+RETURNING (xmax = 0)`,
+			id, next, word, strings.TrimSpace(it.Translation), strings.TrimSpace(it.Phonetic), limit).Scan(&inserted)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue // list full
+		}
+		if err != nil {
+			return 0, err
+		}
+		if inserted {
+			added++
+			next++
+		}
+	}
+	if added > 0 {
+		if _, err := tx.Exec(ctx, `UPDATE word_lists SET updated_at=NOW() WHERE id=$1`, id); err != nil {
+			return 0, err
+		}
+	}
+	return added, nil
+}
+
+// RemoveWord deletes word from the list, reporting whether it was there.
+func (s *WordListStore) RemoveWord(ctx context.Context, id, word string) (bool, error) {
+	tag, err := s.pool.Exec(ctx, `
+DELETE FROM word_list_items WHERE list_id=$1 AND lower(word)=lower($2)`, id, strings.TrimSpace(word))
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() > 0 {
+		_, err = s.pool.Exec(ctx, `UPDATE word_lists SET updated_at=NOW() WHERE id=$1`, id)
+	}
+	return tag.RowsAffected() > 0, err
+}
@@ -0,0 +1,100 @@
+package textnorm
+
+import "slices"
+
+// Kinds of difference that only occur when aligning texts word by word.
+const (
+	KindMissing Kind = "missing" // an expected word the answer leaves out
+	KindExtra   Kind = "extra"   // a word the answer adds
+)
+
+// WordDiff is one step of an alignment: an expected word and the answer
+// word it lines up with. Expected is empty for extra words, Answer for
+// missing ones.
+type WordDiff struct {
+	Expected string `json:"expected,omitempty"`
+	Answer   string `json:"answer,omitempty"`
+	Kind     Kind   `json:"kind"` // exact, script, accent, typo, wrong_word, missing or extra
+}
+
+// Align lines answer up with expected word by word, as in a dictation: the
+// alignment with the fewest and mildest differences, where a word spelled
+// with the wrong accent or a typo still pairs with the word it was meant to
+// be. Case and punctuation are ignored.
+func Align(expected, answer, lang string) []WordDiff {
+	exp, ans := Words(expected, lang), Words(answer, lang)
+
+	kinds := make([][]Kind, len(exp))
+	for i := range exp {
+		kinds[i] = make([]Kind, len(ans))
+		for j := range ans {
+			kinds[i][j] = wordKind(exp[i], ans[j], lang)
+		}
+	}
+
+	d := make([][]int, len(exp)+1)
+	for i := range d {
+		d[i] = make([]int, len(ans)+1)
+		d[i][0] = i * gapCost
+	}
+	for j := range d[0] {
+		d[0][j] = j * gapCost
+	}
+	for i := 1; i <= len(exp); i++ {
+		for j := 1; j <= len(ans); j++ {
+			d[i][j] = min(d[i-1][j-1]+pairCost(kinds[i-1][j-1]), d[i-1][j]+gapCost, d[i][j-1]+gapCost)
+		}
+	}
+
+	// Walk back, preferring to pair words over gaps on ties
+	var out []WordDiff
+	i, j := len(exp), len(ans)
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && d[i][j] == d[i-1][j-1]+pairCost(kinds[i-1][j-1]):
+			out = append(out, WordDiff{Expected: exp[i-1], Answer: ans[j-1], Kind: kinds[i-1][j-1]})
+			i, j = i-1, j-1
+		case i > 0 && d[i][j] == d[i-1][j]+gapCost:
+			out = append(out, WordDiff{Expected: exp[i-1], Kind: KindMissing})
+			i--
+		default:
+			out = append(out, WordDiff{Answer: ans[j-1], Kind: KindExtra})
+			j--
+		}
+	}
+	slices.Reverse(out)
+	return out
+}
+
+// Alignment costs, doubled so accent and script slips cost half a typo. A
+// different word costs as much as leaving one out and adding another.
+const gapCost = 4
+
+func pairCost(k Kind) int {
+	switch k {
+	case KindExact:
+		return 0
+	case KindScript, KindAccent:
+		return 1
+	case KindTypo:
+		return 3
+	}
+	return 2 * gapCost
+}
+
+// wordKind classifies the difference between two single words.
+func wordKind(expected, answer, lang string) Kind {
+	switch {
+	case expected == answer:
+		return KindExact
+	case FoldKana(expected) == FoldKana(answer):
+		return KindScript
+	case FoldAccents(FoldKana(expected), lang) == FoldAccents(FoldKana(answer), lang):
+		return KindAccent
+	}
+	e, a := FoldAccents(expected, lang), FoldAccents(answer, lang)
+	if distance(e, a) <= typoAllowance(e, lang) {
+		return KindTypo
+	}
+	return KindWrong
+}
@@ -0,0 +1,154 @@
+package textnorm
+
+import "strings"
+
+// Strictness sets which differences still count as a correct answer.
+type Strictness int
+
+const (
+	// Lenient accepts accent, script, article and typo differences
+	// (spoken answers, flashcards).
+	Lenient Strictness = iota
+	// Standard accepts the same differences but callers should point them
+	// out; it is the default for typed answers.
+	Standard
+	// Strict accepts only exact matches after normalisation.
+	Strict
+)
+
+// Kind classifies the difference between an answer and the expected text.
+type Kind string
+
+const (
+	KindExact   Kind = "exact"      // identical after normalisation
+	KindScript  Kind = "script"     // only hiragana/katakana differ
+	KindAccent  Kind = "accent"     // only accents/diacritics differ
+	KindArticle Kind = "article"    // only articles differ (missing, extra or wrong)
+	KindTypo    Kind = "typo"       // a few letters off
+	KindWrong   Kind = "wrong_word" // a different word or answer
+)
+
+// Result is the outcome of Match.
+type Result struct {
+	Kind     Kind   `json:"kind"`
+	Correct  bool   `json:"correct"`
+	Distance int    `json:"distance"` // letter edits between the accent-folded forms
+	Expected string `json:"expected"` // normalised expected text
+	Answer   string `json:"answer"`   // normalised answer
+}
+
+// Match compares answer with expected in lang. Differences are checked from
+// the mildest up: exact, kana script, accents, articles, then typos, which
+// are judged by edit distance relative to the answer's length.
+func Match(expected, answer, lang string, strictness Strictness) Result {
+	exp, ans := Tokens(expected, lang), Tokens(answer, lang)
+	res := Result{Expected: strings.Join(exp, " "), Answer: strings.Join(ans, " ")}
+
+	kana := func(ts []string) string { return FoldKana(strings.Join(ts, " ")) }
+	accent := func(ts []string) string { return FoldAccents(kana(ts), lang) }
+	bare := func(ts []string) string { return FoldAccents(kana(withoutArticles(ts, lang)), lang) }
+
+	switch {
+	case res.Expected == res.Answer:
+		res.Kind = KindExact
+	case kana(exp) == kana(ans):
+		res.Kind = KindScript
+	case accent(exp) == accent(ans):
+		res.Kind = KindAccent
+	case bare(exp) == bare(ans):
+		res.Kind = KindArticle
+	default:
+		res.Distance = distance(bare(exp), bare(ans))
+		if len(ans) > 0 && res.Distance <= typoAllowance(bare(exp), lang) {
+			res.Kind = KindTypo
+		} else {
+			res.Kind = KindWrong
+		}
+	}
+	if res.Kind != KindExact {
+		res.Distance = max(res.Distance, distance(res.Expected, res.Answer))
+	}
+
+	switch res.Kind {
+	case KindExact:
+		res.Correct = true
+	case KindWrong:
+		res.Correct = false
+	default:
+		res.Correct = strictness != Strict
+	}
+	return res
+}
+
+// MatchAny matches answer against each accepted variant and returns the best
+// result: the first correct one with the mildest difference.
+func MatchAny(expected []string, answer, lang string, strictness Strictness) Result {
+	var best Result
+	for i, e := range expected {
+		r := Match(e, answer, lang, strictness)
+		if i == 0 || rank(r) < rank(best) {
+			best = r
+		}
+	}
+	return best
+}
+
+var kindRank = map[Kind]int{KindExact: 0, KindScript: 1, KindAccent: 2, KindArticle: 3, KindTypo: 4, KindWrong: 5}
+
+func rank(r Result) int {
+	return kindRank[r.Kind]*1000 + r.Distance
+}
+
+func withoutArticles(ts []string, lang string) []string {
+	arts := articles[lang]
+	if arts == nil {
+		return ts
+	}
+	out := make([]string, 0, len(ts))
+	for _, t := range ts {
+		if !arts[t] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// typoAllowance is the number of letter edits still read as a typo: none
+// for short words, where one letter makes a different word, then one per
+// eight letters. Spaceless scripts allow one wrong character from four.
+func typoAllowance(s, lang string) int {
+	n := len([]rune(strings.ReplaceAll(s, " ", "")))
+	if spaceless(lang) {
+		return n / 4
+	}
+	switch {
+	case n <= 3:
+		return 0
+	case n <= 8:
+		return 1
+	default:
+		return 1 + n/8
+	}
+}
+
+// distance is the Levenshtein distance between a and b in runes.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			c := 1
+			if ra[i-1] == rb[j-1] {
+				c = 0
+			}
+			cur[j] = min(prev[j-1]+c, prev[j]+1, cur[j-1]+1)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
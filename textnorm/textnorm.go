@@ -0,0 +1,208 @@
+// Package textnorm normalises learner answers for comparison and matches
+// them against expected answers deterministically, reporting what kind of
+// mismatch occurred (accents only, articles, a typo, or a different word).
+//
+// Normalisation is language-aware: apostrophe elision and contractions are
+// expanded ("del" → "de el" in Spanish), Romanian cedilla letters are mapped
+// to their comma-below forms, full-width characters are narrowed, and
+// Japanese and Chinese text is compared character by character.
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// Normalize returns s in canonical form for lang: NFC, width-folded, lower
+// case, without punctuation, with contractions expanded and words separated
+// by single spaces. Accents and kana script are kept.
+func Normalize(s, lang string) string {
+	return strings.Join(Tokens(s, lang), " ")
+}
+
+// Tokens returns the normalised words of s. In languages written without
+// spaces (ja, zh) every character is a token.
+func Tokens(s, lang string) []string {
+	return expandContractions(Words(s, lang), lang)
+}
+
+// Words returns the normalised words of s as written, with contractions
+// kept whole ("del", not "de el").
+func Words(s, lang string) []string {
+	s = width.Fold.String(norm.NFC.String(s))
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '’', '‘', '`', '´', 'ʼ':
+			return '\''
+		case 'ş':
+			return 'ș'
+		case 'Ş':
+			return 'Ș'
+		case 'ţ':
+			return 'ț'
+		case 'Ţ':
+			return 'Ț'
+		}
+		return r
+	}, s)
+	s = strings.ToLower(s)
+
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = nil
+		}
+	}
+	for _, r := range s {
+		switch {
+		case r == '\'':
+			// Elision ("l'acqua", "j'ai") keeps the apostrophe on the first
+			// word; other apostrophes are dropped.
+			if len(cur) > 0 && elides[lang] {
+				cur = append(cur, r)
+				flush()
+			}
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			flush()
+		case isCJK(r):
+			flush()
+			words = append(words, string(r))
+		case r == 'ー' || unicode.Is(unicode.Mn, r):
+			// Long-vowel mark and combining marks stay attached.
+			if len(words) > 0 && len(cur) == 0 && isCJK([]rune(words[len(words)-1])[0]) {
+				words[len(words)-1] += string(r)
+			} else {
+				cur = append(cur, r)
+			}
+		default:
+			cur = append(cur, r)
+		}
+	}
+	flush()
+	return words
+}
+
+// elides lists languages whose articles and pronouns elide with an apostrophe.
+var elides = map[string]bool{"it": true, "fr": true}
+
+func expandContractions(words []string, lang string) []string {
+	table := contractions[lang]
+	if table == nil {
+		return words
+	}
+	out := make([]string, 0, len(words))
+	for _, w := range words {
+		if exp, ok := table[w]; ok {
+			out = append(out, exp...)
+		} else {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// contractions maps preposition + article contractions to their parts, so
+// "del" and "de el" compare equal.
+var contractions = map[string]map[string][]string{
+	"es": {
+		"al":  {"a", "el"},
+		"del": {"de", "el"},
+	},
+	"pt": {
+		"ao": {"a", "o"}, "aos": {"a", "os"}, "à": {"a", "a"}, "às": {"a", "as"},
+		"do": {"de", "o"}, "da": {"de", "a"}, "dos": {"de", "os"}, "das": {"de", "as"},
+		"no": {"em", "o"}, "na": {"em", "a"}, "nos": {"em", "os"}, "nas": {"em", "as"},
+		"num": {"em", "um"}, "numa": {"em", "uma"},
+		"pelo": {"por", "o"}, "pela": {"por", "a"}, "pelos": {"por", "os"}, "pelas": {"por", "as"},
+	},
+	"it": {
+		"al": {"a", "il"}, "allo": {"a", "lo"}, "alla": {"a", "la"}, "ai": {"a", "i"}, "agli": {"a", "gli"}, "alle": {"a", "le"}, "all'": {"a", "l'"},
+		"del": {"di", "il"}, "dello": {"di", "lo"}, "della": {"di", "la"}, "dei": {"di", "i"}, "degli": {"di", "gli"}, "delle": {"di", "le"}, "dell'": {"di", "l'"},
+		"nel": {"in", "il"}, "nello": {"in", "lo"}, "nella": {"in", "la"}, "nei": {"in", "i"}, "negli": {"in", "gli"}, "nelle": {"in", "le"}, "nell'": {"in", "l'"},
+		"sul": {"su", "il"}, "sullo": {"su", "lo"}, "sulla": {"su", "la"}, "sui": {"su", "i"}, "sugli": {"su", "gli"}, "sulle": {"su", "le"}, "sull'": {"su", "l'"},
+		"dal": {"da", "il"}, "dallo": {"da", "lo"}, "dalla": {"da", "la"}, "dai": {"da", "i"}, "dagli": {"da", "gli"}, "dalle": {"da", "le"}, "dall'": {"da", "l'"},
+	},
+	"fr": {
+		"au": {"à", "le"}, "aux": {"à", "les"},
+		"du": {"de", "le"}, "des": {"de", "les"},
+	},
+	"de": {
+		"am": {"an", "dem"}, "ans": {"an", "das"}, "im": {"in", "dem"}, "ins": {"in", "das"},
+		"beim": {"bei", "dem"}, "vom": {"von", "dem"}, "zum": {"zu", "dem"}, "zur": {"zu", "der"},
+	},
+}
+
+// articles lists each language's articles, after contraction expansion.
+var articles = map[string]map[string]bool{
+	"es": set("el", "la", "los", "las", "un", "una", "unos", "unas", "lo"),
+	"pt": set("o", "a", "os", "as", "um", "uma", "uns", "umas"),
+	"it": set("il", "lo", "la", "i", "gli", "le", "l'", "un", "uno", "una", "un'"),
+	"fr": set("le", "la", "les", "l'", "un", "une", "des"),
+	"de": set("der", "die", "das", "den", "dem", "des", "ein", "eine", "einen", "einem", "einer", "eines"),
+}
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// FoldAccents removes diacritics (NFD, combining marks dropped) and maps
+// letters with no decomposition to their base spelling: ß → ss, ø → o, ...
+// In Russian only ё and stress marks are folded, since й is its own letter;
+// Japanese and Chinese are returned unchanged (dakuten change the word).
+func FoldAccents(s, lang string) string {
+	switch lang {
+	case "ja", "zh":
+		return s
+	case "ru":
+		return strings.NewReplacer("ё", "е", "Ё", "Е", "\u0301", "", "\u0300", "").Replace(norm.NFC.String(s))
+	}
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		switch r {
+		case 'ß':
+			b.WriteString("ss")
+		case 'ø':
+			b.WriteRune('o')
+		case 'æ':
+			b.WriteString("ae")
+		case 'œ':
+			b.WriteString("oe")
+		case 'ł':
+			b.WriteRune('l')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return norm.NFC.String(b.String())
+}
+
+// FoldKana maps katakana to hiragana so both scripts compare equal.
+func FoldKana(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ァ' && r <= 'ヶ' {
+			return r - 0x60
+		}
+		return r
+	}, s)
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// spaceless reports whether lang is written without spaces between words.
+func spaceless(lang string) bool {
+	return lang == "ja" || lang == "zh"
+}
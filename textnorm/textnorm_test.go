@@ -0,0 +1,107 @@
+package textnorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	cases := []struct {
+		in, lang string
+		want     []string
+	}{
+		{"¿Dónde está el baño?", "es", []string{"dónde", "está", "el", "baño"}},
+		{"Voy al parque del barrio", "es", []string{"voy", "a", "el", "parque", "de", "el", "barrio"}},
+		{"L’acqua è nella bottiglia", "it", []string{"l'", "acqua", "è", "in", "la", "bottiglia"}},
+		{"Ｈｅｌｌｏ，ｗｏｒｌｄ！", "en", []string{"hello", "world"}},
+		{"我喜欢茶。", "zh", []string{"我", "喜", "欢", "茶"}},
+		{"Mulţumesc", "ro", []string{"mulțumesc"}},
+	}
+	for _, c := range cases {
+		if got := Tokens(c.in, c.lang); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Tokens(%q, %s) = %q, want %q", c.in, c.lang, got, c.want)
+		}
+	}
+}
+
+func TestMatch_Kinds(t *testing.T) {
+	cases := []struct {
+		expected, answer, lang string
+		want                   Kind
+	}{
+		{"¿Dónde está el baño?", "donde esta el baño", "es", KindAccent},
+		{"Voy al mercado", "voy a el mercado", "es", KindExact},
+		{"Ich trinke den Kaffee", "ich trinke kaffee", "de", KindArticle},
+		{"Die Straße", "die strasse", "de", KindAccent},
+		{"biblioteca", "biblioteka", "es", KindTypo},
+		{"gato", "perro", "es", KindWrong},
+		{"sol", "sal", "es", KindWrong}, // one letter in a short word is another word
+		{"コーヒー", "こーひー", "ja", KindScript},
+		{"かき", "がき", "ja", KindWrong}, // dakuten are not accents
+		{"我喜欢喝茶", "我喜欢喝水", "zh", KindTypo},
+		{"我喜欢茶", "你好", "zh", KindWrong},
+		{"Mulţumesc", "mulțumesc", "ro", KindExact},
+		{"Всё хорошо", "все хорошо", "ru", KindAccent},
+		{"Hello", "", "en", KindWrong},
+	}
+	for _, c := range cases {
+		got := Match(c.expected, c.answer, c.lang, Standard)
+		if got.Kind != c.want {
+			t.Errorf("Match(%q, %q, %s).Kind = %s, want %s", c.expected, c.answer, c.lang, got.Kind, c.want)
+		}
+	}
+}
+
+func TestMatch_Strictness(t *testing.T) {
+	for _, s := range []Strictness{Lenient, Standard} {
+		if r := Match("está", "esta", "es", s); !r.Correct {
+			t.Errorf("strictness %d: accent-only answer should be accepted", s)
+		}
+	}
+	if r := Match("está", "esta", "es", Strict); r.Correct {
+		t.Error("strict: accent-only answer should be rejected")
+	}
+	if r := Match("está", "Está.", "es", Strict); !r.Correct {
+		t.Error("strict: case and punctuation should not matter")
+	}
+	if r := Match("gato", "perro", "es", Lenient); r.Correct {
+		t.Error("lenient: a different word should still be wrong")
+	}
+}
+
+func TestMatchAny_PicksClosest(t *testing.T) {
+	r := MatchAny([]string{"el coche", "el carro"}, "el caro", "es", Standard)
+	if r.Kind != KindTypo || r.Expected != "el carro" {
+		t.Errorf("MatchAny = %+v, want typo against \"el carro\"", r)
+	}
+}
+
+func TestAlign(t *testing.T) {
+	got := Align("Ayer fuimos al mercado del pueblo.", "ayer fuimos mercado del puebo y", "es")
+	want := []WordDiff{
+		{"ayer", "ayer", KindExact},
+		{"fuimos", "fuimos", KindExact},
+		{"al", "", KindMissing},
+		{"mercado", "mercado", KindExact},
+		{"del", "del", KindExact},
+		{"pueblo", "puebo", KindTypo},
+		{"", "y", KindExtra},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Align = %+v\nwant %+v", got, want)
+	}
+
+	got = Align("Él está aquí", "el esta alli", "es")
+	want = []WordDiff{
+		{"él", "el", KindAccent},
+		{"está", "esta", KindAccent},
+		{"aquí", "alli", KindWrong},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Align = %+v\nwant %+v", got, want)
+	}
+
+	if got := Align("Buongiorno", "", "it"); len(got) != 1 || got[0].Kind != KindMissing {
+		t.Errorf("empty answer: %+v", got)
+	}
+}
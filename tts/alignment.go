@@ -0,0 +1,260 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Alignment maps a rendered clip back to its text so clients can highlight
+// words as they are spoken and replay a single sentence.
+type Alignment struct {
+	Duration  float64  `json:"duration"`  // seconds
+	Estimated bool     `json:"estimated"` // true when timings are proportional guesses
+	Words     []Timing `json:"words"`
+	Sentences []Timing `json:"sentences"`
+}
+
+// Timing is one span of the text. CharStart/CharEnd are rune offsets into
+// the request text (end exclusive); Start/End are seconds into the clip.
+type Timing struct {
+	Text      string  `json:"text"`
+	CharStart int     `json:"char_start"`
+	CharEnd   int     `json:"char_end"`
+	Start     float64 `json:"start"`
+	End       float64 `json:"end"`
+}
+
+// Aligner is implemented by providers that can return exact timings along
+// with the audio (e.g. ElevenLabs' with-timestamps endpoint).
+type Aligner interface {
+	SynthesizeAligned(ctx context.Context, req Request, voice Voice) (*Audio, *Alignment, error)
+}
+
+// charTiming is the timing of one rune of the source text.
+type charTiming struct {
+	start, end float64
+}
+
+// buildAlignment groups per-rune timings into words and sentences. Words are
+// runs of non-space runes, except that CJK characters are one word each,
+// since those scripts are written without spaces.
+func buildAlignment(text string, chars []charTiming, duration float64, estimated bool) *Alignment {
+	runes := []rune(text)
+	if len(chars) < len(runes) {
+		runes = runes[:len(chars)]
+	}
+	a := &Alignment{Duration: duration, Estimated: estimated, Words: []Timing{}, Sentences: []Timing{}}
+
+	span := func(from, to int) Timing {
+		return Timing{
+			Text:      string(runes[from:to]),
+			CharStart: from,
+			CharEnd:   to,
+			Start:     round3(chars[from].start),
+			End:       round3(chars[to-1].end),
+		}
+	}
+
+	wordStart, sentStart := -1, -1
+	for i, r := range runes {
+		space := unicode.IsSpace(r)
+		if !space && sentStart < 0 {
+			sentStart = i
+		}
+		if wordStart >= 0 && (space || isCJK(r)) {
+			a.Words = append(a.Words, span(wordStart, i))
+			wordStart = -1
+		}
+		if !space && !unicode.IsPunct(r) {
+			if isCJK(r) {
+				a.Words = append(a.Words, span(i, i+1))
+			} else if wordStart < 0 {
+				wordStart = i
+			}
+		}
+		if sentStart >= 0 && isSentenceEnd(r) {
+			a.Sentences = append(a.Sentences, span(sentStart, i+1))
+			sentStart = -1
+		}
+	}
+	if wordStart >= 0 {
+		a.Words = append(a.Words, span(wordStart, len(runes)))
+	}
+	if sentStart >= 0 {
+		end := len(runes)
+		for end > sentStart && unicode.IsSpace(runes[end-1]) {
+			end--
+		}
+		a.Sentences = append(a.Sentences, span(sentStart, end))
+	}
+	// Trailing punctuation belongs to the word for display purposes only
+	for i := range a.Words {
+		a.Words[i].Text = strings.TrimRightFunc(a.Words[i].Text, unicode.IsPunct)
+	}
+	return a
+}
+
+// estimateAlignment spreads duration over text in proportion to rune count,
+// with extra weight on pauses after punctuation. Used for offline engines and
+// for clips rendered without timestamps.
+func estimateAlignment(text string, duration float64) *Alignment {
+	runes := []rune(text)
+	weights := make([]float64, len(runes))
+	var total float64
+	for i, r := range runes {
+		switch {
+		case isSentenceEnd(r):
+			weights[i] = 4
+		case unicode.IsPunct(r):
+			weights[i] = 2
+		case unicode.IsSpace(r):
+			weights[i] = 0.5
+		case isCJK(r):
+			weights[i] = 3 // one syllable per character
+		default:
+			weights[i] = 1
+		}
+		total += weights[i]
+	}
+	chars := make([]charTiming, len(runes))
+	var t float64
+	for i, w := range weights {
+		d := 0.0
+		if total > 0 {
+			d = duration * w / total
+		}
+		chars[i] = charTiming{start: t, end: t + d}
+		t += d
+	}
+	return buildAlignment(text, chars, duration, true)
+}
+
+func isSentenceEnd(r rune) bool {
+	return strings.ContainsRune(".!?。！？…", r)
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+func round3(f float64) float64 {
+	return float64(int64(f*1000+0.5)) / 1000
+}
+
+// ── Clip duration ─────────────────────────────────────────────────────────────
+
+// audioDuration returns the length of a WAV or constant-bitrate MP3 clip in
+// seconds. When the format cannot be parsed it estimates from the text at an
+// average speaking rate of 14 characters per second.
+func audioDuration(data []byte, contentType, text string, speed float64) float64 {
+	var d float64
+	switch contentType {
+	case "audio/wav":
+		d = wavDuration(data)
+	case "audio/mpeg":
+		d = mp3Duration(data)
+	}
+	if d > 0 {
+		return d
+	}
+	if speed <= 0 {
+		speed = 1
+	}
+	return float64(utf8.RuneCountInString(text)) / 14 / speed
+}
+
+func wavDuration(data []byte) float64 {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return 0
+	}
+	var byteRate uint32
+	for off := 12; off+8 <= len(data); {
+		id := string(data[off : off+4])
+		size := binary.LittleEndian.Uint32(data[off+4 : off+8])
+		body := off + 8
+		switch id {
+		case "fmt ":
+			if body+12 <= len(data) {
+				byteRate = binary.LittleEndian.Uint32(data[body+8 : body+12])
+			}
+		case "data":
+			if byteRate == 0 {
+				return 0
+			}
+			// Streamed WAVs (espeak-ng --stdout) leave the size unset
+			n := len(data) - body
+			if int(size) < n {
+				n = int(size)
+			}
+			return float64(n) / float64(byteRate)
+		}
+		off = body + int(size) + int(size&1)
+	}
+	return 0
+}
+
+// mp3Bitrates is the MPEG-1 Layer III bitrate table in kbit/s.
+var mp3Bitrates = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+
+func mp3Duration(data []byte) float64 {
+	off := 0
+	if len(data) >= 10 && string(data[0:3]) == "ID3" {
+		size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+		off = 10 + size
+	}
+	for ; off+4 <= len(data); off++ {
+		// frame sync + MPEG-1 Layer III
+		if data[off] != 0xFF || data[off+1]&0xFE != 0xFA {
+			continue
+		}
+		kbps := mp3Bitrates[data[off+2]>>4]
+		if kbps == 0 {
+			continue
+		}
+		return float64(len(data)-off) * 8 / float64(kbps*1000)
+	}
+	return 0
+}
+
+// ── Service integration ───────────────────────────────────────────────────────
+
+// SynthesizeAligned renders req like Synthesize and also returns its
+// alignment: exact timings from providers implementing Aligner, otherwise a
+// proportional estimate. The audio is fully buffered.
+func (s *Service) SynthesizeAligned(ctx context.Context, req Request) (*Result, *Alignment, error) {
+	req = normalize(req)
+	routes := s.Routes(req)
+	if len(routes) > 0 {
+		if al, ok := routes[0].Provider.(Aligner); ok {
+			audio, alignment, err := al.SynthesizeAligned(ctx, routes[0].request(req), routes[0].Voice)
+			if err == nil {
+				// Timings refer to the rendered text; if the lexicon or markup
+				// changed it, spread them over what the learner sees instead.
+				if display := DisplayText(req); routes[0].Text != display {
+					alignment = estimateAlignment(display, alignment.Duration)
+				}
+				return &Result{Audio: audio, Route: routes[0]}, alignment, nil
+			}
+			log.Printf("tts: %s aligned render failed for %s: %v", routes[0].Provider.Name(), req.Language, err)
+			routes = routes[1:]
+		}
+	}
+	res, err := s.synthesize(ctx, req, routes)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	display := DisplayText(req)
+	return res, estimateAlignment(display, audioDuration(data, res.ContentType, display, req.Speed)), nil
+}
@@ -0,0 +1,50 @@
+package tts
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateAlignment_WordsAndSentences(t *testing.T) {
+	a := estimateAlignment("Ciao, Marco! Come stai?", 3.0)
+
+	require.Len(t, a.Words, 4)
+	assert.Equal(t, "Ciao", a.Words[0].Text)
+	assert.Equal(t, "stai", a.Words[3].Text)
+	assert.Equal(t, 18, a.Words[3].CharStart)
+	assert.True(t, a.Estimated)
+
+	require.Len(t, a.Sentences, 2)
+	assert.Equal(t, "Ciao, Marco!", a.Sentences[0].Text)
+	assert.Equal(t, "Come stai?", a.Sentences[1].Text)
+	assert.Equal(t, 0.0, a.Sentences[0].Start)
+	assert.InDelta(t, 3.0, a.Sentences[1].End, 0.001)
+	for i := 1; i < len(a.Words); i++ {
+		assert.GreaterOrEqual(t, a.Words[i].Start, a.Words[i-1].End, "words must not overlap")
+	}
+}
+
+func TestBuildAlignment_CJKCharactersAreWords(t *testing.T) {
+	a := estimateAlignment("你好。", 1.0)
+	require.Len(t, a.Words, 2)
+	assert.Equal(t, "你", a.Words[0].Text)
+	require.Len(t, a.Sentences, 1)
+	assert.Equal(t, "你好。", a.Sentences[0].Text)
+}
+
+func TestWavDuration(t *testing.T) {
+	// 16 kHz mono 16-bit → 32000 bytes per second; one second of silence
+	wav := make([]byte, 44+32000)
+	copy(wav[0:], "RIFF")
+	copy(wav[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(wav[16:], 16)
+	binary.LittleEndian.PutUint32(wav[28:], 32000)
+	copy(wav[36:], "data")
+	binary.LittleEndian.PutUint32(wav[40:], 0xFFFFFFFF) // streamed, size unset
+
+	assert.InDelta(t, 1.0, wavDuration(wav), 0.001)
+	assert.InDelta(t, 1.0, audioDuration(nil, "audio/mpeg", "abcdefghijklmn", 1), 0.001, "falls back to text length")
+}
@@ -0,0 +1,272 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ailanguagetutor/config"
+)
+
+const elevenLabsAPI = "https://api.elevenlabs.io"
+
+// elevenLabsVoiceInfo is metadata for ElevenLabs pre-made voices. Voice IDs
+// overridden through config that are not listed here get a bare catalog entry.
+var elevenLabsVoiceInfo = map[string]Voice{
+	"21m00Tcm4TlvDq8ikWAM": {Name: "Rachel", Gender: "female", Styles: []string{"calm", "clear"}},
+	"pNInz6obpgDQGcFmaJgB": {Name: "Adam", Gender: "male", Styles: []string{"deep", "authoritative"}},
+	"ErXwobaYiN019PkySvjV": {Name: "Antoni", Gender: "male", Styles: []string{"warm", "conversational"}},
+	"yoZ06aMxZJJ28mfd3POQ": {Name: "Sam", Gender: "male", Styles: []string{"raspy", "casual"}},
+	"TxGEqnHWrfWFTfGW9XjX": {Name: "Josh", Gender: "male", Styles: []string{"confident", "professional"}},
+	"VR6AewLTigWG4xSOukaG": {Name: "Arnold", Gender: "male", Styles: []string{"energetic", "adventurous"}},
+}
+
+// ElevenLabs synthesizes speech with the ElevenLabs streaming API.
+type ElevenLabs struct {
+	apiKey   string
+	model    string
+	phonemes bool // model honours <phoneme> tags
+	client   *http.Client
+	voices   []Voice
+}
+
+// elevenLabsPhonemeModels accept inline <phoneme> tags; other models read
+// the tag text literally, so lexicon aliases are used instead.
+var elevenLabsPhonemeModels = map[string]bool{
+	"eleven_flash_v2": true,
+	"eleven_turbo_v2": true,
+}
+
+// NewElevenLabs builds the provider and its catalog from the voice IDs in cfg.
+// Language voices that share an ID are merged into one multilingual entry.
+func NewElevenLabs(cfg *config.Config) *ElevenLabs {
+	p := &ElevenLabs{
+		apiKey:   cfg.ElevenLabsAPIKey,
+		model:    cfg.ElevenLabsModel,
+		phonemes: elevenLabsPhonemeModels[cfg.ElevenLabsModel],
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+
+	langVoices := []struct{ lang, id string }{
+		{"it", cfg.ElevenLabsVoiceIT},
+		{"es", cfg.ElevenLabsVoiceES},
+		{"pt", cfg.ElevenLabsVoicePT},
+		{"fr", cfg.ElevenLabsVoiceFR},
+		{"de", cfg.ElevenLabsVoiceDE},
+		{"ja", cfg.ElevenLabsVoiceJA},
+		{"ru", cfg.ElevenLabsVoiceRU},
+		{"ro", cfg.ElevenLabsVoiceRO},
+		{"zh", cfg.ElevenLabsVoiceZH},
+		{"en", cfg.ElevenLabsVoiceEN},
+	}
+	index := map[string]int{}
+	for _, lv := range langVoices {
+		if lv.id == "" {
+			continue
+		}
+		if i, ok := index[lv.id]; ok {
+			p.voices[i].Languages = append(p.voices[i].Languages, lv.lang)
+			continue
+		}
+		index[lv.id] = len(p.voices)
+		p.voices = append(p.voices, p.voice(lv.id, "", []string{lv.lang}))
+	}
+
+	// Personality voices speak every language through the multilingual model.
+	personalityVoices := []struct{ personality, id string }{
+		{"professor", cfg.ElevenLabsVoiceProfessor},
+		{"friendly-partner", cfg.ElevenLabsVoiceFriendly},
+		{"bartender", cfg.ElevenLabsVoiceBartender},
+		{"business-executive", cfg.ElevenLabsVoiceExecutive},
+		{"travel-guide", cfg.ElevenLabsVoiceTravel},
+	}
+	for _, pv := range personalityVoices {
+		if pv.id != "" {
+			p.voices = append(p.voices, p.voice(pv.id, pv.personality, []string{"*"}))
+		}
+	}
+	return p
+}
+
+func (p *ElevenLabs) voice(id, personality string, langs []string) Voice {
+	v := elevenLabsVoiceInfo[id]
+	v.ID = id
+	v.Provider = p.Name()
+	v.Languages = langs
+	v.Personality = personality
+	if v.Name == "" {
+		v.Name = id
+	}
+	return v
+}
+
+func (p *ElevenLabs) Name() string { return "elevenlabs" }
+
+func (p *ElevenLabs) Voices() []Voice { return p.voices }
+
+func (p *ElevenLabs) Metered() bool { return true }
+
+// RenderMarkup emits ElevenLabs' inline tags: <break time="1.2s" /> and, on
+// models that support it, <phoneme>. There is no emphasis tag; capitals are
+// the documented way to stress a word. Text is escaped so it cannot open
+// tags of its own.
+func (p *ElevenLabs) RenderMarkup(doc Document) string {
+	var b strings.Builder
+	for _, n := range doc {
+		switch n.Kind {
+		case NodeBreak:
+			fmt.Fprintf(&b, ` <break time="%.1fs" /> `, n.Pause.Seconds())
+		case NodeEmphasis:
+			b.WriteString(html.EscapeString(strings.ToUpper(n.Text)))
+		case NodeSpell:
+			b.WriteString(html.EscapeString(RenderPlain(Document{n})))
+		case NodePhoneme:
+			if p.phonemes && n.IPA != "" {
+				fmt.Fprintf(&b, `<phoneme alphabet="ipa" ph="%s">%s</phoneme>`, html.EscapeString(n.IPA), html.EscapeString(n.Text))
+			} else {
+				b.WriteString(html.EscapeString(aliasOr(n)))
+			}
+		case NodeSub:
+			b.WriteString(html.EscapeString(aliasOr(n)))
+		default:
+			b.WriteString(html.EscapeString(n.Text))
+		}
+	}
+	return b.String()
+}
+
+type elevenLabsBody struct {
+	Text          string        `json:"text"`
+	ModelID       string        `json:"model_id"`
+	VoiceSettings voiceSettings `json:"voice_settings"`
+	Speed         float64       `json:"speed,omitempty"`
+}
+
+type voiceSettings struct {
+	Stability       float64 `json:"stability"`
+	SimilarityBoost float64 `json:"similarity_boost"`
+	Style           float64 `json:"style"`
+	UseSpeakerBoost bool    `json:"use_speaker_boost"`
+}
+
+func (p *ElevenLabs) body(req Request) elevenLabsBody {
+	return elevenLabsBody{
+		Text:    req.Text,
+		ModelID: p.model,
+		VoiceSettings: voiceSettings{
+			Stability:       0.5,
+			SimilarityBoost: 0.75,
+			Style:           0.0,
+			UseSpeakerBoost: true,
+		},
+		Speed: req.Speed,
+	}
+}
+
+func (p *ElevenLabs) CacheKey(req Request, voice Voice) any {
+	b := p.body(req)
+	b.Text = ""
+	return b
+}
+
+func (p *ElevenLabs) Synthesize(ctx context.Context, req Request, voice Voice) (*Audio, error) {
+	bodyBytes, err := json.Marshal(p.body(req))
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/v1/text-to-speech/%s/stream", elevenLabsAPI, voice.ID)
+	elReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	elReq.Header.Set("Content-Type", "application/json")
+	elReq.Header.Set("xi-api-key", p.apiKey)
+	elReq.Header.Set("Accept", "audio/mpeg")
+
+	resp, err := p.client.Do(elReq)
+	if err != nil {
+		return nil, fmt.Errorf("elevenlabs: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		// ElevenLabs reports an exhausted character quota as 401 quota_exceeded.
+		if strings.Contains(string(body), "quota_exceeded") {
+			return nil, ErrQuotaExceeded
+		}
+		return nil, fmt.Errorf("elevenlabs error %d: %s", resp.StatusCode, string(body))
+	}
+	return &Audio{Body: resp.Body, ContentType: "audio/mpeg"}, nil
+}
+
+type elevenLabsTimestamps struct {
+	AudioBase64 string `json:"audio_base64"`
+	Alignment   struct {
+		Characters []string  `json:"characters"`
+		Starts     []float64 `json:"character_start_times_seconds"`
+		Ends       []float64 `json:"character_end_times_seconds"`
+	} `json:"alignment"`
+}
+
+// SynthesizeAligned uses the with-timestamps endpoint, which returns the whole
+// clip base64-encoded together with per-character timings.
+func (p *ElevenLabs) SynthesizeAligned(ctx context.Context, req Request, voice Voice) (*Audio, *Alignment, error) {
+	bodyBytes, err := json.Marshal(p.body(req))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	url := fmt.Sprintf("%s/v1/text-to-speech/%s/with-timestamps", elevenLabsAPI, voice.ID)
+	elReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+	elReq.Header.Set("Content-Type", "application/json")
+	elReq.Header.Set("xi-api-key", p.apiKey)
+
+	resp, err := p.client.Do(elReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("elevenlabs: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if strings.Contains(string(raw), "quota_exceeded") {
+			return nil, nil, ErrQuotaExceeded
+		}
+		return nil, nil, fmt.Errorf("elevenlabs error %d: %s", resp.StatusCode, string(raw))
+	}
+
+	var parsed elevenLabsTimestamps
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, nil, fmt.Errorf("elevenlabs: parse timestamps: %w", err)
+	}
+	audio, err := base64.StdEncoding.DecodeString(parsed.AudioBase64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("elevenlabs: decode audio: %w", err)
+	}
+
+	// Characters are returned one per rune of the request text.
+	al := parsed.Alignment
+	n := min(len(al.Characters), len(al.Starts), len(al.Ends))
+	chars := make([]charTiming, n)
+	var duration float64
+	for i := 0; i < n; i++ {
+		chars[i] = charTiming{start: al.Starts[i], end: al.Ends[i]}
+		duration = max(duration, al.Ends[i])
+	}
+	text := strings.Join(al.Characters[:n], "")
+	return &Audio{Body: io.NopCloser(bytes.NewReader(audio)), ContentType: "audio/mpeg"},
+		buildAlignment(text, chars, duration, false), nil
+}
@@ -0,0 +1,153 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localTimeout bounds a single offline render; both engines are much faster
+// than real time, so anything slower is a hung process.
+const localTimeout = 30 * time.Second
+
+// ── espeak-ng ─────────────────────────────────────────────────────────────────
+
+// espeakVoices maps app language codes to espeak-ng voice names.
+var espeakVoices = map[string]string{
+	"it": "it",
+	"es": "es",
+	"pt": "pt",
+	"fr": "fr",
+	"de": "de",
+	"ja": "ja",
+	"ru": "ru",
+	"ro": "ro",
+	"zh": "cmn",
+	"en": "en-us",
+}
+
+// Espeak synthesizes speech with a locally installed espeak-ng binary.
+// Quality is robotic but it needs no network and has no quota.
+type Espeak struct {
+	binary string
+	voices []Voice
+}
+
+func NewEspeak(binary string) *Espeak {
+	p := &Espeak{binary: binary}
+	for _, lang := range sortedKeys(espeakVoices) {
+		p.voices = append(p.voices, Voice{
+			ID:        "espeak-ng:" + espeakVoices[lang],
+			Provider:  p.Name(),
+			Name:      "espeak-ng " + espeakVoices[lang],
+			Languages: []string{lang},
+			Styles:    []string{"synthetic"},
+		})
+	}
+	return p
+}
+
+func (p *Espeak) Name() string { return "espeak-ng" }
+
+func (p *Espeak) Voices() []Voice { return p.voices }
+
+// RenderMarkup passes pauses, emphasis and spelling through as SSML, which
+// espeak-ng reads natively in -m mode.
+func (p *Espeak) RenderMarkup(doc Document) string { return RenderSSML(doc) }
+
+func (p *Espeak) CacheKey(req Request, voice Voice) any {
+	return map[string]any{"speed": req.Speed}
+}
+
+func (p *Espeak) Synthesize(ctx context.Context, req Request, voice Voice) (*Audio, error) {
+	ctx, cancel := context.WithTimeout(ctx, localTimeout)
+	defer cancel()
+
+	name := strings.TrimPrefix(voice.ID, "espeak-ng:")
+	wpm := int(175 * req.Speed) // espeak-ng default rate is 175 words per minute
+	// -m: the text is SSML, rendered by RenderMarkup
+	cmd := exec.CommandContext(ctx, p.binary, "-v", name, "-s", strconv.Itoa(wpm), "-m", "--stdout", "--stdin")
+	cmd.Stdin = strings.NewReader(req.Text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("espeak-ng: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return &Audio{Body: io.NopCloser(bytes.NewReader(out)), ContentType: "audio/wav"}, nil
+}
+
+// ── Piper ─────────────────────────────────────────────────────────────────────
+
+// Piper synthesizes speech with a locally installed Piper binary and one
+// .onnx model per language.
+type Piper struct {
+	binary string
+	models map[string]string // voice ID → model path
+	voices []Voice
+}
+
+// NewPiper registers one voice per "lang:model_path" entry in spec
+// (comma-separated), e.g. "it:/models/it_IT-paola-medium.onnx".
+func NewPiper(binary, spec string) *Piper {
+	p := &Piper{binary: binary, models: map[string]string{}}
+	for lang, model := range parsePairs(spec) {
+		name := strings.TrimSuffix(filepath.Base(model), ".onnx")
+		id := "piper:" + name
+		p.models[id] = model
+		p.voices = append(p.voices, Voice{
+			ID:        id,
+			Provider:  p.Name(),
+			Name:      name,
+			Languages: []string{lang},
+			Styles:    []string{"neural"},
+		})
+	}
+	sortVoices(p.voices)
+	return p
+}
+
+func (p *Piper) Name() string { return "piper" }
+
+func (p *Piper) Voices() []Voice { return p.voices }
+
+func (p *Piper) CacheKey(req Request, voice Voice) any {
+	return map[string]any{"model": p.models[voice.ID], "speed": req.Speed}
+}
+
+func (p *Piper) Synthesize(ctx context.Context, req Request, voice Voice) (*Audio, error) {
+	ctx, cancel := context.WithTimeout(ctx, localTimeout)
+	defer cancel()
+
+	out, err := os.CreateTemp("", "piper-*.wav")
+	if err != nil {
+		return nil, err
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	// Piper's length_scale is inverse speed: 0.8 speaks 25% faster.
+	lengthScale := strconv.FormatFloat(1/req.Speed, 'f', 2, 64)
+	cmd := exec.CommandContext(ctx, p.binary,
+		"--model", p.models[voice.ID],
+		"--length_scale", lengthScale,
+		"--output_file", out.Name())
+	cmd.Stdin = strings.NewReader(req.Text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("piper: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Audio{Body: io.NopCloser(bytes.NewReader(data)), ContentType: "audio/wav"}, nil
+}
@@ -0,0 +1,361 @@
+package tts
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ailanguagetutor/store"
+)
+
+// ── Markup documents ──────────────────────────────────────────────────────────
+//
+// Requests may carry a small SSML subset:
+//
+//	<break time="500ms"/>                       pause (ms or s, max 3s)
+//	<emphasis>word</emphasis>                    stressed word(s)
+//	<say-as interpret-as="characters">abc</say-as>  spell slowly, letter by letter
+//	<sub alias="Organizzazione">ONU</sub>          read alias instead of the text
+//	<phoneme ph="ˈkjaːve">chiave</phoneme>         IPA pronunciation
+//
+// Text is parsed into a Document, pronunciation lexicons are applied to its
+// plain text, and each provider renders it into the form it supports.
+
+// NodeKind identifies a Document node.
+type NodeKind int
+
+const (
+	NodeText NodeKind = iota
+	NodeBreak
+	NodeEmphasis
+	NodeSpell
+	NodeSub
+	NodePhoneme
+)
+
+// Node is one piece of a Document.
+type Node struct {
+	Kind  NodeKind
+	Text  string        // displayed text (empty for breaks)
+	Alias string        // NodeSub: what to say instead
+	IPA   string        // NodePhoneme: IPA transcription
+	Pause time.Duration // NodeBreak
+}
+
+// Document is parsed request text.
+type Document []Node
+
+// maxBreak caps pauses; ElevenLabs ignores breaks longer than 3 seconds.
+const maxBreak = 3 * time.Second
+
+// ErrInvalidMarkup is returned by ParseMarkup for malformed or unsupported markup.
+var ErrInvalidMarkup = errors.New("tts: invalid markup")
+
+// PlainDocument wraps text that contains no markup.
+func PlainDocument(text string) Document {
+	return Document{{Kind: NodeText, Text: text}}
+}
+
+// ParseMarkup parses text containing the supported SSML subset. A <speak>
+// wrapper is optional.
+func ParseMarkup(text string) (Document, error) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "<speak") {
+		text = "<speak>" + text + "</speak>"
+	}
+	dec := xml.NewDecoder(strings.NewReader(text))
+	dec.Strict = true
+
+	var doc Document
+	var open []xml.StartElement // element stack inside <speak>
+	var inner strings.Builder   // text of the current non-text element
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMarkup, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "speak":
+				continue
+			case "break":
+				if len(open) > 0 {
+					return nil, fmt.Errorf("%w: <break> cannot be nested", ErrInvalidMarkup)
+				}
+				d, err := parsePause(attr(t, "time"))
+				if err != nil {
+					return nil, err
+				}
+				doc = append(doc, Node{Kind: NodeBreak, Pause: d})
+				if err := dec.Skip(); err != nil {
+					return nil, fmt.Errorf("%w: %v", ErrInvalidMarkup, err)
+				}
+				continue
+			case "emphasis", "sub", "phoneme":
+			case "say-as":
+				if ia := attr(t, "interpret-as"); ia != "characters" && ia != "spell-out" {
+					return nil, fmt.Errorf("%w: say-as %q not supported", ErrInvalidMarkup, ia)
+				}
+			default:
+				return nil, fmt.Errorf("%w: <%s> not supported", ErrInvalidMarkup, t.Name.Local)
+			}
+			if len(open) > 0 {
+				return nil, fmt.Errorf("%w: <%s> cannot be nested", ErrInvalidMarkup, t.Name.Local)
+			}
+			open = append(open, t)
+			inner.Reset()
+		case xml.EndElement:
+			if len(open) == 0 {
+				continue // </speak>
+			}
+			el := open[0]
+			open = open[:0]
+			n := Node{Text: inner.String()}
+			switch el.Name.Local {
+			case "emphasis":
+				n.Kind = NodeEmphasis
+			case "say-as":
+				n.Kind = NodeSpell
+			case "sub":
+				n.Kind, n.Alias = NodeSub, attr(el, "alias")
+			case "phoneme":
+				n.Kind, n.IPA = NodePhoneme, attr(el, "ph")
+			}
+			doc = append(doc, n)
+		case xml.CharData:
+			if len(open) > 0 {
+				inner.Write(t)
+			} else {
+				doc = append(doc, Node{Kind: NodeText, Text: string(t)})
+			}
+		}
+	}
+	return doc, nil
+}
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func parsePause(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 500 * time.Millisecond, nil
+	}
+	var d time.Duration
+	if v, ok := strings.CutSuffix(s, "ms"); ok {
+		ms, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: break time %q", ErrInvalidMarkup, s)
+		}
+		d = time.Duration(ms * float64(time.Millisecond))
+	} else if v, ok := strings.CutSuffix(s, "s"); ok {
+		sec, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: break time %q", ErrInvalidMarkup, s)
+		}
+		d = time.Duration(sec * float64(time.Second))
+	} else {
+		return 0, fmt.Errorf("%w: break time %q", ErrInvalidMarkup, s)
+	}
+	return min(max(d, 0), maxBreak), nil
+}
+
+// document parses req into a Document, treating unparsable markup as text.
+func document(req Request) Document {
+	if req.Markup {
+		if doc, err := ParseMarkup(req.Text); err == nil {
+			return doc
+		}
+	}
+	return PlainDocument(req.Text)
+}
+
+// DisplayText returns the text of req as shown to the learner; alignments
+// are computed against it.
+func DisplayText(req Request) string {
+	if !req.Markup {
+		return req.Text
+	}
+	return document(req).Display()
+}
+
+// Display returns the document's text as shown to the learner: markup
+// removed, original spellings kept, breaks as a single space.
+func (d Document) Display() string {
+	var b strings.Builder
+	for _, n := range d {
+		if n.Kind == NodeBreak {
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteString(n.Text)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// ── Lexicons ──────────────────────────────────────────────────────────────────
+
+// Lexicon supplies per-language pronunciation entries, longest term first.
+type Lexicon interface {
+	Entries(language string) []store.LexiconEntry
+}
+
+// applyLexicon rewrites lexicon terms in the document's plain text nodes as
+// sub or phoneme nodes. Text inside explicit markup is left alone.
+func (d Document) applyLexicon(entries []store.LexiconEntry) Document {
+	if len(entries) == 0 {
+		return d
+	}
+	var out Document
+	for _, n := range d {
+		if n.Kind != NodeText {
+			out = append(out, n)
+			continue
+		}
+		out = append(out, splitLexicon(n.Text, entries)...)
+	}
+	return out
+}
+
+func splitLexicon(text string, entries []store.LexiconEntry) Document {
+	runes := []rune(text)
+	var out Document
+	plainStart := 0
+	for i := 0; i < len(runes); i++ {
+		if i > 0 && isWordRune(runes[i-1]) {
+			continue // only match at word starts
+		}
+		e, n := matchTerm(runes[i:], entries)
+		if n == 0 {
+			continue
+		}
+		if plainStart < i {
+			out = append(out, Node{Kind: NodeText, Text: string(runes[plainStart:i])})
+		}
+		word := string(runes[i : i+n])
+		if e.Phoneme != "" {
+			out = append(out, Node{Kind: NodePhoneme, Text: word, IPA: e.Phoneme, Alias: e.Alias})
+		} else {
+			out = append(out, Node{Kind: NodeSub, Text: word, Alias: e.Alias})
+		}
+		i += n - 1
+		plainStart = i + 1
+	}
+	if plainStart < len(runes) {
+		out = append(out, Node{Kind: NodeText, Text: string(runes[plainStart:])})
+	}
+	return out
+}
+
+// matchTerm returns the first entry whose term starts s as a whole word, and
+// the term's length in runes.
+func matchTerm(s []rune, entries []store.LexiconEntry) (store.LexiconEntry, int) {
+	for _, e := range entries {
+		term := []rune(e.Term)
+		n := len(term)
+		if n == 0 || n > len(s) || (n < len(s) && isWordRune(s[n])) {
+			continue
+		}
+		if strings.EqualFold(string(s[:n]), e.Term) {
+			return e, n
+		}
+	}
+	return store.LexiconEntry{}, 0
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// ── Rendering ─────────────────────────────────────────────────────────────────
+
+// MarkupRenderer is implemented by providers that accept some markup. Others
+// receive RenderPlain output.
+type MarkupRenderer interface {
+	RenderMarkup(doc Document) string
+}
+
+// render returns the text to send to p for doc.
+func render(p Provider, doc Document) string {
+	if mr, ok := p.(MarkupRenderer); ok {
+		return mr.RenderMarkup(doc)
+	}
+	return RenderPlain(doc)
+}
+
+// RenderPlain renders doc as plain text for engines without markup support:
+// aliases are spoken, pauses become ellipses, spelled words become letters
+// separated by commas.
+func RenderPlain(doc Document) string {
+	var b strings.Builder
+	for _, n := range doc {
+		switch n.Kind {
+		case NodeBreak:
+			b.WriteString(" … ")
+		case NodeSpell:
+			b.WriteString(spellOut(n.Text))
+		case NodeSub, NodePhoneme:
+			b.WriteString(aliasOr(n))
+		default:
+			b.WriteString(n.Text)
+		}
+	}
+	return b.String()
+}
+
+// RenderSSML renders doc as an SSML <speak> document (espeak-ng -m).
+func RenderSSML(doc Document) string {
+	var b strings.Builder
+	b.WriteString("<speak>")
+	for _, n := range doc {
+		switch n.Kind {
+		case NodeBreak:
+			fmt.Fprintf(&b, `<break time="%dms"/>`, n.Pause.Milliseconds())
+		case NodeEmphasis:
+			b.WriteString("<emphasis>" + html.EscapeString(n.Text) + "</emphasis>")
+		case NodeSpell:
+			b.WriteString(`<say-as interpret-as="characters">` + html.EscapeString(n.Text) + "</say-as>")
+		case NodeSub, NodePhoneme:
+			b.WriteString(html.EscapeString(aliasOr(n)))
+		default:
+			b.WriteString(html.EscapeString(n.Text))
+		}
+	}
+	b.WriteString("</speak>")
+	return b.String()
+}
+
+func aliasOr(n Node) string {
+	if n.Alias != "" {
+		return n.Alias
+	}
+	return n.Text
+}
+
+// spellOut separates the letters of s with commas so engines read them one
+// at a time, slowly.
+func spellOut(s string) string {
+	var letters []string
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			letters = append(letters, string(r))
+		}
+	}
+	return strings.Join(letters, ", ")
+}
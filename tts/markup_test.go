@@ -0,0 +1,60 @@
+package tts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMarkup(t *testing.T) {
+	doc, err := ParseMarkup(`Si scrive <say-as interpret-as="characters">Sciò</say-as>.<break time="1.5s"/> Ripeti <emphasis>piano</emphasis>!`)
+	require.NoError(t, err)
+
+	kinds := []NodeKind{}
+	for _, n := range doc {
+		kinds = append(kinds, n.Kind)
+	}
+	assert.Equal(t, []NodeKind{NodeText, NodeSpell, NodeText, NodeBreak, NodeText, NodeEmphasis, NodeText}, kinds)
+	assert.Equal(t, 1500*time.Millisecond, doc[3].Pause)
+	assert.Equal(t, "Si scrive Sciò. Ripeti piano!", doc.Display())
+
+	_, err = ParseMarkup(`<audio src="x.mp3"/>`)
+	assert.ErrorIs(t, err, ErrInvalidMarkup)
+	_, err = ParseMarkup(`a < b`)
+	assert.ErrorIs(t, err, ErrInvalidMarkup)
+	_, err = ParseMarkup(`<emphasis>molto <break time="1s"/> bene</emphasis>`)
+	assert.ErrorIs(t, err, ErrInvalidMarkup, "a break inside an element would be read out of order")
+}
+
+func TestApplyLexicon_WholeWordsCaseInsensitive(t *testing.T) {
+	entries := []store.LexiconEntry{
+		{Term: "São Paulo", Alias: "Sãun Páulu"},
+		{Term: "Sr.", Alias: "Senhor"},
+		{Term: "ONU", Alias: "Organização das Nações Unidas"},
+	}
+	doc := PlainDocument("O sr. Silva mora em são paulo, não em ONUS.").applyLexicon(entries)
+
+	assert.Equal(t, "O Senhor Silva mora em Sãun Páulu, não em ONUS.", RenderPlain(doc))
+	assert.Equal(t, "O sr. Silva mora em são paulo, não em ONUS.", doc.Display(), "display keeps the original spelling")
+}
+
+func TestRenderers(t *testing.T) {
+	doc, err := ParseMarkup(`Ciao <break time="300ms"/><emphasis>Marco</emphasis> <say-as interpret-as="characters">ABC</say-as> &amp; co`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Ciao  … Marco A, B, C & co", RenderPlain(doc))
+	assert.Equal(t, `<speak>Ciao <break time="300ms"/><emphasis>Marco</emphasis> <say-as interpret-as="characters">ABC</say-as> &amp; co</speak>`, RenderSSML(doc))
+
+	el := &ElevenLabs{}
+	assert.Equal(t, `Ciao  <break time="0.3s" /> MARCO A, B, C &amp; co`, el.RenderMarkup(doc))
+	tags := Document{{Kind: NodeEmphasis, Text: `<break time="9s"/>`}, {Kind: NodeSub, Text: "x", Alias: "<b>"}}
+	assert.Equal(t, `&lt;BREAK TIME=&#34;9S&#34;/&gt;&lt;b&gt;`, el.RenderMarkup(tags), "text cannot open tags")
+
+	ph := Document{{Kind: NodePhoneme, Text: "chiave", IPA: "ˈkjaːve", Alias: "kiave"}}
+	assert.Equal(t, "kiave", el.RenderMarkup(ph))
+	el.phonemes = true
+	assert.Equal(t, `<phoneme alphabet="ipa" ph="ˈkjaːve">chiave</phoneme>`, el.RenderMarkup(ph))
+}
@@ -0,0 +1,236 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ailanguagetutor/store"
+)
+
+// Cache sidecars: the Request an entry renders, and its word timings.
+const (
+	recipeSidecar    = "recipe"
+	alignmentSidecar = "alignment"
+)
+
+// renderTimeout bounds one background render, including provider fallback.
+const renderTimeout = 2 * time.Minute
+
+// Renderer pre-renders audio into the AudioCache outside of a client request.
+// Prepare records a recipe under a stable key and returns the key at once;
+// the audio is produced by a background worker (Enqueue) or on first fetch
+// (Render), whichever comes first.
+type Renderer struct {
+	svc   *Service
+	cache *store.AudioCache
+	queue chan queuedRender
+	meter RenderFunc
+
+	mu       sync.Mutex
+	inflight map[string]chan struct{}
+}
+
+// queuedRender is a key waiting for the background workers and the user
+// whose request queued it.
+type queuedRender struct {
+	userID string
+	key    string
+}
+
+// A RenderFunc renders key on behalf of userID, charging the render to them.
+type RenderFunc func(ctx context.Context, userID, key string) error
+
+// NewRenderer starts workers background goroutines draining the render queue.
+func NewRenderer(svc *Service, cache *store.AudioCache, workers int) *Renderer {
+	r := &Renderer{
+		svc:      svc,
+		cache:    cache,
+		queue:    make(chan queuedRender, 256),
+		inflight: map[string]chan struct{}{},
+	}
+	for i := 0; i < workers; i++ {
+		go r.work()
+	}
+	return r
+}
+
+// Prepare stores the recipe for req and returns its cache key. The key is the
+// preferred route's key, so audio rendered through /api/tts is shared.
+func (r *Renderer) Prepare(req Request) (string, error) {
+	req = normalize(req)
+	routes := r.svc.Routes(req)
+	if len(routes) == 0 {
+		return "", ErrNoVoice
+	}
+	key := routes[0].Key
+	if err := r.cache.WriteSidecar(key, recipeSidecar, req); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Remember stores req as the recipe of an entry that was rendered elsewhere
+// (e.g. streamed by /api/tts), so it can be aligned or re-rendered later.
+func (r *Renderer) Remember(key string, req Request) error {
+	return r.cache.WriteSidecar(key, recipeSidecar, normalize(req))
+}
+
+// Recipe returns the request the entry for key renders. It returns
+// store.ErrAudioNotCached when key has no recipe.
+func (r *Renderer) Recipe(key string) (Request, error) {
+	var req Request
+	err := r.cache.ReadSidecar(key, recipeSidecar, &req)
+	return req, err
+}
+
+// MeterWith makes the background workers render through meter, so queued
+// renders are charged to the user who queued them like renders on first
+// fetch. Call it before anything is enqueued. Without a meter queued renders
+// are not charged to anyone.
+func (r *Renderer) MeterWith(meter RenderFunc) {
+	r.meter = meter
+}
+
+// Enqueue schedules keys for background rendering on behalf of userID. When
+// the queue is full the remaining keys are dropped; Render will produce them
+// lazily on first fetch.
+func (r *Renderer) Enqueue(userID string, keys ...string) {
+	for _, key := range keys {
+		select {
+		case r.queue <- queuedRender{userID: userID, key: key}:
+		default:
+			log.Printf("tts renderer: queue full, %d clips left for lazy rendering", len(keys))
+			return
+		}
+	}
+}
+
+func (r *Renderer) work() {
+	for q := range r.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), renderTimeout)
+		var err error
+		if r.meter != nil {
+			err = r.meter(ctx, q.userID, q.key)
+		} else {
+			_, err = r.Render(ctx, q.key)
+		}
+		if err != nil && !errors.Is(err, store.ErrAudioNotCached) {
+			log.Printf("tts renderer: %s: %v", q.key, err)
+		}
+		cancel()
+	}
+}
+
+// Rendered describes a clip synthesized by Render, for metering.
+type Rendered struct {
+	Provider Provider // the provider that produced the clip, possibly a fallback
+	Request  Request  // the recipe it was rendered from
+}
+
+// Render makes sure the audio for key is in the cache, synthesizing it from
+// the stored recipe if needed. Concurrent calls for one key share one render,
+// and only the call that synthesized the clip gets a non-nil Rendered back.
+// It returns store.ErrAudioNotCached when key has no recipe.
+//
+// The clip is stored under key even when a fallback provider produced it, so
+// a prepared key always resolves to one file instead of re-rendering on every
+// fetch.
+func (r *Renderer) Render(ctx context.Context, key string) (*Rendered, error) {
+	for {
+		if f, err := r.cache.Open(key); err == nil {
+			f.Close()
+			return nil, nil
+		}
+
+		r.mu.Lock()
+		wait, busy := r.inflight[key]
+		if !busy {
+			wait = make(chan struct{})
+			r.inflight[key] = wait
+		}
+		r.mu.Unlock()
+
+		if !busy {
+			rendered, err := r.render(ctx, key)
+			r.mu.Lock()
+			delete(r.inflight, key)
+			r.mu.Unlock()
+			close(wait)
+			return rendered, err
+		}
+		select {
+		case <-wait:
+			// the other render finished; re-check the cache
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (r *Renderer) render(ctx context.Context, key string) (*Rendered, error) {
+	var req Request
+	if err := r.cache.ReadSidecar(key, recipeSidecar, &req); err != nil {
+		return nil, err
+	}
+	res, alignment, err := r.svc.SynthesizeAligned(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	cw, err := r.cache.Create(key, res.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(cw, res.Body); err != nil {
+		cw.Abort()
+		return nil, err
+	}
+	if err := r.cache.WriteSidecar(key, alignmentSidecar, alignment); err != nil {
+		log.Printf("tts renderer: alignment %s: %v", key, err)
+	}
+	if err := cw.Commit(); err != nil {
+		return nil, err
+	}
+	return &Rendered{Provider: res.Provider, Request: req}, nil
+}
+
+// Alignment returns the word timings for key, rendering the clip first if
+// needed. Clips rendered without timings (streamed through /api/tts) get a
+// proportional estimate from their duration, which is then stored.
+func (r *Renderer) Alignment(ctx context.Context, key string) (*Alignment, error) {
+	var a Alignment
+	if err := r.cache.ReadSidecar(key, alignmentSidecar, &a); err == nil {
+		return &a, nil
+	}
+	if _, err := r.Render(ctx, key); err != nil {
+		return nil, err
+	}
+	if err := r.cache.ReadSidecar(key, alignmentSidecar, &a); err == nil {
+		return &a, nil
+	}
+
+	var req Request
+	if err := r.cache.ReadSidecar(key, recipeSidecar, &req); err != nil {
+		return nil, err
+	}
+	f, err := r.cache.Open(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	display := DisplayText(req)
+	est := estimateAlignment(display, audioDuration(data, f.ContentType, display, req.Speed))
+	if err := r.cache.WriteSidecar(key, alignmentSidecar, est); err != nil {
+		log.Printf("tts renderer: alignment %s: %v", key, err)
+	}
+	return est, nil
+}
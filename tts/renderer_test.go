@@ -0,0 +1,103 @@
+package tts_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ailanguagetutor/store"
+	"github.com/ailanguagetutor/tts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer_PrepareThenLazyRender(t *testing.T) {
+	el := newFake("elevenlabs", tts.Voice{ID: "rachel", Languages: []string{"it"}})
+	cache := store.NewAudioCache(t.TempDir(), 0)
+	r := tts.NewRenderer(tts.NewServiceWith("", nil, el), cache, 0)
+
+	key, err := r.Prepare(tts.Request{Text: "C'era una volta", Language: "it", Speed: 0.75})
+	require.NoError(t, err)
+	_, err = cache.Open(key)
+	assert.ErrorIs(t, err, store.ErrAudioNotCached, "Prepare must not render")
+
+	rendered, err := r.Render(context.Background(), key)
+	require.NoError(t, err)
+	require.NotNil(t, rendered)
+	assert.Equal(t, "elevenlabs", rendered.Provider.Name())
+	assert.Equal(t, "C'era una volta", rendered.Request.Text)
+	rendered, err = r.Render(context.Background(), key)
+	require.NoError(t, err)
+	assert.Nil(t, rendered, "nothing was synthesized")
+	assert.Equal(t, 1, el.calls, "second Render is a cache hit")
+
+	f, err := cache.Open(key)
+	require.NoError(t, err)
+	defer f.Close()
+	data, _ := io.ReadAll(f)
+	assert.Equal(t, "rachel:C'era una volta", string(data))
+}
+
+func TestRenderer_UnknownKey(t *testing.T) {
+	el := newFake("elevenlabs", tts.Voice{ID: "rachel", Languages: []string{"it"}})
+	r := tts.NewRenderer(tts.NewServiceWith("", nil, el), store.NewAudioCache(t.TempDir(), 0), 0)
+
+	_, err := r.Render(context.Background(), store.AudioKey("never prepared"))
+	assert.ErrorIs(t, err, store.ErrAudioNotCached)
+	assert.Equal(t, 0, el.calls)
+}
+
+func TestRenderer_AlignmentIsStoredWithRender(t *testing.T) {
+	el := newFake("elevenlabs", tts.Voice{ID: "rachel", Languages: []string{"it"}})
+	r := tts.NewRenderer(tts.NewServiceWith("", nil, el), store.NewAudioCache(t.TempDir(), 0), 0)
+
+	key, err := r.Prepare(tts.Request{Text: "Buongiorno a tutti.", Language: "it"})
+	require.NoError(t, err)
+
+	a, err := r.Alignment(context.Background(), key)
+	require.NoError(t, err)
+	assert.True(t, a.Estimated, "fake provider has no timestamps")
+	require.Len(t, a.Words, 3)
+	assert.Equal(t, "tutti", a.Words[2].Text)
+
+	_, err = r.Alignment(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, 1, el.calls, "alignment sidecar is reused")
+}
+
+func TestRenderer_ReportsFallbackProvider(t *testing.T) {
+	el := newFake("elevenlabs", tts.Voice{ID: "rachel", Languages: []string{"it"}})
+	espeak := newFake("espeak-ng", tts.Voice{ID: "espeak-ng:it", Languages: []string{"it"}})
+	r := tts.NewRenderer(tts.NewServiceWith("espeak-ng", nil, el, espeak), store.NewAudioCache(t.TempDir(), 0), 0)
+
+	key, err := r.Prepare(tts.Request{Text: "Ciao", Language: "it"})
+	require.NoError(t, err)
+	el.err = tts.ErrQuotaExceeded
+
+	rendered, err := r.Render(context.Background(), key)
+	require.NoError(t, err)
+	require.NotNil(t, rendered)
+	assert.Equal(t, "espeak-ng", rendered.Provider.Name(), "the fallback rendered the clip")
+}
+
+func TestRenderer_QueuedRendersGoThroughMeter(t *testing.T) {
+	el := newFake("elevenlabs", tts.Voice{ID: "rachel", Languages: []string{"it"}})
+	r := tts.NewRenderer(tts.NewServiceWith("", nil, el), store.NewAudioCache(t.TempDir(), 0), 1)
+	metered := make(chan string, 1)
+	r.MeterWith(func(ctx context.Context, userID, key string) error {
+		_, err := r.Render(ctx, key)
+		metered <- userID
+		return err
+	})
+
+	key, err := r.Prepare(tts.Request{Text: "Buongiorno", Language: "it"})
+	require.NoError(t, err)
+	r.Enqueue("user-1", key)
+	select {
+	case userID := <-metered:
+		assert.Equal(t, "user-1", userID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued render was not metered")
+	}
+}
@@ -0,0 +1,228 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/ailanguagetutor/config"
+	"github.com/ailanguagetutor/store"
+)
+
+// Route is one provider/voice candidate for a request, with the cache key
+// its rendering would be stored under.
+type Route struct {
+	Provider Provider
+	Voice    Voice
+	Text     string // request text rendered for the provider (lexicon + markup applied)
+	Key      string
+}
+
+// Result is a successful synthesis and the route that produced it.
+type Result struct {
+	*Audio
+	Route
+}
+
+// Service routes requests to providers: a per-language provider if one is
+// configured, else the default provider, then the fallback provider.
+type Service struct {
+	providers     map[string]Provider
+	order         []string          // registration order, for stable catalog listings
+	primary       string            // default provider name
+	langProviders map[string]string // language code → provider name
+	fallback      string            // provider tried when the first choice fails
+	lexicon       Lexicon           // pronunciation overrides; may be nil
+}
+
+// NewService registers ElevenLabs plus any offline engines found on the host
+// and applies the routing from cfg. Routes naming a provider that is not
+// installed are logged and ignored. lexicon may be nil.
+func NewService(cfg *config.Config, lexicon Lexicon) *Service {
+	s := &Service{
+		providers:     map[string]Provider{},
+		primary:       cfg.TTSProvider,
+		langProviders: parsePairs(cfg.TTSLanguageProviders),
+		fallback:      cfg.TTSFallbackProvider,
+		lexicon:       lexicon,
+	}
+	s.Register(NewElevenLabs(cfg))
+	if _, err := exec.LookPath(cfg.EspeakBinary); err == nil {
+		s.Register(NewEspeak(cfg.EspeakBinary))
+	}
+	if cfg.PiperVoices != "" {
+		if _, err := exec.LookPath(cfg.PiperBinary); err == nil {
+			s.Register(NewPiper(cfg.PiperBinary, cfg.PiperVoices))
+		} else {
+			log.Printf("tts: PIPER_VOICES set but %q not found", cfg.PiperBinary)
+		}
+	}
+
+	for _, name := range append([]string{s.primary, s.fallback}, sortedValues(s.langProviders)...) {
+		if name != "" && s.providers[name] == nil {
+			log.Printf("tts: provider %q is configured but not available", name)
+		}
+	}
+	return s
+}
+
+// NewServiceWith builds a service from explicit providers. The first provider
+// is the default; fallback may be empty.
+func NewServiceWith(fallback string, langProviders map[string]string, providers ...Provider) *Service {
+	s := &Service{providers: map[string]Provider{}, langProviders: langProviders, fallback: fallback}
+	for _, p := range providers {
+		s.Register(p)
+	}
+	if len(providers) > 0 {
+		s.primary = providers[0].Name()
+	}
+	return s
+}
+
+// SetLexicon replaces the pronunciation lexicon applied before synthesis.
+func (s *Service) SetLexicon(l Lexicon) {
+	s.lexicon = l
+}
+
+// Register adds a provider, replacing any provider with the same name.
+func (s *Service) Register(p Provider) {
+	if _, ok := s.providers[p.Name()]; !ok {
+		s.order = append(s.order, p.Name())
+	}
+	s.providers[p.Name()] = p
+}
+
+// Voices lists the catalog of every registered provider, filtered to voices
+// that speak lang (all voices when lang is empty).
+func (s *Service) Voices(lang string) []Voice {
+	var out []Voice
+	for _, name := range s.order {
+		for _, v := range s.providers[name].Voices() {
+			if lang == "" || v.Speaks(lang) {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+// Voice returns the voice provider would use for personality and lang.
+func (s *Service) Voice(provider, personality, lang string) (Voice, bool) {
+	p := s.providers[provider]
+	if p == nil {
+		return Voice{}, false
+	}
+	return pickVoice(p.Voices(), Request{Language: lang, Personality: personality})
+}
+
+// Routes returns the candidates for req in the order they will be tried.
+// With req.Unmetered, paid providers are skipped, leaving only offline ones.
+func (s *Service) Routes(req Request) []Route {
+	req = normalize(req)
+	names := []string{s.primary}
+	if name, ok := s.langProviders[req.Language]; ok {
+		names = []string{name}
+	}
+	if s.fallback != "" {
+		names = append(names, s.fallback)
+	}
+
+	doc := document(req)
+	if s.lexicon != nil {
+		doc = doc.applyLexicon(s.lexicon.Entries(req.Language))
+	}
+
+	var routes []Route
+	seen := map[string]bool{}
+	for _, name := range names {
+		p := s.providers[name]
+		if p == nil || seen[name] || (req.Unmetered && IsMetered(p)) {
+			continue
+		}
+		seen[name] = true
+		v, ok := pickVoice(p.Voices(), req)
+		if !ok {
+			continue
+		}
+		text := render(p, doc)
+		routes = append(routes, Route{
+			Provider: p,
+			Voice:    v,
+			Text:     text,
+			Key:      store.AudioKey(p.Name(), v.ID, text, p.CacheKey(req, v)),
+		})
+	}
+	return routes
+}
+
+// Synthesize renders req with the first route that succeeds.
+func (s *Service) Synthesize(ctx context.Context, req Request) (*Result, error) {
+	return s.synthesize(ctx, normalize(req), s.Routes(req))
+}
+
+func (s *Service) synthesize(ctx context.Context, req Request, routes []Route) (*Result, error) {
+	if len(routes) == 0 {
+		return nil, ErrNoVoice
+	}
+	var errs []error
+	for _, rt := range routes {
+		audio, err := rt.Provider.Synthesize(ctx, rt.request(req), rt.Voice)
+		if err == nil {
+			return &Result{Audio: audio, Route: rt}, nil
+		}
+		log.Printf("tts: %s failed for %s: %v", rt.Provider.Name(), req.Language, err)
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// request returns req with the text rendered for this route's provider.
+func (rt Route) request(req Request) Request {
+	req.Text = rt.Text
+	return req
+}
+
+func normalize(req Request) Request {
+	if req.Speed == 0 {
+		req.Speed = 1.0
+	}
+	return req
+}
+
+// ── helpers ───────────────────────────────────────────────────────────────────
+
+// parsePairs parses "k1:v1,k2:v2" into a map. Values may contain colons.
+func parsePairs(spec string) map[string]string {
+	out := map[string]string{}
+	for _, part := range strings.Split(spec, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if ok && k != "" && v != "" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedValues(m map[string]string) []string {
+	var vals []string
+	for _, k := range sortedKeys(m) {
+		vals = append(vals, m[k])
+	}
+	return vals
+}
+
+func sortVoices(vs []Voice) {
+	sort.Slice(vs, func(i, j int) bool { return vs[i].ID < vs[j].ID })
+}
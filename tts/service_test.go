@@ -0,0 +1,97 @@
+package tts_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ailanguagetutor/tts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	name   string
+	voices []tts.Voice
+	err    error
+	calls  int
+}
+
+func (f *fakeProvider) Name() string                        { return f.name }
+func (f *fakeProvider) Voices() []tts.Voice                 { return f.voices }
+func (f *fakeProvider) CacheKey(tts.Request, tts.Voice) any { return nil }
+
+func (f *fakeProvider) Synthesize(_ context.Context, req tts.Request, v tts.Voice) (*tts.Audio, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &tts.Audio{Body: io.NopCloser(strings.NewReader(v.ID + ":" + req.Text)), ContentType: "audio/wav"}, nil
+}
+
+func newFake(name string, voices ...tts.Voice) *fakeProvider {
+	for i := range voices {
+		voices[i].Provider = name
+	}
+	return &fakeProvider{name: name, voices: voices}
+}
+
+func TestService_PicksPersonalityThenLanguageVoice(t *testing.T) {
+	el := newFake("elevenlabs",
+		tts.Voice{ID: "rachel", Languages: []string{"it", "es"}},
+		tts.Voice{ID: "adam", Languages: []string{"*"}, Personality: "professor"},
+	)
+	s := tts.NewServiceWith("", nil, el)
+
+	v, ok := s.Voice("elevenlabs", "professor", "it")
+	require.True(t, ok)
+	assert.Equal(t, "adam", v.ID)
+
+	v, ok = s.Voice("elevenlabs", "", "es")
+	require.True(t, ok)
+	assert.Equal(t, "rachel", v.ID)
+
+	_, ok = s.Voice("elevenlabs", "", "ja")
+	assert.False(t, ok)
+}
+
+func TestService_LanguageOverrideAndFallback(t *testing.T) {
+	el := newFake("elevenlabs", tts.Voice{ID: "rachel", Languages: []string{"it", "ro"}})
+	espeak := newFake("espeak-ng",
+		tts.Voice{ID: "espeak-ng:it", Languages: []string{"it"}},
+		tts.Voice{ID: "espeak-ng:ro", Languages: []string{"ro"}},
+	)
+	s := tts.NewServiceWith("espeak-ng", map[string]string{"ro": "espeak-ng"}, el, espeak)
+
+	routes := s.Routes(tts.Request{Text: "ciao", Language: "it"})
+	require.Len(t, routes, 2)
+	assert.Equal(t, "elevenlabs", routes[0].Provider.Name())
+	assert.Equal(t, "espeak-ng", routes[1].Provider.Name())
+	assert.NotEqual(t, routes[0].Key, routes[1].Key)
+
+	routes = s.Routes(tts.Request{Text: "salut", Language: "ro"})
+	require.Len(t, routes, 1, "override and fallback are the same provider")
+	assert.Equal(t, "espeak-ng:ro", routes[0].Voice.ID)
+
+	el.err = tts.ErrQuotaExceeded
+	res, err := s.Synthesize(context.Background(), tts.Request{Text: "ciao", Language: "it"})
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "espeak-ng", res.Provider.Name())
+	assert.Equal(t, "espeak-ng:it", res.Voice.ID)
+	assert.Equal(t, 1, el.calls)
+}
+
+func TestService_AllProvidersFail(t *testing.T) {
+	el := newFake("elevenlabs", tts.Voice{ID: "rachel", Languages: []string{"it"}})
+	el.err = errors.New("boom")
+	s := tts.NewServiceWith("", nil, el)
+
+	_, err := s.Synthesize(context.Background(), tts.Request{Text: "ciao", Language: "it"})
+	assert.Error(t, err)
+
+	_, err = s.Synthesize(context.Background(), tts.Request{Text: "ciao", Language: "ja"})
+	assert.ErrorIs(t, err, tts.ErrNoVoice)
+}
@@ -0,0 +1,108 @@
+// Package tts abstracts speech synthesis behind a Provider interface so the
+// app can use ElevenLabs for most languages and a locally installed engine
+// (espeak-ng or Piper) where ElevenLabs is unsuitable, unavailable, or out of
+// quota. Each provider publishes a voice catalog; the Service picks a provider
+// and voice for a request and falls back to the next provider on failure.
+package tts
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrQuotaExceeded is returned by a provider whose account quota is used up.
+	ErrQuotaExceeded = errors.New("tts: provider quota exceeded")
+	// ErrNoVoice means no configured provider has a voice for the request.
+	ErrNoVoice = errors.New("tts: no voice available")
+)
+
+// Voice describes one voice in a provider's catalog.
+type Voice struct {
+	ID          string   `json:"id"`
+	Provider    string   `json:"provider"`
+	Name        string   `json:"name"`
+	Languages   []string `json:"languages"`             // language codes the voice can speak
+	Gender      string   `json:"gender,omitempty"`      // "female", "male" or empty when unknown
+	Styles      []string `json:"styles,omitempty"`      // descriptive tags, e.g. "calm", "deep"
+	Personality string   `json:"personality,omitempty"` // tutor personality this voice is cast for
+}
+
+// Speaks reports whether the voice lists lang among its languages.
+func (v Voice) Speaks(lang string) bool {
+	for _, l := range v.Languages {
+		if l == lang || l == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Request is a single synthesis request.
+type Request struct {
+	Text        string
+	Language    string
+	Personality string  // optional; selects the personality voice when the provider has one
+	VoiceID     string  // optional; explicit voice from the catalog
+	Speed       float64 // 1.0 = normal
+	Unmetered   bool    // only route to providers that do not bill per character
+	Markup      bool    // Text contains SSML markup (see ParseMarkup)
+}
+
+// Audio is a rendered clip. Body may be a live stream; the caller must close it.
+type Audio struct {
+	Body        io.ReadCloser
+	ContentType string
+}
+
+// Provider is a speech synthesis backend.
+type Provider interface {
+	// Name is the identifier used in configuration (e.g. "elevenlabs").
+	Name() string
+	// Voices returns the provider's voice catalog.
+	Voices() []Voice
+	// Synthesize renders req with voice, which is always one of Voices().
+	Synthesize(ctx context.Context, req Request, voice Voice) (*Audio, error)
+	// CacheKey returns everything besides the text that affects the rendered
+	// output (model, settings, ...), for building content-addressed cache keys.
+	CacheKey(req Request, voice Voice) any
+}
+
+// Metered is implemented by providers that bill per synthesized character.
+// Providers without it are treated as free (offline engines).
+type Metered interface {
+	Metered() bool
+}
+
+// IsMetered reports whether p bills per character.
+func IsMetered(p Provider) bool {
+	m, ok := p.(Metered)
+	return ok && m.Metered()
+}
+
+// pickVoice chooses a voice from catalog for req: the explicit voice ID if the
+// catalog has it, then the personality voice, then the first voice that
+// speaks the request language.
+func pickVoice(catalog []Voice, req Request) (Voice, bool) {
+	if req.VoiceID != "" {
+		for _, v := range catalog {
+			if v.ID == req.VoiceID {
+				return v, true
+			}
+		}
+	}
+	if req.Personality != "" {
+		for _, v := range catalog {
+			if v.Personality == req.Personality {
+				return v, true
+			}
+		}
+	}
+	for _, v := range catalog {
+		if v.Personality == "" && v.Speaks(req.Language) {
+			return v, true
+		}
+	}
+	return Voice{}, false
+}